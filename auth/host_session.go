@@ -222,7 +222,7 @@ func SessionHandler(conn *websocket.Conn) {
 		if err = conn.WriteMessage(messageType, reponseData); err != nil {
 			logger.Log(0, "error during message writing:", err.Error())
 		}
-		go CheckNetRegAndHostUpdate(netsToAdd[:], &result.Host, uuid.Nil, []models.TagID{})
+		go CheckNetRegAndHostUpdate(netsToAdd[:], &result.Host, uuid.Nil, []models.TagID{}, 0)
 	case <-timeout: // the read from req.answerCh has timed out
 		logger.Log(0, "timeout signal recv,exiting oauth socket conn")
 		break
@@ -236,21 +236,29 @@ func SessionHandler(conn *websocket.Conn) {
 }
 
 // CheckNetRegAndHostUpdate - run through networks and send a host update
-func CheckNetRegAndHostUpdate(networks []string, h *models.Host, relayNodeId uuid.UUID, tags []models.TagID) {
+func CheckNetRegAndHostUpdate(networks []string, h *models.Host, relayNodeId uuid.UUID, tags []models.TagID, nodeTTL int64) {
 	// publish host update through MQ
 	for i := range networks {
 		network := networks[i]
+		if err := logic.CheckNetworkFreeze(network); err != nil {
+			logger.Log(0, "not adding host to frozen network:", h.ID.String(), h.Name, network)
+			continue
+		}
 		if ok, _ := logic.NetworkExists(network); ok {
 			newNode, err := logic.UpdateHostNetwork(h, network, true)
 			if err != nil {
 				logger.Log(0, "failed to add host to network:", h.ID.String(), h.Name, network, err.Error())
 				continue
 			}
-			if len(tags) > 0 {
-				newNode.Tags = make(map[models.TagID]struct{})
-				for _, tagI := range tags {
-					newNode.Tags[tagI] = struct{}{}
+			if len(tags) > 0 || nodeTTL > 0 {
+				if len(tags) > 0 {
+					newNode.Tags = make(map[models.TagID]struct{})
+					for _, tagI := range tags {
+						newNode.Tags[tagI] = struct{}{}
+					}
 				}
+				// the lease of the enrollment key overrides the network default
+				logic.ApplyNodeLease(newNode, nodeTTL)
 				logic.UpsertNode(newNode)
 			}
 
@@ -289,13 +297,14 @@ func CheckNetRegAndHostUpdate(networks []string, h *models.Host, relayNodeId uui
 		}
 	}
 	if servercfg.IsMessageQueueBackend() {
-		mq.HostUpdate(&models.HostUpdate{
-			Action: models.RequestAck,
-			Host:   *h,
-		})
-		if err := mq.PublishPeerUpdate(false); err != nil {
-			logger.Log(0, "failed to publish peer update during registration -", err.Error())
+		if len(tags) > 0 && logic.ApplyHostConnectivityProfiles(h) {
+			mq.HostUpdate(&models.HostUpdate{
+				Action: models.UpdateHost,
+				Host:   *h,
+			})
 		}
+		mq.RequestAck(h)
+		mq.QueuePeerUpdate(false, logic.GetHostNetworks(h.ID.String())...)
 	}
 }
 
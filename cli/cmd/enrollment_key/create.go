@@ -14,6 +14,8 @@ var (
 	networks      string
 	unlimited     bool
 	tags          string
+	labels        map[string]string
+	nodeTTL       int64
 )
 
 var enrollmentKeyCreateCmd = &cobra.Command{
@@ -26,6 +28,10 @@ var enrollmentKeyCreateCmd = &cobra.Command{
 			Expiration:    int64(expiration),
 			UsesRemaining: usesRemaining,
 			Unlimited:     unlimited,
+			Labels:        labels,
+		}
+		if nodeTTL > 0 {
+			enrollKey.NodeTTL = &nodeTTL
 		}
 		if networks != "" {
 			enrollKey.Networks = strings.Split(networks, ",")
@@ -43,5 +49,7 @@ func init() {
 	enrollmentKeyCreateCmd.Flags().StringVar(&networks, "networks", "", "Comma-separated list of networks which the enrollment key can access")
 	enrollmentKeyCreateCmd.Flags().BoolVar(&unlimited, "unlimited", false, "Should the key have unlimited uses ?")
 	enrollmentKeyCreateCmd.Flags().StringVar(&tags, "tags", "", "Comma-separated list of any additional tags")
+	enrollmentKeyCreateCmd.Flags().StringToStringVar(&labels, "labels", nil, "Labels assigned to hosts enrolled with the key (eg: env=prod,site=fra1)")
+	enrollmentKeyCreateCmd.Flags().Int64Var(&nodeTTL, "node-ttl", 0, "Lifetime in seconds of nodes created with the key, 0 uses the network default")
 	rootCmd.AddCommand(enrollmentKeyCreateCmd)
 }
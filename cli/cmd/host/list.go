@@ -5,16 +5,23 @@ import (
 	"github.com/spf13/cobra"
 )
 
+var selector string
+
 var hostListCmd = &cobra.Command{
 	Use:   "list",
 	Args:  cobra.NoArgs,
 	Short: "List all hosts",
 	Long:  `List all hosts`,
 	Run: func(cmd *cobra.Command, args []string) {
+		if selector != "" {
+			functions.PrettyPrint(functions.GetHostsBySelector(selector))
+			return
+		}
 		functions.PrettyPrint(functions.GetHosts())
 	},
 }
 
 func init() {
+	hostListCmd.Flags().StringVar(&selector, "selector", "", "Host label selector (eg: env=prod,site!=fra1)")
 	rootCmd.AddCommand(hostListCmd)
 }
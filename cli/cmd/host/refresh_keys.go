@@ -5,17 +5,24 @@ import (
 	"github.com/spf13/cobra"
 )
 
+var refreshSelector string
+
 var hostRefreshKeysCmd = &cobra.Command{
 	Use:   "refresh_keys [HOST ID] ",
 	Args:  cobra.MaximumNArgs(1),
 	Short: "Refresh wireguard keys on host",
 	Long: `Refresh wireguard keys on specified or all hosts
-	If HOSTID is not specified, all hosts will be updated`,
+	If HOSTID is not specified, all hosts (or the ones matching --selector) will be updated`,
 	Run: func(cmd *cobra.Command, args []string) {
-		functions.PrettyPrint(functions.RefreshKeys(args[0]))
+		hostID := ""
+		if len(args) == 1 {
+			hostID = args[0]
+		}
+		functions.PrettyPrint(functions.RefreshKeys(hostID, refreshSelector))
 	},
 }
 
 func init() {
+	hostRefreshKeysCmd.Flags().StringVar(&refreshSelector, "selector", "", "Host label selector to refresh keys on a set of hosts (eg: env=prod)")
 	rootCmd.AddCommand(hostRefreshKeysCmd)
 }
@@ -22,6 +22,7 @@ var (
 	isStatic        bool
 	isDefault       bool
 	keepAlive       int
+	labels          map[string]string
 )
 
 var hostUpdateCmd = &cobra.Command{
@@ -50,6 +51,9 @@ var hostUpdateCmd = &cobra.Command{
 			apiHost.IsStatic = isStatic
 			apiHost.IsDefault = isDefault
 			apiHost.PersistentKeepalive = keepAlive
+			if cmd.Flags().Changed("labels") {
+				apiHost.Labels = labels
+			}
 		}
 		functions.PrettyPrint(functions.UpdateHost(args[0], apiHost))
 	},
@@ -66,5 +70,6 @@ func init() {
 	hostUpdateCmd.Flags().BoolVar(&isStaticPort, "static_port", false, "Make Host Static Port?")
 	hostUpdateCmd.Flags().BoolVar(&isStatic, "static_endpoint", false, "Make Host Static Endpoint?")
 	hostUpdateCmd.Flags().BoolVar(&isDefault, "default", false, "Make Host Default ?")
+	hostUpdateCmd.Flags().StringToStringVar(&labels, "labels", nil, "Host labels, replaces the existing ones (eg: env=prod,site=fra1)")
 	rootCmd.AddCommand(hostUpdateCmd)
 }
@@ -0,0 +1,49 @@
+package network
+
+import (
+	"log"
+	"time"
+
+	"github.com/gravitl/netmaker/cli/functions"
+	"github.com/gravitl/netmaker/models"
+	"github.com/spf13/cobra"
+)
+
+var (
+	freezeReason   string
+	freezeDuration time.Duration
+)
+
+var networkFreezeCmd = &cobra.Command{
+	Use:   "freeze [NETWORK NAME]",
+	Short: "Freeze a network",
+	Long:  `Freeze a network so that no node, ACL, tag, gateway or DNS changes can be made until the freeze expires or is lifted`,
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if freezeReason == "" {
+			log.Fatal("a reason is required to freeze a network")
+		}
+		req := &models.NetworkFreezeRequest{Reason: freezeReason}
+		if freezeDuration > 0 {
+			req.Until = time.Now().Add(freezeDuration)
+		}
+		functions.PrettyPrint(functions.FreezeNetwork(args[0], req))
+	},
+}
+
+var networkUnfreezeCmd = &cobra.Command{
+	Use:   "unfreeze [NETWORK NAME]",
+	Short: "Lift the freeze on a network",
+	Long:  `Lift the freeze on a network`,
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		functions.PrettyPrint(functions.UnfreezeNetwork(args[0]))
+	},
+}
+
+func init() {
+	networkFreezeCmd.Flags().StringVar(&freezeReason, "reason", "", "Reason for freezing the network")
+	networkFreezeCmd.Flags().DurationVar(&freezeDuration, "duration", 0, "Duration of the freeze (eg: 2h), freeze does not expire if unset")
+	rootCmd.AddCommand(networkFreezeCmd)
+	rootCmd.AddCommand(networkUnfreezeCmd)
+}
@@ -22,11 +22,15 @@ var networkListCmd = &cobra.Command{
 			functions.PrettyPrint(networks)
 		default:
 			table := tablewriter.NewWriter(os.Stdout)
-			table.SetHeader([]string{"NetId", "Address Range (IPv4)", "Address Range (IPv6)", "Network Last Modified", "Nodes Last Modified"})
+			table.SetHeader([]string{"NetId", "Address Range (IPv4)", "Address Range (IPv6)", "Network Last Modified", "Nodes Last Modified", "Frozen"})
 			for _, n := range *networks {
 				networkLastModified := time.Unix(n.NetworkLastModified, 0).Format(time.RFC3339)
 				nodesLastModified := time.Unix(n.NodesLastModified, 0).Format(time.RFC3339)
-				table.Append([]string{n.NetID, n.AddressRange, n.AddressRange6, networkLastModified, nodesLastModified})
+				frozen := "no"
+				if n.IsFrozen() {
+					frozen = "yes (" + n.FreezeReason + ")"
+				}
+				table.Append([]string{n.NetID, n.AddressRange, n.AddressRange6, networkLastModified, nodesLastModified, frozen})
 			}
 			table.Render()
 		}
@@ -17,4 +17,5 @@ var (
 	defaultACL             bool
 	dnsOn                  bool
 	disconnect             bool
+	selector               string
 )
@@ -21,6 +21,8 @@ var nodeListCmd = &cobra.Command{
 		var data []models.ApiNode
 		if networkName != "" {
 			data = *functions.GetNodes(networkName)
+		} else if selector != "" {
+			data = *functions.GetNodesBySelector(selector)
 		} else {
 			data = *functions.GetNodes()
 		}
@@ -51,5 +53,6 @@ var nodeListCmd = &cobra.Command{
 
 func init() {
 	nodeListCmd.Flags().StringVar(&networkName, "network", "", "Network name specifier")
+	nodeListCmd.Flags().StringVar(&selector, "selector", "", "Host label selector (eg: env=prod,site!=fra1), ignored when a network is given")
 	rootCmd.AddCommand(nodeListCmd)
 }
@@ -16,6 +16,7 @@ import (
 	"github.com/gravitl/netmaker/cli/cmd/network"
 	"github.com/gravitl/netmaker/cli/cmd/node"
 	"github.com/gravitl/netmaker/cli/cmd/server"
+	"github.com/gravitl/netmaker/cli/cmd/simulate"
 	"github.com/gravitl/netmaker/cli/cmd/user"
 	"github.com/spf13/cobra"
 )
@@ -57,4 +58,5 @@ func init() {
 	rootCmd.AddCommand(enrollment_key.GetRoot())
 	rootCmd.AddCommand(failover.GetRoot())
 	rootCmd.AddCommand(gateway.GetRoot())
+	rootCmd.AddCommand(simulate.GetRoot())
 }
@@ -0,0 +1,128 @@
+package simulate
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"sort"
+	"syscall"
+	"time"
+
+	"github.com/gravitl/netmaker/cli/cmd/commons"
+	"github.com/gravitl/netmaker/cli/config"
+	"github.com/gravitl/netmaker/cli/functions"
+	"github.com/guumaster/tablewriter"
+	"github.com/spf13/cobra"
+)
+
+var (
+	hostCount       int
+	enrollmentToken string
+	apiEndpoint     string
+	hostVersion     string
+	namePrefix      string
+	duration        time.Duration
+	rampRate        float64
+	churnRate       float64
+	checkInInterval time.Duration
+	metricsInterval time.Duration
+	pullInterval    time.Duration
+	keepHosts       bool
+)
+
+var simulateHostsCmd = &cobra.Command{
+	Use:   "hosts",
+	Args:  cobra.NoArgs,
+	Short: "Simulate hosts joining, checking in and churning",
+	Long: `Registers fake hosts through the enrollment flow, connects them to the broker and keeps them
+checking in, reporting metrics and consuming peer updates, replacing hosts at the churn rate.
+Reports API latencies, the time from a host joining to its first peer update and the peer update
+fan-out time from a host joining or leaving until each of the other hosts received an update.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if apiEndpoint == "" {
+			_, ctx := config.GetCurrentContext()
+			apiEndpoint = ctx.Endpoint
+		}
+		if hostVersion == "" {
+			hostVersion = functions.GetServerInfo().Version
+		}
+		if rampRate <= 0 {
+			log.Fatal("ramp rate must be positive")
+		}
+		if checkInInterval <= 0 {
+			log.Fatal("check-in interval must be positive")
+		}
+		sim := &simulator{
+			api:             apiEndpoint,
+			enrollmentToken: enrollmentToken,
+			version:         hostVersion,
+			prefix:          namePrefix,
+			checkInInterval: checkInInterval,
+			metricsInterval: metricsInterval,
+			pullInterval:    pullInterval,
+			httpClient:      &http.Client{Timeout: time.Minute},
+			stats:           newStats(),
+			hosts:           make(map[string]*simHost),
+		}
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		ctx, cancel := context.WithTimeout(ctx, duration)
+		defer cancel()
+		start := time.Now()
+		sim.run(ctx, hostCount, rampRate, churnRate)
+		elapsed := time.Since(start)
+		hosts := len(sim.liveHosts())
+		sim.cleanup(!keepHosts)
+		latencies, counters := sim.stats.summarize()
+		r := report{
+			Hosts:     hosts,
+			Duration:  elapsed.Round(time.Second).String(),
+			Latencies: latencies,
+			Counters:  counters,
+		}
+		switch commons.OutputFormat {
+		case commons.JsonOutput:
+			functions.PrettyPrint(r)
+		default:
+			fmt.Printf("%d hosts simulated for %s\n", r.Hosts, r.Duration)
+			table := tablewriter.NewWriter(os.Stdout)
+			table.SetHeader([]string{"Latency", "Count", "P50 (ms)", "P90 (ms)", "P99 (ms)", "Max (ms)"})
+			for _, l := range r.Latencies {
+				table.Append([]string{l.Name, fmt.Sprint(l.Count), fmt.Sprintf("%.1f", l.P50),
+					fmt.Sprintf("%.1f", l.P90), fmt.Sprintf("%.1f", l.P99), fmt.Sprintf("%.1f", l.Max)})
+			}
+			table.Render()
+			names := make([]string, 0, len(r.Counters))
+			for name := range r.Counters {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			table = tablewriter.NewWriter(os.Stdout)
+			table.SetHeader([]string{"Counter", "Value"})
+			for _, name := range names {
+				table.Append([]string{name, fmt.Sprint(r.Counters[name])})
+			}
+			table.Render()
+		}
+	},
+}
+
+func init() {
+	simulateHostsCmd.Flags().StringVar(&enrollmentToken, "token", "", "Enrollment key token the hosts register with")
+	simulateHostsCmd.MarkFlagRequired("token")
+	simulateHostsCmd.Flags().IntVar(&hostCount, "hosts", 100, "Number of hosts to simulate")
+	simulateHostsCmd.Flags().StringVar(&apiEndpoint, "api", "", "Server API endpoint, defaults to the current context's endpoint")
+	simulateHostsCmd.Flags().StringVar(&hostVersion, "version", "", "Client version the hosts report, defaults to the server's version")
+	simulateHostsCmd.Flags().StringVar(&namePrefix, "prefix", "sim-host", "Name prefix of the simulated hosts")
+	simulateHostsCmd.Flags().DurationVar(&duration, "duration", 10*time.Minute, "How long to run the simulation, including the ramp up")
+	simulateHostsCmd.Flags().Float64Var(&rampRate, "ramp", 10, "Hosts registered per second while ramping up")
+	simulateHostsCmd.Flags().Float64Var(&churnRate, "churn", 0, "Hosts replaced per minute once all hosts joined")
+	simulateHostsCmd.Flags().DurationVar(&checkInInterval, "checkin-interval", time.Minute, "Interval between host check-ins")
+	simulateHostsCmd.Flags().DurationVar(&metricsInterval, "metrics-interval", 0, "Interval between metrics reports, 0 disables metrics")
+	simulateHostsCmd.Flags().DurationVar(&pullInterval, "pull-interval", 0, "Interval between host pulls over the API, 0 pulls only when requested")
+	simulateHostsCmd.Flags().BoolVar(&keepHosts, "keep", false, "Keep the simulated hosts registered on the server when done")
+	rootCmd.AddCommand(simulateHostsCmd)
+}
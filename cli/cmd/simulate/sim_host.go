@@ -0,0 +1,447 @@
+package simulate
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+	"github.com/google/uuid"
+	"github.com/gravitl/netmaker/models"
+	"github.com/gravitl/netmaker/netclient/ncutils"
+	"golang.org/x/crypto/nacl/box"
+	"golang.zx2c4.com/wireguard/wgctrl/wgtypes"
+)
+
+// emqxBrokerType - broker type reported by servers using EMQX, hosts then log in with their own credentials
+const emqxBrokerType = "emqx"
+
+const mqTimeout = 30 * time.Second
+
+// simHost - a fake host registered through the enrollment flow
+type simHost struct {
+	sim        *simulator
+	host       models.Host
+	password   string
+	trafficKey *[32]byte
+	serverKey  *[32]byte
+	server     models.ServerConfig
+	client     mqtt.Client
+	token      string
+	joinedAt   time.Time
+	stop       chan struct{}
+	stopOnce   sync.Once
+	mutex      sync.Mutex
+	nodes      map[string]string
+	peerIDs    models.PeerMap
+	generation uint64
+	// actionID - the last queued action received, acknowledged on the next ack request
+	actionID string
+	// changedAt - the earliest change in the network the host has not seen a peer update for
+	changedAt time.Time
+	updated   bool
+}
+
+func randomMac() net.HardwareAddr {
+	mac := make(net.HardwareAddr, 6)
+	_, _ = rand.Read(mac)
+	// locally administered unicast
+	mac[0] = (mac[0] | 0x02) & 0xfe
+	return mac
+}
+
+func randomInt(n int) int {
+	if n <= 0 {
+		return 0
+	}
+	v, err := rand.Int(rand.Reader, big.NewInt(int64(n)))
+	if err != nil {
+		return 0
+	}
+	return int(v.Int64())
+}
+
+// newSimHost - generates the keys and identity of a fake host
+func newSimHost(sim *simulator, name string) (*simHost, error) {
+	wgKey, err := wgtypes.GeneratePrivateKey()
+	if err != nil {
+		return nil, err
+	}
+	trafficPub, trafficPriv, err := box.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	trafficPubBytes, err := ncutils.ConvertKeyToBytes(trafficPub)
+	if err != nil {
+		return nil, err
+	}
+	h := &simHost{
+		sim:        sim,
+		password:   uuid.NewString(),
+		trafficKey: trafficPriv,
+		stop:       make(chan struct{}),
+		nodes:      make(map[string]string),
+	}
+	h.host = models.Host{
+		ID:               uuid.New(),
+		Name:             name,
+		Version:          sim.version,
+		OS:               models.OS_Types.Linux,
+		HostPass:         h.password,
+		PublicKey:        wgKey.PublicKey(),
+		TrafficKeyPublic: trafficPubBytes,
+		MacAddress:       randomMac(),
+		ListenPort:       51821,
+		MTU:              1420,
+		FirewallInUse:    "none",
+		// documentation range, the host is never dialed
+		EndpointIP: net.IPv4(198, 51, 100, byte(1+randomInt(254))),
+	}
+	return h, nil
+}
+
+// register - registers the host with the enrollment key, as a netclient would
+func (h *simHost) register() error {
+	var res models.RegisterResponse
+	if err := h.sim.call("register", http.MethodPost, "/api/v1/host/register/"+h.sim.enrollmentToken, "", &h.host, &res); err != nil {
+		return err
+	}
+	serverKey, err := ncutils.ConvertBytesToKey(res.ServerConf.TrafficKey)
+	if err != nil {
+		return err
+	}
+	h.server = res.ServerConf
+	h.serverKey = serverKey
+	// the server may have picked other ports, check-ins have to report what it stored
+	h.host.ListenPort = res.RequestedHost.ListenPort
+	h.host.WgPublicListenPort = res.RequestedHost.WgPublicListenPort
+	h.joinedAt = time.Now()
+	return nil
+}
+
+// authenticate - fetches a host token for the API
+func (h *simHost) authenticate() error {
+	var res struct {
+		Response models.SuccessfulLoginResponse
+	}
+	params := models.AuthParams{ID: h.host.ID.String(), Password: h.password, MacAddress: h.host.MacAddress.String()}
+	if err := h.sim.call("authenticate", http.MethodPost, "/api/hosts/adm/authenticate", "", params, &res); err != nil {
+		return err
+	}
+	h.mutex.Lock()
+	h.token = res.Response.AuthToken
+	h.mutex.Unlock()
+	return nil
+}
+
+func (h *simHost) hostToken() (string, error) {
+	h.mutex.Lock()
+	token := h.token
+	h.mutex.Unlock()
+	if token != "" {
+		return token, nil
+	}
+	if err := h.authenticate(); err != nil {
+		return "", err
+	}
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	return h.token, nil
+}
+
+// pull - fetches the full host state over the API
+func (h *simHost) pull() error {
+	token, err := h.hostToken()
+	if err != nil {
+		return err
+	}
+	var pull models.HostPull
+	if err := h.sim.call("pull", http.MethodGet, "/api/v1/host", token, nil, &pull); err != nil {
+		return err
+	}
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	for _, node := range pull.Nodes {
+		h.nodes[node.ID.String()] = node.Network
+	}
+	return nil
+}
+
+// connect - connects to the broker and subscribes to the host's topics
+func (h *simHost) connect() error {
+	opts := mqtt.NewClientOptions()
+	opts.AddBroker(h.server.Broker)
+	opts.ClientID = h.host.ID.String()
+	if h.server.BrokerType == emqxBrokerType {
+		opts.SetUsername(h.host.ID.String())
+		opts.SetPassword(h.password)
+	} else {
+		opts.SetUsername(h.server.MQUserName)
+		opts.SetPassword(h.server.MQPassword)
+	}
+	opts.SetAutoReconnect(true)
+	opts.SetCleanSession(true)
+	opts.SetConnectRetryInterval(time.Second * 5)
+	opts.SetKeepAlive(time.Minute)
+	opts.SetOrderMatters(false)
+	opts.SetOnConnectHandler(func(client mqtt.Client) {
+		subscriptions := map[string]mqtt.MessageHandler{
+			fmt.Sprintf("peers/host/%s/%s", h.host.ID.String(), h.server.Server):  h.handlePeerUpdate,
+			fmt.Sprintf("host/update/%s/%s", h.host.ID.String(), h.server.Server): h.handleHostUpdate,
+		}
+		for topic, handler := range subscriptions {
+			if token := client.Subscribe(topic, 0, handler); !token.WaitTimeout(mqTimeout) || token.Error() != nil {
+				h.sim.stats.count("mq subscribe errors")
+			}
+		}
+	})
+	opts.SetConnectionLostHandler(func(mqtt.Client, error) {
+		h.sim.stats.count("mq connections lost")
+	})
+	h.client = mqtt.NewClient(opts)
+	start := time.Now()
+	token := h.client.Connect()
+	if !token.WaitTimeout(mqTimeout) {
+		return errors.New("timed out connecting to broker")
+	}
+	if token.Error() != nil {
+		return token.Error()
+	}
+	h.sim.stats.observe("mq connect", time.Since(start))
+	return nil
+}
+
+func decryptAESGCM(key, ciphertext []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aesGCM, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	if len(ciphertext) < aesGCM.NonceSize() {
+		return nil, errors.New("message too short")
+	}
+	nonce, data := ciphertext[:aesGCM.NonceSize()], ciphertext[aesGCM.NonceSize():]
+	return aesGCM.Open(nil, nonce, data, nil)
+}
+
+// decrypt - opens a message from the server, gzipped AES-GCM for current clients and nacl boxes for older ones
+func (h *simHost) decrypt(payload []byte) ([]byte, error) {
+	if len(h.host.TrafficKeyPublic) >= 32 {
+		if zipped, err := decryptAESGCM(h.host.TrafficKeyPublic[0:32], payload); err == nil {
+			zr, err := gzip.NewReader(bytes.NewReader(zipped))
+			if err != nil {
+				return nil, err
+			}
+			defer zr.Close()
+			return io.ReadAll(zr)
+		}
+	}
+	return ncutils.DeChunk(payload, h.serverKey, h.trafficKey)
+}
+
+// publish - encrypts and publishes a message to the server
+func (h *simHost) publish(name, topic string, v any) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		h.sim.stats.count(name + " errors")
+		return
+	}
+	encrypted, err := ncutils.Chunk(data, h.serverKey, h.trafficKey)
+	if err != nil {
+		h.sim.stats.count(name + " errors")
+		return
+	}
+	if token := h.client.Publish(topic, 0, false, encrypted); !token.WaitTimeout(mqTimeout) || token.Error() != nil {
+		h.sim.stats.count(name + " errors")
+		return
+	}
+	h.sim.stats.count(name + "s sent")
+}
+
+func (h *simHost) publishHostUpdate(name string, update models.HostUpdate) {
+	h.publish(name, fmt.Sprintf("host/serverupdate/%s/%s", h.server.Server, h.host.ID.String()), update)
+}
+
+func (h *simHost) handlePeerUpdate(client mqtt.Client, msg mqtt.Message) {
+	data, err := h.decrypt(msg.Payload())
+	if err != nil {
+		h.sim.stats.count("decrypt errors")
+		return
+	}
+	var update models.HostPeerUpdate
+	if err := json.Unmarshal(data, &update); err != nil {
+		h.sim.stats.count("decode errors")
+		return
+	}
+	h.sim.stats.count("peer updates received")
+	h.sim.stats.add("peer update bytes", int64(len(msg.Payload())))
+	now := time.Now()
+	h.mutex.Lock()
+	if update.Generation > h.generation {
+		h.generation = update.Generation
+	}
+	h.peerIDs = update.PeerIDs
+	changedAt, first := h.changedAt, !h.updated
+	h.changedAt = time.Time{}
+	h.updated = true
+	h.mutex.Unlock()
+	if first {
+		h.sim.stats.observe("join to first peer update", now.Sub(h.joinedAt))
+	}
+	if !changedAt.IsZero() {
+		h.sim.stats.observe("peer update fan-out", now.Sub(changedAt))
+	}
+}
+
+func (h *simHost) handleHostUpdate(client mqtt.Client, msg mqtt.Message) {
+	data, err := h.decrypt(msg.Payload())
+	if err != nil {
+		h.sim.stats.count("decrypt errors")
+		return
+	}
+	var update models.HostUpdate
+	if err := json.Unmarshal(data, &update); err != nil {
+		h.sim.stats.count("decode errors")
+		return
+	}
+	h.sim.stats.count("host updates received")
+	if update.ActionID != "" {
+		h.mutex.Lock()
+		h.actionID = update.ActionID
+		h.mutex.Unlock()
+	}
+	switch update.Action {
+	case models.RequestAck:
+		h.mutex.Lock()
+		ack := models.HostUpdate{Action: models.Acknowledgement, Host: h.host, ActionID: h.actionID}
+		h.mutex.Unlock()
+		go h.publishHostUpdate("ack", ack)
+	case models.JoinHostToNetwork:
+		h.mutex.Lock()
+		h.nodes[update.Node.ID.String()] = update.Node.Network
+		h.mutex.Unlock()
+	case models.RequestPull:
+		go func() {
+			if err := h.pull(); err != nil {
+				h.sim.stats.count("pull errors")
+			}
+		}()
+	case models.DeleteHost:
+		// removed on the server, nothing left to simulate
+		go h.shutdown()
+	}
+}
+
+// markChanged - notes a change in the network the host should receive a peer update for
+func (h *simHost) markChanged(at time.Time) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	if h.changedAt.IsZero() {
+		h.changedAt = at
+	}
+}
+
+func (h *simHost) checkIn() {
+	h.mutex.Lock()
+	generation := h.generation
+	h.mutex.Unlock()
+	h.publishHostUpdate("check-in", models.HostUpdate{Action: models.CheckIn, Host: h.host, Generation: generation})
+}
+
+// sendMetrics - reports synthetic connectivity to the peers of each node
+func (h *simHost) sendMetrics() {
+	h.mutex.Lock()
+	nodes := make(map[string]string, len(h.nodes))
+	for id, network := range h.nodes {
+		nodes[id] = network
+	}
+	peerIDs := h.peerIDs
+	h.mutex.Unlock()
+	for nodeID, network := range nodes {
+		metrics := models.Metrics{
+			Network:      network,
+			NodeID:       nodeID,
+			NodeName:     h.host.Name,
+			Connectivity: make(map[string]models.Metric),
+		}
+		for _, peer := range peerIDs {
+			if peer.Network != network {
+				continue
+			}
+			metrics.Connectivity[peer.ID] = models.Metric{
+				NodeName:  peer.Name,
+				Connected: true,
+				Latency:   int64(5 + randomInt(45)),
+				TotalTime: int64(h.sim.metricsInterval.Seconds()),
+				Uptime:    int64(h.sim.metricsInterval.Seconds()),
+			}
+		}
+		h.publish("metric", fmt.Sprintf("metrics/%s/%s", h.server.Server, nodeID), metrics)
+	}
+}
+
+// run - checks in and reports metrics until the host is stopped
+func (h *simHost) run() {
+	checkIn := time.NewTicker(h.sim.checkInInterval)
+	defer checkIn.Stop()
+	var metrics, pull <-chan time.Time
+	if h.sim.metricsInterval > 0 {
+		t := time.NewTicker(h.sim.metricsInterval)
+		defer t.Stop()
+		metrics = t.C
+	}
+	if h.sim.pullInterval > 0 {
+		t := time.NewTicker(h.sim.pullInterval)
+		defer t.Stop()
+		pull = t.C
+	}
+	for {
+		select {
+		case <-h.stop:
+			return
+		case <-checkIn.C:
+			h.checkIn()
+		case <-metrics:
+			h.sendMetrics()
+		case <-pull:
+			if err := h.pull(); err != nil {
+				h.sim.stats.count("pull errors")
+			}
+		}
+	}
+}
+
+// shutdown - stops the host's loops and disconnects it from the broker
+func (h *simHost) shutdown() {
+	h.stopOnce.Do(func() {
+		close(h.stop)
+		if h.client != nil {
+			h.client.Disconnect(250)
+		}
+		h.sim.forget(h)
+	})
+}
+
+// leave - removes the host from the server, as a netclient uninstall would
+func (h *simHost) leave() error {
+	h.shutdown()
+	token, err := h.hostToken()
+	if err != nil {
+		return err
+	}
+	return h.sim.call("delete", http.MethodDelete, "/api/hosts/"+h.host.ID.String()+"?force=true", token, nil, nil)
+}
@@ -0,0 +1,221 @@
+package simulate
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// simulator - registers fake hosts and keeps them busy with check-ins, metrics and churn
+type simulator struct {
+	api             string
+	enrollmentToken string
+	version         string
+	prefix          string
+	checkInInterval time.Duration
+	metricsInterval time.Duration
+	pullInterval    time.Duration
+	httpClient      *http.Client
+	stats           *stats
+	mutex           sync.Mutex
+	hosts           map[string]*simHost
+	seq             int
+	// steady - set once all hosts joined, changes are then tracked for fan-out
+	steady bool
+}
+
+// call - makes a timed API request; the latency is recorded even if the request fails
+func (s *simulator) call(name, method, route, bearer string, payload, result any) error {
+	var body io.Reader
+	if payload != nil {
+		data, err := json.Marshal(payload)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(data)
+	}
+	req, err := http.NewRequest(method, s.api+route, body)
+	if err != nil {
+		return err
+	}
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if bearer != "" {
+		req.Header.Set("Authorization", "Bearer "+bearer)
+	}
+	start := time.Now()
+	res, err := s.httpClient.Do(req)
+	if err != nil {
+		s.stats.observe("api "+name, time.Since(start))
+		s.stats.count("api " + name + " errors")
+		return err
+	}
+	defer res.Body.Close()
+	data, err := io.ReadAll(res.Body)
+	s.stats.observe("api "+name, time.Since(start))
+	if err != nil {
+		s.stats.count("api " + name + " errors")
+		return err
+	}
+	if res.StatusCode != http.StatusOK {
+		s.stats.count("api " + name + " errors")
+		return fmt.Errorf("%s %s: status %d: %s", method, route, res.StatusCode, string(data))
+	}
+	if result != nil && len(data) > 0 {
+		return json.Unmarshal(data, result)
+	}
+	return nil
+}
+
+// markChanged - notes a change for all hosts but the one causing it
+func (s *simulator) markChanged(except *simHost) {
+	now := time.Now()
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if !s.steady {
+		return
+	}
+	for _, h := range s.hosts {
+		if h != except {
+			h.markChanged(now)
+		}
+	}
+}
+
+func (s *simulator) forget(h *simHost) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	delete(s.hosts, h.host.ID.String())
+}
+
+func (s *simulator) liveHosts() []*simHost {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	hosts := make([]*simHost, 0, len(s.hosts))
+	for _, h := range s.hosts {
+		hosts = append(hosts, h)
+	}
+	return hosts
+}
+
+// join - registers a new fake host, connects it and starts its loops
+func (s *simulator) join() {
+	s.mutex.Lock()
+	s.seq++
+	name := fmt.Sprintf("%s-%d", s.prefix, s.seq)
+	s.mutex.Unlock()
+	h, err := newSimHost(s, name)
+	if err != nil {
+		log.Println("failed to generate host", name, err)
+		return
+	}
+	s.markChanged(nil)
+	if err := h.register(); err != nil {
+		log.Println("failed to register host", name, err)
+		return
+	}
+	if err := h.connect(); err != nil {
+		s.stats.count("mq connect errors")
+		log.Println("failed to connect host", name, "to broker", err)
+		return
+	}
+	s.mutex.Lock()
+	s.hosts[h.host.ID.String()] = h
+	s.mutex.Unlock()
+	s.stats.count("hosts joined")
+	if err := h.pull(); err != nil {
+		s.stats.count("pull errors")
+	}
+	go h.run()
+}
+
+// churn - replaces a random host with a new one
+func (s *simulator) churn() {
+	hosts := s.liveHosts()
+	if len(hosts) == 0 {
+		return
+	}
+	h := hosts[randomInt(len(hosts))]
+	s.markChanged(h)
+	if err := h.leave(); err != nil {
+		log.Println("failed to remove host", h.host.Name, err)
+	} else {
+		s.stats.count("hosts left")
+	}
+	s.join()
+}
+
+// run - ramps up to count hosts, then churns until ctx is done
+func (s *simulator) run(ctx context.Context, count int, rampRate float64, churnRate float64) {
+	ramp := time.NewTicker(time.Duration(float64(time.Second) / rampRate))
+	wg := &sync.WaitGroup{}
+ramping:
+	for i := 0; i < count; i++ {
+		select {
+		case <-ctx.Done():
+			break ramping
+		case <-ramp.C:
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.join()
+		}()
+	}
+	ramp.Stop()
+	wg.Wait()
+	s.mutex.Lock()
+	s.steady = true
+	s.mutex.Unlock()
+	log.Printf("%d hosts joined, simulating until done\n", len(s.liveHosts()))
+	var churn <-chan time.Time
+	if churnRate > 0 {
+		t := time.NewTicker(time.Duration(float64(time.Minute) / churnRate))
+		defer t.Stop()
+		churn = t.C
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			wg.Wait()
+			return
+		case <-churn:
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				s.churn()
+			}()
+		}
+	}
+}
+
+// cleanup - removes all remaining fake hosts, or only disconnects them when remove is false
+func (s *simulator) cleanup(remove bool) {
+	wg := &sync.WaitGroup{}
+	sem := make(chan struct{}, 20)
+	for _, h := range s.liveHosts() {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(h *simHost) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			if !remove {
+				h.shutdown()
+				return
+			}
+			if err := h.leave(); err != nil {
+				log.Println("failed to remove host", h.host.Name, err)
+			}
+		}(h)
+	}
+	wg.Wait()
+}
@@ -0,0 +1,96 @@
+package simulate
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+// stats - latencies and counters observed while simulating
+type stats struct {
+	mutex    sync.Mutex
+	samples  map[string][]time.Duration
+	counters map[string]int64
+}
+
+// latencySummary - the distribution of one kind of latency
+type latencySummary struct {
+	Name  string  `json:"name"`
+	Count int     `json:"count"`
+	P50   float64 `json:"p50_ms"`
+	P90   float64 `json:"p90_ms"`
+	P99   float64 `json:"p99_ms"`
+	Max   float64 `json:"max_ms"`
+}
+
+// report - the outcome of a simulation run
+type report struct {
+	Hosts     int              `json:"hosts"`
+	Duration  string           `json:"duration"`
+	Latencies []latencySummary `json:"latencies"`
+	Counters  map[string]int64 `json:"counters"`
+}
+
+func newStats() *stats {
+	return &stats{
+		samples:  make(map[string][]time.Duration),
+		counters: make(map[string]int64),
+	}
+}
+
+func (s *stats) observe(name string, d time.Duration) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.samples[name] = append(s.samples[name], d)
+}
+
+func (s *stats) add(name string, n int64) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.counters[name] += n
+}
+
+func (s *stats) count(name string) {
+	s.add(name, 1)
+}
+
+// percentile - nearest rank percentile of sorted samples
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	i := int(math.Ceil(p/100*float64(len(sorted)))) - 1
+	if i < 0 {
+		i = 0
+	}
+	return sorted[i]
+}
+
+func toMillis(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
+
+func (s *stats) summarize() ([]latencySummary, map[string]int64) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	summaries := []latencySummary{}
+	for name, samples := range s.samples {
+		sorted := append([]time.Duration{}, samples...)
+		sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+		summaries = append(summaries, latencySummary{
+			Name:  name,
+			Count: len(sorted),
+			P50:   toMillis(percentile(sorted, 50)),
+			P90:   toMillis(percentile(sorted, 90)),
+			P99:   toMillis(percentile(sorted, 99)),
+			Max:   toMillis(sorted[len(sorted)-1]),
+		})
+	}
+	sort.Slice(summaries, func(i, j int) bool { return summaries[i].Name < summaries[j].Name })
+	counters := make(map[string]int64, len(s.counters))
+	for name, n := range s.counters {
+		counters[name] = n
+	}
+	return summaries, counters
+}
@@ -3,6 +3,7 @@ package functions
 import (
 	"fmt"
 	"net/http"
+	"net/url"
 
 	"github.com/gravitl/netmaker/models"
 )
@@ -16,6 +17,11 @@ func GetHosts() *[]models.ApiHost {
 	return request[[]models.ApiHost](http.MethodGet, "/api/hosts", nil)
 }
 
+// GetHostsBySelector - fetch the hosts whose labels match the selector
+func GetHostsBySelector(selector string) *[]models.ApiHost {
+	return request[[]models.ApiHost](http.MethodGet, "/api/hosts?selector="+url.QueryEscape(selector), nil)
+}
+
 // DeleteHost - delete a host
 func DeleteHost(hostID string, force bool) *models.ApiHost {
 	return request[models.ApiHost](http.MethodDelete, fmt.Sprintf("/api/hosts/%s?force=%t", hostID, force), nil)
@@ -50,10 +56,10 @@ func DeleteRelay(netID, nodeID string) *models.ApiNode {
 	return request[models.ApiNode](http.MethodDelete, fmt.Sprintf("/api/nodes/%s/%s/deleterelay", netID, nodeID), nil)
 }
 
-// RefreshKeys - refresh wireguard keys
-func RefreshKeys(hostID string) any {
+// RefreshKeys - refresh wireguard keys, when no host ID is given all hosts matching the selector are refreshed
+func RefreshKeys(hostID, selector string) any {
 	if hostID == "" {
-		return request[any](http.MethodPut, "/api/hosts/keys", nil)
+		return request[any](http.MethodPut, "/api/hosts/keys?selector="+url.QueryEscape(selector), nil)
 	}
 	return request[any](http.MethodPut, fmt.Sprintf("/api/hosts/%s/keys", hostID), nil)
 
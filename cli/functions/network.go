@@ -38,3 +38,13 @@ func GetNetwork(name string) *models.Network {
 func DeleteNetwork(name string) *string {
 	return request[string](http.MethodDelete, "/api/networks/"+name, nil)
 }
+
+// FreezeNetwork - freeze a network
+func FreezeNetwork(name string, payload *models.NetworkFreezeRequest) *models.Network {
+	return request[models.Network](http.MethodPut, fmt.Sprintf("/api/networks/%s/freeze", name), payload)
+}
+
+// UnfreezeNetwork - lift the freeze on a network
+func UnfreezeNetwork(name string) *models.Network {
+	return request[models.Network](http.MethodDelete, fmt.Sprintf("/api/networks/%s/freeze", name), nil)
+}
@@ -3,6 +3,7 @@ package functions
 import (
 	"fmt"
 	"net/http"
+	"net/url"
 
 	"github.com/gravitl/netmaker/models"
 )
@@ -16,6 +17,11 @@ func GetNodes(networkName ...string) *[]models.ApiNode {
 	}
 }
 
+// GetNodesBySelector - fetch the nodes whose host labels match the selector
+func GetNodesBySelector(selector string) *[]models.ApiNode {
+	return request[[]models.ApiNode](http.MethodGet, "/api/nodes?selector="+url.QueryEscape(selector), nil)
+}
+
 // GetNodeByID - fetch a single node by ID
 func GetNodeByID(networkName, nodeID string) *models.NodeGet {
 	return request[models.NodeGet](http.MethodGet, fmt.Sprintf("/api/nodes/%s/%s", networkName, nodeID), nil)
@@ -0,0 +1,173 @@
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/gravitl/netmaker/logger"
+	"github.com/gravitl/netmaker/logic"
+	"github.com/gravitl/netmaker/models"
+	"github.com/gravitl/netmaker/mq"
+	"golang.org/x/exp/slog"
+)
+
+func accessRequestHandlers(r *mux.Router) {
+	r.HandleFunc("/api/v1/acls/access_requests", logic.SecurityCheck(true, http.HandlerFunc(listAccessRequests))).
+		Methods(http.MethodGet)
+	r.HandleFunc("/api/v1/acls/access_requests", logic.SecurityCheck(false, http.HandlerFunc(createAccessRequest))).
+		Methods(http.MethodPost)
+	r.HandleFunc("/api/v1/acls/access_requests/{id}/approve", logic.SecurityCheck(true, http.HandlerFunc(approveAccessRequest))).
+		Methods(http.MethodPost)
+	r.HandleFunc("/api/v1/acls/access_requests/{id}/deny", logic.SecurityCheck(true, http.HandlerFunc(denyAccessRequest))).
+		Methods(http.MethodPost)
+}
+
+// @Summary     List just-in-time access requests
+// @Router      /api/v1/acls/access_requests [get]
+// @Tags        ACL
+// @Security    oauth
+// @Param       network query string false "Network ID"
+// @Param       status query string false "pending, approved, denied or expired"
+// @Success     200 {array} models.AccessRequest
+func listAccessRequests(w http.ResponseWriter, r *http.Request) {
+	netID := models.NetworkID(r.URL.Query().Get("network"))
+	status := models.AccessRequestStatus(r.URL.Query().Get("status"))
+	logic.ReturnSuccessResponseWithJson(w, r, logic.ListAccessRequests(netID, status), "fetched access requests")
+}
+
+// @Summary     Request temporary access to a tag or device of a network
+// @Router      /api/v1/acls/access_requests [post]
+// @Tags        ACL
+// @Security    oauth
+// @Param       network query string false "Network ID, required for network users"
+// @Param       body body models.AccessRequestReq true "Access request"
+// @Success     200 {object} models.AccessRequest
+// @Failure     400 {object} models.ErrorResponse
+// @Failure     403 {object} models.ErrorResponse
+func createAccessRequest(w http.ResponseWriter, r *http.Request) {
+	var req models.AccessRequestReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logger.Log(0, "error decoding request body: ", err.Error())
+		logic.ReturnErrorResponse(w, r, logic.FormatError(err, "badrequest"))
+		return
+	}
+	// permissions were checked against the network of the query
+	if netID := r.Header.Get("NET_ID"); netID != "" {
+		if req.NetworkID == "" {
+			req.NetworkID = models.NetworkID(netID)
+		}
+		if req.NetworkID.String() != netID {
+			logic.ReturnErrorResponse(w, r, logic.FormatError(
+				fmt.Errorf("network %s does not match the network of the request %s", netID, req.NetworkID), "badrequest"))
+			return
+		}
+	}
+	accessReq, err := logic.CreateAccessRequest(req, r.Header.Get("user"))
+	if err != nil {
+		logic.ReturnErrorResponse(w, r, logic.FormatError(err, "badrequest"))
+		return
+	}
+	slog.Info("access requested", "request", accessReq.ID, "network", accessReq.NetworkID,
+		"user", accessReq.Requester, "target", accessReq.Target.Value)
+	logic.ReturnSuccessResponseWithJson(w, r, accessReq, "created access request")
+}
+
+// @Summary     Approve an access request, granting access until it expires
+// @Router      /api/v1/acls/access_requests/{id}/approve [post]
+// @Tags        ACL
+// @Security    oauth
+// @Param       id path string true "Access request ID"
+// @Param       network query string false "Network ID of the request, required for network admins"
+// @Param       body body models.AccessRequestReview false "Review note"
+// @Success     200 {object} models.AccessRequest
+// @Failure     400 {object} models.ErrorResponse
+// @Failure     403 {object} models.ErrorResponse
+// @Failure     404 {object} models.ErrorResponse
+func approveAccessRequest(w http.ResponseWriter, r *http.Request) {
+	accessReq, err := logic.GetAccessRequest(mux.Vars(r)["id"])
+	if err != nil {
+		logic.ReturnErrorResponse(w, r, logic.FormatError(err, "notfound"))
+		return
+	}
+	if !isAccessRequestInNetwork(w, r, accessReq) {
+		return
+	}
+	review, ok := decodeAccessRequestReview(w, r)
+	if !ok {
+		return
+	}
+	if isNetworkFrozen(w, r, accessReq.NetworkID.String()) {
+		return
+	}
+	accessReq, err = logic.ApproveAccessRequest(accessReq.ID, r.Header.Get("user"), review.Note)
+	if err != nil {
+		logic.ReturnErrorResponse(w, r, logic.FormatError(err, "badrequest"))
+		return
+	}
+	slog.Info("approved access request", "request", accessReq.ID, "network", accessReq.NetworkID,
+		"requester", accessReq.Requester, "user", accessReq.Reviewer, "expires", accessReq.ExpiresAt)
+	mq.QueuePeerUpdate(true, accessReq.NetworkID.String())
+	logic.ReturnSuccessResponseWithJson(w, r, accessReq, "approved access request")
+}
+
+// @Summary     Deny an access request
+// @Router      /api/v1/acls/access_requests/{id}/deny [post]
+// @Tags        ACL
+// @Security    oauth
+// @Param       id path string true "Access request ID"
+// @Param       network query string false "Network ID of the request, required for network admins"
+// @Param       body body models.AccessRequestReview false "Review note"
+// @Success     200 {object} models.AccessRequest
+// @Failure     400 {object} models.ErrorResponse
+// @Failure     403 {object} models.ErrorResponse
+// @Failure     404 {object} models.ErrorResponse
+func denyAccessRequest(w http.ResponseWriter, r *http.Request) {
+	accessReq, err := logic.GetAccessRequest(mux.Vars(r)["id"])
+	if err != nil {
+		logic.ReturnErrorResponse(w, r, logic.FormatError(err, "notfound"))
+		return
+	}
+	if !isAccessRequestInNetwork(w, r, accessReq) {
+		return
+	}
+	review, ok := decodeAccessRequestReview(w, r)
+	if !ok {
+		return
+	}
+	accessReq, err = logic.DenyAccessRequest(accessReq.ID, r.Header.Get("user"), review.Note)
+	if err != nil {
+		logic.ReturnErrorResponse(w, r, logic.FormatError(err, "badrequest"))
+		return
+	}
+	slog.Info("denied access request", "request", accessReq.ID, "network", accessReq.NetworkID,
+		"requester", accessReq.Requester, "user", accessReq.Reviewer)
+	logic.ReturnSuccessResponseWithJson(w, r, accessReq, "denied access request")
+}
+
+// isAccessRequestInNetwork - checks the request belongs to the network the permissions of the
+// reviewer were checked against, so an admin of one network can not review requests of another
+func isAccessRequestInNetwork(w http.ResponseWriter, r *http.Request, accessReq models.AccessRequest) bool {
+	netID := r.Header.Get("NET_ID")
+	if netID == "" || netID == accessReq.NetworkID.String() {
+		return true
+	}
+	logic.ReturnErrorResponse(w, r, logic.FormatError(
+		fmt.Errorf("access request %s belongs to network %s", accessReq.ID, accessReq.NetworkID), "forbidden"))
+	return false
+}
+
+// decodeAccessRequestReview - reads the optional review note of a request
+func decodeAccessRequestReview(w http.ResponseWriter, r *http.Request) (models.AccessRequestReview, bool) {
+	var review models.AccessRequestReview
+	if r.ContentLength == 0 {
+		return review, true
+	}
+	if err := json.NewDecoder(r.Body).Decode(&review); err != nil {
+		logger.Log(0, "error decoding request body: ", err.Error())
+		logic.ReturnErrorResponse(w, r, logic.FormatError(err, "badrequest"))
+		return review, false
+	}
+	return review, true
+}
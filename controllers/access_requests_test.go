@@ -0,0 +1,77 @@
+package controller
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/gravitl/netmaker/logic"
+	"github.com/gravitl/netmaker/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReviewAccessRequestNetwork(t *testing.T) {
+	deleteAllNetworks()
+	createNet()
+	createNetv1("skynet2")
+	tag := models.Tag{ID: "skynet2.staging", TagName: "staging", Network: "skynet2"}
+	assert.Nil(t, logic.InsertTag(tag))
+	defer logic.DeleteTag(tag.ID, false)
+	assert.Nil(t, logic.UpsertUser(models.User{UserName: "contractor@example.com"}))
+	defer logic.DeleteUser("contractor@example.com")
+	accessReq, err := logic.CreateAccessRequest(models.AccessRequestReq{
+		NetworkID:     "skynet2",
+		Target:        models.AclPolicyTag{ID: models.NodeTagID, Value: tag.ID.String()},
+		Proto:         models.TCP,
+		Ports:         []string{"22"},
+		Duration:      60,
+		Justification: "fix failing deploy",
+	}, "contractor@example.com")
+	assert.Nil(t, err)
+
+	router := mux.NewRouter()
+	router.Use(userMiddleWare)
+	router.HandleFunc("/api/v1/acls/access_requests/{id}/approve", approveAccessRequest).Methods(http.MethodPost)
+	router.HandleFunc("/api/v1/acls/access_requests/{id}/deny", denyAccessRequest).Methods(http.MethodPost)
+	serve := func(action, network string) int {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/acls/access_requests/"+accessReq.ID+"/"+action+"?network="+network, nil)
+		req.Header.Set("user", "admin")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+	t.Run("OtherNetworkAdmin", func(t *testing.T) {
+		assert.Equal(t, http.StatusForbidden, serve("approve", "skynet"))
+		assert.Equal(t, http.StatusForbidden, serve("deny", "skynet"))
+		accessReq, err := logic.GetAccessRequest(accessReq.ID)
+		assert.Nil(t, err)
+		assert.Equal(t, models.AccessRequestPending, accessReq.Status)
+	})
+	t.Run("NetworkAdmin", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, serve("deny", "skynet2"))
+		accessReq, err := logic.GetAccessRequest(accessReq.ID)
+		assert.Nil(t, err)
+		assert.Equal(t, models.AccessRequestDenied, accessReq.Status)
+	})
+}
+
+func TestAccessRequestResource(t *testing.T) {
+	router := mux.NewRouter()
+	router.Use(userMiddleWare)
+	rsrc := ""
+	record := func(w http.ResponseWriter, r *http.Request) { rsrc = r.Header.Get("TARGET_RSRC") }
+	router.HandleFunc("/api/v1/acls/access_requests", record).Methods(http.MethodGet, http.MethodPost)
+	router.HandleFunc("/api/v1/acls/access_requests/{id}/approve", record).Methods(http.MethodPost)
+	for _, tc := range []struct {
+		method, target string
+		rsrc           models.RsrcType
+	}{
+		{http.MethodPost, "/api/v1/acls/access_requests?network=skynet", models.AccessRequestRsrc},
+		{http.MethodGet, "/api/v1/acls/access_requests?network=skynet", models.AclRsrc},
+		{http.MethodPost, "/api/v1/acls/access_requests/id/approve?network=skynet", models.AclRsrc},
+	} {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(tc.method, tc.target, nil))
+		assert.Equal(t, tc.rsrc.String(), rsrc, tc.method+" "+tc.target)
+	}
+}
@@ -0,0 +1,128 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+
+	"github.com/gorilla/mux"
+	"github.com/gravitl/netmaker/logger"
+	"github.com/gravitl/netmaker/logic"
+	"github.com/gravitl/netmaker/models"
+	"github.com/gravitl/netmaker/mq"
+)
+
+func aclServiceHandlers(r *mux.Router) {
+	r.HandleFunc("/api/v1/acls/services", logic.SecurityCheck(true, http.HandlerFunc(listAclServices))).
+		Methods(http.MethodGet)
+	r.HandleFunc("/api/v1/acls/services", logic.SecurityCheck(true, http.HandlerFunc(createAclService))).
+		Methods(http.MethodPost)
+	r.HandleFunc("/api/v1/acls/services", logic.SecurityCheck(true, http.HandlerFunc(updateAclService))).
+		Methods(http.MethodPut)
+	r.HandleFunc("/api/v1/acls/services", logic.SecurityCheck(true, http.HandlerFunc(deleteAclService))).
+		Methods(http.MethodDelete)
+}
+
+// @Summary     List the service objects of a network with their usage
+// @Router      /api/v1/acls/services [get]
+// @Tags        ACL
+// @Security    oauth
+// @Param       network query string true "Network ID"
+// @Success     200 {array} models.AclServiceStatus
+// @Failure     400 {object} models.ErrorResponse
+func listAclServices(w http.ResponseWriter, r *http.Request) {
+	netID, _ := url.QueryUnescape(r.URL.Query().Get("network"))
+	if netID == "" {
+		logic.ReturnErrorResponse(w, r, logic.FormatError(errors.New("network id param is missing"), "badrequest"))
+		return
+	}
+	logic.ReturnSuccessResponseWithJson(w, r, logic.ListAclServices(models.NetworkID(netID)), "fetched services")
+}
+
+// @Summary     Create a service object policies can reference
+// @Router      /api/v1/acls/services [post]
+// @Tags        ACL
+// @Security    oauth
+// @Param       body body models.AclService true "Service"
+// @Success     200 {object} models.AclService
+// @Failure     400 {object} models.ErrorResponse
+func createAclService(w http.ResponseWriter, r *http.Request) {
+	var svc models.AclService
+	if err := json.NewDecoder(r.Body).Decode(&svc); err != nil {
+		logger.Log(0, "error decoding request body: ", err.Error())
+		logic.ReturnErrorResponse(w, r, logic.FormatError(err, "badrequest"))
+		return
+	}
+	if isNetworkFrozen(w, r, svc.NetworkID.String()) {
+		return
+	}
+	svc, err := logic.CreateAclService(svc, r.Header.Get("user"))
+	if err != nil {
+		logic.ReturnErrorResponse(w, r, logic.FormatError(err, "badrequest"))
+		return
+	}
+	logic.ReturnSuccessResponseWithJson(w, r, svc, "created service "+svc.Name)
+}
+
+// @Summary     Update a service object and every policy using it
+// @Router      /api/v1/acls/services [put]
+// @Tags        ACL
+// @Security    oauth
+// @Param       body body models.AclService true "Service"
+// @Success     200 {object} models.AclService
+// @Failure     400 {object} models.ErrorResponse
+// @Failure     404 {object} models.ErrorResponse
+func updateAclService(w http.ResponseWriter, r *http.Request) {
+	var update models.AclService
+	if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+		logger.Log(0, "error decoding request body: ", err.Error())
+		logic.ReturnErrorResponse(w, r, logic.FormatError(err, "badrequest"))
+		return
+	}
+	svc, err := logic.GetAclService(update.ID)
+	if err != nil {
+		logic.ReturnErrorResponse(w, r, logic.FormatError(err, "notfound"))
+		return
+	}
+	if isNetworkFrozen(w, r, svc.NetworkID.String()) {
+		return
+	}
+	update.NetworkID = svc.NetworkID
+	if err := logic.ValidateAclService(update); err != nil {
+		logic.ReturnErrorResponse(w, r, logic.FormatError(err, "badrequest"))
+		return
+	}
+	svc, err = logic.UpdateAclService(update, r.Header.Get("user"))
+	if err != nil {
+		logic.ReturnErrorResponse(w, r, logic.FormatError(err, "internal"))
+		return
+	}
+	mq.QueuePeerUpdate(true, svc.NetworkID.String())
+	logic.ReturnSuccessResponseWithJson(w, r, svc, "updated service "+svc.Name)
+}
+
+// @Summary     Delete a service object no policy uses
+// @Router      /api/v1/acls/services [delete]
+// @Tags        ACL
+// @Security    oauth
+// @Param       id query string true "Service ID"
+// @Success     200 {object} models.SuccessResponse
+// @Failure     400 {object} models.ErrorResponse
+// @Failure     404 {object} models.ErrorResponse
+func deleteAclService(w http.ResponseWriter, r *http.Request) {
+	id, _ := url.QueryUnescape(r.URL.Query().Get("id"))
+	svc, err := logic.GetAclService(id)
+	if err != nil {
+		logic.ReturnErrorResponse(w, r, logic.FormatError(err, "notfound"))
+		return
+	}
+	if isNetworkFrozen(w, r, svc.NetworkID.String()) {
+		return
+	}
+	if err := logic.DeleteAclService(id); err != nil {
+		logic.ReturnErrorResponse(w, r, logic.FormatError(err, "badrequest"))
+		return
+	}
+	logic.ReturnSuccessResponse(w, r, "deleted service "+svc.Name)
+}
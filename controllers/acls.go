@@ -3,8 +3,11 @@ package controller
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
+	"io"
 	"net/http"
 	"net/url"
+	"strconv"
 	"time"
 
 	"github.com/google/uuid"
@@ -28,12 +31,29 @@ func aclHandlers(r *mux.Router) {
 		Methods(http.MethodDelete)
 	r.HandleFunc("/api/v1/acls/debug", logic.SecurityCheck(true, http.HandlerFunc(aclDebug))).
 		Methods(http.MethodGet)
+	r.HandleFunc("/api/v1/acls/simulate", logic.SecurityCheck(true, http.HandlerFunc(simulateAcl))).
+		Methods(http.MethodPost)
+	r.HandleFunc("/api/v1/acls/matrix", logic.SecurityCheck(true, http.HandlerFunc(getAclMatrix))).
+		Methods(http.MethodGet)
+	r.HandleFunc("/api/v1/acls/file", logic.SecurityCheck(true, http.HandlerFunc(exportAclPolicyFile))).
+		Methods(http.MethodGet)
+	r.HandleFunc("/api/v1/acls/file", logic.SecurityCheck(true, http.HandlerFunc(applyAclPolicyFile))).
+		Methods(http.MethodPost)
+	r.HandleFunc("/api/v1/acls/versions", logic.SecurityCheck(true, http.HandlerFunc(listAclVersions))).
+		Methods(http.MethodGet)
+	r.HandleFunc("/api/v1/acls/versions/diff", logic.SecurityCheck(true, http.HandlerFunc(diffAclVersions))).
+		Methods(http.MethodGet)
+	r.HandleFunc("/api/v1/acls/versions/rollback", logic.SecurityCheck(true, http.HandlerFunc(rollbackAclVersion))).
+		Methods(http.MethodPost)
+	r.HandleFunc("/api/v1/acls/lint", logic.SecurityCheck(true, http.HandlerFunc(lintAcls))).
+		Methods(http.MethodGet)
 }
 
 // @Summary     List Acl Policy types
 // @Router      /api/v1/acls/policy_types [get]
 // @Tags        ACL
 // @Accept      json
+// @Param       network query string false "Network ID, lists the service objects of the network"
 // @Success     200 {array} models.SuccessResponse
 // @Failure     500 {object} models.ErrorResponse
 func aclPolicyTypes(w http.ResponseWriter, r *http.Request) {
@@ -138,6 +158,9 @@ func aclPolicyTypes(w http.ResponseWriter, r *http.Request) {
 			},
 		},
 	}
+	if netID, _ := url.QueryUnescape(r.URL.Query().Get("network")); netID != "" {
+		resp.Services = logic.ListAclServices(models.NetworkID(netID))
+	}
 	logic.ReturnSuccessResponseWithJson(w, r, resp, "fetched acls types")
 }
 
@@ -214,7 +237,12 @@ func getAcls(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	logic.SortAclEntrys(acls[:])
-	logic.ReturnSuccessResponseWithJson(w, r, acls, "fetched all acls in the network "+netID)
+	now := time.Now()
+	statuses := make([]models.AclStatus, 0, len(acls))
+	for _, acl := range acls {
+		statuses = append(statuses, models.AclStatus{Acl: acl, Effective: logic.IsAclEffective(acl, now)})
+	}
+	logic.ReturnSuccessResponseWithJson(w, r, statuses, "fetched all acls in the network "+netID)
 }
 
 // @Summary     Create Acl
@@ -242,22 +270,39 @@ func createAcl(w http.ResponseWriter, r *http.Request) {
 		logic.ReturnErrorResponse(w, r, logic.FormatError(err, "badrequest"))
 		return
 	}
+	if isNetworkFrozen(w, r, req.NetworkID.String()) {
+		return
+	}
 
+	if err := logic.ValidateAclSchedule(req.Schedule); err != nil {
+		logic.ReturnErrorResponse(w, r, logic.FormatError(err, "badrequest"))
+		return
+	}
 	acl := req
 	acl.ID = uuid.New().String()
 	acl.CreatedBy = user.UserName
 	acl.CreatedAt = time.Now().UTC()
 	acl.Default = false
+	acl.ServiceParentID = ""
 	if acl.ServiceType == models.Any {
 		acl.Port = []string{}
 		acl.Proto = models.ALL
 	}
+	if err := logic.ApplyAclService(&acl); err != nil {
+		logic.ReturnErrorResponse(w, r, logic.FormatError(err, "badrequest"))
+		return
+	}
 	// validate create acl policy
 	if !logic.IsAclPolicyValid(acl) {
 		logic.ReturnErrorResponse(w, r, logic.FormatError(errors.New("invalid policy"), "badrequest"))
 		return
 	}
-	err = logic.InsertAcl(acl)
+	if shadow, ok := logic.FindShadowingPolicy(acl); ok && acl.Enabled {
+		logic.ReturnErrorResponse(w, r, logic.FormatError(
+			fmt.Errorf("policy would never take effect, it is shadowed by policy %s (priority %d)", shadow.Name, shadow.Priority), "badrequest"))
+		return
+	}
+	err = logic.CreateAclWithServicePolicies(acl)
 	if err != nil {
 		logic.ReturnErrorResponse(w, r, logic.FormatError(err, "internal"))
 		return
@@ -267,7 +312,8 @@ func createAcl(w http.ResponseWriter, r *http.Request) {
 		logic.ReturnErrorResponse(w, r, logic.FormatError(err, "internal"))
 		return
 	}
-	go mq.PublishPeerUpdate(true)
+	logic.RecordAclVersion(acl.NetworkID, user.UserName, "created policy "+acl.Name)
+	mq.QueuePeerUpdate(true, acl.NetworkID.String())
 	logic.ReturnSuccessResponseWithJson(w, r, acl, "created acl successfully")
 }
 
@@ -292,6 +338,24 @@ func updateAcl(w http.ResponseWriter, r *http.Request) {
 		logic.ReturnErrorResponse(w, r, logic.FormatError(err, "badrequest"))
 		return
 	}
+	if isNetworkFrozen(w, r, acl.NetworkID.String()) {
+		return
+	}
+	if acl.ServiceParentID != "" {
+		logic.ReturnErrorResponse(w, r, logic.FormatError(
+			fmt.Errorf("policy follows policy %s, update that one instead", acl.ServiceParentID), "badrequest"))
+		return
+	}
+	if err := logic.ValidateAclSchedule(updateAcl.Schedule); err != nil {
+		logic.ReturnErrorResponse(w, r, logic.FormatError(err, "badrequest"))
+		return
+	}
+	if !acl.Default {
+		if err := logic.ApplyAclService(&updateAcl.Acl); err != nil {
+			logic.ReturnErrorResponse(w, r, logic.FormatError(err, "badrequest"))
+			return
+		}
+	}
 	if !logic.IsAclPolicyValid(updateAcl.Acl) {
 		logic.ReturnErrorResponse(w, r, logic.FormatError(errors.New("invalid policy"), "badrequest"))
 		return
@@ -300,6 +364,19 @@ func updateAcl(w http.ResponseWriter, r *http.Request) {
 		logic.ReturnErrorResponse(w, r, logic.FormatError(errors.New("invalid policy, network id mismatch"), "badrequest"))
 		return
 	}
+	if !acl.Default && updateAcl.Acl.Enabled {
+		proposed := updateAcl.Acl
+		proposed.ID, proposed.NetworkID, proposed.RuleType = acl.ID, acl.NetworkID, acl.RuleType
+		if proposed.ServiceType == models.Any {
+			proposed.Port = []string{}
+			proposed.Proto = models.ALL
+		}
+		if shadow, ok := logic.FindShadowingPolicy(proposed); ok {
+			logic.ReturnErrorResponse(w, r, logic.FormatError(
+				fmt.Errorf("policy would never take effect, it is shadowed by policy %s (priority %d)", shadow.Name, shadow.Priority), "badrequest"))
+			return
+		}
+	}
 	if !acl.Default && updateAcl.NewName != "" {
 		//check if policy exists with same name
 		updateAcl.Acl.Name = updateAcl.NewName
@@ -309,7 +386,15 @@ func updateAcl(w http.ResponseWriter, r *http.Request) {
 		logic.ReturnErrorResponse(w, r, logic.FormatError(err, "badrequest"))
 		return
 	}
-	go mq.PublishPeerUpdate(true)
+	if acl, err = logic.GetAcl(acl.ID); err == nil {
+		err = logic.SyncAclServicePolicies(acl)
+	}
+	if err != nil {
+		logic.ReturnErrorResponse(w, r, logic.FormatError(err, "internal"))
+		return
+	}
+	logic.RecordAclVersion(acl.NetworkID, r.Header.Get("user"), "updated policy "+acl.Name)
+	mq.QueuePeerUpdate(true, acl.NetworkID.String())
 	logic.ReturnSuccessResponse(w, r, "updated acl "+acl.Name)
 }
 
@@ -330,16 +415,308 @@ func deleteAcl(w http.ResponseWriter, r *http.Request) {
 		logic.ReturnErrorResponse(w, r, logic.FormatError(err, "badrequest"))
 		return
 	}
+	if isNetworkFrozen(w, r, acl.NetworkID.String()) {
+		return
+	}
 	if acl.Default {
 		logic.ReturnErrorResponse(w, r, logic.FormatError(errors.New("cannot delete default policy"), "badrequest"))
 		return
 	}
-	err = logic.DeleteAcl(acl)
+	if acl.ServiceParentID != "" {
+		logic.ReturnErrorResponse(w, r, logic.FormatError(
+			fmt.Errorf("policy follows policy %s, delete that one instead", acl.ServiceParentID), "badrequest"))
+		return
+	}
+	err = logic.DeleteAclWithServicePolicies(acl)
 	if err != nil {
-		logic.ReturnErrorResponse(w, r,
-			logic.FormatError(errors.New("cannot delete default policy"), "internal"))
+		logic.ReturnErrorResponse(w, r, logic.FormatError(err, "internal"))
 		return
 	}
-	go mq.PublishPeerUpdate(true)
+	logic.RecordAclVersion(acl.NetworkID, r.Header.Get("user"), "deleted policy "+acl.Name)
+	mq.QueuePeerUpdate(true, acl.NetworkID.String())
 	logic.ReturnSuccessResponse(w, r, "deleted acl "+acl.Name)
 }
+
+// @Summary     Simulate an Acl change
+// @Router      /api/v1/acls/simulate [post]
+// @Tags        ACL
+// @Security    oauth
+// @Accept      json
+// @Param       body body models.AclSimulationRequest true "Proposed change"
+// @Success     200 {object} models.AclSimulationResult
+// @Failure     400 {object} models.ErrorResponse
+func simulateAcl(w http.ResponseWriter, r *http.Request) {
+	var req models.AclSimulationRequest
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		logger.Log(0, "error decoding request body: ",
+			err.Error())
+		logic.ReturnErrorResponse(w, r, logic.FormatError(err, "badrequest"))
+		return
+	}
+	result, err := logic.SimulateAclChange(req)
+	if err != nil {
+		logic.ReturnErrorResponse(w, r, logic.FormatError(err, "badrequest"))
+		return
+	}
+	logic.ReturnSuccessResponseWithJson(w, r, result, "simulated acl "+string(req.Action))
+}
+
+// @Summary     Get the reachability matrix of a network's nodes, static nodes and user groups
+// @Router      /api/v1/acls/matrix [get]
+// @Tags        ACL
+// @Security    oauth
+// @Param       network query string true "Network ID"
+// @Param       tag query string false "Only devices with this tag"
+// @Param       user_group query string false "Only this user group"
+// @Param       format query string false "Output format (json, csv)"
+// @Success     200 {object} models.AclMatrix
+// @Failure     400 {object} models.ErrorResponse
+// @Failure     404 {object} models.ErrorResponse
+func getAclMatrix(w http.ResponseWriter, r *http.Request) {
+	netID, _ := url.QueryUnescape(r.URL.Query().Get("network"))
+	if netID == "" {
+		logic.ReturnErrorResponse(w, r, logic.FormatError(errors.New("network id param is missing"), "badrequest"))
+		return
+	}
+	if _, err := logic.GetNetwork(netID); err != nil {
+		logic.ReturnErrorResponse(w, r, logic.FormatError(err, "notfound"))
+		return
+	}
+	format := r.URL.Query().Get("format")
+	if format != "" && format != "json" && format != "csv" {
+		logic.ReturnErrorResponse(w, r, logic.FormatError(errors.New("unsupported format "+format), "badrequest"))
+		return
+	}
+	tag, _ := url.QueryUnescape(r.URL.Query().Get("tag"))
+	userGroup, _ := url.QueryUnescape(r.URL.Query().Get("user_group"))
+	matrix, err := logic.GetAclMatrix(models.NetworkID(netID), logic.AclMatrixFilter{
+		Tag:       models.TagID(tag),
+		UserGroup: models.UserGroupID(userGroup),
+	})
+	if err != nil {
+		logic.ReturnErrorResponse(w, r, logic.FormatError(err, "internal"))
+		return
+	}
+	if format != "csv" {
+		logic.ReturnSuccessResponseWithJson(w, r, matrix, "fetched acl matrix of network "+netID)
+		return
+	}
+	data, err := logic.AclMatrixToCSV(matrix)
+	if err != nil {
+		logic.ReturnErrorResponse(w, r, logic.FormatError(err, "internal"))
+		return
+	}
+	w.Header().Set("Content-Type", "text/csv")
+	w.Header().Set("Content-Disposition", "attachment; filename="+netID+"-acl-matrix.csv")
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write(data); err != nil {
+		logger.Log(1, r.Header.Get("user"), "response writer error (acl matrix) ", err.Error())
+	}
+}
+
+// @Summary     Export the policies of a network as a policy file
+// @Router      /api/v1/acls/file [get]
+// @Tags        ACL
+// @Security    oauth
+// @Param       network query string true "Network ID"
+// @Param       format query string false "File format (yaml, hujson)"
+// @Success     200 {object} models.AclPolicyFile
+// @Failure     400 {object} models.ErrorResponse
+// @Failure     404 {object} models.ErrorResponse
+func exportAclPolicyFile(w http.ResponseWriter, r *http.Request) {
+	netID, _ := url.QueryUnescape(r.URL.Query().Get("network"))
+	if netID == "" {
+		logic.ReturnErrorResponse(w, r, logic.FormatError(errors.New("network id param is missing"), "badrequest"))
+		return
+	}
+	format, err := logic.ParseAclPolicyFileFormat(r.URL.Query().Get("format"))
+	if err != nil {
+		logic.ReturnErrorResponse(w, r, logic.FormatError(err, "badrequest"))
+		return
+	}
+	file, err := logic.ExportAclPolicyFile(models.NetworkID(netID))
+	if err != nil {
+		logic.ReturnErrorResponse(w, r, logic.FormatError(err, "notfound"))
+		return
+	}
+	data, err := logic.MarshalAclPolicyFile(file, format)
+	if err != nil {
+		logic.ReturnErrorResponse(w, r, logic.FormatError(err, "internal"))
+		return
+	}
+	if format == models.AclPolicyFileHuJSON {
+		w.Header().Set("Content-Type", "application/hujson")
+	} else {
+		w.Header().Set("Content-Type", "application/yaml")
+	}
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%s-acls.%s", netID, format))
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write(data); err != nil {
+		logger.Log(1, r.Header.Get("user"), "response writer error (acl policy file) ", err.Error())
+	}
+}
+
+// @Summary     Validate a policy file and make the policies of a network match it
+// @Router      /api/v1/acls/file [post]
+// @Tags        ACL
+// @Security    oauth
+// @Param       network query string true "Network ID"
+// @Param       format query string false "File format (yaml, hujson)"
+// @Param       body body models.AclPolicyFile true "Policy file"
+// @Success     200 {object} models.AclPolicyFileResult
+// @Failure     400 {object} models.ErrorResponse
+// @Failure     404 {object} models.ErrorResponse
+func applyAclPolicyFile(w http.ResponseWriter, r *http.Request) {
+	netID, _ := url.QueryUnescape(r.URL.Query().Get("network"))
+	if netID == "" {
+		logic.ReturnErrorResponse(w, r, logic.FormatError(errors.New("network id param is missing"), "badrequest"))
+		return
+	}
+	if _, err := logic.GetNetwork(netID); err != nil {
+		logic.ReturnErrorResponse(w, r, logic.FormatError(err, "notfound"))
+		return
+	}
+	format, err := logic.ParseAclPolicyFileFormat(r.URL.Query().Get("format"))
+	if err != nil {
+		logic.ReturnErrorResponse(w, r, logic.FormatError(err, "badrequest"))
+		return
+	}
+	if isNetworkFrozen(w, r, netID) {
+		return
+	}
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		logic.ReturnErrorResponse(w, r, logic.FormatError(err, "badrequest"))
+		return
+	}
+	result, err := logic.ApplyAclPolicyFile(models.NetworkID(netID), data, format, r.Header.Get("user"))
+	if err != nil {
+		var fileErr *logic.AclPolicyFileError
+		if errors.As(err, &fileErr) {
+			logic.ReturnErrorResponse(w, r, logic.FormatError(err, "badrequest"))
+		} else {
+			logic.ReturnErrorResponse(w, r, logic.FormatError(err, "internal"))
+		}
+		return
+	}
+	logger.Log(1, r.Header.Get("user"), "applied acl policy file to network", netID)
+	if len(result.Created)+len(result.Updated)+len(result.Deleted) > 0 {
+		mq.QueuePeerUpdate(true, netID)
+	}
+	logic.ReturnSuccessResponseWithJson(w, r, result, "applied acl policy file to network "+netID)
+}
+
+// @Summary     List the versions of the policies of a network
+// @Router      /api/v1/acls/versions [get]
+// @Tags        ACL
+// @Security    oauth
+// @Param       network query string true "Network ID"
+// @Success     200 {array} models.AclVersion
+// @Failure     400 {object} models.ErrorResponse
+// @Failure     500 {object} models.ErrorResponse
+func listAclVersions(w http.ResponseWriter, r *http.Request) {
+	netID, _ := url.QueryUnescape(r.URL.Query().Get("network"))
+	if netID == "" {
+		logic.ReturnErrorResponse(w, r, logic.FormatError(errors.New("network id param is missing"), "badrequest"))
+		return
+	}
+	versions, err := logic.ListAclVersions(models.NetworkID(netID))
+	if err != nil {
+		logic.ReturnErrorResponse(w, r, logic.FormatError(err, "internal"))
+		return
+	}
+	logic.ReturnSuccessResponseWithJson(w, r, versions, "fetched policy versions of network "+netID)
+}
+
+// @Summary     Diff two versions of the policies of a network
+// @Router      /api/v1/acls/versions/diff [get]
+// @Tags        ACL
+// @Security    oauth
+// @Param       network query string true "Network ID"
+// @Param       from query int true "Version to diff from"
+// @Param       to query int true "Version to diff to"
+// @Success     200 {object} models.AclVersionDiff
+// @Failure     400 {object} models.ErrorResponse
+// @Failure     404 {object} models.ErrorResponse
+func diffAclVersions(w http.ResponseWriter, r *http.Request) {
+	netID, _ := url.QueryUnescape(r.URL.Query().Get("network"))
+	if netID == "" {
+		logic.ReturnErrorResponse(w, r, logic.FormatError(errors.New("network id param is missing"), "badrequest"))
+		return
+	}
+	from, err := strconv.Atoi(r.URL.Query().Get("from"))
+	if err != nil {
+		logic.ReturnErrorResponse(w, r, logic.FormatError(errors.New("invalid from version"), "badrequest"))
+		return
+	}
+	to, err := strconv.Atoi(r.URL.Query().Get("to"))
+	if err != nil {
+		logic.ReturnErrorResponse(w, r, logic.FormatError(errors.New("invalid to version"), "badrequest"))
+		return
+	}
+	diff, err := logic.DiffAclVersions(models.NetworkID(netID), from, to)
+	if err != nil {
+		logic.ReturnErrorResponse(w, r, logic.FormatError(err, "notfound"))
+		return
+	}
+	logic.ReturnSuccessResponseWithJson(w, r, diff, "fetched policy diff of network "+netID)
+}
+
+// @Summary     Roll back the policies of a network to a prior version
+// @Router      /api/v1/acls/versions/rollback [post]
+// @Tags        ACL
+// @Security    oauth
+// @Param       network query string true "Network ID"
+// @Param       version query int true "Version to roll back to"
+// @Success     200 {object} models.AclVersionDiff
+// @Failure     400 {object} models.ErrorResponse
+// @Failure     404 {object} models.ErrorResponse
+func rollbackAclVersion(w http.ResponseWriter, r *http.Request) {
+	netID, _ := url.QueryUnescape(r.URL.Query().Get("network"))
+	if netID == "" {
+		logic.ReturnErrorResponse(w, r, logic.FormatError(errors.New("network id param is missing"), "badrequest"))
+		return
+	}
+	version, err := strconv.Atoi(r.URL.Query().Get("version"))
+	if err != nil {
+		logic.ReturnErrorResponse(w, r, logic.FormatError(errors.New("invalid version"), "badrequest"))
+		return
+	}
+	if _, err := logic.GetAclVersion(models.NetworkID(netID), version); err != nil {
+		logic.ReturnErrorResponse(w, r, logic.FormatError(err, "notfound"))
+		return
+	}
+	if isNetworkFrozen(w, r, netID) {
+		return
+	}
+	diff, err := logic.RollbackAclVersion(models.NetworkID(netID), version, r.Header.Get("user"))
+	if err != nil {
+		logic.ReturnErrorResponse(w, r, logic.FormatError(err, "badrequest"))
+		return
+	}
+	logger.Log(1, r.Header.Get("user"), "rolled back policies of network", netID, "to version", strconv.Itoa(version))
+	mq.QueuePeerUpdate(true, netID)
+	logic.ReturnSuccessResponseWithJson(w, r, diff, fmt.Sprintf("rolled back policies of network %s to version %d", netID, version))
+}
+
+// @Summary     Lint the policies of a network for shadowed, redundant, unreachable and broken policies
+// @Router      /api/v1/acls/lint [get]
+// @Tags        ACL
+// @Security    oauth
+// @Param       network query string true "Network ID"
+// @Success     200 {object} models.AclLintReport
+// @Failure     400 {object} models.ErrorResponse
+func lintAcls(w http.ResponseWriter, r *http.Request) {
+	netID, _ := url.QueryUnescape(r.URL.Query().Get("network"))
+	if netID == "" {
+		logic.ReturnErrorResponse(w, r, logic.FormatError(errors.New("network id param is missing"), "badrequest"))
+		return
+	}
+	report, err := logic.LintAclPolicies(models.NetworkID(netID))
+	if err != nil {
+		logic.ReturnErrorResponse(w, r, logic.FormatError(err, "badrequest"))
+		return
+	}
+	logic.ReturnSuccessResponseWithJson(w, r, report, "linted policies of network "+netID)
+}
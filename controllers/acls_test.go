@@ -0,0 +1,79 @@
+package controller
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/gravitl/netmaker/logic"
+	"github.com/gravitl/netmaker/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAclVersionHistory(t *testing.T) {
+	deleteAllNetworks()
+	netID := models.NetworkID("versionnet")
+	_, err := logic.CreateNetwork(models.Network{NetID: netID.String(), AddressRange: "10.77.0.0/24"})
+	assert.Nil(t, err)
+	// as done when the network is created
+	logic.CreateDefaultAclNetworkPolicies(netID)
+	defer logic.DeleteNetworkPolicies(netID)
+	assert.Nil(t, logic.UpsertUser(models.User{UserName: "alice"}))
+	defer logic.DeleteUser("alice")
+
+	router := mux.NewRouter()
+	router.HandleFunc("/api/v1/acls", createAcl).Methods(http.MethodPost)
+	router.HandleFunc("/api/v1/acls", updateAcl).Methods(http.MethodPut)
+	router.HandleFunc("/api/v1/acls", deleteAcl).Methods(http.MethodDelete)
+	serve := func(method, target string, body any) int {
+		data, _ := json.Marshal(body)
+		req := httptest.NewRequest(method, target, bytes.NewReader(data))
+		req.Header.Set("user", "alice")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+	assert.Equal(t, http.StatusOK, serve(http.MethodPost, "/api/v1/acls", models.Acl{
+		Name:             "https",
+		NetworkID:        netID,
+		RuleType:         models.DevicePolicy,
+		Src:              []models.AclPolicyTag{{ID: models.NodeTagID, Value: "*"}},
+		Dst:              []models.AclPolicyTag{{ID: models.NodeTagID, Value: "*"}},
+		AllowedDirection: models.TrafficDirectionUni,
+		Proto:            models.TCP,
+		ServiceType:      models.Custom,
+		Port:             []string{"443"},
+		Enabled:          true,
+	}))
+	var acl models.Acl
+	policies, _ := logic.ListAclsByNetwork(netID)
+	for _, policy := range policies {
+		if !policy.Default {
+			acl = policy
+		}
+	}
+	assert.Equal(t, "https", acl.Name)
+	acl.Port = []string{"8443"}
+	assert.Equal(t, http.StatusOK, serve(http.MethodPut, "/api/v1/acls", models.UpdateAclRequest{Acl: acl}))
+	assert.Equal(t, http.StatusOK, serve(http.MethodDelete, "/api/v1/acls?acl_id="+acl.ID, nil))
+
+	versions, err := logic.ListAclVersions(netID)
+	assert.Nil(t, err)
+	assert.Len(t, versions, 4)
+	baseline := versions[3]
+	assert.Equal(t, 1, baseline.Version)
+	assert.Empty(t, baseline.Changes.Added, "the policies the network started with are no change")
+	created := versions[2]
+	assert.Equal(t, "alice", created.User)
+	assert.Len(t, created.Changes.Added, 1, "only the new policy is added")
+	assert.Equal(t, acl.ID, created.Changes.Added[0].ID)
+	assert.Len(t, versions[1].Changes.Modified, 1)
+	assert.Len(t, versions[0].Changes.Removed, 1)
+	diff, err := logic.DiffAclVersions(netID, 1, 4)
+	assert.Nil(t, err)
+	assert.Empty(t, diff.Added)
+	assert.Empty(t, diff.Removed)
+}
@@ -0,0 +1,188 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/gravitl/netmaker/logger"
+	"github.com/gravitl/netmaker/logic"
+	"github.com/gravitl/netmaker/models"
+	"github.com/gravitl/netmaker/mq"
+)
+
+func connectivityProfileHandlers(r *mux.Router) {
+	r.HandleFunc("/api/v1/tags/profiles", logic.SecurityCheck(true, http.HandlerFunc(getConnectivityProfiles))).
+		Methods(http.MethodGet)
+	r.HandleFunc("/api/v1/tags/profiles", logic.SecurityCheck(true, http.HandlerFunc(createConnectivityProfile))).
+		Methods(http.MethodPost)
+	r.HandleFunc("/api/v1/tags/profiles", logic.SecurityCheck(true, http.HandlerFunc(updateConnectivityProfile))).
+		Methods(http.MethodPut)
+	r.HandleFunc("/api/v1/tags/profiles", logic.SecurityCheck(true, http.HandlerFunc(deleteConnectivityProfile))).
+		Methods(http.MethodDelete)
+	r.HandleFunc("/api/v1/nodes/{network}/{nodeid}/connectivity", logic.SecurityCheck(true, http.HandlerFunc(getNodeConnectivity))).
+		Methods(http.MethodGet)
+}
+
+// @Summary     List connectivity profiles in a network
+// @Router      /api/v1/tags/profiles [get]
+// @Tags        TAG
+// @Accept      json
+// @Param       network query string true "Network ID"
+// @Success     200 {array} models.SuccessResponse
+// @Failure     500 {object} models.ErrorResponse
+func getConnectivityProfiles(w http.ResponseWriter, r *http.Request) {
+	netID, _ := url.QueryUnescape(r.URL.Query().Get("network"))
+	if netID == "" {
+		logic.ReturnErrorResponse(w, r, logic.FormatError(errors.New("network id param is missing"), "badrequest"))
+		return
+	}
+	// check if network exists
+	_, err := logic.GetNetwork(netID)
+	if err != nil {
+		logic.ReturnErrorResponse(w, r, logic.FormatError(err, "badrequest"))
+		return
+	}
+	profiles, err := logic.ListNetworkConnectivityProfiles(models.NetworkID(netID))
+	if err != nil {
+		logger.Log(0, r.Header.Get("user"), "failed to get network connectivity profiles: ", err.Error())
+		logic.ReturnErrorResponse(w, r, logic.FormatError(err, "internal"))
+		return
+	}
+	logic.ReturnSuccessResponseWithJson(w, r, profiles, "fetched connectivity profiles in the network "+netID)
+}
+
+// @Summary     Create connectivity profile
+// @Router      /api/v1/tags/profiles [post]
+// @Tags        TAG
+// @Accept      json
+// @Param       body body models.ConnectivityProfile true "Connectivity profile"
+// @Success     200 {array} models.SuccessResponse
+// @Failure     500 {object} models.ErrorResponse
+func createConnectivityProfile(w http.ResponseWriter, r *http.Request) {
+	var profile models.ConnectivityProfile
+	err := json.NewDecoder(r.Body).Decode(&profile)
+	if err != nil {
+		logger.Log(0, "error decoding request body: ",
+			err.Error())
+		logic.ReturnErrorResponse(w, r, logic.FormatError(err, "badrequest"))
+		return
+	}
+	if isNetworkFrozen(w, r, profile.Network.String()) {
+		return
+	}
+	profile.ID = uuid.New().String()
+	profile.CreatedBy = r.Header.Get("user")
+	profile.CreatedAt = time.Now().UTC()
+	if err = logic.ValidateConnectivityProfile(profile); err != nil {
+		logic.ReturnErrorResponse(w, r, logic.FormatError(err, "badrequest"))
+		return
+	}
+	if err = logic.UpsertConnectivityProfile(profile); err != nil {
+		logic.ReturnErrorResponse(w, r, logic.FormatError(err, "internal"))
+		return
+	}
+	logic.ReturnSuccessResponseWithJson(w, r, profile, "created connectivity profile successfully")
+}
+
+// @Summary     Update connectivity profile
+// @Router      /api/v1/tags/profiles [put]
+// @Tags        TAG
+// @Accept      json
+// @Param       body body models.ConnectivityProfile true "Connectivity profile"
+// @Success     200 {array} models.SuccessResponse
+// @Failure     500 {object} models.ErrorResponse
+func updateConnectivityProfile(w http.ResponseWriter, r *http.Request) {
+	var req models.ConnectivityProfile
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		logger.Log(0, "error decoding request body: ",
+			err.Error())
+		logic.ReturnErrorResponse(w, r, logic.FormatError(err, "badrequest"))
+		return
+	}
+	profile, err := logic.GetConnectivityProfile(req.ID)
+	if err != nil {
+		logic.ReturnErrorResponse(w, r, logic.FormatError(err, "badrequest"))
+		return
+	}
+	if isNetworkFrozen(w, r, profile.Network.String()) {
+		return
+	}
+	if req.Network != profile.Network {
+		logic.ReturnErrorResponse(w, r, logic.FormatError(errors.New("invalid profile, network id mismatch"), "badrequest"))
+		return
+	}
+	profile.Name = req.Name
+	profile.MTU = req.MTU
+	profile.PersistentKeepalive = req.PersistentKeepalive
+	profile.ListenPort = req.ListenPort
+	profile.Priority = req.Priority
+	if err = logic.ValidateConnectivityProfile(profile); err != nil {
+		logic.ReturnErrorResponse(w, r, logic.FormatError(err, "badrequest"))
+		return
+	}
+	if err = logic.UpsertConnectivityProfile(profile); err != nil {
+		logic.ReturnErrorResponse(w, r, logic.FormatError(err, "internal"))
+		return
+	}
+	go func() {
+		mq.PublishNetworkConnectivity(profile.Network)
+		mq.QueuePeerUpdate(false, profile.Network.String())
+	}()
+	logic.ReturnSuccessResponseWithJson(w, r, profile, "updated connectivity profile "+profile.Name)
+}
+
+// @Summary     Delete connectivity profile
+// @Router      /api/v1/tags/profiles [delete]
+// @Tags        TAG
+// @Accept      json
+// @Param       profile_id query string true "Profile ID"
+// @Success     200 {array} models.SuccessResponse
+// @Failure     500 {object} models.ErrorResponse
+func deleteConnectivityProfile(w http.ResponseWriter, r *http.Request) {
+	profileID, _ := url.QueryUnescape(r.URL.Query().Get("profile_id"))
+	if profileID == "" {
+		logic.ReturnErrorResponse(w, r, logic.FormatError(errors.New("profile id is required"), "badrequest"))
+		return
+	}
+	profile, err := logic.GetConnectivityProfile(profileID)
+	if err != nil {
+		logic.ReturnErrorResponse(w, r, logic.FormatError(err, "badrequest"))
+		return
+	}
+	if isNetworkFrozen(w, r, profile.Network.String()) {
+		return
+	}
+	if err = logic.DeleteConnectivityProfile(profile); err != nil {
+		logic.ReturnErrorResponse(w, r, logic.FormatError(err, "badrequest"))
+		return
+	}
+	logic.ReturnSuccessResponse(w, r, "deleted connectivity profile "+profile.Name)
+}
+
+// @Summary     Get the resolved connectivity settings of a node
+// @Router      /api/v1/nodes/{network}/{nodeid}/connectivity [get]
+// @Tags        Nodes
+// @Param       network path string true "Network ID"
+// @Param       nodeid path string true "Node ID"
+// @Success     200 {object} models.SuccessResponse
+// @Failure     500 {object} models.ErrorResponse
+func getNodeConnectivity(w http.ResponseWriter, r *http.Request) {
+	var params = mux.Vars(r)
+	node, err := logic.GetNodeByID(params["nodeid"])
+	if err != nil {
+		logic.ReturnErrorResponse(w, r, logic.FormatError(err, "badrequest"))
+		return
+	}
+	settings, err := logic.ResolveNodeConnectivity(&node)
+	if err != nil {
+		logic.ReturnErrorResponse(w, r, logic.FormatError(err, "internal"))
+		return
+	}
+	logic.ReturnSuccessResponseWithJson(w, r, settings, "fetched node connectivity settings")
+}
@@ -36,6 +36,16 @@ var HttpHandlers = []interface{}{
 	hostHandlers,
 	enrollmentKeyHandlers,
 	tagHandlers,
+	connectivityProfileHandlers,
+	upgradeCampaignHandlers,
+	keyRotationHandlers,
+	quarantineHandlers,
+	hostActionHandlers,
+	hostHistoryHandlers,
+	accessRequestHandlers,
+	aclServiceHandlers,
+	topologyHandlers,
+	zombieHandlers,
 	aclHandlers,
 	legacyHandlers,
 }
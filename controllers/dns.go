@@ -26,13 +26,13 @@ func dnsHandlers(r *mux.Router) {
 		Methods(http.MethodGet)
 	r.HandleFunc("/api/dns/adm/{network}", logic.SecurityCheck(true, http.HandlerFunc(getDNS))).
 		Methods(http.MethodGet)
-	r.HandleFunc("/api/dns/adm/{network}/sync", logic.SecurityCheck(true, http.HandlerFunc(syncDNS))).
+	r.HandleFunc("/api/dns/adm/{network}/sync", logic.SecurityCheck(true, NetworkFreezeCheck(http.HandlerFunc(syncDNS)))).
 		Methods(http.MethodPost)
-	r.HandleFunc("/api/dns/{network}", logic.SecurityCheck(true, http.HandlerFunc(createDNS))).
+	r.HandleFunc("/api/dns/{network}", logic.SecurityCheck(true, NetworkFreezeCheck(http.HandlerFunc(createDNS)))).
 		Methods(http.MethodPost)
 	r.HandleFunc("/api/dns/adm/pushdns", logic.SecurityCheck(true, http.HandlerFunc(pushDNS))).
 		Methods(http.MethodPost)
-	r.HandleFunc("/api/dns/{network}/{domain}", logic.SecurityCheck(true, http.HandlerFunc(deleteDNS))).
+	r.HandleFunc("/api/dns/{network}/{domain}", logic.SecurityCheck(true, NetworkFreezeCheck(http.HandlerFunc(deleteDNS)))).
 		Methods(http.MethodDelete)
 }
 
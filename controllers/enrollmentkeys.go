@@ -2,6 +2,7 @@ package controller
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"time"
@@ -151,12 +152,22 @@ func createEnrollmentKey(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	if enrollmentKeyBody.NodeTTL != nil && *enrollmentKeyBody.NodeTTL < 0 {
+		logic.ReturnErrorResponse(w, r, logic.FormatError(errors.New("node ttl cannot be negative"), "badrequest"))
+		return
+	}
+
+	if areNetworksFrozen(w, r, enrollmentKeyBody.Networks) {
+		return
+	}
+
 	newEnrollmentKey, err := logic.CreateEnrollmentKey(
 		enrollmentKeyBody.UsesRemaining,
 		newTime,
 		enrollmentKeyBody.Networks,
 		enrollmentKeyBody.Tags,
 		enrollmentKeyBody.Groups,
+		enrollmentKeyBody.Labels,
 		enrollmentKeyBody.Unlimited,
 		relayId,
 		false,
@@ -166,6 +177,13 @@ func createEnrollmentKey(w http.ResponseWriter, r *http.Request) {
 		logic.ReturnErrorResponse(w, r, logic.FormatError(err, "internal"))
 		return
 	}
+	if enrollmentKeyBody.NodeTTL != nil && *enrollmentKeyBody.NodeTTL > 0 {
+		if err = logic.SetEnrollmentKeyNodeTTL(newEnrollmentKey, *enrollmentKeyBody.NodeTTL); err != nil {
+			logger.Log(0, r.Header.Get("user"), "failed to set node ttl of enrollment key:", err.Error())
+			logic.ReturnErrorResponse(w, r, logic.FormatError(err, "internal"))
+			return
+		}
+	}
 
 	if err = logic.Tokenize(newEnrollmentKey, servercfg.GetAPIHost()); err != nil {
 		logger.Log(0, r.Header.Get("user"), "failed to create enrollment key:", err.Error())
@@ -208,12 +226,33 @@ func updateEnrollmentKey(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	newEnrollmentKey, err := logic.UpdateEnrollmentKey(keyId, relayId, enrollmentKeyBody.Groups)
+	if enrollmentKeyBody.NodeTTL != nil && *enrollmentKeyBody.NodeTTL < 0 {
+		logic.ReturnErrorResponse(w, r, logic.FormatError(errors.New("node ttl cannot be negative"), "badrequest"))
+		return
+	}
+
+	currKey, err := logic.GetEnrollmentKey(keyId)
+	if err != nil {
+		logic.ReturnErrorResponse(w, r, logic.FormatError(err, "notfound"))
+		return
+	}
+	if areNetworksFrozen(w, r, currKey.Networks) {
+		return
+	}
+
+	newEnrollmentKey, err := logic.UpdateEnrollmentKey(keyId, relayId, enrollmentKeyBody.Groups, enrollmentKeyBody.Labels)
 	if err != nil {
 		slog.Error("failed to update enrollment key", "error", err)
 		logic.ReturnErrorResponse(w, r, logic.FormatError(err, "internal"))
 		return
 	}
+	if enrollmentKeyBody.NodeTTL != nil {
+		if err = logic.SetEnrollmentKeyNodeTTL(newEnrollmentKey, *enrollmentKeyBody.NodeTTL); err != nil {
+			slog.Error("failed to update enrollment key", "error", err)
+			logic.ReturnErrorResponse(w, r, logic.FormatError(err, "internal"))
+			return
+		}
+	}
 
 	if err = logic.Tokenize(newEnrollmentKey, servercfg.GetAPIHost()); err != nil {
 		slog.Error("failed to update enrollment key", "error", err)
@@ -246,6 +285,12 @@ func handleHostRegister(w http.ResponseWriter, r *http.Request) {
 		logic.ReturnErrorResponse(w, r, logic.FormatError(err, "badrequest"))
 		return
 	}
+	for _, netID := range enrollmentKey.Networks {
+		if err := logic.CheckNetworkFreeze(netID); err != nil {
+			logic.ReturnErrorResponse(w, r, logic.FormatError(err, "locked"))
+			return
+		}
+	}
 	// get the host
 	var newHost models.Host
 	if err = json.NewDecoder(r.Body).Decode(&newHost); err != nil {
@@ -271,6 +316,11 @@ func handleHostRegister(w http.ResponseWriter, r *http.Request) {
 		)
 		return
 	}
+	if hostExists && logic.IsHostQuarantined(newHost.ID.String()) {
+		slog.Warn("quarantined host attempted to re-register", "host", newHost.ID.String(), "name", newHost.Name)
+		logic.ReturnErrorResponse(w, r, logic.FormatError(errors.New("host is quarantined"), "forbidden"))
+		return
+	}
 	// version check
 	if !logic.IsVersionCompatible(newHost.Version) {
 		err := fmt.Errorf("bad client version on register: %s", newHost.Version)
@@ -300,6 +350,8 @@ func handleHostRegister(w http.ResponseWriter, r *http.Request) {
 	}
 	if !hostExists {
 		newHost.PersistentKeepalive = models.DefaultPersistentKeepAlive
+		// labels are only assigned by the enrollment key, not by the client
+		newHost.Labels = logic.MergeHostLabels(nil, enrollmentKey.Labels)
 		// register host
 		logic.CheckHostPorts(&newHost)
 		// create EMQX credentials and ACLs for host
@@ -340,6 +392,8 @@ func handleHostRegister(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 		logic.UpdateHostFromClient(&newHost, currHost)
+		currHost.Labels = logic.MergeHostLabels(currHost.Labels, enrollmentKey.Labels)
+		newHost.Labels = currHost.Labels
 		err = logic.UpsertHost(currHost)
 		if err != nil {
 			slog.Error("failed to update host", "id", currHost.ID, "error", err)
@@ -358,5 +412,5 @@ func handleHostRegister(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(&response)
 	// notify host of changes, peer and node updates
-	go auth.CheckNetRegAndHostUpdate(enrollmentKey.Networks, &newHost, enrollmentKey.Relay, enrollmentKey.Groups)
+	go auth.CheckNetRegAndHostUpdate(enrollmentKey.Networks, &newHost, enrollmentKey.Relay, enrollmentKey.Groups, enrollmentKey.NodeTTL)
 }
@@ -36,11 +36,11 @@ func extClientHandlers(r *mux.Router) {
 		Methods(http.MethodGet)
 	r.HandleFunc("/api/extclients/{network}/{clientid}/{type}", logic.SecurityCheck(false, http.HandlerFunc(getExtClientConf))).
 		Methods(http.MethodGet)
-	r.HandleFunc("/api/extclients/{network}/{clientid}", logic.SecurityCheck(false, http.HandlerFunc(updateExtClient))).
+	r.HandleFunc("/api/extclients/{network}/{clientid}", logic.SecurityCheck(false, NetworkFreezeCheck(http.HandlerFunc(updateExtClient)))).
 		Methods(http.MethodPut)
-	r.HandleFunc("/api/extclients/{network}/{clientid}", logic.SecurityCheck(false, http.HandlerFunc(deleteExtClient))).
+	r.HandleFunc("/api/extclients/{network}/{clientid}", logic.SecurityCheck(false, NetworkFreezeCheck(http.HandlerFunc(deleteExtClient)))).
 		Methods(http.MethodDelete)
-	r.HandleFunc("/api/extclients/{network}/{nodeid}", logic.SecurityCheck(false, checkFreeTierLimits(limitChoiceMachines, http.HandlerFunc(createExtClient)))).
+	r.HandleFunc("/api/extclients/{network}/{nodeid}", logic.SecurityCheck(false, checkFreeTierLimits(limitChoiceMachines, NetworkFreezeCheck(http.HandlerFunc(createExtClient))))).
 		Methods(http.MethodPost)
 	r.HandleFunc("/api/v1/client_conf/{network}", logic.SecurityCheck(false, http.HandlerFunc(getExtClientHAConf))).Methods(http.MethodGet)
 }
@@ -635,9 +635,7 @@ Endpoint = %s
 			logic.ReturnErrorResponse(w, r, logic.FormatError(err, "internal"))
 			return
 		}
-		if err := mq.PublishPeerUpdate(false); err != nil {
-			logger.Log(1, "error publishing peer update ", err.Error())
-		}
+		mq.QueuePeerUpdate(false, extclient.Network)
 		if servercfg.IsDNSMode() {
 			logic.SetDNS()
 		}
@@ -813,9 +811,7 @@ func createExtClient(w http.ResponseWriter, r *http.Request) {
 			logic.ReturnErrorResponse(w, r, logic.FormatError(err, "internal"))
 			return
 		}
-		if err := mq.PublishPeerUpdate(false); err != nil {
-			logger.Log(1, "error setting ext peers on "+nodeid+": "+err.Error())
-		}
+		mq.QueuePeerUpdate(false, node.Network)
 		if servercfg.IsDNSMode() {
 			logic.SetDNS()
 		}
@@ -948,15 +944,7 @@ func updateExtClient(w http.ResponseWriter, r *http.Request) {
 		if sendPeerUpdate { // need to send a peer update to the ingress node as enablement of one of it's clients has changed
 			ingressNode, err := logic.GetNodeByID(newclient.IngressGatewayID)
 			if err == nil {
-				if err = mq.PublishPeerUpdate(false); err != nil {
-					logger.Log(
-						1,
-						"error setting ext peers on",
-						ingressNode.ID.String(),
-						":",
-						err.Error(),
-					)
-				}
+				mq.QueuePeerUpdate(false, ingressNode.Network)
 			}
 			if !update.Enabled {
 				ingressHost, err := logic.GetHost(ingressNode.HostID.String())
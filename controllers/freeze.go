@@ -0,0 +1,71 @@
+package controller
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/gravitl/netmaker/logic"
+	"github.com/gravitl/netmaker/models"
+)
+
+// overrideFreezeParam - query param used by super admins to bypass a network freeze
+const overrideFreezeParam = "override_freeze"
+
+// canOverrideFreeze - checks if the caller is a super admin explicitly overriding a network freeze
+func canOverrideFreeze(r *http.Request) bool {
+	if r.URL.Query().Get(overrideFreezeParam) != "true" {
+		return false
+	}
+	if r.Header.Get("ismaster") == "yes" {
+		return true
+	}
+	user, err := logic.GetUser(r.Header.Get("user"))
+	if err != nil {
+		return false
+	}
+	return user.PlatformRoleID == models.SuperAdminRole
+}
+
+// isNetworkFrozen - writes a 423 response and returns true if the network is frozen for the caller
+func isNetworkFrozen(w http.ResponseWriter, r *http.Request, netID string) bool {
+	err := logic.CheckNetworkFreeze(netID)
+	if err == nil || canOverrideFreeze(r) {
+		return false
+	}
+	logic.ReturnErrorResponse(w, r, logic.FormatError(err, "locked"))
+	return true
+}
+
+// areNetworksFrozen - writes a 423 response and returns true if any of the networks is frozen for the caller
+func areNetworksFrozen(w http.ResponseWriter, r *http.Request, networks []string) bool {
+	for _, netID := range networks {
+		if isNetworkFrozen(w, r, netID) {
+			return true
+		}
+	}
+	return false
+}
+
+// requestNetworks - the networks a request names in its path and query, along with the network
+// of the node it targets, as handlers may act on any of them
+func requestNetworks(r *http.Request) []string {
+	params := mux.Vars(r)
+	networks := []string{params["network"], params["networkname"], r.URL.Query().Get("network")}
+	if nodeID := params["nodeid"]; nodeID != "" {
+		if node, err := logic.GetNodeByID(nodeID); err == nil {
+			networks = append(networks, node.Network)
+		}
+	}
+	return networks
+}
+
+// NetworkFreezeCheck - rejects changes to resources of a frozen network, the request is rejected
+// if any network it names is frozen
+func NetworkFreezeCheck(next http.Handler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if areNetworksFrozen(w, r, requestNetworks(r)) {
+			return
+		}
+		next.ServeHTTP(w, r)
+	}
+}
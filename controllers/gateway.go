@@ -16,11 +16,11 @@ import (
 )
 
 func gwHandlers(r *mux.Router) {
-	r.HandleFunc("/api/nodes/{network}/{nodeid}/gateway", logic.SecurityCheck(true, checkFreeTierLimits(limitChoiceIngress, http.HandlerFunc(createGateway)))).Methods(http.MethodPost)
-	r.HandleFunc("/api/nodes/{network}/{nodeid}/gateway", logic.SecurityCheck(true, http.HandlerFunc(deleteGateway))).Methods(http.MethodDelete)
+	r.HandleFunc("/api/nodes/{network}/{nodeid}/gateway", logic.SecurityCheck(true, checkFreeTierLimits(limitChoiceIngress, NetworkFreezeCheck(http.HandlerFunc(createGateway))))).Methods(http.MethodPost)
+	r.HandleFunc("/api/nodes/{network}/{nodeid}/gateway", logic.SecurityCheck(true, NetworkFreezeCheck(http.HandlerFunc(deleteGateway)))).Methods(http.MethodDelete)
 	// old relay handlers
-	r.HandleFunc("/api/nodes/{network}/{nodeid}/createrelay", logic.SecurityCheck(true, http.HandlerFunc(createGateway))).Methods(http.MethodPost)
-	r.HandleFunc("/api/nodes/{network}/{nodeid}/deleterelay", logic.SecurityCheck(true, http.HandlerFunc(deleteGateway))).Methods(http.MethodDelete)
+	r.HandleFunc("/api/nodes/{network}/{nodeid}/createrelay", logic.SecurityCheck(true, NetworkFreezeCheck(http.HandlerFunc(createGateway)))).Methods(http.MethodPost)
+	r.HandleFunc("/api/nodes/{network}/{nodeid}/deleterelay", logic.SecurityCheck(true, NetworkFreezeCheck(http.HandlerFunc(deleteGateway)))).Methods(http.MethodDelete)
 }
 
 // @Summary     Create a gateway
@@ -96,7 +96,7 @@ func createGateway(w http.ResponseWriter, r *http.Request) {
 		if err := mq.NodeUpdate(&node); err != nil {
 			slog.Error("error publishing node update to node", "node", node.ID, "error", err)
 		}
-		mq.PublishPeerUpdate(false)
+		mq.QueuePeerUpdate(false, node.Network)
 	}()
 
 }
@@ -183,7 +183,7 @@ func deleteGateway(w http.ResponseWriter, r *http.Request) {
 					slog.Error("publishSingleHostUpdate", "host", host.Name, "error", err)
 				}
 			}
-			mq.PublishPeerUpdate(false)
+			mq.QueuePeerUpdate(false, node.Network)
 			if err := mq.NodeUpdate(&node); err != nil {
 				slog.Error(
 					"error publishing node update to node",
@@ -0,0 +1,99 @@
+package controller
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/gravitl/netmaker/logic"
+	"github.com/gravitl/netmaker/logic/hostactions"
+	"github.com/gravitl/netmaker/models"
+	"github.com/gravitl/netmaker/mq"
+	"golang.org/x/exp/slog"
+)
+
+func hostActionHandlers(r *mux.Router) {
+	r.HandleFunc("/api/v1/hosts/{hostid}/actions", logic.SecurityCheck(true, http.HandlerFunc(listHostActions))).
+		Methods(http.MethodGet)
+	r.HandleFunc("/api/v1/hosts/{hostid}/actions/{action_id}", logic.SecurityCheck(true, http.HandlerFunc(cancelHostAction))).
+		Methods(http.MethodDelete)
+	r.HandleFunc("/api/v1/hosts/{hostid}/actions/{action_id}/retry", logic.SecurityCheck(true, http.HandlerFunc(retryHostAction))).
+		Methods(http.MethodPost)
+}
+
+// sanitizeHostActions - strips the host secrets from the queued updates
+func sanitizeHostActions(actions []models.HostAction) []models.HostAction {
+	for i := range actions {
+		actions[i].Update.Host = models.Host{
+			ID:   actions[i].Update.Host.ID,
+			Name: actions[i].Update.Host.Name,
+		}
+	}
+	return actions
+}
+
+// @Summary     List the queued and recently finished actions of a host
+// @Router      /api/v1/hosts/{hostid}/actions [get]
+// @Tags        Hosts
+// @Security    oauth
+// @Param       hostid path string true "Host ID"
+// @Success     200 {array} models.HostAction
+// @Failure     404 {object} models.ErrorResponse
+func listHostActions(w http.ResponseWriter, r *http.Request) {
+	host, err := logic.GetHost(mux.Vars(r)["hostid"])
+	if err != nil {
+		logic.ReturnErrorResponse(w, r, logic.FormatError(err, "notfound"))
+		return
+	}
+	actions, err := hostactions.ListActions(host.ID.String())
+	if err != nil {
+		logic.ReturnErrorResponse(w, r, logic.FormatError(err, "internal"))
+		return
+	}
+	logic.ReturnSuccessResponseWithJson(w, r, sanitizeHostActions(actions), "fetched actions of host "+host.Name)
+}
+
+// @Summary     Cancel an action of a host that was not acknowledged yet
+// @Router      /api/v1/hosts/{hostid}/actions/{action_id} [delete]
+// @Tags        Hosts
+// @Security    oauth
+// @Param       hostid path string true "Host ID"
+// @Param       action_id path string true "Action ID"
+// @Success     200 {object} models.SuccessResponse
+// @Failure     400 {object} models.ErrorResponse
+func cancelHostAction(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	if err := hostactions.CancelAction(params["hostid"], params["action_id"]); err != nil {
+		logic.ReturnErrorResponse(w, r, logic.FormatError(err, "badrequest"))
+		return
+	}
+	slog.Info("cancelled host action", "host", params["hostid"], "action", params["action_id"], "user", r.Header.Get("user"))
+	logic.ReturnSuccessResponse(w, r, "cancelled action "+params["action_id"])
+}
+
+// @Summary     Queue an action of a host again and request the host to pull it
+// @Router      /api/v1/hosts/{hostid}/actions/{action_id}/retry [post]
+// @Tags        Hosts
+// @Security    oauth
+// @Param       hostid path string true "Host ID"
+// @Param       action_id path string true "Action ID"
+// @Success     200 {object} models.HostAction
+// @Failure     400 {object} models.ErrorResponse
+// @Failure     404 {object} models.ErrorResponse
+func retryHostAction(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	host, err := logic.GetHost(params["hostid"])
+	if err != nil {
+		logic.ReturnErrorResponse(w, r, logic.FormatError(err, "notfound"))
+		return
+	}
+	action, err := hostactions.RetryAction(host.ID.String(), params["action_id"])
+	if err != nil {
+		logic.ReturnErrorResponse(w, r, logic.FormatError(err, "badrequest"))
+		return
+	}
+	if err := mq.RequestAck(host); err != nil {
+		slog.Error("failed to request ack from host", "host", host.ID.String(), "error", err)
+	}
+	slog.Info("retrying host action", "host", host.ID.String(), "action", action.ID, "user", r.Header.Get("user"))
+	logic.ReturnSuccessResponseWithJson(w, r, sanitizeHostActions([]models.HostAction{action})[0], "retrying action "+action.ID)
+}
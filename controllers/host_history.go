@@ -0,0 +1,50 @@
+package controller
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/gravitl/netmaker/logic"
+	"github.com/gravitl/netmaker/models"
+)
+
+func hostHistoryHandlers(r *mux.Router) {
+	r.HandleFunc("/api/v1/hosts/{hostid}/history", logic.SecurityCheck(true, http.HandlerFunc(getHostHistory))).
+		Methods(http.MethodGet)
+}
+
+// @Summary     Get the endpoint, NAT and interface change history of a host
+// @Router      /api/v1/hosts/{hostid}/history [get]
+// @Tags        Hosts
+// @Security    oauth
+// @Param       hostid path string true "Host ID"
+// @Param       type query string false "Change type (endpoint, endpoint6, nat, interfaces)"
+// @Param       unexpected query bool false "Only unexpected public endpoint changes"
+// @Success     200 {object} models.HostHistory
+// @Failure     404 {object} models.ErrorResponse
+func getHostHistory(w http.ResponseWriter, r *http.Request) {
+	host, err := logic.GetHost(mux.Vars(r)["hostid"])
+	if err != nil {
+		logic.ReturnErrorResponse(w, r, logic.FormatError(err, "notfound"))
+		return
+	}
+	history, err := logic.GetHostHistory(host.ID.String())
+	if err != nil {
+		logic.ReturnErrorResponse(w, r, logic.FormatError(err, "internal"))
+		return
+	}
+	changeType := models.HostChangeType(r.URL.Query().Get("type"))
+	unexpected := r.URL.Query().Get("unexpected") == "true"
+	entries := []models.HostHistoryEntry{}
+	for _, entry := range history.Entries {
+		if changeType != "" && entry.Type != changeType {
+			continue
+		}
+		if unexpected && !entry.Unexpected {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	history.Entries = entries
+	logic.ReturnSuccessResponseWithJson(w, r, history, "fetched history of host "+host.Name)
+}
@@ -28,6 +28,10 @@ func hostHandlers(r *mux.Router) {
 		Methods(http.MethodPost)
 	r.HandleFunc("/api/hosts/upgrade", logic.SecurityCheck(true, http.HandlerFunc(upgradeHosts))).
 		Methods(http.MethodPost)
+	r.HandleFunc("/api/hosts/drift", logic.SecurityCheck(true, http.HandlerFunc(getHostsDrift))).
+		Methods(http.MethodGet)
+	r.HandleFunc("/api/hosts/{hostid}/drift", logic.SecurityCheck(true, http.HandlerFunc(getHostDrift))).
+		Methods(http.MethodGet)
 	r.HandleFunc("/api/hosts/{hostid}/keys", logic.SecurityCheck(true, http.HandlerFunc(updateKeys))).
 		Methods(http.MethodPut)
 	r.HandleFunc("/api/hosts/{hostid}/sync", logic.SecurityCheck(true, http.HandlerFunc(syncHost))).
@@ -38,9 +42,9 @@ func hostHandlers(r *mux.Router) {
 		Methods(http.MethodDelete)
 	r.HandleFunc("/api/hosts/{hostid}/upgrade", logic.SecurityCheck(true, http.HandlerFunc(upgradeHost))).
 		Methods(http.MethodPut)
-	r.HandleFunc("/api/hosts/{hostid}/networks/{network}", logic.SecurityCheck(true, http.HandlerFunc(addHostToNetwork))).
+	r.HandleFunc("/api/hosts/{hostid}/networks/{network}", logic.SecurityCheck(true, NetworkFreezeCheck(http.HandlerFunc(addHostToNetwork)))).
 		Methods(http.MethodPost)
-	r.HandleFunc("/api/hosts/{hostid}/networks/{network}", logic.SecurityCheck(true, http.HandlerFunc(deleteHostFromNetwork))).
+	r.HandleFunc("/api/hosts/{hostid}/networks/{network}", logic.SecurityCheck(true, NetworkFreezeCheck(http.HandlerFunc(deleteHostFromNetwork)))).
 		Methods(http.MethodDelete)
 	r.HandleFunc("/api/hosts/adm/authenticate", authenticateHost).Methods(http.MethodPost)
 	r.HandleFunc("/api/v1/host", Authorize(true, false, "host", http.HandlerFunc(pull))).
@@ -61,10 +65,16 @@ func hostHandlers(r *mux.Router) {
 // @Tags        Hosts
 // @Security    oauth
 // @Param       force query bool false "Force upgrade"
+// @Param       selector query string false "Host label selector, e.g. env=prod,site!=fra1"
 // @Success     200 {string} string "upgrade all hosts request received"
 func upgradeHosts(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
+	selector, err := logic.ParseLabelSelector(r.URL.Query().Get("selector"))
+	if err != nil {
+		logic.ReturnErrorResponse(w, r, logic.FormatError(err, "badrequest"))
+		return
+	}
 	action := models.Upgrade
 
 	if r.URL.Query().Get("force") == "true" {
@@ -81,6 +91,7 @@ func upgradeHosts(w http.ResponseWriter, r *http.Request) {
 			slog.Error("failed to retrieve all hosts", "user", user, "error", err)
 			return
 		}
+		hosts = logic.FilterHostsBySelector(hosts, selector)
 
 		for _, host := range hosts {
 			go func(host models.Host) {
@@ -136,17 +147,25 @@ func upgradeHost(w http.ResponseWriter, r *http.Request) {
 // @Router      /api/hosts [get]
 // @Tags        Hosts
 // @Security    oauth
+// @Param       selector query string false "Host label selector, e.g. env=prod,site!=fra1"
 // @Success     200 {array} models.ApiHost
+// @Failure     400 {object} models.ErrorResponse
 // @Failure     500 {object} models.ErrorResponse
 func getHosts(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
+	selector, err := logic.ParseLabelSelector(r.URL.Query().Get("selector"))
+	if err != nil {
+		logic.ReturnErrorResponse(w, r, logic.FormatError(err, "badrequest"))
+		return
+	}
 	currentHosts, err := logic.GetAllHosts()
 	if err != nil {
 		logger.Log(0, r.Header.Get("user"), "failed to fetch hosts: ", err.Error())
 		logic.ReturnErrorResponse(w, r, logic.FormatError(err, "internal"))
 		return
 	}
+	currentHosts = logic.FilterHostsBySelector(currentHosts, selector)
 
 	apiHosts := logic.GetAllHostsAPI(currentHosts[:])
 	logger.Log(2, r.Header.Get("user"), "fetched all hosts")
@@ -193,9 +212,7 @@ func pull(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 	if sendPeerUpdate {
-		if err := mq.PublishPeerUpdate(false); err != nil {
-			logger.Log(0, "fail to publish peer update: ", err.Error())
-		}
+		mq.QueuePeerUpdate(false, logic.GetHostNetworks(hostID)...)
 	}
 	allNodes, err := logic.GetAllNodes()
 	if err != nil {
@@ -264,8 +281,16 @@ func updateHost(w http.ResponseWriter, r *http.Request) {
 	}
 
 	newHost := newHostData.ConvertAPIHostToNMHost(currHost)
+	if err = logic.ValidateHostLabels(newHost.Labels); err != nil {
+		logic.ReturnErrorResponse(w, r, logic.FormatError(err, "badrequest"))
+		return
+	}
 
 	logic.UpdateHost(newHost, currHost) // update the in memory struct values
+	if err = logic.CheckHostConnectivityEdit(newHost, currHost); err != nil {
+		logic.ReturnErrorResponse(w, r, logic.FormatError(err, "badrequest"))
+		return
+	}
 	if err = logic.UpsertHost(newHost); err != nil {
 		logger.Log(0, r.Header.Get("user"), "failed to update a host:", err.Error())
 		logic.ReturnErrorResponse(w, r, logic.FormatError(err, "internal"))
@@ -284,10 +309,8 @@ func updateHost(w http.ResponseWriter, r *http.Request) {
 			err.Error(),
 		)
 	}
+	mq.QueuePeerUpdate(false, logic.GetHostNetworks(newHost.ID.String())...)
 	go func() {
-		if err := mq.PublishPeerUpdate(false); err != nil {
-			logger.Log(0, "fail to publish peer update: ", err.Error())
-		}
 		if newHost.Name != currHost.Name {
 			if servercfg.IsDNSMode() {
 				logic.SetDNS()
@@ -330,9 +353,11 @@ func hostUpdateFallback(w http.ResponseWriter, r *http.Request) {
 	slog.Info("recieved host update", "name", hostUpdate.Host.Name, "id", hostUpdate.Host.ID, "action", hostUpdate.Action)
 	switch hostUpdate.Action {
 	case models.CheckIn:
+		logic.RecordAppliedGeneration(currentHost.ID.String(), hostUpdate.Generation)
 		sendPeerUpdate = mq.HandleHostCheckin(&hostUpdate.Host, currentHost)
 
 	case models.UpdateHost:
+		logic.RecordAppliedGeneration(currentHost.ID.String(), hostUpdate.Generation)
 		if hostUpdate.Host.PublicKey != currentHost.PublicKey {
 			//remove old peer entry
 			replacePeers = true
@@ -350,10 +375,8 @@ func hostUpdateFallback(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if sendPeerUpdate {
-		err := mq.PublishPeerUpdate(replacePeers)
-		if err != nil {
-			slog.Error("failed to publish peer update", "error", err)
-		}
+		// a deleted host has no networks left, so all hosts are updated
+		mq.QueuePeerUpdate(replacePeers, logic.GetHostNetworks(hostid)...)
 	}
 	logic.ReturnSuccessResponse(w, r, "updated host data")
 }
@@ -378,6 +401,10 @@ func deleteHost(w http.ResponseWriter, r *http.Request) {
 		logic.ReturnErrorResponse(w, r, logic.FormatError(err, "internal"))
 		return
 	}
+	// the host's nodes are removed from all of its networks
+	if areNetworksFrozen(w, r, logic.GetHostNetworks(hostid)) {
+		return
+	}
 	for _, nodeID := range currHost.Nodes {
 		node, err := logic.GetNodeByID(nodeID)
 		if err != nil {
@@ -482,7 +509,7 @@ func addHostToNetwork(w http.ResponseWriter, r *http.Request) {
 			Host:   *currHost,
 			Node:   *newNode,
 		})
-		mq.PublishPeerUpdate(false)
+		mq.QueuePeerUpdate(false, network)
 		if servercfg.IsDNSMode() {
 			logic.SetDNS()
 		}
@@ -688,6 +715,14 @@ func authenticateHost(response http.ResponseWriter, request *http.Request) {
 		logic.ReturnErrorResponse(response, request, errorResponse)
 		return
 	}
+	if logic.IsHostQuarantined(host.ID.String()) {
+		// no new token or broker credentials until an admin lifts the quarantine
+		errorResponse.Code = http.StatusForbidden
+		errorResponse.Message = "host is quarantined"
+		slog.Warn("quarantined host tried to authenticate", "host", host.ID.String(), "name", host.Name)
+		logic.ReturnErrorResponse(response, request, errorResponse)
+		return
+	}
 
 	tokenString, err := logic.CreateJWT(authRequest.ID, authRequest.MacAddress, "")
 	if tokenString == "" {
@@ -798,11 +833,17 @@ func signalPeer(w http.ResponseWriter, r *http.Request) {
 // @Router      /api/hosts/keys [put]
 // @Tags        Hosts
 // @Security    oauth
+// @Param       selector query string false "Host label selector, e.g. env=prod,site!=fra1"
 // @Success     200 {string} string "OK"
 // @Failure     400 {object} models.ErrorResponse
 func updateAllKeys(w http.ResponseWriter, r *http.Request) {
 	var errorResponse = models.ErrorResponse{}
 	w.Header().Set("Content-Type", "application/json")
+	selector, err := logic.ParseLabelSelector(r.URL.Query().Get("selector"))
+	if err != nil {
+		logic.ReturnErrorResponse(w, r, logic.FormatError(err, "badrequest"))
+		return
+	}
 	hosts, err := logic.GetAllHosts()
 	if err != nil {
 		errorResponse.Code = http.StatusBadRequest
@@ -812,6 +853,7 @@ func updateAllKeys(w http.ResponseWriter, r *http.Request) {
 		logic.ReturnErrorResponse(w, r, errorResponse)
 		return
 	}
+	hosts = logic.FilterHostsBySelector(hosts, selector)
 	go func() {
 		hostUpdate := models.HostUpdate{}
 		hostUpdate.Action = models.UpdateKeys
@@ -871,10 +913,17 @@ func updateKeys(w http.ResponseWriter, r *http.Request) {
 // @Router      /api/hosts/sync [post]
 // @Tags        Hosts
 // @Security    oauth
+// @Param       selector query string false "Host label selector, e.g. env=prod,site!=fra1"
 // @Success     200 {string} string "sync all hosts request received"
 func syncHosts(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
+	selector, err := logic.ParseLabelSelector(r.URL.Query().Get("selector"))
+	if err != nil {
+		logic.ReturnErrorResponse(w, r, logic.FormatError(err, "badrequest"))
+		return
+	}
+
 	user := r.Header.Get("user")
 
 	go func() {
@@ -885,6 +934,7 @@ func syncHosts(w http.ResponseWriter, r *http.Request) {
 			slog.Error("failed to retrieve all hosts", "user", user, "error", err)
 			return
 		}
+		hosts = logic.FilterHostsBySelector(hosts, selector)
 
 		for _, host := range hosts {
 			go func(host models.Host) {
@@ -1003,3 +1053,52 @@ func getHostPeerInfo(w http.ResponseWriter, r *http.Request) {
 	}
 	logic.ReturnSuccessResponseWithJson(w, r, peerInfo, "fetched host peer info")
 }
+
+// @Summary     Lists the desired state drift of all hosts
+// @Router      /api/hosts/drift [get]
+// @Tags        Hosts
+// @Security    oauth
+// @Param       selector query string false "Host label selector, e.g. env=prod,site!=fra1"
+// @Param       status query string false "Only return hosts with this drift status"
+// @Success     200 {array} models.HostDrift
+// @Failure     400 {object} models.ErrorResponse
+// @Failure     500 {object} models.ErrorResponse
+func getHostsDrift(w http.ResponseWriter, r *http.Request) {
+	selector, err := logic.ParseLabelSelector(r.URL.Query().Get("selector"))
+	if err != nil {
+		logic.ReturnErrorResponse(w, r, logic.FormatError(err, "badrequest"))
+		return
+	}
+	hosts, err := logic.GetAllHosts()
+	if err != nil {
+		logic.ReturnErrorResponse(w, r, logic.FormatError(err, "internal"))
+		return
+	}
+	drifts := logic.GetHostsDrift(logic.FilterHostsBySelector(hosts, selector))
+	if status := r.URL.Query().Get("status"); status != "" {
+		filtered := []models.HostDrift{}
+		for _, drift := range drifts {
+			if drift.Status == models.HostDriftStatus(status) {
+				filtered = append(filtered, drift)
+			}
+		}
+		drifts = filtered
+	}
+	logic.ReturnSuccessResponseWithJson(w, r, drifts, "fetched hosts drift status")
+}
+
+// @Summary     Fetches the desired state drift of a host
+// @Router      /api/hosts/{hostid}/drift [get]
+// @Tags        Hosts
+// @Security    oauth
+// @Param       hostid path string true "Host ID"
+// @Success     200 {object} models.HostDrift
+// @Failure     400 {object} models.ErrorResponse
+func getHostDrift(w http.ResponseWriter, r *http.Request) {
+	host, err := logic.GetHost(mux.Vars(r)["hostid"])
+	if err != nil {
+		logic.ReturnErrorResponse(w, r, logic.FormatError(err, "badrequest"))
+		return
+	}
+	logic.ReturnSuccessResponseWithJson(w, r, logic.GetHostDrift(host), "fetched host drift status")
+}
@@ -0,0 +1,97 @@
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+
+	"github.com/gorilla/mux"
+	"github.com/gravitl/netmaker/logger"
+	"github.com/gravitl/netmaker/logic"
+	"github.com/gravitl/netmaker/models"
+)
+
+func keyRotationHandlers(r *mux.Router) {
+	r.HandleFunc("/api/v1/hosts/key-rotation/policies", logic.SecurityCheck(true, http.HandlerFunc(getKeyRotationPolicies))).
+		Methods(http.MethodGet)
+	r.HandleFunc("/api/v1/hosts/key-rotation/policies", logic.SecurityCheck(true, http.HandlerFunc(upsertKeyRotationPolicy))).
+		Methods(http.MethodPut)
+	r.HandleFunc("/api/v1/hosts/key-rotation/policies", logic.SecurityCheck(true, http.HandlerFunc(deleteKeyRotationPolicy))).
+		Methods(http.MethodDelete)
+	r.HandleFunc("/api/v1/hosts/key-rotation/status", logic.SecurityCheck(true, http.HandlerFunc(getKeyRotationStatus))).
+		Methods(http.MethodGet)
+}
+
+// @Summary     List key rotation policies
+// @Router      /api/v1/hosts/key-rotation/policies [get]
+// @Tags        Hosts
+// @Security    oauth
+// @Success     200 {array} models.KeyRotationPolicy
+// @Failure     500 {object} models.ErrorResponse
+func getKeyRotationPolicies(w http.ResponseWriter, r *http.Request) {
+	policies, err := logic.ListKeyRotationPolicies()
+	if err != nil {
+		logic.ReturnErrorResponse(w, r, logic.FormatError(err, "internal"))
+		return
+	}
+	logic.ReturnSuccessResponseWithJson(w, r, policies, "fetched key rotation policies")
+}
+
+// @Summary     Create or update a key rotation policy, an empty network sets the global policy
+// @Router      /api/v1/hosts/key-rotation/policies [put]
+// @Tags        Hosts
+// @Security    oauth
+// @Param       body body models.KeyRotationPolicy true "Key rotation policy"
+// @Success     200 {object} models.KeyRotationPolicy
+// @Failure     400 {object} models.ErrorResponse
+func upsertKeyRotationPolicy(w http.ResponseWriter, r *http.Request) {
+	var policy models.KeyRotationPolicy
+	if err := json.NewDecoder(r.Body).Decode(&policy); err != nil {
+		logger.Log(0, "error decoding request body: ", err.Error())
+		logic.ReturnErrorResponse(w, r, logic.FormatError(err, "badrequest"))
+		return
+	}
+	if policy.Network != "" && isNetworkFrozen(w, r, policy.Network) {
+		return
+	}
+	policy.UpdatedBy = r.Header.Get("user")
+	policy, err := logic.UpsertKeyRotationPolicy(policy)
+	if err != nil {
+		logic.ReturnErrorResponse(w, r, logic.FormatError(err, "badrequest"))
+		return
+	}
+	logic.ReturnSuccessResponseWithJson(w, r, policy, "updated key rotation policy")
+}
+
+// @Summary     Delete a key rotation policy
+// @Router      /api/v1/hosts/key-rotation/policies [delete]
+// @Tags        Hosts
+// @Security    oauth
+// @Param       network query string false "Network ID, empty deletes the global policy"
+// @Success     200 {object} models.SuccessResponse
+// @Failure     400 {object} models.ErrorResponse
+func deleteKeyRotationPolicy(w http.ResponseWriter, r *http.Request) {
+	network, _ := url.QueryUnescape(r.URL.Query().Get("network"))
+	if err := logic.DeleteKeyRotationPolicy(network); err != nil {
+		logic.ReturnErrorResponse(w, r, logic.FormatError(err, "badrequest"))
+		return
+	}
+	logic.ReturnSuccessResponse(w, r, "deleted key rotation policy")
+}
+
+// @Summary     Key rotation status of hosts
+// @Router      /api/v1/hosts/key-rotation/status [get]
+// @Tags        Hosts
+// @Security    oauth
+// @Param       network query string false "Only hosts in this network"
+// @Success     200 {array} models.HostKeyRotationStatus
+// @Failure     500 {object} models.ErrorResponse
+func getKeyRotationStatus(w http.ResponseWriter, r *http.Request) {
+	network, _ := url.QueryUnescape(r.URL.Query().Get("network"))
+	statuses, err := logic.GetKeyRotationStatus(network)
+	if err != nil {
+		logic.ReturnErrorResponse(w, r, logic.FormatError(err, "internal"))
+		return
+	}
+	logic.ReturnSuccessResponseWithJson(w, r, statuses, "fetched key rotation status")
+}
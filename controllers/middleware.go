@@ -60,6 +60,10 @@ func userMiddleWare(handler http.Handler) http.Handler {
 		if strings.Contains(route, "acls") {
 			r.Header.Set("TARGET_RSRC", models.AclRsrc.String())
 		}
+		// filing an access request is separate from managing acls, reviewing one is not
+		if route == "/api/v1/acls/access_requests" && r.Method == http.MethodPost {
+			r.Header.Set("TARGET_RSRC", models.AccessRequestRsrc.String())
+		}
 		if strings.Contains(route, "tags") {
 			r.Header.Set("TARGET_RSRC", models.TagRsrc.String())
 		}
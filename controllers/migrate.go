@@ -92,7 +92,7 @@ func migrate(w http.ResponseWriter, r *http.Request) {
 	if err := logic.UpsertHost(&host); err != nil {
 		slog.Error("save host", "error", err)
 	}
-	go mq.PublishPeerUpdate(false)
+	mq.QueuePeerUpdate(false, logic.GetHostNetworks(host.ID.String())...)
 	response := models.HostPull{
 		Host:         host,
 		Nodes:        nodes,
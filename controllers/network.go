@@ -30,18 +30,25 @@ func networkHandlers(r *mux.Router) {
 		Methods(http.MethodPost)
 	r.HandleFunc("/api/networks/{networkname}", logic.SecurityCheck(true, http.HandlerFunc(getNetwork))).
 		Methods(http.MethodGet)
-	r.HandleFunc("/api/networks/{networkname}", logic.SecurityCheck(true, http.HandlerFunc(deleteNetwork))).
+	r.HandleFunc("/api/networks/{networkname}", logic.SecurityCheck(true, NetworkFreezeCheck(http.HandlerFunc(deleteNetwork)))).
 		Methods(http.MethodDelete)
-	r.HandleFunc("/api/networks/{networkname}", logic.SecurityCheck(true, http.HandlerFunc(updateNetwork))).
+	r.HandleFunc("/api/networks/{networkname}", logic.SecurityCheck(true, NetworkFreezeCheck(http.HandlerFunc(updateNetwork)))).
 		Methods(http.MethodPut)
 	// ACLs
-	r.HandleFunc("/api/networks/{networkname}/acls", logic.SecurityCheck(true, http.HandlerFunc(updateNetworkACL))).
+	r.HandleFunc("/api/networks/{networkname}/acls", logic.SecurityCheck(true, NetworkFreezeCheck(http.HandlerFunc(updateNetworkACL)))).
 		Methods(http.MethodPut)
-	r.HandleFunc("/api/networks/{networkname}/acls/v2", logic.SecurityCheck(true, http.HandlerFunc(updateNetworkACLv2))).
+	r.HandleFunc("/api/networks/{networkname}/acls/v2", logic.SecurityCheck(true, NetworkFreezeCheck(http.HandlerFunc(updateNetworkACLv2)))).
 		Methods(http.MethodPut)
 	r.HandleFunc("/api/networks/{networkname}/acls", logic.SecurityCheck(true, http.HandlerFunc(getNetworkACL))).
 		Methods(http.MethodGet)
 	r.HandleFunc("/api/networks/{networkname}/egress_routes", logic.SecurityCheck(true, http.HandlerFunc(getNetworkEgressRoutes)))
+	r.HandleFunc("/api/networks/{networkname}/node-expiry", logic.SecurityCheck(true, NetworkFreezeCheck(http.HandlerFunc(updateNetworkNodeExpiry)))).
+		Methods(http.MethodPut)
+	// freeze
+	r.HandleFunc("/api/networks/{networkname}/freeze", logic.SecurityCheck(true, http.HandlerFunc(freezeNetwork))).
+		Methods(http.MethodPut)
+	r.HandleFunc("/api/networks/{networkname}/freeze", logic.SecurityCheck(true, http.HandlerFunc(unfreezeNetwork))).
+		Methods(http.MethodDelete)
 }
 
 // @Summary     Lists all networks
@@ -184,11 +191,7 @@ func updateNetworkACL(w http.ResponseWriter, r *http.Request) {
 	logger.Log(1, r.Header.Get("user"), "updated ACLs for network", netname)
 
 	// send peer updates
-	go func() {
-		if err = mq.PublishPeerUpdate(false); err != nil {
-			logger.Log(0, "failed to publish peer update after ACL update on network:", netname)
-		}
-	}()
+	mq.QueuePeerUpdate(false, netname)
 
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(newNetACL)
@@ -367,11 +370,8 @@ func updateNetworkACLv2(w http.ResponseWriter, r *http.Request) {
 	logger.Log(1, r.Header.Get("user"), "updated ACLs for network", netname)
 
 	// send peer updates
+	mq.QueuePeerUpdate(false, netname)
 	go func() {
-		if err = mq.PublishPeerUpdate(false); err != nil {
-			logger.Log(0, "failed to publish peer update after ACL update on network:", netname)
-		}
-
 		// update ingress gateways of associated clients
 		hosts, err := logic.GetAllHosts()
 		if err != nil {
@@ -495,12 +495,15 @@ func deleteNetwork(w http.ResponseWriter, r *http.Request) {
 	go logic.UnlinkNetworkAndTagsFromEnrollmentKeys(network, true)
 	go logic.DeleteNetworkRoles(network)
 	go logic.DeleteAllNetworkTags(models.NetworkID(network))
+	go logic.DeleteNetworkConnectivityProfiles(models.NetworkID(network))
+	go logic.DeleteKeyRotationPolicy(network)
 	go logic.DeleteNetworkPolicies(models.NetworkID(network))
 	//delete network from allocated ip map
 	go logic.RemoveNetworkFromAllocatedIpMap(network)
 	go func() {
 		<-doneCh
-		mq.PublishPeerUpdate(true)
+		// the network's nodes are gone, so all hosts are updated
+		mq.QueuePeerUpdate(true)
 		// send node update to clean up locally
 		for _, node := range networkNodes {
 			node := node
@@ -633,9 +636,7 @@ func createNetwork(w http.ResponseWriter, r *http.Request) {
 			})
 		}
 		// send peer updates
-		if err = mq.PublishPeerUpdate(false); err != nil {
-			logger.Log(1, "failed to publish peer update for default hosts after network is added")
-		}
+		mq.QueuePeerUpdate(false, network.NetID)
 	}()
 
 	logger.Log(1, r.Header.Get("user"), "created network", network.NetID)
@@ -666,7 +667,13 @@ func updateNetwork(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	netOld, err := logic.GetNetwork(payload.NetID)
+	netname := mux.Vars(r)["networkname"]
+	if payload.NetID != "" && payload.NetID != netname {
+		logic.ReturnErrorResponse(w, r, logic.FormatError(errors.New("network id in the body does not match the path"), "badrequest"))
+		return
+	}
+	payload.NetID = netname
+	netOld, err := logic.GetNetwork(netname)
 	if err != nil {
 		slog.Info("error fetching network", "user", r.Header.Get("user"), "err", err)
 		logic.ReturnErrorResponse(w, r, logic.FormatError(err, "badrequest"))
@@ -681,8 +688,104 @@ func updateNetwork(w http.ResponseWriter, r *http.Request) {
 		logic.ReturnErrorResponse(w, r, logic.FormatError(err, "badrequest"))
 		return
 	}
-	go mq.PublishPeerUpdate(false)
+	mq.QueuePeerUpdate(false, payload.NetID)
 	slog.Info("updated network", "network", payload.NetID, "user", r.Header.Get("user"))
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(payload)
 }
+
+// @Summary     Set the default node lifetime and expiry action of a network
+// @Router      /api/networks/{networkname}/node-expiry [put]
+// @Tags        Networks
+// @Security    oauth
+// @Param       networkname path string true "Network name"
+// @Param       body body models.NodeExpiryPolicy true "Node expiry policy"
+// @Produce     json
+// @Success     200 {object} models.Network
+// @Failure     400 {object} models.ErrorResponse
+func updateNetworkNodeExpiry(w http.ResponseWriter, r *http.Request) {
+	netname := mux.Vars(r)["networkname"]
+	var policy models.NodeExpiryPolicy
+	if err := json.NewDecoder(r.Body).Decode(&policy); err != nil {
+		logger.Log(0, r.Header.Get("user"), "error decoding request body: ",
+			err.Error())
+		logic.ReturnErrorResponse(w, r, logic.FormatError(err, "badrequest"))
+		return
+	}
+	network, err := logic.SetNetworkNodeExpiry(netname, policy)
+	if err != nil {
+		slog.Error("failed to update node expiry", "network", netname, "user", r.Header.Get("user"), "error", err)
+		logic.ReturnErrorResponse(w, r, logic.FormatError(err, "badrequest"))
+		return
+	}
+	slog.Info("updated node expiry", "network", netname, "ttl", network.DefaultNodeTTL,
+		"action", network.NodeExpiryAction, "user", r.Header.Get("user"))
+	logic.ReturnSuccessResponseWithJson(w, r, network, "updated node expiry of network "+netname)
+}
+
+// @Summary     Freeze a network
+// @Router      /api/networks/{networkname}/freeze [put]
+// @Tags        Networks
+// @Security    oauth
+// @Param       networkname path string true "Network name"
+// @Param       body body models.NetworkFreezeRequest true "Freeze details"
+// @Produce     json
+// @Success     200 {object} models.Network
+// @Failure     400 {object} models.ErrorResponse
+func freezeNetwork(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	var params = mux.Vars(r)
+	netname := params["networkname"]
+	var req models.NetworkFreezeRequest
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		logger.Log(0, r.Header.Get("user"), "error decoding request body: ",
+			err.Error())
+		logic.ReturnErrorResponse(w, r, logic.FormatError(err, "badrequest"))
+		return
+	}
+	network, err := logic.FreezeNetwork(netname, req, r.Header.Get("user"))
+	if err != nil {
+		slog.Error("failed to freeze network", "network", netname, "user", r.Header.Get("user"), "error", err)
+		logic.ReturnErrorResponse(w, r, logic.FormatError(err, "badrequest"))
+		return
+	}
+	slog.Info("froze network", "network", netname, "reason", req.Reason, "user", r.Header.Get("user"))
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(network)
+}
+
+// @Summary     Lift the freeze on a network
+// @Router      /api/networks/{networkname}/freeze [delete]
+// @Tags        Networks
+// @Security    oauth
+// @Param       networkname path string true "Network name"
+// @Produce     json
+// @Success     200 {object} models.Network
+// @Failure     400 {object} models.ErrorResponse
+// @Failure     403 {object} models.ErrorResponse
+func unfreezeNetwork(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	var params = mux.Vars(r)
+	netname := params["networkname"]
+	if r.Header.Get("ismaster") != "yes" {
+		user, err := logic.GetUser(r.Header.Get("user"))
+		if err != nil {
+			logic.ReturnErrorResponse(w, r, logic.FormatError(err, "internal"))
+			return
+		}
+		if user.PlatformRoleID != models.SuperAdminRole {
+			logic.ReturnErrorResponse(w, r, logic.FormatError(errors.New("only super admins can lift a network freeze"), "forbidden"))
+			return
+		}
+	}
+	network, err := logic.UnfreezeNetwork(netname)
+	if err != nil {
+		slog.Error("failed to unfreeze network", "network", netname, "user", r.Header.Get("user"), "error", err)
+		logic.ReturnErrorResponse(w, r, logic.FormatError(err, "badrequest"))
+		return
+	}
+	slog.Info("lifted network freeze", "network", netname, "user", r.Header.Get("user"))
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(network)
+}
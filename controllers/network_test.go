@@ -2,10 +2,15 @@ package controller
 
 import (
 	"context"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/gorilla/mux"
 	"github.com/gravitl/netmaker/database"
 	"github.com/gravitl/netmaker/logger"
 	"github.com/gravitl/netmaker/logic"
@@ -92,6 +97,96 @@ func TestDeleteNetwork(t *testing.T) {
 	})
 }
 
+func TestFreezeNetwork(t *testing.T) {
+	deleteAllNetworks()
+	createNet()
+	t.Run("ReasonRequired", func(t *testing.T) {
+		_, err := logic.FreezeNetwork("skynet", models.NetworkFreezeRequest{}, "admin")
+		assert.NotNil(t, err)
+	})
+	t.Run("FreezeNetwork", func(t *testing.T) {
+		network, err := logic.FreezeNetwork("skynet", models.NetworkFreezeRequest{Reason: "audit"}, "admin")
+		assert.Nil(t, err)
+		assert.True(t, network.IsFrozen())
+		assert.ErrorIs(t, logic.CheckNetworkFreeze("skynet"), logic.ErrNetworkFrozen)
+	})
+	t.Run("ExpiredFreeze", func(t *testing.T) {
+		network, err := logic.GetNetwork("skynet")
+		assert.Nil(t, err)
+		network.FrozenUntil = time.Now().Add(-time.Minute)
+		assert.False(t, network.IsFrozen())
+	})
+	t.Run("UnfreezeNetwork", func(t *testing.T) {
+		network, err := logic.UnfreezeNetwork("skynet")
+		assert.Nil(t, err)
+		assert.False(t, network.IsFrozen())
+		assert.Nil(t, logic.CheckNetworkFreeze("skynet"))
+	})
+}
+
+func TestNetworkFreezeCheck(t *testing.T) {
+	deleteAllNetworks()
+	createNet()
+	createNetv1("skynet2")
+	_, err := logic.FreezeNetwork("skynet", models.NetworkFreezeRequest{Reason: "audit"}, "admin")
+	assert.Nil(t, err)
+	defer logic.UnfreezeNetwork("skynet")
+
+	router := mux.NewRouter()
+	router.HandleFunc("/api/nodes/{network}/{nodeid}", NetworkFreezeCheck(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))).Methods(http.MethodPut)
+	router.HandleFunc("/api/v1/host/register/{token}", handleHostRegister).Methods(http.MethodPost)
+	serve := func(method, target string) int {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(method, target, strings.NewReader("{}")))
+		return w.Code
+	}
+	t.Run("PathNetwork", func(t *testing.T) {
+		assert.Equal(t, http.StatusLocked, serve(http.MethodPut, "/api/nodes/skynet/node-1?network=skynet2"))
+	})
+	t.Run("QueryNetwork", func(t *testing.T) {
+		assert.Equal(t, http.StatusLocked, serve(http.MethodPut, "/api/nodes/skynet2/node-1?network=skynet"))
+	})
+	t.Run("NotFrozen", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, serve(http.MethodPut, "/api/nodes/skynet2/node-1"))
+	})
+	t.Run("HostRegistration", func(t *testing.T) {
+		key, err := logic.CreateEnrollmentKey(0, time.Time{}, []string{"skynet"}, nil, nil, nil, true, uuid.Nil, false)
+		assert.Nil(t, err)
+		assert.Nil(t, logic.Tokenize(key, "api.example.com"))
+		defer logic.DeleteEnrollmentKey(key.Value, true)
+		assert.Equal(t, http.StatusLocked, serve(http.MethodPost, "/api/v1/host/register/"+key.Token))
+	})
+	t.Run("NetworkDelete", func(t *testing.T) {
+		router.HandleFunc("/api/networks/{networkname}", NetworkFreezeCheck(http.HandlerFunc(deleteNetwork))).Methods(http.MethodDelete)
+		assert.Equal(t, http.StatusLocked, serve(http.MethodDelete, "/api/networks/skynet"))
+		_, err := logic.GetNetwork("skynet")
+		assert.Nil(t, err)
+	})
+	t.Run("HostDelete", func(t *testing.T) {
+		router.HandleFunc("/api/hosts/{hostid}", deleteHost).Methods(http.MethodDelete)
+		host := models.Host{ID: uuid.New(), Name: "frozen-host"}
+		node := models.Node{}
+		node.ID, node.HostID, node.Network = uuid.New(), host.ID, "skynet"
+		host.Nodes = []string{node.ID.String()}
+		assert.Nil(t, logic.UpsertHost(&host))
+		assert.Nil(t, logic.UpsertNode(&node))
+		defer logic.RemoveHostByID(host.ID.String())
+		defer logic.DeleteNodeByID(&node)
+		assert.Equal(t, http.StatusLocked, serve(http.MethodDelete, "/api/hosts/"+host.ID.String()))
+		_, err := logic.GetHost(host.ID.String())
+		assert.Nil(t, err)
+	})
+	t.Run("EnrollmentKeyCreate", func(t *testing.T) {
+		router.HandleFunc("/api/v1/enrollment-keys", createEnrollmentKey).Methods(http.MethodPost)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/v1/enrollment-keys",
+			strings.NewReader(`{"networks":["skynet"],"tags":["frozen-key"],"unlimited":true}`)))
+		assert.Equal(t, http.StatusLocked, w.Code)
+	})
+}
+
 func TestSecurityCheck(t *testing.T) {
 	//these seem to work but not sure it the tests are really testing the functionality
 
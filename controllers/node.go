@@ -2,7 +2,9 @@ package controller
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"maps"
 	"net/http"
 	"strings"
 
@@ -24,13 +26,14 @@ func nodeHandlers(r *mux.Router) {
 	r.HandleFunc("/api/nodes", logic.SecurityCheck(true, http.HandlerFunc(getAllNodes))).Methods(http.MethodGet)
 	r.HandleFunc("/api/nodes/{network}", logic.SecurityCheck(true, http.HandlerFunc(getNetworkNodes))).Methods(http.MethodGet)
 	r.HandleFunc("/api/nodes/{network}/{nodeid}", Authorize(true, true, "node", http.HandlerFunc(getNode))).Methods(http.MethodGet)
-	r.HandleFunc("/api/nodes/{network}/{nodeid}", logic.SecurityCheck(true, http.HandlerFunc(updateNode))).Methods(http.MethodPut)
-	r.HandleFunc("/api/nodes/{network}/{nodeid}", Authorize(true, true, "node", http.HandlerFunc(deleteNode))).Methods(http.MethodDelete)
-	r.HandleFunc("/api/nodes/{network}/{nodeid}/creategateway", logic.SecurityCheck(true, checkFreeTierLimits(limitChoiceEgress, http.HandlerFunc(createEgressGateway)))).Methods(http.MethodPost)
-	r.HandleFunc("/api/nodes/{network}/{nodeid}/deletegateway", logic.SecurityCheck(true, http.HandlerFunc(deleteEgressGateway))).Methods(http.MethodDelete)
-	r.HandleFunc("/api/nodes/{network}/{nodeid}/createingress", logic.SecurityCheck(true, checkFreeTierLimits(limitChoiceIngress, http.HandlerFunc(createGateway)))).Methods(http.MethodPost)
-	r.HandleFunc("/api/nodes/{network}/{nodeid}/deleteingress", logic.SecurityCheck(true, http.HandlerFunc(deleteGateway))).Methods(http.MethodDelete)
+	r.HandleFunc("/api/nodes/{network}/{nodeid}", logic.SecurityCheck(true, NetworkFreezeCheck(http.HandlerFunc(updateNode)))).Methods(http.MethodPut)
+	r.HandleFunc("/api/nodes/{network}/{nodeid}", Authorize(true, true, "node", NetworkFreezeCheck(http.HandlerFunc(deleteNode)))).Methods(http.MethodDelete)
+	r.HandleFunc("/api/nodes/{network}/{nodeid}/creategateway", logic.SecurityCheck(true, checkFreeTierLimits(limitChoiceEgress, NetworkFreezeCheck(http.HandlerFunc(createEgressGateway))))).Methods(http.MethodPost)
+	r.HandleFunc("/api/nodes/{network}/{nodeid}/deletegateway", logic.SecurityCheck(true, NetworkFreezeCheck(http.HandlerFunc(deleteEgressGateway)))).Methods(http.MethodDelete)
+	r.HandleFunc("/api/nodes/{network}/{nodeid}/createingress", logic.SecurityCheck(true, checkFreeTierLimits(limitChoiceIngress, NetworkFreezeCheck(http.HandlerFunc(createGateway))))).Methods(http.MethodPost)
+	r.HandleFunc("/api/nodes/{network}/{nodeid}/deleteingress", logic.SecurityCheck(true, NetworkFreezeCheck(http.HandlerFunc(deleteGateway)))).Methods(http.MethodDelete)
 	r.HandleFunc("/api/nodes/adm/{network}/authenticate", authenticate).Methods(http.MethodPost)
+	r.HandleFunc("/api/v1/nodes/{network}/{nodeid}/renew", Authorize(true, false, "host", NetworkFreezeCheck(http.HandlerFunc(renewNode)))).Methods(http.MethodPost)
 	r.HandleFunc("/api/v1/nodes/{network}/status", logic.SecurityCheck(true, http.HandlerFunc(getNetworkNodeStatus))).Methods(http.MethodGet)
 	r.HandleFunc("/api/v1/nodes/migrate", migrate).Methods(http.MethodPost)
 }
@@ -179,6 +182,10 @@ func Authorize(
 			if hostAllowed {
 				// TODO --- should ensure that node is only operating on itself
 				if hostID, _, _, err := logic.VerifyHostToken(authToken); err == nil {
+					if logic.IsHostQuarantined(hostID) {
+						logic.ReturnErrorResponse(w, r, logic.FormatError(errors.New("host is quarantined"), "forbidden"))
+						return
+					}
 					r.Header.Set(hostIDHeader, hostID)
 					// this indicates request is from a node
 					// used for failover - if a getNode comes from node, this will trigger a metrics wipe
@@ -341,13 +348,19 @@ func getNetworkNodes(w http.ResponseWriter, r *http.Request) {
 // @Router      /api/nodes [get]
 // @Tags        Nodes
 // @Securitydefinitions.oauth2.application OAuth2Application
+// @Param       selector query string false "Host label selector, e.g. env=prod,site!=fra1"
 // @Success     200 {array} models.ApiNode
 // @Failure     500 {object} models.ErrorResponse
 // Not quite sure if this is necessary. Probably necessary based on front end but may want to review after iteration 1 if it's being used or not
 func getAllNodes(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
+	selector, err := logic.ParseLabelSelector(r.URL.Query().Get("selector"))
+	if err != nil {
+		logic.ReturnErrorResponse(w, r, logic.FormatError(err, "badrequest"))
+		return
+	}
 	var nodes []models.Node
-	nodes, err := logic.GetAllNodes()
+	nodes, err = logic.GetAllNodes()
 	if err != nil {
 		logger.Log(0, "error fetching all nodes info: ", err.Error())
 		logic.ReturnErrorResponse(w, r, logic.FormatError(err, "internal"))
@@ -369,6 +382,11 @@ func getAllNodes(w http.ResponseWriter, r *http.Request) {
 
 	}
 	nodes = logic.AddStaticNodestoList(nodes)
+	nodes, err = logic.FilterNodesBySelector(nodes, selector)
+	if err != nil {
+		logic.ReturnErrorResponse(w, r, logic.FormatError(err, "internal"))
+		return
+	}
 	nodes = logic.AddStatusToNodes(nodes, false)
 	// return all the nodes in JSON/API format
 	apiNodes := logic.GetAllNodesAPI(nodes[:])
@@ -546,7 +564,7 @@ func createEgressGateway(w http.ResponseWriter, r *http.Request) {
 		if err := mq.NodeUpdate(&node); err != nil {
 			slog.Error("error publishing node update to node", "node", node.ID, "error", err)
 		}
-		mq.PublishPeerUpdate(false)
+		mq.QueuePeerUpdate(false, node.Network)
 	}()
 }
 
@@ -591,7 +609,7 @@ func deleteEgressGateway(w http.ResponseWriter, r *http.Request) {
 		if err := mq.NodeUpdate(&node); err != nil {
 			slog.Error("error publishing node update to node", "node", node.ID, "error", err)
 		}
-		mq.PublishPeerUpdate(false)
+		mq.QueuePeerUpdate(false, node.Network)
 	}()
 }
 
@@ -664,9 +682,7 @@ func updateNode(w http.ResponseWriter, r *http.Request) {
 		logic.ReturnErrorResponse(w, r, logic.FormatError(err, "internal"))
 		return
 	}
-	ifaceDelta := logic.IfaceDelta(&currentNode, newNode)
-	aclUpdate := currentNode.DefaultACL != newNode.DefaultACL
-
+	tagsChanged := !maps.Equal(currentNode.Tags, newNode.Tags)
 	err = logic.UpdateNode(&currentNode, newNode)
 	if err != nil {
 		logger.Log(0, r.Header.Get("user"),
@@ -691,20 +707,18 @@ func updateNode(w http.ResponseWriter, r *http.Request) {
 	)
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(apiNode)
-	go func(aclUpdate, relayupdate bool, newNode *models.Node) {
+	go func(newNode *models.Node) {
 		if err := mq.NodeUpdate(newNode); err != nil {
 			slog.Error("error publishing node update to node", "node", newNode.ID, "error", err)
 		}
-		if aclUpdate || relayupdate || ifaceDelta {
-			if err := mq.PublishPeerUpdate(false); err != nil {
-				logger.Log(0, "error during node ACL update for node", newNode.ID.String())
-			}
+		if tagsChanged {
+			mq.PublishNetworkConnectivity(models.NetworkID(newNode.Network))
 		}
-		mq.PublishPeerUpdate(false)
+		mq.QueuePeerUpdate(false, newNode.Network)
 		if servercfg.IsDNSMode() {
 			logic.SetDNS()
 		}
-	}(aclUpdate, relayUpdate, newNode)
+	}(newNode)
 }
 
 // @Summary     Delete an individual node
@@ -745,3 +759,51 @@ func deleteNode(w http.ResponseWriter, r *http.Request) {
 	logger.Log(1, r.Header.Get("user"), "Deleted node", nodeid, "from network", params["network"])
 	go mq.PublishMqUpdatesForDeletedNode(node, !fromNode, gwClients)
 }
+
+// @Summary     Renew the lease of a node, callable by admins and by the host of the node
+// @Router      /api/v1/nodes/{network}/{nodeid}/renew [post]
+// @Tags        Nodes
+// @Security    oauth
+// @Param       network path string true "Network ID"
+// @Param       nodeid path string true "Node ID"
+// @Param       body body models.NodeRenewalReq false "Lease to renew by"
+// @Success     200 {object} models.ApiNode
+// @Failure     400 {object} models.ErrorResponse
+// @Failure     403 {object} models.ErrorResponse
+func renewNode(w http.ResponseWriter, r *http.Request) {
+	var params = mux.Vars(r)
+	node, err := logic.ValidateParams(params["nodeid"], params["network"])
+	if err != nil {
+		logic.ReturnErrorResponse(w, r, logic.FormatError(err, "badrequest"))
+		return
+	}
+	var req models.NodeRenewalReq
+	if r.ContentLength > 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			logic.ReturnErrorResponse(w, r, logic.FormatError(err, "badrequest"))
+			return
+		}
+	}
+	hostID := r.Header.Get(hostIDHeader)
+	byHost := hostID != ""
+	if byHost && hostID != node.HostID.String() {
+		logic.ReturnErrorResponse(w, r, logic.FormatError(fmt.Errorf("host can only renew its own nodes"), "forbidden"))
+		return
+	}
+	wasConnected := node.Connected
+	if err := logic.RenewNode(&node, req.TTL, byHost); err != nil {
+		logic.ReturnErrorResponse(w, r, logic.FormatError(err, "badrequest"))
+		return
+	}
+	slog.Info("renewed node lease", "nodeid", node.ID.String(), "network", node.Network,
+		"expires", node.ExpirationDateTime, "user", r.Header.Get("user"), "host", hostID)
+	if node.Connected != wasConnected {
+		go func() {
+			if err := mq.NodeUpdate(&node); err != nil {
+				slog.Error("error publishing node update", "nodeid", node.ID.String(), "error", err)
+			}
+			mq.QueuePeerUpdate(false, node.Network)
+		}()
+	}
+	logic.ReturnSuccessResponseWithJson(w, r, node.ConvertToAPINode(), "renewed node "+node.ID.String())
+}
@@ -0,0 +1,150 @@
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/gravitl/netmaker/logger"
+	"github.com/gravitl/netmaker/logic"
+	"github.com/gravitl/netmaker/models"
+	"github.com/gravitl/netmaker/mq"
+	"github.com/gravitl/netmaker/servercfg"
+	"golang.org/x/exp/slog"
+)
+
+func quarantineHandlers(r *mux.Router) {
+	r.HandleFunc("/api/v1/quarantine", logic.SecurityCheck(true, http.HandlerFunc(listQuarantine))).
+		Methods(http.MethodGet)
+	r.HandleFunc("/api/v1/hosts/{hostid}/quarantine", logic.SecurityCheck(true, http.HandlerFunc(quarantineHost))).
+		Methods(http.MethodPost)
+	r.HandleFunc("/api/v1/hosts/{hostid}/quarantine", logic.SecurityCheck(true, http.HandlerFunc(liftHostQuarantine))).
+		Methods(http.MethodDelete)
+	r.HandleFunc("/api/v1/nodes/{network}/{nodeid}/quarantine", logic.SecurityCheck(true, http.HandlerFunc(quarantineNode))).
+		Methods(http.MethodPost)
+	r.HandleFunc("/api/v1/nodes/{network}/{nodeid}/quarantine", logic.SecurityCheck(true, http.HandlerFunc(liftNodeQuarantine))).
+		Methods(http.MethodDelete)
+}
+
+// @Summary     List quarantined hosts and nodes
+// @Router      /api/v1/quarantine [get]
+// @Tags        Hosts
+// @Security    oauth
+// @Success     200 {array} models.Quarantine
+func listQuarantine(w http.ResponseWriter, r *http.Request) {
+	logic.ReturnSuccessResponseWithJson(w, r, logic.ListQuarantine(), "fetched quarantined hosts and nodes")
+}
+
+// @Summary     Quarantine a host, cutting it off from all peers and revoking its broker credentials
+// @Router      /api/v1/hosts/{hostid}/quarantine [post]
+// @Tags        Hosts
+// @Security    oauth
+// @Param       hostid path string true "Host ID"
+// @Param       body body models.QuarantineReq true "Quarantine reason"
+// @Success     200 {object} models.Quarantine
+// @Failure     400 {object} models.ErrorResponse
+// @Failure     404 {object} models.ErrorResponse
+func quarantineHost(w http.ResponseWriter, r *http.Request) {
+	host, err := logic.GetHost(mux.Vars(r)["hostid"])
+	if err != nil {
+		logic.ReturnErrorResponse(w, r, logic.FormatError(err, "notfound"))
+		return
+	}
+	var req models.QuarantineReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logger.Log(0, "error decoding request body: ", err.Error())
+		logic.ReturnErrorResponse(w, r, logic.FormatError(err, "badrequest"))
+		return
+	}
+	q, err := logic.QuarantineHost(host, req.Reason, r.Header.Get("user"))
+	if err != nil {
+		logic.ReturnErrorResponse(w, r, logic.FormatError(err, "badrequest"))
+		return
+	}
+	slog.Warn("quarantined host", "host", host.ID.String(), "name", host.Name, "reason", req.Reason, "user", r.Header.Get("user"))
+	// peers drop the host first, then it loses access to the broker
+	if err := mq.PublishNetworkPeerUpdates(false, logic.GetHostNetworks(host.ID.String())...); err != nil {
+		slog.Error("failed to publish peer update for quarantined host", "host", host.ID.String(), "error", err)
+	}
+	if servercfg.GetBrokerType() == servercfg.EmqxBrokerType {
+		if err := mq.GetEmqxHandler().DeleteEmqxUser(host.ID.String()); err != nil {
+			slog.Error("failed to revoke broker credentials of quarantined host", "host", host.ID.String(), "error", err)
+		}
+	}
+	logic.ReturnSuccessResponseWithJson(w, r, q, "quarantined host "+host.Name)
+}
+
+// @Summary     Lift the quarantine of a host, the host re-authenticates to regain broker access
+// @Router      /api/v1/hosts/{hostid}/quarantine [delete]
+// @Tags        Hosts
+// @Security    oauth
+// @Param       hostid path string true "Host ID"
+// @Success     200 {object} models.Quarantine
+// @Failure     400 {object} models.ErrorResponse
+func liftHostQuarantine(w http.ResponseWriter, r *http.Request) {
+	q, err := logic.LiftQuarantine(mux.Vars(r)["hostid"], models.QuarantineHost)
+	if err != nil {
+		logic.ReturnErrorResponse(w, r, logic.FormatError(err, "badrequest"))
+		return
+	}
+	slog.Info("lifted host quarantine", "host", q.HostID, "name", q.HostName, "user", r.Header.Get("user"))
+	mq.QueuePeerUpdate(false, logic.GetHostNetworks(q.HostID)...)
+	logic.ReturnSuccessResponseWithJson(w, r, q, "lifted quarantine of host "+q.HostName)
+}
+
+// @Summary     Quarantine a node, cutting it off from its peers in the network
+// @Router      /api/v1/nodes/{network}/{nodeid}/quarantine [post]
+// @Tags        Nodes
+// @Security    oauth
+// @Param       network path string true "Network ID"
+// @Param       nodeid path string true "Node ID"
+// @Param       body body models.QuarantineReq true "Quarantine reason"
+// @Success     200 {object} models.Quarantine
+// @Failure     400 {object} models.ErrorResponse
+func quarantineNode(w http.ResponseWriter, r *http.Request) {
+	var params = mux.Vars(r)
+	node, err := logic.ValidateParams(params["nodeid"], params["network"])
+	if err != nil {
+		logic.ReturnErrorResponse(w, r, logic.FormatError(err, "badrequest"))
+		return
+	}
+	var req models.QuarantineReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logger.Log(0, "error decoding request body: ", err.Error())
+		logic.ReturnErrorResponse(w, r, logic.FormatError(err, "badrequest"))
+		return
+	}
+	q, err := logic.QuarantineNode(&node, req.Reason, r.Header.Get("user"))
+	if err != nil {
+		logic.ReturnErrorResponse(w, r, logic.FormatError(err, "badrequest"))
+		return
+	}
+	slog.Warn("quarantined node", "nodeid", node.ID.String(), "network", node.Network, "reason", req.Reason, "user", r.Header.Get("user"))
+	mq.QueuePeerUpdate(false, node.Network)
+	logic.ReturnSuccessResponseWithJson(w, r, q, "quarantined node "+node.ID.String())
+}
+
+// @Summary     Lift the quarantine of a node
+// @Router      /api/v1/nodes/{network}/{nodeid}/quarantine [delete]
+// @Tags        Nodes
+// @Security    oauth
+// @Param       network path string true "Network ID"
+// @Param       nodeid path string true "Node ID"
+// @Success     200 {object} models.Quarantine
+// @Failure     400 {object} models.ErrorResponse
+func liftNodeQuarantine(w http.ResponseWriter, r *http.Request) {
+	var params = mux.Vars(r)
+	node, err := logic.ValidateParams(params["nodeid"], params["network"])
+	if err != nil {
+		logic.ReturnErrorResponse(w, r, logic.FormatError(err, "badrequest"))
+		return
+	}
+	q, err := logic.LiftQuarantine(node.ID.String(), models.QuarantineNode)
+	if err != nil {
+		logic.ReturnErrorResponse(w, r, logic.FormatError(err, "badrequest"))
+		return
+	}
+	slog.Info("lifted node quarantine", "nodeid", node.ID.String(), "network", node.Network, "user", r.Header.Get("user"))
+	mq.QueuePeerUpdate(false, node.Network)
+	logic.ReturnSuccessResponseWithJson(w, r, q, "lifted quarantine of node "+node.ID.String())
+}
@@ -82,6 +82,13 @@ func createTag(w http.ResponseWriter, r *http.Request) {
 		logic.ReturnErrorResponse(w, r, logic.FormatError(errors.New("failed to get network details for "+req.Network.String()), "badrequest"))
 		return
 	}
+	if isNetworkFrozen(w, r, req.Network.String()) {
+		return
+	}
+	if err = logic.ValidateTagProfile(req.ProfileID, req.Network); err != nil {
+		logic.ReturnErrorResponse(w, r, logic.FormatError(err, "badrequest"))
+		return
+	}
 	// check if tag exists
 	tag := models.Tag{
 		ID:        models.TagID(fmt.Sprintf("%s.%s", req.Network, req.TagName)),
@@ -89,6 +96,7 @@ func createTag(w http.ResponseWriter, r *http.Request) {
 		Network:   req.Network,
 		CreatedBy: user.UserName,
 		ColorCode: req.ColorCode,
+		ProfileID: req.ProfileID,
 		CreatedAt: time.Now(),
 	}
 	_, err = logic.GetTag(tag.ID)
@@ -130,8 +138,11 @@ func createTag(w http.ResponseWriter, r *http.Request) {
 			node.Tags[tag.ID] = struct{}{}
 			logic.UpsertNode(&node)
 		}
+		if tag.ProfileID != "" {
+			mq.PublishNetworkConnectivity(tag.Network)
+		}
 	}()
-	go mq.PublishPeerUpdate(false)
+	mq.QueuePeerUpdate(false, tag.Network.String())
 
 	var res models.TagListRespNodes = models.TagListRespNodes{
 		Tag:         tag,
@@ -163,6 +174,9 @@ func updateTag(w http.ResponseWriter, r *http.Request) {
 		logic.ReturnErrorResponse(w, r, logic.FormatError(err, "badrequest"))
 		return
 	}
+	if isNetworkFrozen(w, r, tag.Network.String()) {
+		return
+	}
 	updateTag.NewName = strings.TrimSpace(updateTag.NewName)
 	var newID models.TagID
 	if updateTag.NewName != "" {
@@ -191,12 +205,30 @@ func updateTag(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 	}
+	if updateTag.ProfileID != nil && *updateTag.ProfileID != tag.ProfileID {
+		err = logic.ValidateTagProfile(*updateTag.ProfileID, tag.Network)
+		if err != nil {
+			logic.ReturnErrorResponse(w, r, logic.FormatError(err, "badrequest"))
+			return
+		}
+		tag.ProfileID = *updateTag.ProfileID
+		err = logic.UpsertTag(tag)
+		if err != nil {
+			logic.ReturnErrorResponse(w, r, logic.FormatError(err, "badrequest"))
+			return
+		}
+	}
+	user := r.Header.Get("user")
 	go func() {
 		logic.UpdateTag(updateTag, newID)
 		if updateTag.NewName != "" {
 			logic.UpdateDeviceTag(updateTag.ID, newID, tag.Network)
+			logic.RecordAclVersion(tag.Network, user, "renamed tag "+updateTag.ID.String())
+		}
+		if tag.ProfileID != "" || updateTag.ProfileID != nil {
+			mq.PublishNetworkConnectivity(tag.Network)
 		}
-		mq.PublishPeerUpdate(false)
+		mq.QueuePeerUpdate(false, tag.Network.String())
 	}()
 
 	var res models.TagListRespNodes = models.TagListRespNodes{
@@ -225,6 +257,9 @@ func deleteTag(w http.ResponseWriter, r *http.Request) {
 		logic.ReturnErrorResponse(w, r, logic.FormatError(err, "badrequest"))
 		return
 	}
+	if isNetworkFrozen(w, r, tag.Network.String()) {
+		return
+	}
 	// check if active policy is using the tag
 	if logic.CheckIfTagAsActivePolicy(tag.ID, tag.Network) {
 		logic.ReturnErrorResponse(w, r, logic.FormatError(errors.New("tag is currently in use by an active policy"), "badrequest"))
@@ -236,10 +271,15 @@ func deleteTag(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	user := r.Header.Get("user")
 	go func() {
 		logic.RemoveDeviceTagFromAclPolicies(tag.ID, tag.Network)
+		logic.RecordAclVersion(tag.Network, user, "deleted tag "+tag.ID.String())
 		logic.RemoveTagFromEnrollmentKeys(tag.ID)
-		mq.PublishPeerUpdate(false)
+		if tag.ProfileID != "" {
+			mq.PublishNetworkConnectivity(tag.Network)
+		}
+		mq.QueuePeerUpdate(false, tag.Network.String())
 	}()
 	logic.ReturnSuccessResponse(w, r, "deleted tag "+tagID)
 }
@@ -0,0 +1,62 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/gravitl/netmaker/logger"
+	"github.com/gravitl/netmaker/logic"
+)
+
+func topologyHandlers(r *mux.Router) {
+	r.HandleFunc("/api/v1/networks/{network}/topology", logic.SecurityCheck(true, http.HandlerFunc(getNetworkTopology))).
+		Methods(http.MethodGet)
+}
+
+// @Summary     Get the graph of a network's nodes, relays, failovers, gateways, ext clients and egress ranges
+// @Router      /api/v1/networks/{network}/topology [get]
+// @Tags        Networks
+// @Security    oauth
+// @Param       network path string true "Network ID"
+// @Param       format query string false "Output format (json, dot, graphml)"
+// @Success     200 {object} models.Topology
+// @Failure     400 {object} models.ErrorResponse
+// @Failure     404 {object} models.ErrorResponse
+func getNetworkTopology(w http.ResponseWriter, r *http.Request) {
+	netID := mux.Vars(r)["network"]
+	if _, err := logic.GetNetwork(netID); err != nil {
+		logic.ReturnErrorResponse(w, r, logic.FormatError(err, "notfound"))
+		return
+	}
+	format := r.URL.Query().Get("format")
+	if format != "" && format != "json" && format != "dot" && format != "graphml" {
+		logic.ReturnErrorResponse(w, r, logic.FormatError(errors.New("unsupported format "+format), "badrequest"))
+		return
+	}
+	topology, err := logic.GetNetworkTopology(netID)
+	if err != nil {
+		logic.ReturnErrorResponse(w, r, logic.FormatError(err, "internal"))
+		return
+	}
+	var data []byte
+	switch format {
+	case "dot":
+		w.Header().Set("Content-Type", "text/vnd.graphviz")
+		data = []byte(logic.TopologyToDOT(topology))
+	case "graphml":
+		data, err = logic.TopologyToGraphML(topology)
+		if err != nil {
+			logic.ReturnErrorResponse(w, r, logic.FormatError(err, "internal"))
+			return
+		}
+		w.Header().Set("Content-Type", "application/graphml+xml")
+	default:
+		logic.ReturnSuccessResponseWithJson(w, r, topology, "fetched topology of network "+netID)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write(data); err != nil {
+		logger.Log(1, r.Header.Get("user"), "response writer error (topology) ", err.Error())
+	}
+}
@@ -0,0 +1,118 @@
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/gravitl/netmaker/logger"
+	"github.com/gravitl/netmaker/logic"
+	"github.com/gravitl/netmaker/models"
+	"golang.org/x/exp/slog"
+)
+
+func upgradeCampaignHandlers(r *mux.Router) {
+	r.HandleFunc("/api/v1/hosts/upgrade-campaigns", logic.SecurityCheck(true, http.HandlerFunc(listUpgradeCampaigns))).
+		Methods(http.MethodGet)
+	r.HandleFunc("/api/v1/hosts/upgrade-campaigns", logic.SecurityCheck(true, http.HandlerFunc(createUpgradeCampaign))).
+		Methods(http.MethodPost)
+	r.HandleFunc("/api/v1/hosts/upgrade-campaigns/{campaign_id}", logic.SecurityCheck(true, http.HandlerFunc(getUpgradeCampaign))).
+		Methods(http.MethodGet)
+	r.HandleFunc("/api/v1/hosts/upgrade-campaigns/{campaign_id}", logic.SecurityCheck(true, http.HandlerFunc(deleteUpgradeCampaign))).
+		Methods(http.MethodDelete)
+	r.HandleFunc("/api/v1/hosts/upgrade-campaigns/{campaign_id}/pause", logic.SecurityCheck(true, updateUpgradeCampaignStatus(models.CampaignPaused))).
+		Methods(http.MethodPost)
+	r.HandleFunc("/api/v1/hosts/upgrade-campaigns/{campaign_id}/resume", logic.SecurityCheck(true, updateUpgradeCampaignStatus(models.CampaignRunning))).
+		Methods(http.MethodPost)
+	r.HandleFunc("/api/v1/hosts/upgrade-campaigns/{campaign_id}/cancel", logic.SecurityCheck(true, updateUpgradeCampaignStatus(models.CampaignCancelled))).
+		Methods(http.MethodPost)
+}
+
+// @Summary     List upgrade campaigns
+// @Router      /api/v1/hosts/upgrade-campaigns [get]
+// @Tags        Hosts
+// @Security    oauth
+// @Success     200 {array} models.UpgradeCampaign
+// @Failure     500 {object} models.ErrorResponse
+func listUpgradeCampaigns(w http.ResponseWriter, r *http.Request) {
+	campaigns, err := logic.ListUpgradeCampaigns()
+	if err != nil {
+		logic.ReturnErrorResponse(w, r, logic.FormatError(err, "internal"))
+		return
+	}
+	logic.ReturnSuccessResponseWithJson(w, r, campaigns, "fetched upgrade campaigns")
+}
+
+// @Summary     Create a staged upgrade campaign
+// @Router      /api/v1/hosts/upgrade-campaigns [post]
+// @Tags        Hosts
+// @Security    oauth
+// @Param       body body models.UpgradeCampaignReq true "Upgrade campaign"
+// @Success     200 {object} models.UpgradeCampaign
+// @Failure     400 {object} models.ErrorResponse
+func createUpgradeCampaign(w http.ResponseWriter, r *http.Request) {
+	var req models.UpgradeCampaignReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logger.Log(0, "error decoding request body: ", err.Error())
+		logic.ReturnErrorResponse(w, r, logic.FormatError(err, "badrequest"))
+		return
+	}
+	campaign, err := logic.CreateUpgradeCampaign(req, r.Header.Get("user"))
+	if err != nil {
+		logic.ReturnErrorResponse(w, r, logic.FormatError(err, "badrequest"))
+		return
+	}
+	slog.Info("created upgrade campaign", "user", r.Header.Get("user"), "campaign", campaign.Name,
+		"hosts", len(campaign.Hosts), "waves", len(campaign.Waves))
+	logic.ReturnSuccessResponseWithJson(w, r, campaign, "created upgrade campaign "+campaign.Name)
+}
+
+// @Summary     Get an upgrade campaign
+// @Router      /api/v1/hosts/upgrade-campaigns/{campaign_id} [get]
+// @Tags        Hosts
+// @Security    oauth
+// @Param       campaign_id path string true "Campaign ID"
+// @Success     200 {object} models.UpgradeCampaign
+// @Failure     404 {object} models.ErrorResponse
+func getUpgradeCampaign(w http.ResponseWriter, r *http.Request) {
+	campaign, err := logic.GetUpgradeCampaign(mux.Vars(r)["campaign_id"])
+	if err != nil {
+		logic.ReturnErrorResponse(w, r, logic.FormatError(err, "notfound"))
+		return
+	}
+	logic.ReturnSuccessResponseWithJson(w, r, campaign, "fetched upgrade campaign "+campaign.Name)
+}
+
+// @Summary     Delete an upgrade campaign that is not running
+// @Router      /api/v1/hosts/upgrade-campaigns/{campaign_id} [delete]
+// @Tags        Hosts
+// @Security    oauth
+// @Param       campaign_id path string true "Campaign ID"
+// @Success     200 {object} models.SuccessResponse
+// @Failure     400 {object} models.ErrorResponse
+func deleteUpgradeCampaign(w http.ResponseWriter, r *http.Request) {
+	if err := logic.DeleteUpgradeCampaign(mux.Vars(r)["campaign_id"]); err != nil {
+		logic.ReturnErrorResponse(w, r, logic.FormatError(err, "badrequest"))
+		return
+	}
+	logic.ReturnSuccessResponse(w, r, "deleted upgrade campaign")
+}
+
+// @Summary     Pause, resume or cancel an upgrade campaign
+// @Router      /api/v1/hosts/upgrade-campaigns/{campaign_id}/{action} [post]
+// @Tags        Hosts
+// @Security    oauth
+// @Param       campaign_id path string true "Campaign ID"
+// @Success     200 {object} models.UpgradeCampaign
+// @Failure     400 {object} models.ErrorResponse
+func updateUpgradeCampaignStatus(status models.UpgradeCampaignStatus) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		campaign, err := logic.UpdateUpgradeCampaignStatus(mux.Vars(r)["campaign_id"], status)
+		if err != nil {
+			logic.ReturnErrorResponse(w, r, logic.FormatError(err, "badrequest"))
+			return
+		}
+		slog.Info("updated upgrade campaign", "user", r.Header.Get("user"), "campaign", campaign.Name, "status", campaign.Status)
+		logic.ReturnSuccessResponseWithJson(w, r, campaign, "upgrade campaign is "+string(campaign.Status))
+	}
+}
@@ -0,0 +1,158 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/gravitl/netmaker/logger"
+	"github.com/gravitl/netmaker/logic"
+	"github.com/gravitl/netmaker/models"
+	"github.com/gravitl/netmaker/mq"
+	"github.com/gravitl/netmaker/servercfg"
+	"golang.org/x/exp/slog"
+)
+
+func zombieHandlers(r *mux.Router) {
+	r.HandleFunc("/api/v1/zombies", logic.SecurityCheck(true, http.HandlerFunc(listZombies))).
+		Methods(http.MethodGet)
+	r.HandleFunc("/api/v1/zombies/{zombie_id}/approve", logic.SecurityCheck(true, http.HandlerFunc(approveZombie))).
+		Methods(http.MethodPost)
+	r.HandleFunc("/api/v1/zombies/{zombie_id}/ignore", logic.SecurityCheck(true, http.HandlerFunc(ignoreZombie))).
+		Methods(http.MethodPost)
+	r.HandleFunc("/api/networks/{networkname}/zombie-handling", logic.SecurityCheck(true, NetworkFreezeCheck(http.HandlerFunc(updateNetworkZombieHandling)))).
+		Methods(http.MethodPut)
+}
+
+// @Summary     List zombie host and node candidates
+// @Router      /api/v1/zombies [get]
+// @Tags        Hosts
+// @Security    oauth
+// @Param       network query string false "Network to filter by"
+// @Param       state query string false "pending or ignored"
+// @Success     200 {array} models.Zombie
+func listZombies(w http.ResponseWriter, r *http.Request) {
+	zombies := logic.ListZombies(r.URL.Query().Get("network"), models.ZombieState(r.URL.Query().Get("state")))
+	logic.ReturnSuccessResponseWithJson(w, r, zombies, "fetched zombies")
+}
+
+// @Summary     Approve the delete of a zombie host or node
+// @Router      /api/v1/zombies/{zombie_id}/approve [post]
+// @Tags        Hosts
+// @Security    oauth
+// @Param       zombie_id path string true "ID of the zombie host or node"
+// @Success     200 {object} models.Zombie
+// @Failure     404 {object} models.ErrorResponse
+// @Failure     500 {object} models.ErrorResponse
+func approveZombie(w http.ResponseWriter, r *http.Request) {
+	z, err := logic.GetZombie(mux.Vars(r)["zombie_id"])
+	if err != nil {
+		logic.ReturnErrorResponse(w, r, logic.FormatError(errors.New("zombie not found"), "notfound"))
+		return
+	}
+	networks := []string{z.Network}
+	if z.Kind == models.ZombieHost {
+		networks = logic.GetHostNetworks(z.ID)
+	}
+	if areNetworksFrozen(w, r, networks) {
+		return
+	}
+	if z.Kind == models.ZombieHost {
+		err = deleteZombieHost(z.ID)
+	} else {
+		err = deleteZombieNode(z.ID)
+	}
+	if err != nil {
+		logic.ReturnErrorResponse(w, r, logic.FormatError(err, "internal"))
+		return
+	}
+	logic.DeleteZombie(z.ID)
+	slog.Info("deleted zombie", "kind", z.Kind, "id", z.ID, "reason", z.Reason, "user", r.Header.Get("user"))
+	logic.ReturnSuccessResponseWithJson(w, r, z, "deleted zombie "+string(z.Kind)+" "+z.ID)
+}
+
+func deleteZombieNode(nodeID string) error {
+	node, err := logic.GetNodeByID(nodeID)
+	if err != nil {
+		return err
+	}
+	var gwClients []models.ExtClient
+	if node.IsIngressGateway {
+		gwClients = logic.GetGwExtclients(node.ID.String(), node.Network)
+	}
+	if err := logic.DeleteNode(&node, true); err != nil {
+		return err
+	}
+	go mq.PublishMqUpdatesForDeletedNode(node, true, gwClients)
+	return nil
+}
+
+func deleteZombieHost(hostID string) error {
+	host, err := logic.GetHost(hostID)
+	if err != nil {
+		return err
+	}
+	for _, nodeID := range host.Nodes {
+		node, err := logic.GetNodeByID(nodeID)
+		if err != nil {
+			continue
+		}
+		var gwClients []models.ExtClient
+		if node.IsIngressGateway {
+			gwClients = logic.GetGwExtclients(node.ID.String(), node.Network)
+		}
+		go mq.PublishMqUpdatesForDeletedNode(node, false, gwClients)
+	}
+	if servercfg.GetBrokerType() == servercfg.EmqxBrokerType {
+		if err := mq.GetEmqxHandler().DeleteEmqxUser(host.ID.String()); err != nil {
+			slog.Error("failed to remove host credentials from EMQX", "id", host.ID, "error", err)
+		}
+	}
+	if err := mq.HostUpdate(&models.HostUpdate{Action: models.DeleteHost, Host: *host}); err != nil {
+		slog.Error("failed to send delete host update", "id", host.ID, "error", err)
+	}
+	return logic.RemoveHost(host, true)
+}
+
+// @Summary     Ignore a zombie candidate as a false positive
+// @Router      /api/v1/zombies/{zombie_id}/ignore [post]
+// @Tags        Hosts
+// @Security    oauth
+// @Param       zombie_id path string true "ID of the zombie host or node"
+// @Success     200 {object} models.Zombie
+// @Failure     400 {object} models.ErrorResponse
+func ignoreZombie(w http.ResponseWriter, r *http.Request) {
+	z, err := logic.IgnoreZombie(mux.Vars(r)["zombie_id"], r.Header.Get("user"))
+	if err != nil {
+		logic.ReturnErrorResponse(w, r, logic.FormatError(err, "badrequest"))
+		return
+	}
+	slog.Info("ignored zombie", "kind", z.Kind, "id", z.ID, "reason", z.Reason, "user", r.Header.Get("user"))
+	logic.ReturnSuccessResponseWithJson(w, r, z, "ignored zombie "+string(z.Kind)+" "+z.ID)
+}
+
+// @Summary     Set whether zombie candidates of a network are deleted automatically or after approval
+// @Router      /api/networks/{networkname}/zombie-handling [put]
+// @Tags        Networks
+// @Security    oauth
+// @Param       networkname path string true "Network name"
+// @Param       body body models.ZombieHandlingReq true "Zombie handling"
+// @Success     200 {object} models.Network
+// @Failure     400 {object} models.ErrorResponse
+func updateNetworkZombieHandling(w http.ResponseWriter, r *http.Request) {
+	netname := mux.Vars(r)["networkname"]
+	var req models.ZombieHandlingReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logger.Log(0, r.Header.Get("user"), "error decoding request body: ", err.Error())
+		logic.ReturnErrorResponse(w, r, logic.FormatError(err, "badrequest"))
+		return
+	}
+	network, err := logic.SetNetworkZombieHandling(netname, req.Mode)
+	if err != nil {
+		logic.ReturnErrorResponse(w, r, logic.FormatError(err, "badrequest"))
+		return
+	}
+	slog.Info("updated zombie handling", "network", netname, "mode", req.Mode, "user", r.Header.Get("user"))
+	logic.ReturnSuccessResponseWithJson(w, r, network, "zombie handling of network "+netname+" is "+req.Mode)
+}
@@ -73,6 +73,28 @@ const (
 	TAG_TABLE_NAME = "tags"
 	// PEER_ACK_TABLE - table for failover peer ack
 	PEER_ACK_TABLE = "peer_ack"
+	// CONNECTIVITY_PROFILES_TABLE_NAME - table for tag connectivity profiles
+	CONNECTIVITY_PROFILES_TABLE_NAME = "connectivity_profiles"
+	// HOST_GENERATIONS_TABLE_NAME - table for desired/applied state generations of hosts
+	HOST_GENERATIONS_TABLE_NAME = "host_generations"
+	// UPGRADE_CAMPAIGNS_TABLE_NAME - table for staged netclient upgrade campaigns
+	UPGRADE_CAMPAIGNS_TABLE_NAME = "upgrade_campaigns"
+	// KEY_ROTATION_POLICIES_TABLE_NAME - table for wireguard key rotation policies
+	KEY_ROTATION_POLICIES_TABLE_NAME = "key_rotation_policies"
+	// HOST_KEY_ROTATIONS_TABLE_NAME - table for the key rotation state of hosts
+	HOST_KEY_ROTATIONS_TABLE_NAME = "host_key_rotations"
+	// QUARANTINE_TABLE_NAME - table for quarantined hosts and nodes
+	QUARANTINE_TABLE_NAME = "quarantine"
+	// ZOMBIES_TABLE_NAME - table for zombie host and node candidates
+	ZOMBIES_TABLE_NAME = "zombies"
+	// HOST_HISTORY_TABLE_NAME - table for the endpoint, nat and interface history of hosts
+	HOST_HISTORY_TABLE_NAME = "host_history"
+	// ACCESS_REQUESTS_TABLE_NAME - table for just-in-time access requests
+	ACCESS_REQUESTS_TABLE_NAME = "access_requests"
+	// ACL_VERSIONS_TABLE_NAME - table for the policy version history of networks
+	ACL_VERSIONS_TABLE_NAME = "acl_versions"
+	// ACL_SERVICES_TABLE_NAME - table for the named service objects of policies
+	ACL_SERVICES_TABLE_NAME = "acl_services"
 	// == ERROR CONSTS ==
 	// NO_RECORD - no singular result found
 	NO_RECORD = "no result found"
@@ -161,6 +183,17 @@ func createTables() {
 	CreateTable(TAG_TABLE_NAME)
 	CreateTable(ACLS_TABLE_NAME)
 	CreateTable(PEER_ACK_TABLE)
+	CreateTable(CONNECTIVITY_PROFILES_TABLE_NAME)
+	CreateTable(HOST_GENERATIONS_TABLE_NAME)
+	CreateTable(UPGRADE_CAMPAIGNS_TABLE_NAME)
+	CreateTable(KEY_ROTATION_POLICIES_TABLE_NAME)
+	CreateTable(HOST_KEY_ROTATIONS_TABLE_NAME)
+	CreateTable(QUARANTINE_TABLE_NAME)
+	CreateTable(ZOMBIES_TABLE_NAME)
+	CreateTable(HOST_HISTORY_TABLE_NAME)
+	CreateTable(ACCESS_REQUESTS_TABLE_NAME)
+	CreateTable(ACL_VERSIONS_TABLE_NAME)
+	CreateTable(ACL_SERVICES_TABLE_NAME)
 }
 
 func CreateTable(tableName string) error {
@@ -0,0 +1,267 @@
+package logic
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gravitl/netmaker/database"
+	"github.com/gravitl/netmaker/models"
+	"golang.org/x/exp/slog"
+)
+
+// MaxAccessRequestDuration - the longest access a request can ask for, in minutes
+const MaxAccessRequestDuration = 7 * 24 * 60
+
+var accessRequestMutex = &sync.Mutex{}
+
+// AccessRequestHook - fired in the background after an access request is created, approved,
+// denied or expires
+var AccessRequestHook = func(req models.AccessRequest) {}
+
+// GetAccessRequest - fetches an access request
+func GetAccessRequest(id string) (models.AccessRequest, error) {
+	req := models.AccessRequest{}
+	record, err := database.FetchRecord(database.ACCESS_REQUESTS_TABLE_NAME, id)
+	if err != nil {
+		return req, err
+	}
+	err = json.Unmarshal([]byte(record), &req)
+	return req, err
+}
+
+// ListAccessRequests - lists the access requests of a network, or of all networks when netID
+// is empty, optionally with the given status, newest first
+func ListAccessRequests(netID models.NetworkID, status models.AccessRequestStatus) []models.AccessRequest {
+	list := []models.AccessRequest{}
+	records, err := database.FetchRecords(database.ACCESS_REQUESTS_TABLE_NAME)
+	if err != nil {
+		return list
+	}
+	for _, record := range records {
+		req := models.AccessRequest{}
+		if err := json.Unmarshal([]byte(record), &req); err != nil {
+			continue
+		}
+		if (netID != "" && req.NetworkID != netID) || (status != "" && req.Status != status) {
+			continue
+		}
+		list = append(list, req)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].CreatedAt.After(list[j].CreatedAt)
+	})
+	return list
+}
+
+func storeAccessRequest(req models.AccessRequest) error {
+	d, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+	return database.Insert(req.ID, string(d), database.ACCESS_REQUESTS_TABLE_NAME)
+}
+
+// validateAccessRequest - checks the target, ports and duration of a request
+func validateAccessRequest(req models.AccessRequestReq) error {
+	if _, err := GetNetwork(req.NetworkID.String()); err != nil {
+		return fmt.Errorf("network %s not found", req.NetworkID)
+	}
+	switch req.Target.ID {
+	case models.NodeTagID:
+		tag, err := GetTag(models.TagID(req.Target.Value))
+		if err != nil || tag.Network != req.NetworkID {
+			return fmt.Errorf("tag %s not found in network %s", req.Target.Value, req.NetworkID)
+		}
+	case models.NodeID:
+		if !checkIfAclTagisValid(req.Target, req.NetworkID, models.UserPolicy, false) {
+			return fmt.Errorf("device %s not found in network %s", req.Target.Value, req.NetworkID)
+		}
+		if node, err := GetNodeByID(req.Target.Value); err == nil && node.Network != req.NetworkID.String() {
+			return fmt.Errorf("device %s not found in network %s", req.Target.Value, req.NetworkID)
+		}
+	default:
+		return errors.New("target must be a tag or a device")
+	}
+	switch req.Proto {
+	case models.ALL, "":
+		if len(req.Ports) > 0 {
+			return errors.New("ports require the tcp or udp protocol")
+		}
+	case models.TCP, models.UDP:
+		for _, port := range req.Ports {
+			if _, _, err := parsePortRange(port); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("unsupported protocol %s", req.Proto)
+	}
+	if req.Duration < 1 || req.Duration > MaxAccessRequestDuration {
+		return fmt.Errorf("duration must be between 1 and %d minutes", MaxAccessRequestDuration)
+	}
+	if strings.TrimSpace(req.Justification) == "" {
+		return errors.New("justification is required")
+	}
+	return nil
+}
+
+// CreateAccessRequest - records a pending request of a user for temporary access
+func CreateAccessRequest(req models.AccessRequestReq, requester string) (models.AccessRequest, error) {
+	if _, err := GetUser(requester); err != nil {
+		return models.AccessRequest{}, err
+	}
+	if err := validateAccessRequest(req); err != nil {
+		return models.AccessRequest{}, err
+	}
+	if req.Proto == "" {
+		req.Proto = models.ALL
+	}
+	now := time.Now().UTC()
+	accessReq := models.AccessRequest{
+		ID:            uuid.NewString(),
+		NetworkID:     req.NetworkID,
+		Requester:     requester,
+		Target:        req.Target,
+		Proto:         req.Proto,
+		Ports:         req.Ports,
+		Duration:      req.Duration,
+		Justification: strings.TrimSpace(req.Justification),
+		Status:        models.AccessRequestPending,
+		CreatedAt:     now,
+		History: []models.AccessRequestEvent{
+			{Time: now, Status: models.AccessRequestPending, User: requester},
+		},
+	}
+	if accessReq.Ports == nil {
+		accessReq.Ports = []string{}
+	}
+	if err := storeAccessRequest(accessReq); err != nil {
+		return accessReq, err
+	}
+	go AccessRequestHook(accessReq)
+	return accessReq, nil
+}
+
+// accessRequestPolicy - the user policy granting an approved request until it expires
+func accessRequestPolicy(req models.AccessRequest, reviewer string, now time.Time) models.Acl {
+	return models.Acl{
+		ID:               uuid.NewString(),
+		Name:             fmt.Sprintf("access request of %s", req.Requester),
+		MetaData:         fmt.Sprintf("access request %s: %s", req.ID, req.Justification),
+		NetworkID:        req.NetworkID,
+		RuleType:         models.UserPolicy,
+		Src:              []models.AclPolicyTag{{ID: models.UserAclID, Value: req.Requester}},
+		Dst:              []models.AclPolicyTag{req.Target},
+		AllowedDirection: models.TrafficDirectionUni,
+		Proto:            req.Proto,
+		ServiceType:      models.Custom,
+		Port:             req.Ports,
+		Enabled:          true,
+		Schedule: &models.AclSchedule{
+			StartTime: now,
+			EndTime:   now.Add(time.Duration(req.Duration) * time.Minute),
+		},
+		CreatedBy: reviewer,
+		CreatedAt: now,
+	}
+}
+
+// ApproveAccessRequest - grants a pending request through a user policy that expires after the
+// requested duration, users can not approve their own requests
+func ApproveAccessRequest(id, reviewer, note string) (models.AccessRequest, error) {
+	accessRequestMutex.Lock()
+	defer accessRequestMutex.Unlock()
+	req, err := GetAccessRequest(id)
+	if err != nil {
+		return req, err
+	}
+	if req.Status != models.AccessRequestPending {
+		return req, fmt.Errorf("access request is %s", req.Status)
+	}
+	if req.Requester == reviewer {
+		return req, errors.New("users can not approve their own access requests")
+	}
+	now := time.Now().UTC()
+	acl := accessRequestPolicy(req, reviewer, now)
+	if !IsAclPolicyValid(acl) {
+		return req, errors.New("requester or target of the access request no longer exists")
+	}
+	if err := InsertAcl(acl); err != nil {
+		return req, err
+	}
+	req.Status = models.AccessRequestApproved
+	req.Reviewer = reviewer
+	req.ReviewNote = note
+	req.AclID = acl.ID
+	req.ExpiresAt = acl.Schedule.EndTime
+	req.History = append(req.History, models.AccessRequestEvent{Time: now, Status: req.Status, User: reviewer, Note: note})
+	if err := storeAccessRequest(req); err != nil {
+		_ = DeleteAcl(acl)
+		return req, err
+	}
+	RecordAclVersion(req.NetworkID, reviewer, "approved access request of "+req.Requester)
+	go AccessRequestHook(req)
+	return req, nil
+}
+
+// DenyAccessRequest - rejects a pending request
+func DenyAccessRequest(id, reviewer, note string) (models.AccessRequest, error) {
+	accessRequestMutex.Lock()
+	defer accessRequestMutex.Unlock()
+	req, err := GetAccessRequest(id)
+	if err != nil {
+		return req, err
+	}
+	if req.Status != models.AccessRequestPending {
+		return req, fmt.Errorf("access request is %s", req.Status)
+	}
+	req.Status = models.AccessRequestDenied
+	req.Reviewer = reviewer
+	req.ReviewNote = note
+	req.History = append(req.History, models.AccessRequestEvent{Time: time.Now().UTC(), Status: req.Status, User: reviewer, Note: note})
+	if err := storeAccessRequest(req); err != nil {
+		return req, err
+	}
+	go AccessRequestHook(req)
+	return req, nil
+}
+
+// ExpireAccessRequests - removes the policies of approved requests whose access ended by now,
+// returns the networks that lost a policy
+func ExpireAccessRequests(now time.Time) []string {
+	accessRequestMutex.Lock()
+	defer accessRequestMutex.Unlock()
+	networks := []string{}
+	seen := make(map[models.NetworkID]struct{})
+	for _, req := range ListAccessRequests("", models.AccessRequestApproved) {
+		if now.Before(req.ExpiresAt) {
+			continue
+		}
+		if acl, err := GetAcl(req.AclID); err == nil {
+			if err := DeleteAcl(acl); err != nil {
+				slog.Error("failed to delete policy of expired access request", "request", req.ID, "acl", acl.ID, "error", err)
+				continue
+			}
+		}
+		req.Status = models.AccessRequestExpired
+		req.History = append(req.History, models.AccessRequestEvent{Time: now.UTC(), Status: req.Status})
+		if err := storeAccessRequest(req); err != nil {
+			slog.Error("failed to store expired access request", "request", req.ID, "error", err)
+			continue
+		}
+		RecordAclVersion(req.NetworkID, "", "access request of "+req.Requester+" expired")
+		go AccessRequestHook(req)
+		if _, ok := seen[req.NetworkID]; !ok {
+			seen[req.NetworkID] = struct{}{}
+			networks = append(networks, req.NetworkID.String())
+		}
+	}
+	sort.Strings(networks)
+	return networks
+}
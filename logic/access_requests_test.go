@@ -0,0 +1,74 @@
+package logic
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gravitl/netmaker/database"
+	"github.com/gravitl/netmaker/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAccessRequests(t *testing.T) {
+	database.InitializeDatabase()
+	defer database.CloseDB()
+	t.Setenv("DNS_MODE", "off")
+	netID := models.NetworkID("jit-net")
+	network := models.Network{NetID: netID.String(), AddressRange: "10.50.0.0/24"}
+	assert.Nil(t, SaveNetwork(&network))
+	tag := models.Tag{ID: "jit-net.staging", TagName: "staging", Network: netID}
+	assert.Nil(t, InsertTag(tag))
+	defer DeleteTag(tag.ID, false)
+	assert.Nil(t, UpsertUser(models.User{UserName: "contractor@example.com"}))
+	defer DeleteUser("contractor@example.com")
+
+	req := models.AccessRequestReq{
+		NetworkID:     netID,
+		Target:        models.AclPolicyTag{ID: models.NodeTagID, Value: "jit-net.staging"},
+		Proto:         models.TCP,
+		Ports:         []string{"22"},
+		Duration:      60,
+		Justification: "fix failing deploy",
+	}
+	bad := req
+	bad.Ports = []string{"70000"}
+	_, err := CreateAccessRequest(bad, "contractor@example.com")
+	assert.NotNil(t, err)
+	bad = req
+	bad.Target.Value = "other-net.staging"
+	_, err = CreateAccessRequest(bad, "contractor@example.com")
+	assert.NotNil(t, err)
+	bad = req
+	bad.Duration = MaxAccessRequestDuration + 1
+	_, err = CreateAccessRequest(bad, "contractor@example.com")
+	assert.NotNil(t, err)
+
+	accessReq, err := CreateAccessRequest(req, "contractor@example.com")
+	assert.Nil(t, err)
+	assert.Equal(t, models.AccessRequestPending, accessReq.Status)
+	assert.Len(t, ListAccessRequests(netID, models.AccessRequestPending), 1)
+
+	_, err = ApproveAccessRequest(accessReq.ID, "contractor@example.com", "")
+	assert.NotNil(t, err)
+	accessReq, err = ApproveAccessRequest(accessReq.ID, "admin", "one hour only")
+	assert.Nil(t, err)
+	assert.Equal(t, models.AccessRequestApproved, accessReq.Status)
+	assert.Len(t, accessReq.History, 2)
+	acl, err := GetAcl(accessReq.AclID)
+	assert.Nil(t, err)
+	assert.Equal(t, models.UserPolicy, acl.RuleType)
+	assert.Equal(t, "contractor@example.com", acl.Src[0].Value)
+	assert.Equal(t, accessReq.ExpiresAt, acl.Schedule.EndTime)
+	assert.True(t, IsAclEffective(acl, time.Now()))
+	_, err = DenyAccessRequest(accessReq.ID, "admin", "")
+	assert.NotNil(t, err)
+
+	assert.Empty(t, ExpireAccessRequests(time.Now()))
+	assert.Equal(t, []string{netID.String()}, ExpireAccessRequests(accessReq.ExpiresAt))
+	_, err = GetAcl(accessReq.AclID)
+	assert.NotNil(t, err)
+	accessReq, err = GetAccessRequest(accessReq.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, models.AccessRequestExpired, accessReq.Status)
+	assert.Len(t, accessReq.History, 3)
+}
@@ -0,0 +1,301 @@
+package logic
+
+import (
+	"maps"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/gravitl/netmaker/models"
+)
+
+// policySelf - stands in for a device's identities that no policy refers to,
+// so tag loops still run once for devices matched only by "*"
+const policySelf = "\x00self"
+
+// compiledAcl - an enabled device policy with its src and dst lookups resolved once
+type compiledAcl struct {
+	acl    models.Acl
+	src    map[string]struct{}
+	dst    map[string]struct{}
+	srcAll bool
+	dstAll bool
+	// devices - src and dst values referring to single devices rather than tags
+	devices map[string]struct{}
+}
+
+// policyDecision - the outcome of checking two devices against a network's policies
+type policyDecision struct {
+	allowed  bool
+	policies []models.Acl
+}
+
+// networkPolicy - the compiled device policies of a network and the decisions made with them,
+// rebuilt when the network's acls change. Decisions are keyed by the identities of both devices
+// that policies refer to, so devices sharing tags share decisions and node or tag changes
+// are picked up without a rebuild. The peers each node may reach are compiled from these
+// decisions into the network's peer model
+type networkPolicy struct {
+	// devicePolicies and userPolicies - in evaluation order
+	devicePolicies []compiledAcl
+	userPolicies   []models.Acl
+	// deviceDeny and userDeny - set if any enabled policy of the type denies traffic
+	deviceDeny    bool
+	userDeny      bool
+	referenced    map[string]struct{}
+	defaultDevice models.Acl
+	defaultErr    error
+	mutex         sync.RWMutex
+	decisions     map[string]policyDecision
+}
+
+// networkTagMap - devices of a network grouped by tag, rebuilt when nodes or ext clients change
+type networkTagMap struct {
+	withStatic bool
+	tags       map[models.TagID][]models.Node
+}
+
+var (
+	networkPoliciesMutex = &sync.RWMutex{}
+	networkPolicies      = make(map[models.NetworkID]*networkPolicy)
+	networkPoliciesGen   uint64
+	networkTagMapsMutex  = &sync.RWMutex{}
+	networkTagMaps       = make(map[models.NetworkID][]networkTagMap)
+	networkTagMapsGen    uint64
+)
+
+func compileAcl(acl models.Acl) compiledAcl {
+	c := compiledAcl{
+		acl: acl,
+		src: convAclTagToValueMap(acl.Src),
+		dst: convAclTagToValueMap(acl.Dst),
+	}
+	_, c.srcAll = c.src["*"]
+	_, c.dstAll = c.dst["*"]
+	c.devices = make(map[string]struct{})
+	for _, tag := range append(acl.Src[:len(acl.Src):len(acl.Src)], acl.Dst...) {
+		if tag.ID == models.NodeID {
+			c.devices[tag.Value] = struct{}{}
+		}
+	}
+	return c
+}
+
+// getNetworkPolicy - fetches the compiled policy of a network, compiling it if needed
+func getNetworkPolicy(netID models.NetworkID) *networkPolicy {
+	networkPoliciesMutex.RLock()
+	p, ok := networkPolicies[netID]
+	gen := networkPoliciesGen
+	networkPoliciesMutex.RUnlock()
+	if ok {
+		return p
+	}
+	acls, _ := ListAclsByNetwork(netID)
+	p = compileNetworkPolicy(netID, acls)
+	networkPoliciesMutex.Lock()
+	defer networkPoliciesMutex.Unlock()
+	if existing, ok := networkPolicies[netID]; ok {
+		return existing
+	}
+	// acls changed while compiling, use the result once but do not keep it
+	if gen == networkPoliciesGen {
+		networkPolicies[netID] = p
+	}
+	return p
+}
+
+// compileNetworkPolicy - compiles the given acls of a network, which need not be the stored ones
+func compileNetworkPolicy(netID models.NetworkID, acls []models.Acl) *networkPolicy {
+	p := &networkPolicy{
+		referenced: make(map[string]struct{}),
+		decisions:  make(map[string]policyDecision),
+	}
+	ordered := append([]models.Acl{}, acls...)
+	sortAclsByPrecedence(ordered)
+	for _, acl := range ordered {
+		if acl.RuleType == models.UserPolicy {
+			p.userPolicies = append(p.userPolicies, acl)
+			p.userDeny = p.userDeny || (isAclActive(acl) && acl.IsDeny())
+		}
+		if acl.RuleType != models.DevicePolicy || !isAclActive(acl) {
+			continue
+		}
+		p.deviceDeny = p.deviceDeny || acl.IsDeny()
+		c := compileAcl(acl)
+		p.devicePolicies = append(p.devicePolicies, c)
+		for value := range c.src {
+			p.referenced[value] = struct{}{}
+		}
+		for value := range c.dst {
+			p.referenced[value] = struct{}{}
+		}
+	}
+	p.defaultDevice, p.defaultErr = defaultPolicyOf(netID, models.DevicePolicy, acls)
+	return p
+}
+
+// hasDenyPolicy - checks if any enabled policy of the network denies traffic, in which case
+// the default policies no longer allow everything
+func hasDenyPolicy(netID models.NetworkID) bool {
+	p := getNetworkPolicy(netID)
+	return p.deviceDeny || p.userDeny
+}
+
+// InvalidateNetworkPolicy - drops the compiled policy of a network after its acls changed
+func InvalidateNetworkPolicy(netID models.NetworkID) {
+	networkPoliciesMutex.Lock()
+	defer networkPoliciesMutex.Unlock()
+	delete(networkPolicies, netID)
+	networkPoliciesGen++
+}
+
+// getNetworkTagMap - fetches the devices of a network grouped by tag; the node slices are shared
+// and clipped, so callers may append to them but must not modify their elements
+func getNetworkTagMap(netID models.NetworkID, withStatic bool) map[models.TagID][]models.Node {
+	networkTagMapsMutex.RLock()
+	gen := networkTagMapsGen
+	for _, m := range networkTagMaps[netID] {
+		if m.withStatic == withStatic {
+			networkTagMapsMutex.RUnlock()
+			return m.tags
+		}
+	}
+	networkTagMapsMutex.RUnlock()
+	tags := GetTagMapWithNodesByNetwork(netID, withStatic)
+	for tagID, nodes := range tags {
+		tags[tagID] = nodes[:len(nodes):len(nodes)]
+	}
+	networkTagMapsMutex.Lock()
+	defer networkTagMapsMutex.Unlock()
+	if gen == networkTagMapsGen {
+		networkTagMaps[netID] = append(networkTagMaps[netID], networkTagMap{withStatic: withStatic, tags: tags})
+	}
+	return tags
+}
+
+// InvalidateNetworkTagMap - drops the grouped devices of a network after a node or ext client changed
+func InvalidateNetworkTagMap(netID models.NetworkID) {
+	networkTagMapsMutex.Lock()
+	defer networkTagMapsMutex.Unlock()
+	delete(networkTagMaps, netID)
+	networkTagMapsGen++
+}
+
+// deviceTags - copies the tags of a device under its lock
+func deviceTags(node *models.Node) map[models.TagID]struct{} {
+	if node.Mutex != nil {
+		node.Mutex.Lock()
+		defer node.Mutex.Unlock()
+	}
+	return maps.Clone(node.Tags)
+}
+
+// policyIdentity - the identities of a device that the network's policies refer to,
+// returned as a sorted key and as the tag set to evaluate the policies with
+func (p *networkPolicy) policyIdentity(id string, tags map[models.TagID]struct{}) (string, map[models.TagID]struct{}) {
+	ids := []string{}
+	if _, ok := p.referenced[id]; ok {
+		ids = append(ids, id)
+	}
+	for tagID := range tags {
+		if tagID.String() == id {
+			continue
+		}
+		if _, ok := p.referenced[tagID.String()]; ok {
+			ids = append(ids, tagID.String())
+		}
+	}
+	sort.Strings(ids)
+	set := make(map[models.TagID]struct{}, len(ids)+1)
+	set[policySelf] = struct{}{}
+	for _, v := range ids {
+		set[models.TagID(v)] = struct{}{}
+	}
+	return strings.Join(ids, ","), set
+}
+
+// decide - looks up a decision, computing and storing it on a miss
+func (p *networkPolicy) decide(key string, compute func() policyDecision) policyDecision {
+	p.mutex.RLock()
+	d, ok := p.decisions[key]
+	p.mutex.RUnlock()
+	if ok {
+		return d
+	}
+	d = compute()
+	p.mutex.Lock()
+	p.decisions[key] = d
+	p.mutex.Unlock()
+	return d
+}
+
+// allowedPolicies - evaluates the policies matching node and peer, see evaluatePolicies
+func (p *networkPolicy) allowedPolicies(nodeId, peerId string, nodeTags, peerTags map[models.TagID]struct{}) (bool, []models.Acl) {
+	matches := []models.Acl{}
+	for _, policy := range p.devicePolicies {
+		if isAclAllowingPeers(policy, nodeId, peerId, nodeTags, peerTags) {
+			matches = append(matches, policy.acl)
+		}
+	}
+	return evaluatePolicies(matches)
+}
+
+// isAclAllowingPeers - checks a single policy, the node is matched as source or, for
+// bi-directional policies, as destination
+func isAclAllowingPeers(policy compiledAcl, nodeId, peerId string, nodeTags, peerTags map[models.TagID]struct{}) bool {
+	srcMap, dstMap, srcAll, dstAll := policy.src, policy.dst, policy.srcAll, policy.dstAll
+	if policy.acl.AllowedDirection == models.TrafficDirectionBi {
+		if _, ok := srcMap[nodeId]; ok || srcAll {
+			if _, ok := dstMap[peerId]; ok || dstAll {
+				return true
+			}
+		}
+		if _, ok := dstMap[nodeId]; ok || dstAll {
+			if _, ok := srcMap[peerId]; ok || srcAll {
+				return true
+			}
+		}
+	}
+	if _, ok := dstMap[peerId]; ok || dstAll {
+		if _, ok := srcMap[nodeId]; ok || srcAll {
+			return true
+		}
+	}
+	if policy.acl.AllowedDirection == models.TrafficDirectionBi {
+		for tagID := range nodeTags {
+			if _, ok := dstMap[tagID.String()]; ok || dstAll {
+				if srcAll {
+					return true
+				}
+				for tagID := range peerTags {
+					if _, ok := srcMap[tagID.String()]; ok {
+						return true
+					}
+				}
+			}
+			if _, ok := srcMap[tagID.String()]; ok || srcAll {
+				if dstAll {
+					return true
+				}
+				for tagID := range peerTags {
+					if _, ok := dstMap[tagID.String()]; ok {
+						return true
+					}
+				}
+			}
+		}
+	}
+	for tagID := range peerTags {
+		if _, ok := dstMap[tagID.String()]; ok || dstAll {
+			if srcAll {
+				return true
+			}
+			for tagID := range nodeTags {
+				if _, ok := srcMap[tagID.String()]; ok {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
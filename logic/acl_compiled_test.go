@@ -0,0 +1,78 @@
+package logic
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/gravitl/netmaker/database"
+	"github.com/gravitl/netmaker/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompiledNetworkPolicy(t *testing.T) {
+	database.InitializeDatabase()
+	defer database.CloseDB()
+	netID := models.NetworkID("compiled-net")
+	newNode := func(tags ...models.TagID) models.Node {
+		node := models.Node{}
+		node.ID = uuid.New()
+		node.HostID = uuid.New()
+		node.Network = netID.String()
+		node.Tags = make(map[models.TagID]struct{})
+		for _, tag := range tags {
+			node.Tags[tag] = struct{}{}
+		}
+		return node
+	}
+	web, db, other := newNode("compiled-net.web"), newNode("compiled-net.db"), newNode()
+	for _, node := range []*models.Node{&web, &db, &other} {
+		assert.Nil(t, UpsertNode(node))
+		defer DeleteNodeByID(node)
+	}
+	acl := models.Acl{
+		ID:               uuid.NewString(),
+		NetworkID:        netID,
+		RuleType:         models.DevicePolicy,
+		Src:              []models.AclPolicyTag{{ID: models.NodeTagID, Value: "compiled-net.web"}},
+		Dst:              []models.AclPolicyTag{{ID: models.NodeTagID, Value: "compiled-net.db"}},
+		AllowedDirection: models.TrafficDirectionBi,
+		Proto:            models.ALL,
+		ServiceType:      models.Any,
+		Enabled:          true,
+	}
+	assert.Nil(t, InsertAcl(acl))
+	defer DeleteAcl(acl)
+
+	for i := 0; i < 2; i++ {
+		// the second round is served from the cached decisions
+		allowed, policies := IsNodeAllowedToCommunicateV1(web, db, true)
+		assert.True(t, allowed)
+		assert.Len(t, policies, 1)
+		allowed, _ = IsNodeAllowedToCommunicateV1(db, web, true)
+		assert.True(t, allowed)
+		allowed, policies = IsNodeAllowedToCommunicateV1(web, other, true)
+		assert.False(t, allowed)
+		assert.Empty(t, policies)
+		assert.True(t, IsPeerAllowed(web, db, true))
+		assert.False(t, IsPeerAllowed(other, db, true))
+	}
+
+	t.Run("tag change needs no rebuild", func(t *testing.T) {
+		other.Tags["compiled-net.db"] = struct{}{}
+		assert.Nil(t, UpsertNode(&other))
+		allowed, _ := IsNodeAllowedToCommunicateV1(web, other, true)
+		assert.True(t, allowed)
+		assert.True(t, IsPeerAllowed(other, web, true))
+		assert.Len(t, getNetworkTagMap(netID, false)["compiled-net.db"], 2)
+	})
+	t.Run("policy change rebuilds", func(t *testing.T) {
+		assert.Nil(t, UpdateAcl(models.Acl{Enabled: false, Src: acl.Src, Dst: acl.Dst, ServiceType: models.Any}, acl))
+		allowed, _ := IsNodeAllowedToCommunicateV1(web, db, true)
+		assert.False(t, allowed)
+		assert.False(t, IsPeerAllowed(web, db, true))
+	})
+	t.Run("deleted node leaves the tag map", func(t *testing.T) {
+		assert.Nil(t, DeleteNodeByID(&other))
+		assert.Len(t, getNetworkTagMap(netID, false)["compiled-net.db"], 1)
+	})
+}
@@ -0,0 +1,232 @@
+package logic
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/gravitl/netmaker/models"
+)
+
+var aclLintSeverityOrder = map[models.AclLintSeverity]int{
+	models.AclLintError:   0,
+	models.AclLintWarning: 1,
+	models.AclLintInfo:    2,
+}
+
+// lintAclReferences - a finding for each tag, device, user, group or service a policy
+// references that does not exist or can not be used where it is
+func lintAclReferences(acl models.Acl) []models.AclLintFinding {
+	findings := []models.AclLintFinding{}
+	if acl.Default {
+		return findings
+	}
+	for _, side := range []struct {
+		name  string
+		tags  []models.AclPolicyTag
+		isSrc bool
+	}{{"src", acl.Src, true}, {"dst", acl.Dst, false}} {
+		for _, tag := range side.tags {
+			if tag.Value == "*" || checkIfAclTagisValid(tag, acl.NetworkID, acl.RuleType, side.isSrc) {
+				continue
+			}
+			fix := fmt.Sprintf("remove %s %s from the %s of the policy", tag.ID, tag.Value, side.name)
+			if len(side.tags) == 1 {
+				fix = fmt.Sprintf("point the %s of the policy at an existing entity or delete the policy", side.name)
+			}
+			findings = append(findings, models.AclLintFinding{
+				Kind:         models.AclLintMissingReference,
+				Severity:     models.AclLintError,
+				Message:      fmt.Sprintf("%s %s in the %s does not exist or can not be used there", tag.ID, tag.Value, side.name),
+				SuggestedFix: fix,
+			})
+		}
+	}
+	if acl.ServiceID != "" {
+		if svc, err := GetAclService(acl.ServiceID); err != nil || svc.NetworkID != acl.NetworkID {
+			findings = append(findings, models.AclLintFinding{
+				Kind:         models.AclLintMissingReference,
+				Severity:     models.AclLintError,
+				Message:      fmt.Sprintf("service %s does not exist", acl.ServiceID),
+				SuggestedFix: "reference an existing service or set the protocol and ports of the policy",
+			})
+		}
+	}
+	return findings
+}
+
+// lintAclUnreachable - checks if a policy can match any traffic: its schedule may have ended,
+// or a side may consist of tags no device carries, or of the same single device as the other
+func lintAclUnreachable(acl models.Acl, tagNodes map[models.TagID][]models.Node, now time.Time) (models.AclLintFinding, bool) {
+	finding := models.AclLintFinding{Kind: models.AclLintUnreachable, Severity: models.AclLintWarning}
+	if acl.Schedule != nil && !acl.Schedule.EndTime.IsZero() && !now.Before(acl.Schedule.EndTime) {
+		finding.Message = fmt.Sprintf("the schedule ended at %s, the policy is never in effect again",
+			acl.Schedule.EndTime.Format(time.RFC3339))
+		finding.SuggestedFix = "delete the policy or move the end time of its schedule"
+		return finding, true
+	}
+	for _, side := range []struct {
+		name string
+		tags []models.AclPolicyTag
+	}{{"src", acl.Src}, {"dst", acl.Dst}} {
+		empty := []string{}
+		for _, tag := range side.tags {
+			if tag.ID != models.NodeTagID || tag.Value == "*" || len(tagNodes[models.TagID(tag.Value)]) > 0 {
+				empty = nil
+				break
+			}
+			empty = append(empty, tag.Value)
+		}
+		if len(empty) > 0 {
+			finding.Message = fmt.Sprintf("no device carries the tags %v in the %s, the policy matches no traffic", empty, side.name)
+			finding.SuggestedFix = "tag the devices the policy is meant for or delete the policy"
+			return finding, true
+		}
+	}
+	if acl.RuleType == models.DevicePolicy && len(acl.Src) == 1 && len(acl.Dst) == 1 &&
+		acl.Src[0].ID == models.NodeID && acl.Src[0] == acl.Dst[0] {
+		finding.Message = fmt.Sprintf("device %s is both the src and the dst, a device does not go through policies to reach itself", acl.Src[0].Value)
+		finding.SuggestedFix = "change the src or dst of the policy or delete it"
+		return finding, true
+	}
+	return finding, false
+}
+
+// lintAclDirection - checks if limiting an allow device policy to one direction changes what it
+// allows, which it does not when its src and dst are the same or another policy allows the
+// reverse direction
+func lintAclDirection(acl models.Acl, policies []models.Acl) (models.AclLintFinding, bool) {
+	finding := models.AclLintFinding{Kind: models.AclLintNoEffect}
+	if acl.Default || acl.IsDeny() || acl.RuleType != models.DevicePolicy || acl.AllowedDirection == models.TrafficDirectionBi {
+		return finding, false
+	}
+	if aclTagsCover(acl.Src, acl.Dst) && aclTagsCover(acl.Dst, acl.Src) {
+		finding.Severity = models.AclLintInfo
+		finding.Message = "src and dst are the same, so the policy allows traffic both ways regardless of its direction"
+		finding.SuggestedFix = "make the policy bi-directional"
+		return finding, true
+	}
+	reverse := acl
+	reverse.Src, reverse.Dst = acl.Dst, acl.Src
+	for _, policy := range policies {
+		if policy.ID == acl.ID || !policy.Enabled || policy.Schedule != nil || policy.IsDeny() {
+			continue
+		}
+		if aclCovers(policy, reverse) {
+			finding.Severity = models.AclLintWarning
+			finding.RelatedPolicyID, finding.RelatedPolicyName = policy.ID, policy.Name
+			finding.Message = fmt.Sprintf("policy %s also allows the traffic from dst to src, so limiting this policy to one direction has no effect", policy.Name)
+			finding.SuggestedFix = fmt.Sprintf("narrow or disable policy %s, or make this policy bi-directional", policy.Name)
+			return finding, true
+		}
+	}
+	return finding, false
+}
+
+// LintAclPolicies - reports the policies of a network that reference missing entities, match no
+// traffic, never take effect because of an earlier policy, or are uni-directional to no effect.
+// Disabled policies are only checked for missing references
+func LintAclPolicies(netID models.NetworkID) (models.AclLintReport, error) {
+	report := models.AclLintReport{NetworkID: netID, Findings: []models.AclLintFinding{}}
+	if _, err := GetNetwork(netID.String()); err != nil {
+		return report, err
+	}
+	policies, _ := ListAclsByNetwork(netID)
+	// of two equal policies the older one is kept and the newer one reported
+	sort.Slice(policies, func(i, j int) bool {
+		if !policies[i].CreatedAt.Equal(policies[j].CreatedAt) {
+			return policies[i].CreatedAt.Before(policies[j].CreatedAt)
+		}
+		return policies[i].ID < policies[j].ID
+	})
+	sortAclsByPrecedence(policies)
+	tagNodes := GetTagMapWithNodesByNetwork(netID, true)
+	now := time.Now().UTC()
+	add := func(acl models.Acl, finding models.AclLintFinding) {
+		finding.PolicyID, finding.PolicyName = acl.ID, acl.Name
+		report.Findings = append(report.Findings, finding)
+	}
+	for i, acl := range policies {
+		missing := lintAclReferences(acl)
+		for _, finding := range missing {
+			add(acl, finding)
+		}
+		if !acl.Enabled || len(missing) > 0 {
+			continue
+		}
+		// default policies, like the one for gateways, are there before the devices they are for
+		if finding, ok := lintAclUnreachable(acl, tagNodes, now); ok && !acl.Default {
+			add(acl, finding)
+			continue
+		}
+		covered := false
+		for j, policy := range policies {
+			// scheduled policies only cover part of the time
+			if j == i || !policy.Enabled || policy.Schedule != nil || !aclCovers(policy, acl) {
+				continue
+			}
+			if policy.IsDeny() != acl.IsDeny() && aclPrecedes(policy, acl) {
+				fix := fmt.Sprintf("narrow policy %s or delete this one", policy.Name)
+				if acl.Default {
+					fix = fmt.Sprintf("narrow policy %s or disable this default policy", policy.Name)
+				} else if policy.Priority > 0 {
+					fix = fmt.Sprintf("give the policy a priority below %d so it is evaluated before %s, or delete it", policy.Priority, policy.Name)
+				}
+				add(acl, models.AclLintFinding{
+					Kind:              models.AclLintShadowed,
+					Severity:          models.AclLintWarning,
+					RelatedPolicyID:   policy.ID,
+					RelatedPolicyName: policy.Name,
+					Message:           fmt.Sprintf("policy %s is evaluated first and %s all of its traffic, the policy never takes effect", policy.Name, policyActionVerb(policy)),
+					SuggestedFix:      fix,
+				})
+				covered = true
+				break
+			}
+			if policy.IsDeny() == acl.IsDeny() && j < i && !acl.Default {
+				add(acl, models.AclLintFinding{
+					Kind:              models.AclLintRedundant,
+					Severity:          models.AclLintWarning,
+					RelatedPolicyID:   policy.ID,
+					RelatedPolicyName: policy.Name,
+					Message:           fmt.Sprintf("policy %s is evaluated first and already %s all of its traffic", policy.Name, policyActionVerb(policy)),
+					SuggestedFix:      "delete the policy",
+				})
+				covered = true
+				break
+			}
+		}
+		if covered {
+			continue
+		}
+		if finding, ok := lintAclDirection(acl, policies); ok {
+			add(acl, finding)
+		}
+	}
+	sort.SliceStable(report.Findings, func(i, j int) bool {
+		a, b := report.Findings[i], report.Findings[j]
+		if a.Severity != b.Severity {
+			return aclLintSeverityOrder[a.Severity] < aclLintSeverityOrder[b.Severity]
+		}
+		return a.PolicyName < b.PolicyName
+	})
+	for _, finding := range report.Findings {
+		switch finding.Severity {
+		case models.AclLintError:
+			report.Errors++
+		case models.AclLintWarning:
+			report.Warnings++
+		default:
+			report.Info++
+		}
+	}
+	return report, nil
+}
+
+// policyActionVerb - what a policy does with its traffic, for messages
+func policyActionVerb(acl models.Acl) string {
+	if acl.IsDeny() {
+		return "denies"
+	}
+	return "allows"
+}
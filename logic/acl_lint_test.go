@@ -0,0 +1,105 @@
+package logic
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gravitl/netmaker/database"
+	"github.com/gravitl/netmaker/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLintAclPolicies(t *testing.T) {
+	database.InitializeDatabase()
+	defer database.CloseDB()
+	t.Setenv("DNS_MODE", "off")
+	netID := models.NetworkID("lint-net")
+	network := models.Network{NetID: netID.String(), AddressRange: "10.90.0.0/24"}
+	assert.Nil(t, SaveNetwork(&network))
+	CreateDefaultAclNetworkPolicies(netID)
+	defer DeleteNetworkPolicies(netID)
+	for i, name := range []string{"web", "db", "cache"} {
+		tag := models.Tag{ID: models.TagID("lint-net." + name), TagName: name, Network: netID}
+		assert.Nil(t, InsertTag(tag))
+		defer DeleteTag(tag.ID, false)
+		if name == "cache" {
+			continue
+		}
+		node := models.Node{}
+		node.ID = uuid.New()
+		node.HostID = uuid.New()
+		node.Network = netID.String()
+		node.Address = net.IPNet{IP: net.IPv4(10, 90, 0, byte(i+1)), Mask: net.CIDRMask(32, 32)}
+		node.Tags = map[models.TagID]struct{}{tag.ID: {}}
+		assert.Nil(t, UpsertNode(&node))
+		defer DeleteNodeByID(&node)
+	}
+
+	tags := func(values ...string) (list []models.AclPolicyTag) {
+		for _, v := range values {
+			list = append(list, models.AclPolicyTag{ID: models.NodeTagID, Value: v})
+		}
+		return
+	}
+	created := time.Now().UTC()
+	policy := func(name string, action models.AclAction, priority int, src, dst []models.AclPolicyTag,
+		proto models.Protocol, ports []string, direction models.AllowedTrafficDirection) models.Acl {
+		created = created.Add(time.Second)
+		acl := models.Acl{
+			ID:               uuid.NewString(),
+			Name:             name,
+			NetworkID:        netID,
+			RuleType:         models.DevicePolicy,
+			Src:              src,
+			Dst:              dst,
+			Proto:            proto,
+			Port:             ports,
+			AllowedDirection: direction,
+			Action:           action,
+			Priority:         priority,
+			Enabled:          true,
+			CreatedAt:        created,
+		}
+		assert.Nil(t, InsertAcl(acl))
+		return acl
+	}
+	denyTCP := policy("deny tcp", models.AclDeny, 10, tags("*"), tags("*"), models.TCP, []string{}, models.TrafficDirectionBi)
+	policy("allow https", models.AclAllow, 20, tags("lint-net.web"), tags("lint-net.db"), models.TCP, []string{"443"}, models.TrafficDirectionUni)
+	policy("deny ssh", models.AclDeny, 30, tags("*"), tags("*"), models.TCP, []string{"22"}, models.TrafficDirectionBi)
+	policy("web to cache", models.AclAllow, 0, tags("lint-net.web"), tags("lint-net.cache"), models.UDP, []string{}, models.TrafficDirectionUni)
+	policy("web to db", models.AclAllow, 0, tags("lint-net.web"), tags("lint-net.db"), models.UDP, []string{"53"}, models.TrafficDirectionUni)
+	policy("web to web", models.AclAllow, 0, tags("lint-net.web"), tags("lint-net.web"), models.UDP, []string{}, models.TrafficDirectionUni)
+	policy("gone to db", models.AclAllow, 0, tags("lint-net.gone"), tags("lint-net.db"), models.UDP, []string{}, models.TrafficDirectionUni)
+
+	report, err := LintAclPolicies(netID)
+	assert.Nil(t, err)
+	kinds := make(map[string]models.AclLintKind)
+	for _, finding := range report.Findings {
+		kinds[finding.PolicyName] = finding.Kind
+		assert.NotEmpty(t, finding.SuggestedFix)
+	}
+	assert.Equal(t, map[string]models.AclLintKind{
+		"gone to db":   models.AclLintMissingReference,
+		"allow https":  models.AclLintShadowed,
+		"deny ssh":     models.AclLintRedundant,
+		"web to cache": models.AclLintUnreachable,
+		"web to db":    models.AclLintNoEffect,
+		"web to web":   models.AclLintNoEffect,
+	}, kinds)
+	assert.Equal(t, []int{1, 4, 1}, []int{report.Errors, report.Warnings, report.Info})
+	assert.Equal(t, models.AclLintError, report.Findings[0].Severity)
+	assert.Equal(t, models.AclLintInfo, report.Findings[len(report.Findings)-1].Severity)
+	for _, finding := range report.Findings {
+		if finding.Kind == models.AclLintShadowed {
+			assert.Equal(t, denyTCP.ID, finding.RelatedPolicyID)
+		}
+		if finding.PolicyName == "web to db" {
+			assert.Equal(t, models.AclLintWarning, finding.Severity, "the default policy allows db to web")
+		}
+	}
+
+	_, err = LintAclPolicies("no-such-net")
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,185 @@
+package logic
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/gravitl/netmaker/models"
+)
+
+// AclMatrixFilter - narrows the reachability matrix down to the devices with a tag
+// and to a single user group, empty fields do not filter
+type AclMatrixFilter struct {
+	Tag       models.TagID
+	UserGroup models.UserGroupID
+}
+
+// GetAclMatrix - evaluates the reachability between every pair of nodes, static nodes and user groups of a network
+func GetAclMatrix(netID models.NetworkID, filter AclMatrixFilter) (models.AclMatrix, error) {
+	matrix := models.AclMatrix{
+		NetworkID: netID,
+		Entities:  []models.AclMatrixEntity{},
+		Cells:     [][]models.AclMatrixCell{},
+	}
+	nodes, err := GetNetworkNodes(netID.String())
+	if err != nil {
+		return matrix, err
+	}
+	devices := []models.Node{}
+	for _, device := range append(nodes, GetStaticNodesByNetwork(netID, true)...) {
+		if filter.Tag != "" {
+			if _, ok := staticAwareTags(device)[filter.Tag]; !ok {
+				continue
+			}
+		}
+		devices = append(devices, device)
+		entity := models.AclMatrixEntity{ID: device.ID.String(), Name: deviceDisplayName(device), Type: "node"}
+		if device.IsStatic {
+			entity.ID, entity.Type = device.StaticNode.ClientID, "static"
+		}
+		matrix.Entities = append(matrix.Entities, entity)
+	}
+	groups := []models.UserGroup{}
+	for _, grp := range GetUserGroupsInNetwork(netID) {
+		if filter.UserGroup != "" && grp.ID != filter.UserGroup {
+			continue
+		}
+		groups = append(groups, grp)
+	}
+	sort.Slice(groups, func(i, j int) bool { return groups[i].ID < groups[j].ID })
+	for _, grp := range groups {
+		name := grp.Name
+		if name == "" {
+			name = grp.ID.String()
+		}
+		matrix.Entities = append(matrix.Entities, models.AclMatrixEntity{ID: grp.ID.String(), Name: name, Type: "user-group"})
+	}
+
+	policy := getNetworkPolicy(netID)
+	defaultUser, _ := defaultPolicyOf(netID, models.UserPolicy, policy.userPolicies)
+	cell := func(allowed bool, policies []models.Acl) models.AclMatrixCell {
+		return models.AclMatrixCell{Allowed: allowed, Grants: policyGrants(allowed, policies)}
+	}
+	for i := range matrix.Entities {
+		row := make([]models.AclMatrixCell, len(matrix.Entities))
+		for j := range matrix.Entities {
+			switch {
+			case i == j:
+				row[j] = cell(false, nil)
+			case i < len(devices) && j < len(devices):
+				row[j] = cell(IsNodeAllowedToCommunicateV1(devices[i], devices[j], true))
+			case i >= len(devices) && j < len(devices):
+				row[j] = cell(isUserGroupAllowedToCommunicate(groups[i-len(devices)].ID, devices[j], policy, defaultUser, false))
+			case i < len(devices) && j >= len(devices):
+				// devices only reach users over bi-directional user policies
+				row[j] = cell(isUserGroupAllowedToCommunicate(groups[j-len(devices)].ID, devices[i], policy, defaultUser, true))
+			default:
+				// user policies never grant traffic between users
+				row[j] = cell(false, nil)
+			}
+		}
+		matrix.Cells = append(matrix.Cells, row)
+	}
+	return matrix, nil
+}
+
+// isUserGroupAllowedToCommunicate - evaluates the user policies letting members of a group reach peer,
+// optionally only the bi-directional ones letting peer reach back. Deny policies apply either way
+func isUserGroupAllowedToCommunicate(grpID models.UserGroupID, peer models.Node, policy *networkPolicy,
+	defaultUser models.Acl, biOnly bool) (bool, []models.Acl) {
+	if defaultUser.Enabled && !policy.userDeny && (!biOnly || defaultUser.AllowedDirection == models.TrafficDirectionBi) {
+		return true, []models.Acl{defaultUser}
+	}
+	peerId := peer.ID.String()
+	if peer.IsStatic {
+		peerId = peer.StaticNode.ClientID
+	}
+	peerTags := staticAwareTags(peer)
+	matches := []models.Acl{}
+	for _, acl := range policy.userPolicies {
+		if !isAclActive(acl) || (biOnly && !acl.IsDeny() && acl.AllowedDirection != models.TrafficDirectionBi) {
+			continue
+		}
+		inSrc := false
+		for _, src := range acl.Src {
+			if src.Value == "*" || (src.ID == models.UserGroupAclID && src.Value == grpID.String()) {
+				inSrc = true
+				break
+			}
+		}
+		if !inSrc {
+			continue
+		}
+		dstMap := convAclTagToValueMap(acl.Dst)
+		if _, ok := dstMap["*"]; ok {
+			matches = append(matches, acl)
+			continue
+		}
+		if _, ok := dstMap[peerId]; ok {
+			matches = append(matches, acl)
+			continue
+		}
+		for tagID := range peerTags {
+			if _, ok := dstMap[tagID.String()]; ok {
+				matches = append(matches, acl)
+				break
+			}
+		}
+	}
+	return evaluatePolicies(matches)
+}
+
+// staticAwareTags - the tags of a node or of the ext client behind a static node
+func staticAwareTags(device models.Node) map[models.TagID]struct{} {
+	if device.IsStatic {
+		device = device.StaticNode.ConvertToStaticNode()
+	}
+	return deviceTags(&device)
+}
+
+// AclMatrixToCSV - renders the matrix with sources as rows and destinations as columns,
+// each allowed cell lists its protocols and ports with the granting policy in evaluation order
+func AclMatrixToCSV(matrix models.AclMatrix) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	writer := csv.NewWriter(buf)
+	header := []string{"source \\ destination"}
+	for _, entity := range matrix.Entities {
+		header = append(header, entity.Name)
+	}
+	if err := writer.Write(header); err != nil {
+		return nil, err
+	}
+	for i, row := range matrix.Cells {
+		record := []string{matrix.Entities[i].Name}
+		for j, cell := range row {
+			if i == j {
+				record = append(record, "-")
+				continue
+			}
+			grants := []string{}
+			for _, grant := range cell.Grants {
+				entry := grant.Proto.String()
+				if len(grant.Ports) > 0 {
+					entry += ":" + strings.Join(grant.Ports, ",")
+				}
+				if grant.Action == models.AclDeny {
+					entry = "deny " + entry
+				}
+				grants = append(grants, fmt.Sprintf("%s (%s)", entry, grant.PolicyID))
+			}
+			if cell.Allowed && len(grants) == 0 {
+				// e.g. failover peers are allowed without any policy
+				grants = append(grants, "allowed")
+			}
+			record = append(record, strings.Join(grants, "; "))
+		}
+		if err := writer.Write(record); err != nil {
+			return nil, err
+		}
+	}
+	writer.Flush()
+	return buf.Bytes(), writer.Error()
+}
@@ -0,0 +1,108 @@
+package logic
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/gravitl/netmaker/database"
+	"github.com/gravitl/netmaker/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetAclMatrix(t *testing.T) {
+	database.InitializeDatabase()
+	defer database.CloseDB()
+	t.Setenv("DNS_MODE", "off")
+	netID := models.NetworkID("matrix-net")
+	network := models.Network{NetID: netID.String(), AddressRange: "10.30.0.0/24"}
+	assert.Nil(t, SaveNetwork(&network))
+	CreateDefaultAclNetworkPolicies(netID)
+	defer DeleteNetworkPolicies(netID)
+	defaultAcl, err := GetAcl(fmt.Sprintf("%s.all-nodes", netID))
+	assert.Nil(t, err)
+	assert.Nil(t, UpdateAcl(models.Acl{Enabled: false}, defaultAcl))
+	newNode := func(name string, tags ...models.TagID) models.Node {
+		host := models.Host{ID: uuid.New(), Name: name}
+		assert.Nil(t, UpsertHost(&host))
+		node := models.Node{}
+		node.ID = uuid.New()
+		node.HostID = host.ID
+		node.Network = netID.String()
+		node.Tags = make(map[models.TagID]struct{})
+		for _, tag := range tags {
+			node.Tags[tag] = struct{}{}
+		}
+		assert.Nil(t, UpsertNode(&node))
+		return node
+	}
+	web, db := newNode("matrix-web", "matrix-net.web"), newNode("matrix-db")
+	defer DeleteNodeByID(&web)
+	defer DeleteNodeByID(&db)
+	acl := models.Acl{
+		ID:               uuid.NewString(),
+		NetworkID:        netID,
+		RuleType:         models.DevicePolicy,
+		Src:              []models.AclPolicyTag{{ID: models.NodeTagID, Value: "matrix-net.web"}},
+		Dst:              []models.AclPolicyTag{{ID: models.NodeID, Value: db.ID.String()}},
+		AllowedDirection: models.TrafficDirectionUni,
+		Proto:            models.TCP,
+		Port:             []string{"5432"},
+		Enabled:          true,
+	}
+	assert.Nil(t, InsertAcl(acl))
+
+	matrix, err := GetAclMatrix(netID, AclMatrixFilter{})
+	assert.Nil(t, err)
+	assert.Len(t, matrix.Entities, 2)
+	index := map[string]int{}
+	for i, entity := range matrix.Entities {
+		index[entity.Name] = i
+	}
+	cell := matrix.Cells[index["matrix-web"]][index["matrix-db"]]
+	assert.True(t, cell.Allowed)
+	assert.Equal(t, acl.ID, cell.Grants[0].PolicyID)
+	assert.Equal(t, []string{"5432"}, cell.Grants[0].Ports)
+	assert.False(t, matrix.Cells[index["matrix-db"]][index["matrix-web"]].Allowed)
+
+	data, err := AclMatrixToCSV(matrix)
+	assert.Nil(t, err)
+	assert.Contains(t, string(data), "tcp:5432 ("+acl.ID+")")
+	assert.Len(t, strings.Split(strings.TrimSpace(string(data)), "\n"), 3)
+
+	matrix, err = GetAclMatrix(netID, AclMatrixFilter{Tag: "matrix-net.web"})
+	assert.Nil(t, err)
+	assert.Len(t, matrix.Entities, 1)
+	assert.Equal(t, web.ID.String(), matrix.Entities[0].ID)
+
+	t.Run("DenyUserPolicy", func(t *testing.T) {
+		getUserGroups := GetUserGroupsInNetwork
+		GetUserGroupsInNetwork = func(models.NetworkID) map[models.UserGroupID]models.UserGroup {
+			return map[models.UserGroupID]models.UserGroup{"devs": {ID: "devs", Name: "devs"}}
+		}
+		defer func() { GetUserGroupsInNetwork = getUserGroups }()
+		deny := models.Acl{
+			ID:               uuid.NewString(),
+			NetworkID:        netID,
+			RuleType:         models.UserPolicy,
+			Src:              []models.AclPolicyTag{{ID: models.UserGroupAclID, Value: "devs"}},
+			Dst:              []models.AclPolicyTag{{ID: models.NodeID, Value: db.ID.String()}},
+			AllowedDirection: models.TrafficDirectionBi,
+			Proto:            models.ALL,
+			Action:           models.AclDeny,
+			Enabled:          true,
+		}
+		assert.Nil(t, InsertAcl(deny))
+		defer DeleteAcl(deny)
+		matrix, err := GetAclMatrix(netID, AclMatrixFilter{})
+		assert.Nil(t, err)
+		index := map[string]int{}
+		for i, entity := range matrix.Entities {
+			index[entity.Name] = i
+		}
+		assert.True(t, matrix.Cells[index["devs"]][index["matrix-web"]].Allowed, "the default user policy still applies")
+		assert.False(t, matrix.Cells[index["devs"]][index["matrix-db"]].Allowed)
+		assert.False(t, matrix.Cells[index["matrix-db"]][index["devs"]].Allowed)
+	})
+}
@@ -0,0 +1,520 @@
+package logic
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gravitl/netmaker/models"
+	"gopkg.in/yaml.v3"
+)
+
+// aclPolicySetMutex - serializes changes replacing the whole policy set of a network
+var aclPolicySetMutex = &sync.Mutex{}
+
+// AclPolicyFileError - the problems found in a policy file, each prefixed with its line
+type AclPolicyFileError struct {
+	Errors []string
+}
+
+func (e *AclPolicyFileError) Error() string {
+	return strings.Join(e.Errors, "; ")
+}
+
+func (e *AclPolicyFileError) add(line int, format string, args ...any) {
+	e.Errors = append(e.Errors, fmt.Sprintf("line %d: ", line)+fmt.Sprintf(format, args...))
+}
+
+// ParseAclPolicyFileFormat - resolves the format query of the policy file apis, yaml by default
+func ParseAclPolicyFileFormat(format string) (models.AclPolicyFileFormat, error) {
+	switch strings.ToLower(format) {
+	case "", "yaml", "yml":
+		return models.AclPolicyFileYAML, nil
+	case "hujson", "json":
+		return models.AclPolicyFileHuJSON, nil
+	}
+	return "", errors.New("unsupported format " + format)
+}
+
+// aclFileEntity - writes a policy tag as a type:value reference, tags without the network prefix
+func aclFileEntity(netID models.NetworkID, tag models.AclPolicyTag) string {
+	value := tag.Value
+	if tag.ID == models.NodeTagID {
+		value = strings.TrimPrefix(value, netID.String()+".")
+	}
+	return tag.ID.String() + ":" + value
+}
+
+// ExportAclPolicyFile - the policies of a network in evaluation order, with the tags and user
+// groups of the network
+func ExportAclPolicyFile(netID models.NetworkID) (models.AclPolicyFile, error) {
+	file := models.AclPolicyFile{Network: netID.String(), Rules: []models.AclPolicyFileRule{}}
+	if _, err := GetNetwork(netID.String()); err != nil {
+		return file, err
+	}
+	tags, _ := ListNetworkTags(netID)
+	for _, tag := range tags {
+		file.Tags = append(file.Tags, tag.TagName)
+	}
+	sort.Strings(file.Tags)
+	for groupID := range GetUserGroupsInNetwork(netID) {
+		file.Groups = append(file.Groups, groupID.String())
+	}
+	sort.Strings(file.Groups)
+	policies, _ := ListAclsByNetwork(netID)
+	sort.Slice(policies, func(i, j int) bool {
+		if policies[i].Name != policies[j].Name {
+			return policies[i].Name < policies[j].Name
+		}
+		return policies[i].ID < policies[j].ID
+	})
+	sortAclsByPrecedence(policies)
+	for _, acl := range policies {
+		if acl.ServiceParentID != "" {
+			continue
+		}
+		enabled := acl.Enabled
+		rule := models.AclPolicyFileRule{
+			ID:          acl.ID,
+			Name:        acl.Name,
+			Description: acl.MetaData,
+			Default:     acl.Default,
+			Type:        acl.RuleType,
+			Action:      acl.Action,
+			Priority:    acl.Priority,
+			Src:         []string{},
+			Dst:         []string{},
+			Direction:   "uni",
+			Enabled:     &enabled,
+			Schedule:    acl.Schedule,
+		}
+		if acl.AllowedDirection == models.TrafficDirectionBi {
+			rule.Direction = "bi"
+		}
+		if svc, err := GetAclService(acl.ServiceID); acl.ServiceID != "" && err == nil {
+			rule.Service = svc.Name
+		} else if !aclCoversAllTraffic(acl) {
+			rule.Protocol = acl.Proto
+			rule.Ports = acl.Port
+		}
+		for _, src := range acl.Src {
+			rule.Src = append(rule.Src, aclFileEntity(netID, src))
+		}
+		for _, dst := range acl.Dst {
+			rule.Dst = append(rule.Dst, aclFileEntity(netID, dst))
+		}
+		file.Rules = append(file.Rules, rule)
+	}
+	return file, nil
+}
+
+// MarshalAclPolicyFile - encodes a policy file
+func MarshalAclPolicyFile(file models.AclPolicyFile, format models.AclPolicyFileFormat) ([]byte, error) {
+	if format == models.AclPolicyFileHuJSON {
+		data, err := json.MarshalIndent(file, "", "  ")
+		return append(data, '\n'), err
+	}
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(file); err != nil {
+		return nil, err
+	}
+	err := enc.Close()
+	return buf.Bytes(), err
+}
+
+// standardizeHuJSON - blanks out the comments and trailing commas of HuJSON, keeping every other
+// byte in place so that lines still match the original
+func standardizeHuJSON(data []byte) []byte {
+	out := make([]byte, len(data))
+	copy(out, data)
+	inString := false
+	for i := 0; i < len(out); i++ {
+		c := out[i]
+		if inString {
+			if c == '\\' {
+				i++
+			} else if c == '"' {
+				inString = false
+			}
+			continue
+		}
+		switch {
+		case c == '"':
+			inString = true
+		case c == '/' && i+1 < len(out) && out[i+1] == '/':
+			for ; i < len(out) && out[i] != '\n'; i++ {
+				out[i] = ' '
+			}
+		case c == '/' && i+1 < len(out) && out[i+1] == '*':
+			end := bytes.Index(out[i+2:], []byte("*/"))
+			last := len(out) - 1
+			if end >= 0 {
+				last = i + 2 + end + 1
+			}
+			for ; i <= last; i++ {
+				if out[i] != '\n' {
+					out[i] = ' '
+				}
+			}
+			i--
+		case c == ',':
+			j := i + 1
+			for j < len(out) && (out[j] == ' ' || out[j] == '\t' || out[j] == '\n' || out[j] == '\r') {
+				j++
+			}
+			if j < len(out) && (out[j] == '}' || out[j] == ']') {
+				out[i] = ' '
+			}
+		}
+	}
+	return out
+}
+
+// yamlMapValue - the value of a key in a mapping node
+func yamlMapValue(node *yaml.Node, key string) *yaml.Node {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// yamlItemLine - the line of the i-th item of a sequence, or of the sequence itself
+func yamlItemLine(node *yaml.Node, i int) int {
+	if node == nil {
+		return 0
+	}
+	if node.Kind == yaml.SequenceNode && i < len(node.Content) {
+		return node.Content[i].Line
+	}
+	return node.Line
+}
+
+// decodeAclPolicyFile - decodes a policy file along with its document node for line lookups
+func decodeAclPolicyFile(data []byte, format models.AclPolicyFileFormat) (models.AclPolicyFile, *yaml.Node, error) {
+	file := models.AclPolicyFile{}
+	if format == models.AclPolicyFileHuJSON {
+		data = standardizeHuJSON(data)
+	}
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return file, nil, err
+	}
+	if len(root.Content) == 0 || root.Content[0].Kind != yaml.MappingNode {
+		return file, nil, errors.New("line 1: policy file must be a mapping")
+	}
+	if err := root.Content[0].Decode(&file); err != nil {
+		return file, nil, err
+	}
+	return file, root.Content[0], nil
+}
+
+// validateAclFileTraffic - checks that ports are valid and only used with tcp or udp
+func validateAclFileTraffic(proto models.Protocol, ports []string) error {
+	switch proto {
+	case "", models.ALL, models.ICMP:
+		if len(ports) > 0 {
+			return errors.New("ports require the tcp or udp protocol")
+		}
+	case models.TCP, models.UDP:
+		for _, port := range ports {
+			if _, _, err := parsePortRange(port); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("unsupported protocol %s", proto)
+	}
+	return nil
+}
+
+// parseAclFileEntity - resolves a type:value reference of a rule into a policy tag
+func parseAclFileEntity(netID models.NetworkID, ref string, tags, groups map[string]struct{}) (models.AclPolicyTag, error) {
+	kind, value, ok := strings.Cut(ref, ":")
+	if !ok || value == "" {
+		return models.AclPolicyTag{}, fmt.Errorf("invalid reference %q, expected type:value", ref)
+	}
+	tag := models.AclPolicyTag{ID: models.AclGroupType(kind), Value: value}
+	switch tag.ID {
+	case models.NodeTagID:
+		if value == "*" {
+			break
+		}
+		if _, ok := tags[value]; !ok {
+			return tag, fmt.Errorf("tag %s is not declared in tags", value)
+		}
+		tag.Value = fmt.Sprintf("%s.%s", netID, value)
+	case models.UserGroupAclID:
+		if _, ok := groups[value]; !ok && value != "*" {
+			return tag, fmt.Errorf("user group %s is not declared in groups", value)
+		}
+	case models.UserAclID, models.NodeID, models.EgressRange:
+	default:
+		return tag, fmt.Errorf("unknown reference type %q", kind)
+	}
+	return tag, nil
+}
+
+// buildAclPolicyFile - turns the rules of a policy file into the policies of the network,
+// collecting every problem with its line, and lists the policies the file drops
+func buildAclPolicyFile(netID models.NetworkID, file models.AclPolicyFile, doc *yaml.Node, user string) ([]models.Acl, []models.Acl, *AclPolicyFileError) {
+	problems := &AclPolicyFileError{}
+	if file.Network != "" && file.Network != netID.String() {
+		problems.add(yamlMapValue(doc, "network").Line, "policy file is for network %s, not %s", file.Network, netID)
+	}
+	groups := make(map[string]struct{})
+	netGroups := GetUserGroupsInNetwork(netID)
+	groupsNode := yamlMapValue(doc, "groups")
+	for i, group := range file.Groups {
+		if _, ok := netGroups[models.UserGroupID(group)]; !ok {
+			problems.add(yamlItemLine(groupsNode, i), "user group %s does not exist in network %s", group, netID)
+		}
+		groups[group] = struct{}{}
+	}
+	tags := make(map[string]struct{})
+	tagsNode := yamlMapValue(doc, "tags")
+	for i, name := range file.Tags {
+		tag, err := GetTag(models.TagID(fmt.Sprintf("%s.%s", netID, name)))
+		if err != nil || tag.Network != netID {
+			problems.add(yamlItemLine(tagsNode, i), "tag %s does not exist in network %s", name, netID)
+		}
+		tags[name] = struct{}{}
+	}
+	servicesNode := yamlMapValue(doc, "services")
+	serviceNames := make([]string, 0, len(file.Services))
+	for name := range file.Services {
+		serviceNames = append(serviceNames, name)
+	}
+	sort.Strings(serviceNames)
+	for _, name := range serviceNames {
+		svc := file.Services[name]
+		if err := validateAclFileTraffic(svc.Protocol, svc.Ports); err != nil {
+			problems.add(yamlMapValue(servicesNode, name).Line, "service %s: %v", name, err)
+		}
+	}
+
+	existing := make(map[string]models.Acl)
+	policies, _ := ListAclsByNetwork(netID)
+	for _, acl := range policies {
+		existing[acl.ID] = acl
+	}
+	seen := make(map[string]struct{})
+	acls := []models.Acl{}
+	now := time.Now().UTC()
+	rulesNode := yamlMapValue(doc, "rules")
+	for i, rule := range file.Rules {
+		ruleNode := rulesNode.Content[i]
+		line := func(key string) int {
+			if v := yamlMapValue(ruleNode, key); v != nil {
+				return v.Line
+			}
+			return ruleNode.Line
+		}
+		count := len(problems.Errors)
+		var acl models.Acl
+		if rule.ID != "" {
+			if _, ok := seen[rule.ID]; ok {
+				problems.add(line("id"), "policy %s is listed more than once", rule.ID)
+				continue
+			}
+			seen[rule.ID] = struct{}{}
+			current, ok := existing[rule.ID]
+			if !ok {
+				problems.add(line("id"), "policy %s does not exist in network %s", rule.ID, netID)
+				continue
+			}
+			if current.ServiceParentID != "" {
+				problems.add(line("id"), "policy %s follows policy %s and can not be listed", rule.ID, current.ServiceParentID)
+				continue
+			}
+			if current.Default {
+				current.Enabled = rule.Enabled == nil || *rule.Enabled
+				acls = append(acls, current)
+				continue
+			}
+			acl = current
+		} else {
+			if rule.Default {
+				problems.add(ruleNode.Line, "default policies can not be created")
+				continue
+			}
+			acl = models.Acl{ID: uuid.NewString(), CreatedBy: user, CreatedAt: now}
+		}
+		acl.Name = rule.Name
+		acl.MetaData = rule.Description
+		acl.NetworkID = netID
+		acl.Action = rule.Action
+		acl.Priority = rule.Priority
+		acl.Schedule = rule.Schedule
+		acl.Enabled = rule.Enabled == nil || *rule.Enabled
+		acl.RuleType = rule.Type
+		if acl.RuleType != models.DevicePolicy && acl.RuleType != models.UserPolicy {
+			problems.add(line("type"), "type must be %s or %s", models.DevicePolicy, models.UserPolicy)
+		}
+		switch rule.Direction {
+		case "", "uni":
+			acl.AllowedDirection = models.TrafficDirectionUni
+		case "bi":
+			acl.AllowedDirection = models.TrafficDirectionBi
+		default:
+			problems.add(line("direction"), "direction must be uni or bi")
+		}
+		acl.Proto, acl.Port = rule.Protocol, rule.Ports
+		acl.ServiceID = ""
+		if rule.Service != "" {
+			if rule.Protocol != "" || len(rule.Ports) > 0 {
+				problems.add(line("service"), "a rule takes either a service or a protocol and ports")
+			}
+			if svc, ok := file.Services[rule.Service]; ok {
+				acl.Proto, acl.Port = svc.Protocol, svc.Ports
+			} else if netSvc, err := GetAclServiceByName(netID, rule.Service); err == nil {
+				acl.ServiceID = netSvc.ID
+				acl.Proto, acl.Port = netSvc.Entries[0].Protocol, netSvc.Entries[0].Ports
+			} else {
+				problems.add(line("service"), "service %s is not declared in services or in network %s", rule.Service, netID)
+			}
+		} else if err := validateAclFileTraffic(rule.Protocol, rule.Ports); err != nil {
+			problems.add(line("protocol"), "%v", err)
+		}
+		if acl.Proto == "" {
+			acl.Proto = models.ALL
+		}
+		if acl.Port == nil {
+			acl.Port = []string{}
+		}
+		acl.ServiceType = models.Custom
+		if aclCoversAllTraffic(acl) {
+			acl.ServiceType = models.Any
+		}
+		if err := ValidateAclSchedule(acl.Schedule); err != nil {
+			problems.add(line("schedule"), "%v", err)
+		}
+		if len(rule.Src) == 0 || len(rule.Dst) == 0 {
+			problems.add(ruleNode.Line, "rule needs a src and a dst")
+		}
+		acl.Src, acl.Dst = []models.AclPolicyTag{}, []models.AclPolicyTag{}
+		for _, side := range []struct {
+			key   string
+			refs  []string
+			tags  *[]models.AclPolicyTag
+			isSrc bool
+		}{{"src", rule.Src, &acl.Src, true}, {"dst", rule.Dst, &acl.Dst, false}} {
+			node := yamlMapValue(ruleNode, side.key)
+			for j, ref := range side.refs {
+				tag, err := parseAclFileEntity(netID, ref, tags, groups)
+				if err == nil && tag.Value != "*" && !checkIfAclTagisValid(tag, netID, acl.RuleType, side.isSrc) {
+					err = fmt.Errorf("%s %s does not exist or can not be used as %s of a %s", tag.ID, tag.Value, side.key, acl.RuleType)
+				}
+				if err != nil {
+					problems.add(yamlItemLine(node, j), "%v", err)
+					continue
+				}
+				*side.tags = append(*side.tags, tag)
+			}
+		}
+		if len(problems.Errors) == count && !IsAclPolicyValid(acl) {
+			problems.add(ruleNode.Line, "rule %q is not a valid policy", rule.Name)
+		}
+		acls = append(acls, acl)
+	}
+	deleted := []models.Acl{}
+	for _, acl := range policies {
+		if _, ok := seen[acl.ID]; !ok && !acl.Default && acl.ServiceParentID == "" {
+			deleted = append(deleted, acl)
+		}
+	}
+	if len(problems.Errors) > 0 {
+		return nil, nil, problems
+	}
+	return acls, deleted, nil
+}
+
+// ApplyAclPolicyFile - validates a policy file and makes the policies of the network match it,
+// policies missing from the file are deleted. Nothing is changed unless the whole file is valid,
+// and a failed write restores the previous policies
+func ApplyAclPolicyFile(netID models.NetworkID, data []byte, format models.AclPolicyFileFormat, user string) (models.AclPolicyFileResult, error) {
+	result := models.AclPolicyFileResult{NetworkID: netID, Created: []string{}, Updated: []string{}, Deleted: []string{}}
+	aclPolicySetMutex.Lock()
+	defer aclPolicySetMutex.Unlock()
+	if _, err := GetNetwork(netID.String()); err != nil {
+		return result, err
+	}
+	file, doc, err := decodeAclPolicyFile(data, format)
+	if err != nil {
+		return result, &AclPolicyFileError{Errors: []string{strings.TrimPrefix(err.Error(), "yaml: ")}}
+	}
+	acls, deleted, problems := buildAclPolicyFile(netID, file, doc, user)
+	if problems != nil {
+		return result, problems
+	}
+	previous, _ := ListAclsByNetwork(netID)
+	current := make(map[string]models.Acl)
+	for _, acl := range previous {
+		current[acl.ID] = acl
+	}
+	for _, acl := range acls {
+		old, ok := current[acl.ID]
+		switch {
+		case !ok:
+			err = InsertAcl(acl)
+			result.Created = append(result.Created, acl.ID)
+		case !reflect.DeepEqual(old, acl):
+			err = UpsertAcl(acl)
+			result.Updated = append(result.Updated, acl.ID)
+		}
+		if err != nil {
+			restoreAclPolicies(netID, previous)
+			return result, err
+		}
+	}
+	for _, acl := range deleted {
+		err := DeleteAcl(acl)
+		if err == nil {
+			err = DeleteAclServicePolicies(acl)
+		}
+		if err != nil {
+			restoreAclPolicies(netID, previous)
+			return result, err
+		}
+		result.Deleted = append(result.Deleted, acl.ID)
+	}
+	for _, acl := range acls {
+		if acl.Default {
+			continue
+		}
+		if err := SyncAclServicePolicies(acl); err != nil {
+			restoreAclPolicies(netID, previous)
+			return result, err
+		}
+	}
+	RecordAclVersion(netID, user, "applied policy file")
+	return result, nil
+}
+
+// restoreAclPolicies - makes the policies of a network match a previous policy set
+func restoreAclPolicies(netID models.NetworkID, previous []models.Acl) {
+	keep := make(map[string]struct{})
+	for _, acl := range previous {
+		keep[acl.ID] = struct{}{}
+		_ = UpsertAcl(acl)
+	}
+	current, _ := ListAclsByNetwork(netID)
+	for _, acl := range current {
+		if _, ok := keep[acl.ID]; !ok {
+			_ = DeleteAcl(acl)
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package logic
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/gravitl/netmaker/database"
+	"github.com/gravitl/netmaker/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAclPolicyFile(t *testing.T) {
+	database.InitializeDatabase()
+	defer database.CloseDB()
+	t.Setenv("DNS_MODE", "off")
+	netID := models.NetworkID("file-net")
+	network := models.Network{NetID: netID.String(), AddressRange: "10.60.0.0/24"}
+	assert.Nil(t, SaveNetwork(&network))
+	CreateDefaultAclNetworkPolicies(netID)
+	defer DeleteNetworkPolicies(netID)
+	for _, name := range []string{"web", "db"} {
+		tag := models.Tag{ID: models.TagID(fmt.Sprintf("%s.%s", netID, name)), TagName: name, Network: netID}
+		assert.Nil(t, InsertTag(tag))
+		defer DeleteTag(tag.ID, false)
+	}
+
+	policyFile := `network: file-net
+tags: [web, db]
+services:
+  postgres:
+    protocol: tcp
+    ports: ["5432"]
+rules:
+  - name: web to db
+    type: device-policy
+    src: [tag:web]
+    dst: [tag:db]
+    service: postgres
+`
+	result, err := ApplyAclPolicyFile(netID, []byte(policyFile), models.AclPolicyFileYAML, "admin")
+	assert.Nil(t, err)
+	assert.Len(t, result.Created, 1)
+	assert.Empty(t, result.Deleted, "default policies are kept")
+	acl, err := GetAcl(result.Created[0])
+	assert.Nil(t, err)
+	assert.Equal(t, models.TCP, acl.Proto)
+	assert.Equal(t, []string{"5432"}, acl.Port)
+	assert.Equal(t, "file-net.db", acl.Dst[0].Value)
+
+	// an exported file applies without changes, in both formats
+	file, err := ExportAclPolicyFile(netID)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"tag:web"}, file.Rules[0].Src)
+	for _, format := range []models.AclPolicyFileFormat{models.AclPolicyFileYAML, models.AclPolicyFileHuJSON} {
+		data, err := MarshalAclPolicyFile(file, format)
+		assert.Nil(t, err)
+		result, err = ApplyAclPolicyFile(netID, data, format, "admin")
+		assert.Nil(t, err)
+		assert.Empty(t, result.Created)
+		assert.Empty(t, result.Updated)
+		assert.Empty(t, result.Deleted)
+	}
+
+	hujson := `{
+	// reviewed by security
+	"network": "file-net",
+	"tags": ["web", "db",],
+	"rules": [
+		{
+			"id": "` + acl.ID + `",
+			"name": "web to db", /* widened */
+			"type": "device-policy",
+			"src": ["tag:web"],
+			"dst": ["tag:db", "tag:cache"],
+			"protocol": "tcp",
+			"ports": ["5432", "6000-5000"],
+		},
+	],
+}`
+	_, err = ApplyAclPolicyFile(netID, []byte(hujson), models.AclPolicyFileHuJSON, "admin")
+	var fileErr *AclPolicyFileError
+	assert.True(t, errors.As(err, &fileErr))
+	assert.Equal(t, []string{
+		`line 12: invalid port range "6000-5000"`,
+		"line 11: tag cache is not declared in tags",
+	}, fileErr.Errors)
+	acl, err = GetAcl(acl.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"5432"}, acl.Port, "an invalid file changes nothing")
+
+	_, err = ApplyAclPolicyFile(netID, []byte("rules:\n  - name: [\n"), models.AclPolicyFileYAML, "admin")
+	assert.True(t, errors.As(err, &fileErr))
+	assert.Contains(t, err.Error(), "line")
+
+	result, err = ApplyAclPolicyFile(netID, []byte("network: file-net\nrules: []\n"), models.AclPolicyFileYAML, "admin")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{acl.ID}, result.Deleted)
+}
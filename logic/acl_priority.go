@@ -0,0 +1,163 @@
+package logic
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gravitl/netmaker/models"
+)
+
+// MaxAclPriority - the highest priority a policy can have
+const MaxAclPriority = 65535
+
+// aclRulePriority - the priority of the rules a policy turns into, ordering them the way
+// aclPrecedes orders policies without depending on the other policies of the network
+func aclRulePriority(acl models.Acl) int {
+	if acl.Default {
+		return 2*MaxAclPriority + 2
+	}
+	if acl.IsDeny() {
+		return 2 * acl.Priority
+	}
+	return 2*acl.Priority + 1
+}
+
+// aclPrecedes - the evaluation order of policies: default policies come last, then ascending
+// priority, and deny before allow on a tie
+func aclPrecedes(a, b models.Acl) bool {
+	if a.Default != b.Default {
+		return b.Default
+	}
+	if a.Priority != b.Priority {
+		return a.Priority < b.Priority
+	}
+	return a.IsDeny() && !b.IsDeny()
+}
+
+// sortAclsByPrecedence - sorts policies into evaluation order, keeping the order of equal ones
+func sortAclsByPrecedence(acls []models.Acl) {
+	sort.SliceStable(acls, func(i, j int) bool { return aclPrecedes(acls[i], acls[j]) })
+}
+
+// aclCoversAllTraffic - checks if a policy matches every protocol and port
+func aclCoversAllTraffic(acl models.Acl) bool {
+	return (acl.Proto == models.ALL || acl.Proto == "") && len(acl.Port) == 0
+}
+
+// evaluatePolicies - walks the policies matching a pair in evaluation order. The pair is allowed
+// by the allow policies met before a deny covering all traffic; denies limited to some protocols
+// or ports are kept in order ahead of the allow policies they restrict, trailing ones are dropped
+func evaluatePolicies(matches []models.Acl) (bool, []models.Acl) {
+	result := []models.Acl{}
+	effective := 0
+	for _, acl := range matches {
+		if acl.IsDeny() {
+			if aclCoversAllTraffic(acl) {
+				break
+			}
+			result = append(result, acl)
+			continue
+		}
+		result = append(result, acl)
+		effective = len(result)
+	}
+	return effective > 0, result[:effective]
+}
+
+// parsePortRange - parses a port or a port range like 8000-8100
+func parsePortRange(port string) (int, int, error) {
+	from, to, isRange := strings.Cut(strings.TrimSpace(port), "-")
+	start, err := strconv.Atoi(strings.TrimSpace(from))
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid port %q", port)
+	}
+	end := start
+	if isRange {
+		end, err = strconv.Atoi(strings.TrimSpace(to))
+		if err != nil {
+			return 0, 0, fmt.Errorf("invalid port range %q", port)
+		}
+	}
+	if start < 1 || end > 65535 || start > end {
+		return 0, 0, fmt.Errorf("invalid port range %q", port)
+	}
+	return start, end, nil
+}
+
+// aclTrafficCovers - checks if every protocol and port matched by inner is matched by outer
+func aclTrafficCovers(outer, inner models.Acl) bool {
+	if outer.Proto != models.ALL && outer.Proto != "" && outer.Proto != inner.Proto {
+		return false
+	}
+	if len(outer.Port) == 0 {
+		return true
+	}
+	if len(inner.Port) == 0 {
+		return false
+	}
+	for _, port := range inner.Port {
+		start, end, err := parsePortRange(port)
+		if err != nil {
+			return false
+		}
+		covered := false
+		for _, outerPort := range outer.Port {
+			outerStart, outerEnd, err := parsePortRange(outerPort)
+			if err == nil && outerStart <= start && end <= outerEnd {
+				covered = true
+				break
+			}
+		}
+		if !covered {
+			return false
+		}
+	}
+	return true
+}
+
+// aclTagsCover - checks if outer lists every value of inner, or all of them
+func aclTagsCover(outer, inner []models.AclPolicyTag) bool {
+	values := convAclTagToValueMap(outer)
+	if _, ok := values["*"]; ok {
+		return true
+	}
+	for _, tag := range inner {
+		if _, ok := values[tag.Value]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// aclCovers - checks if every pair and every packet matched by inner is matched by outer
+func aclCovers(outer, inner models.Acl) bool {
+	if outer.RuleType != inner.RuleType || !aclTrafficCovers(outer, inner) {
+		return false
+	}
+	forward := aclTagsCover(outer.Src, inner.Src) && aclTagsCover(outer.Dst, inner.Dst)
+	if outer.AllowedDirection != models.TrafficDirectionBi {
+		return inner.AllowedDirection != models.TrafficDirectionBi && forward
+	}
+	// a bi-directional policy matches both orientations, so either one covers inner
+	reverse := aclTagsCover(outer.Src, inner.Dst) && aclTagsCover(outer.Dst, inner.Src)
+	return forward || reverse
+}
+
+// FindShadowingPolicy - finds an enabled policy evaluated before acl with the opposite action
+// that matches everything acl matches, so that acl would never take effect
+func FindShadowingPolicy(acl models.Acl) (models.Acl, bool) {
+	policies, _ := ListAclsByNetwork(acl.NetworkID)
+	sortAclsByPrecedence(policies)
+	for _, policy := range policies {
+		// scheduled policies only shadow part of the time
+		if policy.ID == acl.ID || !policy.Enabled || policy.Schedule != nil || policy.IsDeny() == acl.IsDeny() {
+			continue
+		}
+		if aclPrecedes(policy, acl) && aclCovers(policy, acl) {
+			return policy, true
+		}
+	}
+	return models.Acl{}, false
+}
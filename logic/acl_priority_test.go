@@ -0,0 +1,135 @@
+package logic
+
+import (
+	"net"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/gravitl/netmaker/database"
+	"github.com/gravitl/netmaker/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEvaluatePolicies(t *testing.T) {
+	allowAll := models.Acl{ID: "allow-all", Proto: models.ALL}
+	allowSSH := models.Acl{ID: "allow-ssh", Proto: models.TCP, Port: []string{"22"}}
+	denySSH := models.Acl{ID: "deny-ssh", Action: models.AclDeny, Proto: models.TCP, Port: []string{"22"}}
+	denyAll := models.Acl{ID: "deny-all", Action: models.AclDeny, Proto: models.ALL}
+
+	allowed, policies := evaluatePolicies([]models.Acl{denySSH, allowAll})
+	assert.True(t, allowed)
+	assert.Equal(t, []string{"deny-ssh", "allow-all"}, aclIDs(policies))
+
+	allowed, policies = evaluatePolicies([]models.Acl{allowSSH, denyAll, allowAll})
+	assert.True(t, allowed)
+	assert.Equal(t, []string{"allow-ssh"}, aclIDs(policies))
+
+	allowed, policies = evaluatePolicies([]models.Acl{allowAll, denySSH})
+	assert.True(t, allowed)
+	assert.Equal(t, []string{"allow-all"}, aclIDs(policies))
+
+	allowed, policies = evaluatePolicies([]models.Acl{denyAll, allowAll})
+	assert.False(t, allowed)
+	assert.Empty(t, policies)
+}
+
+func TestAclCovers(t *testing.T) {
+	tag := func(values ...string) (tags []models.AclPolicyTag) {
+		for _, v := range values {
+			tags = append(tags, models.AclPolicyTag{ID: models.NodeTagID, Value: v})
+		}
+		return
+	}
+	outer := models.Acl{RuleType: models.DevicePolicy, Src: tag("dev"), Dst: tag("prod", "qa"),
+		Proto: models.TCP, Port: []string{"20-30"}, AllowedDirection: models.TrafficDirectionBi}
+	inner := models.Acl{RuleType: models.DevicePolicy, Src: tag("prod"), Dst: tag("dev"),
+		Proto: models.TCP, Port: []string{"22"}, AllowedDirection: models.TrafficDirectionUni}
+	assert.True(t, aclCovers(outer, inner))
+	inner.Port = []string{"22", "443"}
+	assert.False(t, aclCovers(outer, inner))
+	inner.Port = []string{"22"}
+	outer.AllowedDirection = models.TrafficDirectionUni
+	assert.False(t, aclCovers(outer, inner))
+	inner.Proto = models.UDP
+	outer.AllowedDirection = models.TrafficDirectionBi
+	assert.False(t, aclCovers(outer, inner))
+
+	_, _, err := parsePortRange("30-20")
+	assert.NotNil(t, err)
+	start, end, err := parsePortRange("8000-8100")
+	assert.Nil(t, err)
+	assert.Equal(t, []int{8000, 8100}, []int{start, end})
+}
+
+func TestDenyPolicies(t *testing.T) {
+	database.InitializeDatabase()
+	defer database.CloseDB()
+	netID := models.NetworkID("deny-net")
+	newNode := func(ip string, tag models.TagID) models.Node {
+		node := models.Node{}
+		node.ID = uuid.New()
+		node.HostID = uuid.New()
+		node.Network = netID.String()
+		node.Address = net.IPNet{IP: net.ParseIP(ip), Mask: net.CIDRMask(32, 32)}
+		node.Tags = map[models.TagID]struct{}{tag: {}}
+		return node
+	}
+	dev, prod := newNode("10.40.0.1", "deny-net.dev"), newNode("10.40.0.2", "deny-net.prod")
+	for _, node := range []*models.Node{&dev, &prod} {
+		assert.Nil(t, UpsertNode(node))
+		defer DeleteNodeByID(node)
+	}
+	policy := func(action models.AclAction, priority int, proto models.Protocol, ports ...string) models.Acl {
+		acl := models.Acl{
+			ID:               uuid.NewString(),
+			Name:             string(action) + "-" + proto.String(),
+			NetworkID:        netID,
+			RuleType:         models.DevicePolicy,
+			Src:              []models.AclPolicyTag{{ID: models.NodeTagID, Value: "deny-net.dev"}},
+			Dst:              []models.AclPolicyTag{{ID: models.NodeTagID, Value: "deny-net.prod"}},
+			AllowedDirection: models.TrafficDirectionUni,
+			Proto:            proto,
+			Port:             ports,
+			Action:           action,
+			Priority:         priority,
+			Enabled:          true,
+		}
+		assert.Nil(t, InsertAcl(acl))
+		return acl
+	}
+	allowAll := policy(models.AclAllow, 10, models.ALL)
+	defer DeleteAcl(allowAll)
+	denySSH := policy(models.AclDeny, 0, models.TCP, "22")
+	defer DeleteAcl(denySSH)
+
+	allowed, policies := IsNodeAllowedToCommunicateV1(dev, prod, true)
+	assert.True(t, allowed)
+	assert.Equal(t, []string{denySSH.ID, allowAll.ID}, aclIDs(policies))
+	assert.True(t, IsPeerAllowed(dev, prod, true))
+
+	rules := GetAclRulesForNode(&prod)
+	assert.False(t, rules[denySSH.ID].Allowed)
+	assert.True(t, rules[allowAll.ID].Allowed)
+	assert.Less(t, rules[denySSH.ID].Priority, rules[allowAll.ID].Priority)
+	assert.Equal(t, "10.40.0.1", rules[denySSH.ID].IPList[0].IP.String())
+
+	denyAll := policy(models.AclDeny, 5, models.ALL)
+	defer DeleteAcl(denyAll)
+	allowed, _ = IsNodeAllowedToCommunicateV1(dev, prod, true)
+	assert.False(t, allowed)
+	assert.False(t, IsPeerAllowed(dev, prod, true))
+
+	shadow, ok := FindShadowingPolicy(allowAll)
+	assert.True(t, ok)
+	assert.Equal(t, denyAll.ID, shadow.ID)
+	_, ok = FindShadowingPolicy(denySSH)
+	assert.False(t, ok)
+}
+
+func aclIDs(acls []models.Acl) []string {
+	ids := []string{}
+	for _, acl := range acls {
+		ids = append(ids, acl.ID)
+	}
+	return ids
+}
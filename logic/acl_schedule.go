@@ -0,0 +1,224 @@
+package logic
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gravitl/netmaker/models"
+)
+
+// AclScheduleCheckInterval - how often the hook manager looks for policies entering or leaving their schedule
+const AclScheduleCheckInterval = time.Minute
+
+// aclCronFields - the bounds of the minute, hour, day of month, month and day of week fields
+var aclCronFields = [5][2]int{{0, 59}, {0, 23}, {1, 31}, {1, 12}, {0, 7}}
+
+// aclCron - the values matched by each field of a cron expression
+type aclCron struct {
+	fields [5]map[int]struct{}
+	// domAll and dowAll - set for unrestricted day fields, cron matches either day field
+	// when both are restricted
+	domAll bool
+	dowAll bool
+}
+
+var (
+	aclCronCache      = &sync.Map{}
+	aclLocationCache  = &sync.Map{}
+	aclScheduleMutex  = &sync.Mutex{}
+	aclScheduleActive = make(map[string]bool)
+)
+
+// parseAclCron - parses a five field cron expression; fields take *, values, ranges, lists and steps
+func parseAclCron(expr string) (*aclCron, error) {
+	if c, ok := aclCronCache.Load(expr); ok {
+		return c.(*aclCron), nil
+	}
+	parts := strings.Fields(expr)
+	if len(parts) != 5 {
+		return nil, fmt.Errorf("cron %q must have 5 fields", expr)
+	}
+	c := &aclCron{domAll: parts[2] == "*", dowAll: parts[4] == "*"}
+	for i, part := range parts {
+		values, err := parseAclCronField(part, aclCronFields[i][0], aclCronFields[i][1])
+		if err != nil {
+			return nil, fmt.Errorf("cron %q: %w", expr, err)
+		}
+		c.fields[i] = values
+	}
+	// sunday is both 0 and 7
+	if _, ok := c.fields[4][7]; ok {
+		c.fields[4][0] = struct{}{}
+	}
+	aclCronCache.Store(expr, c)
+	return c, nil
+}
+
+func parseAclCronField(field string, min, max int) (map[int]struct{}, error) {
+	values := make(map[int]struct{})
+	for _, item := range strings.Split(field, ",") {
+		span, stepStr, hasStep := strings.Cut(item, "/")
+		step := 1
+		if hasStep {
+			var err error
+			step, err = strconv.Atoi(stepStr)
+			if err != nil || step < 1 {
+				return nil, fmt.Errorf("invalid step in %q", item)
+			}
+		}
+		start, end := min, max
+		if span != "*" {
+			from, to, isRange := strings.Cut(span, "-")
+			var err error
+			start, err = strconv.Atoi(from)
+			if err != nil {
+				return nil, fmt.Errorf("invalid value in %q", item)
+			}
+			end = start
+			if isRange {
+				end, err = strconv.Atoi(to)
+				if err != nil {
+					return nil, fmt.Errorf("invalid range in %q", item)
+				}
+			} else if hasStep {
+				end = max
+			}
+		}
+		if start < min || end > max || start > end {
+			return nil, fmt.Errorf("%q is out of range %d-%d", item, min, max)
+		}
+		for v := start; v <= end; v += step {
+			values[v] = struct{}{}
+		}
+	}
+	return values, nil
+}
+
+func (c *aclCron) matches(t time.Time) bool {
+	for i, v := range []int{t.Minute(), t.Hour(), -1, int(t.Month()), -1} {
+		if v < 0 {
+			continue
+		}
+		if _, ok := c.fields[i][v]; !ok {
+			return false
+		}
+	}
+	_, dom := c.fields[2][t.Day()]
+	_, dow := c.fields[4][int(t.Weekday())]
+	switch {
+	case c.domAll && c.dowAll:
+		return true
+	case c.domAll:
+		return dow
+	case c.dowAll:
+		return dom
+	}
+	return dom || dow
+}
+
+func loadAclLocation(name string) (*time.Location, error) {
+	if name == "" {
+		return time.UTC, nil
+	}
+	if loc, ok := aclLocationCache.Load(name); ok {
+		return loc.(*time.Location), nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("invalid timezone %q", name)
+	}
+	aclLocationCache.Store(name, loc)
+	return loc, nil
+}
+
+// ValidateAclSchedule - checks the timezone, cron expression and window of a schedule
+func ValidateAclSchedule(schedule *models.AclSchedule) error {
+	if schedule == nil {
+		return nil
+	}
+	if !schedule.StartTime.IsZero() && !schedule.EndTime.IsZero() && !schedule.EndTime.After(schedule.StartTime) {
+		return errors.New("schedule end time must be after its start time")
+	}
+	if _, err := loadAclLocation(schedule.Timezone); err != nil {
+		return err
+	}
+	if schedule.Cron != "" {
+		if _, err := parseAclCron(schedule.Cron); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// IsAclEffective - checks if a policy is enabled and in effect at t
+func IsAclEffective(acl models.Acl, t time.Time) bool {
+	if !acl.Enabled {
+		return false
+	}
+	schedule := acl.Schedule
+	if schedule == nil {
+		return true
+	}
+	if !schedule.StartTime.IsZero() && t.Before(schedule.StartTime) {
+		return false
+	}
+	if !schedule.EndTime.IsZero() && !t.Before(schedule.EndTime) {
+		return false
+	}
+	if schedule.Cron == "" {
+		return true
+	}
+	loc, err := loadAclLocation(schedule.Timezone)
+	if err != nil {
+		return false
+	}
+	c, err := parseAclCron(schedule.Cron)
+	if err != nil {
+		return false
+	}
+	return c.matches(t.In(loc))
+}
+
+// isAclActive - checks if a policy is in effect right now
+func isAclActive(acl models.Acl) bool {
+	return IsAclEffective(acl, time.Now())
+}
+
+// CheckAclSchedules - finds the networks with scheduled policies that came into or went out of
+// effect since the last check and drops their compiled policies; newly seen scheduled
+// policies count as a transition
+func CheckAclSchedules(now time.Time) []string {
+	aclScheduleMutex.Lock()
+	defer aclScheduleMutex.Unlock()
+	changed := make(map[models.NetworkID]struct{})
+	seen := make(map[string]struct{})
+	for _, acl := range ListAcls() {
+		if acl.Schedule == nil {
+			continue
+		}
+		seen[acl.ID] = struct{}{}
+		effective := IsAclEffective(acl, now)
+		if last, ok := aclScheduleActive[acl.ID]; !ok || last != effective {
+			changed[acl.NetworkID] = struct{}{}
+		}
+		aclScheduleActive[acl.ID] = effective
+	}
+	for id := range aclScheduleActive {
+		if _, ok := seen[id]; !ok {
+			delete(aclScheduleActive, id)
+		}
+	}
+	networks := []string{}
+	for netID := range changed {
+		InvalidateNetworkPolicy(netID)
+		InvalidateNetworkPeerModel(netID)
+		networks = append(networks, netID.String())
+	}
+	sort.Strings(networks)
+	return networks
+}
@@ -0,0 +1,94 @@
+package logic
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gravitl/netmaker/database"
+	"github.com/gravitl/netmaker/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsAclEffective(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	assert.Nil(t, err)
+	acl := models.Acl{
+		Enabled:  true,
+		Schedule: &models.AclSchedule{Cron: "* 8-17 * * 1-5", Timezone: "Europe/Berlin"},
+	}
+	// monday 2026-10-19, berlin is utc+2 until the end of october
+	assert.True(t, IsAclEffective(acl, time.Date(2026, 10, 19, 8, 0, 0, 0, berlin)))
+	assert.True(t, IsAclEffective(acl, time.Date(2026, 10, 19, 17, 59, 0, 0, berlin)))
+	assert.False(t, IsAclEffective(acl, time.Date(2026, 10, 19, 18, 0, 0, 0, berlin)))
+	assert.False(t, IsAclEffective(acl, time.Date(2026, 10, 19, 5, 59, 0, 0, time.UTC)))
+	// saturday
+	assert.False(t, IsAclEffective(acl, time.Date(2026, 10, 24, 10, 0, 0, 0, berlin)))
+
+	acl.Schedule = &models.AclSchedule{EndTime: time.Date(2026, 10, 23, 0, 0, 0, 0, time.UTC)}
+	assert.True(t, IsAclEffective(acl, time.Date(2026, 10, 22, 23, 59, 0, 0, time.UTC)))
+	assert.False(t, IsAclEffective(acl, time.Date(2026, 10, 23, 0, 0, 0, 0, time.UTC)))
+	acl.Enabled = false
+	assert.False(t, IsAclEffective(acl, time.Date(2026, 10, 22, 0, 0, 0, 0, time.UTC)))
+
+	c, err := parseAclCron("*/15 0 1,15 * 7")
+	assert.Nil(t, err)
+	assert.Len(t, c.fields[0], 4)
+	// restricted day of month and day of week match either
+	assert.True(t, c.matches(time.Date(2026, 10, 15, 0, 30, 0, 0, time.UTC)))
+	assert.True(t, c.matches(time.Date(2026, 10, 18, 0, 45, 0, 0, time.UTC)))
+	assert.False(t, c.matches(time.Date(2026, 10, 16, 0, 45, 0, 0, time.UTC)))
+
+	assert.NotNil(t, ValidateAclSchedule(&models.AclSchedule{Cron: "* 25 * * *"}))
+	assert.NotNil(t, ValidateAclSchedule(&models.AclSchedule{Cron: "* * *"}))
+	assert.NotNil(t, ValidateAclSchedule(&models.AclSchedule{Timezone: "Mars/Olympus"}))
+	assert.NotNil(t, ValidateAclSchedule(&models.AclSchedule{
+		StartTime: time.Date(2026, 10, 23, 0, 0, 0, 0, time.UTC),
+		EndTime:   time.Date(2026, 10, 22, 0, 0, 0, 0, time.UTC),
+	}))
+}
+
+func TestCheckAclSchedules(t *testing.T) {
+	database.InitializeDatabase()
+	defer database.CloseDB()
+	netID := models.NetworkID("schedule-net")
+	newNode := func(tag models.TagID) models.Node {
+		node := models.Node{}
+		node.ID = uuid.New()
+		node.HostID = uuid.New()
+		node.Network = netID.String()
+		node.Tags = map[models.TagID]struct{}{tag: {}}
+		assert.Nil(t, UpsertNode(&node))
+		return node
+	}
+	contractor, staging := newNode("schedule-net.contractors"), newNode("schedule-net.staging")
+	defer DeleteNodeByID(&contractor)
+	defer DeleteNodeByID(&staging)
+	end := time.Now().Add(time.Hour)
+	acl := models.Acl{
+		ID:               uuid.NewString(),
+		NetworkID:        netID,
+		RuleType:         models.DevicePolicy,
+		Src:              []models.AclPolicyTag{{ID: models.NodeTagID, Value: "schedule-net.contractors"}},
+		Dst:              []models.AclPolicyTag{{ID: models.NodeTagID, Value: "schedule-net.staging"}},
+		AllowedDirection: models.TrafficDirectionBi,
+		Proto:            models.ALL,
+		Enabled:          true,
+		Schedule:         &models.AclSchedule{EndTime: end},
+	}
+	assert.Nil(t, InsertAcl(acl))
+	defer DeleteAcl(acl)
+
+	// a newly seen scheduled policy counts as a transition
+	assert.Equal(t, []string{netID.String()}, CheckAclSchedules(time.Now()))
+	assert.Empty(t, CheckAclSchedules(time.Now()))
+	allowed, _ := IsNodeAllowedToCommunicateV1(contractor, staging, true)
+	assert.True(t, allowed)
+
+	// the grant expires, the cached decision is dropped by the check
+	acl.Schedule.EndTime = time.Now()
+	assert.Nil(t, UpsertAcl(acl))
+	assert.Equal(t, []string{netID.String()}, CheckAclSchedules(time.Now()))
+	allowed, _ = IsNodeAllowedToCommunicateV1(contractor, staging, true)
+	assert.False(t, allowed)
+}
@@ -0,0 +1,387 @@
+package logic
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gravitl/netmaker/database"
+	"github.com/gravitl/netmaker/models"
+)
+
+// GetAclService - fetches a service object
+func GetAclService(id string) (models.AclService, error) {
+	svc := models.AclService{}
+	record, err := database.FetchRecord(database.ACL_SERVICES_TABLE_NAME, id)
+	if err != nil {
+		return svc, err
+	}
+	err = json.Unmarshal([]byte(record), &svc)
+	return svc, err
+}
+
+// listNetworkAclServices - the service objects of a network, sorted by name
+func listNetworkAclServices(netID models.NetworkID) []models.AclService {
+	services := []models.AclService{}
+	records, err := database.FetchRecords(database.ACL_SERVICES_TABLE_NAME)
+	if err != nil {
+		return services
+	}
+	for _, record := range records {
+		svc := models.AclService{}
+		if err := json.Unmarshal([]byte(record), &svc); err != nil || svc.NetworkID != netID {
+			continue
+		}
+		services = append(services, svc)
+	}
+	sort.Slice(services, func(i, j int) bool { return services[i].Name < services[j].Name })
+	return services
+}
+
+// GetAclServiceByName - fetches a service object of a network by its name
+func GetAclServiceByName(netID models.NetworkID, name string) (models.AclService, error) {
+	for _, svc := range listNetworkAclServices(netID) {
+		if strings.EqualFold(svc.Name, name) {
+			return svc, nil
+		}
+	}
+	return models.AclService{}, fmt.Errorf("service %s not found in network %s", name, netID)
+}
+
+// aclServiceUsage - the names of the policies referencing each service of a network, policies
+// carrying the further protocols of a service are not counted
+func aclServiceUsage(netID models.NetworkID) map[string][]string {
+	usage := make(map[string][]string)
+	acls, _ := ListAclsByNetwork(netID)
+	for _, acl := range acls {
+		if acl.ServiceID != "" && acl.ServiceParentID == "" {
+			usage[acl.ServiceID] = append(usage[acl.ServiceID], acl.Name)
+		}
+	}
+	for id := range usage {
+		sort.Strings(usage[id])
+	}
+	return usage
+}
+
+// ListAclServices - the service objects of a network along with the policies using them
+func ListAclServices(netID models.NetworkID) []models.AclServiceStatus {
+	usage := aclServiceUsage(netID)
+	list := []models.AclServiceStatus{}
+	for _, svc := range listNetworkAclServices(netID) {
+		policies := usage[svc.ID]
+		if policies == nil {
+			policies = []string{}
+		}
+		list = append(list, models.AclServiceStatus{AclService: svc, UsageCount: len(policies), Policies: policies})
+	}
+	return list
+}
+
+// ValidateAclService - checks the name and entries of a service, each protocol can be listed
+// once and its port ranges must not overlap
+func ValidateAclService(svc models.AclService) error {
+	if strings.TrimSpace(svc.Name) == "" {
+		return errors.New("service name is required")
+	}
+	if _, err := GetNetwork(svc.NetworkID.String()); err != nil {
+		return fmt.Errorf("network %s not found", svc.NetworkID)
+	}
+	for _, other := range listNetworkAclServices(svc.NetworkID) {
+		if other.ID != svc.ID && strings.EqualFold(other.Name, strings.TrimSpace(svc.Name)) {
+			return fmt.Errorf("service %s already exists in network %s", other.Name, svc.NetworkID)
+		}
+	}
+	if len(svc.Entries) == 0 {
+		return errors.New("service needs at least one protocol")
+	}
+	protocols := make(map[models.Protocol]struct{})
+	for _, entry := range svc.Entries {
+		if _, ok := protocols[entry.Protocol]; ok {
+			return fmt.Errorf("protocol %s is listed more than once", entry.Protocol)
+		}
+		protocols[entry.Protocol] = struct{}{}
+		switch entry.Protocol {
+		case models.ALL:
+			if len(svc.Entries) > 1 {
+				return errors.New("protocol all can not be combined with other protocols")
+			}
+			fallthrough
+		case models.ICMP:
+			if len(entry.Ports) > 0 {
+				return fmt.Errorf("ports require the tcp or udp protocol, not %s", entry.Protocol)
+			}
+		case models.TCP, models.UDP:
+			type portRange struct {
+				port       string
+				start, end int
+			}
+			ranges := []portRange{}
+			for _, port := range entry.Ports {
+				start, end, err := parsePortRange(port)
+				if err != nil {
+					return fmt.Errorf("%s: %v", entry.Protocol, err)
+				}
+				for _, r := range ranges {
+					if start <= r.end && r.start <= end {
+						return fmt.Errorf("%s ports %s and %s overlap", entry.Protocol, r.port, port)
+					}
+				}
+				ranges = append(ranges, portRange{port, start, end})
+			}
+		default:
+			return fmt.Errorf("unsupported protocol %s", entry.Protocol)
+		}
+	}
+	return nil
+}
+
+func storeAclService(svc models.AclService) error {
+	d, err := json.Marshal(svc)
+	if err != nil {
+		return err
+	}
+	return database.Insert(svc.ID, string(d), database.ACL_SERVICES_TABLE_NAME)
+}
+
+// CreateAclService - validates and stores a new service object
+func CreateAclService(svc models.AclService, user string) (models.AclService, error) {
+	svc.Name = strings.TrimSpace(svc.Name)
+	if err := ValidateAclService(svc); err != nil {
+		return svc, err
+	}
+	for i := range svc.Entries {
+		if svc.Entries[i].Ports == nil {
+			svc.Entries[i].Ports = []string{}
+		}
+	}
+	svc.ID = uuid.NewString()
+	svc.CreatedBy = user
+	svc.CreatedAt = time.Now().UTC()
+	return svc, storeAclService(svc)
+}
+
+// UpdateAclService - changes the name, description and entries of a service and carries the new
+// protocols and ports over to every policy using it. On failure the service and policies are
+// left as they were
+func UpdateAclService(update models.AclService, user string) (models.AclService, error) {
+	svc, err := GetAclService(update.ID)
+	if err != nil {
+		return svc, err
+	}
+	old := svc
+	svc.Name = strings.TrimSpace(update.Name)
+	svc.Description = update.Description
+	svc.Entries = update.Entries
+	for i := range svc.Entries {
+		if svc.Entries[i].Ports == nil {
+			svc.Entries[i].Ports = []string{}
+		}
+	}
+	if err := ValidateAclService(svc); err != nil {
+		return svc, err
+	}
+	aclPolicySetMutex.Lock()
+	previous, _ := ListAclsByNetwork(svc.NetworkID)
+	if err := storeAclService(svc); err != nil {
+		aclPolicySetMutex.Unlock()
+		return old, err
+	}
+	for _, acl := range previous {
+		if acl.ServiceID != svc.ID || acl.ServiceParentID != "" {
+			continue
+		}
+		updated := acl
+		setAclServiceEntry(&updated, svc.Entries[0])
+		if !reflect.DeepEqual(acl, updated) {
+			err = UpsertAcl(updated)
+		}
+		if err == nil {
+			err = SyncAclServicePolicies(updated)
+		}
+		if err != nil {
+			break
+		}
+	}
+	if err != nil {
+		// the service and its policies change together or not at all
+		_ = storeAclService(old)
+		restoreAclPolicies(svc.NetworkID, previous)
+		aclPolicySetMutex.Unlock()
+		return old, err
+	}
+	aclPolicySetMutex.Unlock()
+	RecordAclVersion(svc.NetworkID, user, "updated service "+svc.Name)
+	return svc, nil
+}
+
+// DeleteAclService - removes a service object, services used by policies can not be deleted
+func DeleteAclService(id string) error {
+	svc, err := GetAclService(id)
+	if err != nil {
+		return err
+	}
+	if policies := aclServiceUsage(svc.NetworkID)[svc.ID]; len(policies) > 0 {
+		return fmt.Errorf("service %s is used by policies %s", svc.Name, strings.Join(policies, ", "))
+	}
+	return database.DeleteRecord(database.ACL_SERVICES_TABLE_NAME, svc.ID)
+}
+
+// DeleteNetworkAclServices - removes the service objects of a network
+func DeleteNetworkAclServices(netID models.NetworkID) {
+	for _, svc := range listNetworkAclServices(netID) {
+		_ = database.DeleteRecord(database.ACL_SERVICES_TABLE_NAME, svc.ID)
+	}
+}
+
+// setAclServiceEntry - sets the protocol and ports of a policy to a service entry
+func setAclServiceEntry(acl *models.Acl, entry models.AclServiceEntry) {
+	acl.Proto = entry.Protocol
+	acl.Port = entry.Ports
+	if acl.Port == nil {
+		acl.Port = []string{}
+	}
+	acl.ServiceType = models.Custom
+	if aclCoversAllTraffic(*acl) {
+		acl.ServiceType = models.Any
+	}
+}
+
+// ApplyAclService - sets the protocol and ports of a policy referencing a service to the first
+// entry of the service, the other entries are carried by the policies SyncAclServicePolicies
+// maintains
+func ApplyAclService(acl *models.Acl) error {
+	if acl.ServiceID == "" {
+		return nil
+	}
+	svc, err := GetAclService(acl.ServiceID)
+	if err != nil || svc.NetworkID != acl.NetworkID {
+		return fmt.Errorf("service %s not found in network %s", acl.ServiceID, acl.NetworkID)
+	}
+	setAclServiceEntry(acl, svc.Entries[0])
+	return nil
+}
+
+// SyncAclServicePolicies - a policy holds a single protocol, so a policy using a service with
+// several protocols gets a copy of itself for each further protocol. Makes those copies match
+// the policy and its service, and removes them once the policy no longer needs them
+func SyncAclServicePolicies(acl models.Acl) error {
+	companions, err := aclServiceCompanions(acl)
+	if err != nil {
+		return err
+	}
+	want := make(map[string]models.Acl)
+	for _, companion := range companions {
+		want[companion.ID] = companion
+	}
+	acls, _ := ListAclsByNetwork(acl.NetworkID)
+	for _, existing := range acls {
+		if existing.ServiceParentID != acl.ID {
+			continue
+		}
+		companion, ok := want[existing.ID]
+		delete(want, existing.ID)
+		var err error
+		if !ok {
+			err = DeleteAcl(existing)
+		} else if !reflect.DeepEqual(existing, companion) {
+			err = UpsertAcl(companion)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	for _, companion := range want {
+		if err := InsertAcl(companion); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CreateAclWithServicePolicies - stores a new policy along with the copies carrying the further
+// protocols of its service, the policy is removed again if its copies fail to sync
+func CreateAclWithServicePolicies(acl models.Acl) error {
+	if err := InsertAcl(acl); err != nil {
+		return err
+	}
+	if err := SyncAclServicePolicies(acl); err != nil {
+		restoreAclFamily(acl, nil)
+		return err
+	}
+	return nil
+}
+
+// DeleteAclWithServicePolicies - deletes a policy along with the copies carrying the further
+// protocols of its service, the policy and its copies are restored if the copies fail to delete
+func DeleteAclWithServicePolicies(acl models.Acl) error {
+	family := aclFamily(acl)
+	if err := DeleteAcl(acl); err != nil {
+		return err
+	}
+	if err := DeleteAclServicePolicies(acl); err != nil {
+		restoreAclFamily(acl, family)
+		return err
+	}
+	return nil
+}
+
+// aclFamily - the stored policy and its service copies
+func aclFamily(acl models.Acl) []models.Acl {
+	family := []models.Acl{}
+	acls, _ := ListAclsByNetwork(acl.NetworkID)
+	for _, a := range acls {
+		if a.ID == acl.ID || a.ServiceParentID == acl.ID {
+			family = append(family, a)
+		}
+	}
+	return family
+}
+
+// restoreAclFamily - puts back the given policy and service copies, removing any other stored copy
+func restoreAclFamily(acl models.Acl, family []models.Acl) {
+	keep := make(map[string]struct{})
+	for _, a := range family {
+		keep[a.ID] = struct{}{}
+		_ = UpsertAcl(a)
+	}
+	for _, a := range aclFamily(acl) {
+		if _, ok := keep[a.ID]; !ok {
+			_ = DeleteAcl(a)
+		}
+	}
+}
+
+// aclServiceCompanions - builds the copies of a policy carrying the further protocols of its service,
+// without storing them
+func aclServiceCompanions(acl models.Acl) ([]models.Acl, error) {
+	companions := []models.Acl{}
+	if acl.ServiceID == "" {
+		return companions, nil
+	}
+	svc, err := GetAclService(acl.ServiceID)
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range svc.Entries[1:] {
+		companion := acl
+		companion.ID = fmt.Sprintf("%s-%s", acl.ID, entry.Protocol)
+		companion.Name = fmt.Sprintf("%s (%s)", acl.Name, entry.Protocol)
+		companion.ServiceParentID = acl.ID
+		setAclServiceEntry(&companion, entry)
+		companions = append(companions, companion)
+	}
+	return companions, nil
+}
+
+// DeleteAclServicePolicies - removes the copies of a deleted policy carrying the further
+// protocols of its service
+func DeleteAclServicePolicies(acl models.Acl) error {
+	acl.ServiceID = ""
+	return SyncAclServicePolicies(acl)
+}
@@ -0,0 +1,113 @@
+package logic
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/gravitl/netmaker/database"
+	"github.com/gravitl/netmaker/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAclServices(t *testing.T) {
+	database.InitializeDatabase()
+	defer database.CloseDB()
+	t.Setenv("DNS_MODE", "off")
+	netID := models.NetworkID("service-net")
+	network := models.Network{NetID: netID.String(), AddressRange: "10.80.0.0/24"}
+	assert.Nil(t, SaveNetwork(&network))
+	CreateDefaultAclNetworkPolicies(netID)
+	defer DeleteNetworkPolicies(netID)
+
+	for _, svc := range []models.AclService{
+		{NetworkID: netID, Entries: []models.AclServiceEntry{{Protocol: models.TCP}}},
+		{NetworkID: netID, Name: "web", Entries: []models.AclServiceEntry{}},
+		{NetworkID: netID, Name: "web", Entries: []models.AclServiceEntry{{Protocol: models.TCP, Ports: []string{"70000"}}}},
+		{NetworkID: netID, Name: "web", Entries: []models.AclServiceEntry{{Protocol: models.TCP, Ports: []string{"8000-8100", "8080"}}}},
+		{NetworkID: netID, Name: "web", Entries: []models.AclServiceEntry{{Protocol: models.ICMP, Ports: []string{"1"}}}},
+		{NetworkID: netID, Name: "web", Entries: []models.AclServiceEntry{{Protocol: models.TCP}, {Protocol: models.TCP}}},
+		{NetworkID: netID, Name: "web", Entries: []models.AclServiceEntry{{Protocol: models.ALL}, {Protocol: models.UDP}}},
+	} {
+		assert.NotNil(t, ValidateAclService(svc), svc)
+	}
+
+	monitoring, err := CreateAclService(models.AclService{
+		NetworkID: netID,
+		Name:      "monitoring",
+		Entries: []models.AclServiceEntry{
+			{Protocol: models.TCP, Ports: []string{"9100", "9090"}},
+			{Protocol: models.UDP, Ports: []string{"161"}},
+		},
+	}, "admin")
+	assert.Nil(t, err)
+	_, err = CreateAclService(models.AclService{NetworkID: netID, Name: "Monitoring",
+		Entries: []models.AclServiceEntry{{Protocol: models.TCP}}}, "admin")
+	assert.NotNil(t, err, "names are unique per network")
+
+	acl := models.Acl{
+		ID:               uuid.NewString(),
+		Name:             "scrape",
+		NetworkID:        netID,
+		RuleType:         models.DevicePolicy,
+		Src:              []models.AclPolicyTag{{ID: models.NodeTagID, Value: "*"}},
+		Dst:              []models.AclPolicyTag{{ID: models.NodeTagID, Value: "*"}},
+		AllowedDirection: models.TrafficDirectionUni,
+		Enabled:          true,
+		ServiceID:        monitoring.ID,
+	}
+	assert.Nil(t, ApplyAclService(&acl))
+	assert.Equal(t, models.TCP, acl.Proto)
+	assert.Equal(t, []string{"9100", "9090"}, acl.Port)
+	assert.Nil(t, InsertAcl(acl))
+	assert.Nil(t, SyncAclServicePolicies(acl))
+	RecordAclVersion(netID, "admin", "created policy scrape")
+	companion, err := GetAcl(acl.ID + "-udp")
+	assert.Nil(t, err)
+	assert.Equal(t, acl.ID, companion.ServiceParentID)
+	assert.Equal(t, models.UDP, companion.Proto)
+	assert.Equal(t, []string{"161"}, companion.Port)
+
+	services := ListAclServices(netID)
+	assert.Len(t, services, 1)
+	assert.Equal(t, 1, services[0].UsageCount, "companion policies are not counted")
+	assert.Equal(t, []string{"scrape"}, services[0].Policies)
+	assert.NotNil(t, DeleteAclService(monitoring.ID), "services in use can not be deleted")
+
+	// changing the service changes the policies using it
+	monitoring.Entries = []models.AclServiceEntry{{Protocol: models.TCP, Ports: []string{"9100-9200"}}}
+	_, err = UpdateAclService(monitoring, "admin")
+	assert.Nil(t, err)
+	acl, err = GetAcl(acl.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"9100-9200"}, acl.Port)
+	_, err = GetAcl(acl.ID + "-udp")
+	assert.NotNil(t, err, "the udp copy is gone with the udp entry")
+
+	// a rollback keeps policies in line with the service as it is now
+	versions, _ := ListAclVersions(netID)
+	assert.Equal(t, "created policy scrape", versions[1].Action)
+	_, err = RollbackAclVersion(netID, versions[1].Version, "admin")
+	assert.Nil(t, err)
+	acl, err = GetAcl(acl.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"9100-9200"}, acl.Port)
+	_, err = GetAcl(acl.ID + "-udp")
+	assert.NotNil(t, err, "the udp copy is not restored for a service without udp")
+
+	assert.Nil(t, DeleteAclWithServicePolicies(acl))
+	assert.Nil(t, DeleteAclService(monitoring.ID))
+	assert.Empty(t, ListAclServices(netID))
+	RecordAclVersion(netID, "admin", "deleted policy scrape")
+	_, err = RollbackAclVersion(netID, versions[1].Version, "admin")
+	assert.NotNil(t, err, "the service of the policy no longer exists")
+	_, err = GetAcl(acl.ID)
+	assert.NotNil(t, err)
+
+	// a policy whose copies fail to sync is not kept
+	orphan := acl
+	orphan.ID = uuid.NewString()
+	orphan.ServiceID = uuid.NewString()
+	assert.NotNil(t, CreateAclWithServicePolicies(orphan))
+	_, err = GetAcl(orphan.ID)
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,234 @@
+package logic
+
+import (
+	"errors"
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gravitl/netmaker/models"
+)
+
+// SimulateAclChange - evaluates a proposed acl change against an in-memory copy of the network's
+// policies and reports the reachability it would change, nothing is persisted
+func SimulateAclChange(req models.AclSimulationRequest) (models.AclSimulationResult, error) {
+	proposed, acls, err := applyAclChange(req)
+	if err != nil {
+		return models.AclSimulationResult{}, err
+	}
+	netID := proposed.NetworkID
+	result := models.AclSimulationResult{
+		NetworkID:     netID,
+		Action:        req.Action,
+		Acl:           proposed,
+		Changes:       []models.AclSimulationPairChange{},
+		AffectedHosts: []string{},
+	}
+	base := getNetworkPolicy(netID)
+	overlay := compileNetworkPolicy(netID, acls)
+	baseOf := getNetworkPolicy
+	overlayOf := func(id models.NetworkID) *networkPolicy {
+		if id == netID {
+			return overlay
+		}
+		return getNetworkPolicy(id)
+	}
+
+	nodes, err := GetNetworkNodes(netID.String())
+	if err != nil {
+		return result, err
+	}
+	nodesByID := make(map[string]models.Node, len(nodes))
+	for _, node := range nodes {
+		nodesByID[node.ID.String()] = node
+	}
+	devices := append(nodes[:len(nodes):len(nodes)], GetStaticNodesByNetwork(netID, true)...)
+	described := make([]models.AclSimulationDevice, len(devices))
+	for i, device := range devices {
+		described[i] = simulationDevice(device)
+	}
+	affected := make(map[string]struct{})
+	affect := func(device models.Node) {
+		if device.IsStatic {
+			device = nodesByID[device.StaticNode.IngressGatewayID]
+		}
+		if device.HostID != uuid.Nil {
+			affected[device.HostID.String()] = struct{}{}
+		}
+	}
+
+	for i, src := range devices {
+		for j, dst := range devices {
+			if i == j {
+				continue
+			}
+			allowedBefore, before := isNodeAllowedToCommunicate(src, dst, true, baseOf)
+			allowedAfter, after := isNodeAllowedToCommunicate(src, dst, true, overlayOf)
+			change := models.AclSimulationPairChange{
+				Src:    described[i],
+				Dst:    described[j],
+				Before: policyGrants(allowedBefore, before),
+				After:  policyGrants(allowedAfter, after),
+			}
+			switch {
+			case !allowedBefore && allowedAfter:
+				change.Change = "allowed"
+			case allowedBefore && !allowedAfter:
+				change.Change = "denied"
+			case allowedBefore && !reflect.DeepEqual(change.Before, change.After):
+				change.Change = "modified"
+			default:
+				continue
+			}
+			result.Changes = append(result.Changes, change)
+			affect(src)
+			affect(dst)
+		}
+	}
+
+	// firewall rules can change without any pair changing, e.g. for user policies
+	for _, node := range nodes {
+		if _, ok := affected[node.HostID.String()]; ok {
+			continue
+		}
+		changed := !reflect.DeepEqual(getAclRulesForNode(&node, base), getAclRulesForNode(&node, overlay))
+		if !changed && node.IsEgressGateway {
+			changed = !reflect.DeepEqual(getEgressRulesForNode(node, base), getEgressRulesForNode(node, overlay))
+		}
+		if changed {
+			affect(node)
+		}
+	}
+
+	sort.Slice(result.Changes, func(i, j int) bool {
+		a, b := result.Changes[i], result.Changes[j]
+		if a.Src.Name != b.Src.Name {
+			return a.Src.Name < b.Src.Name
+		}
+		return a.Dst.Name < b.Dst.Name
+	})
+	for hostID := range affected {
+		result.AffectedHosts = append(result.AffectedHosts, hostID)
+	}
+	sort.Strings(result.AffectedHosts)
+	return result, nil
+}
+
+// applyAclChange - validates a proposed change and applies it to a copy of the network's acls,
+// returning the proposed acl and the resulting policy set
+func applyAclChange(req models.AclSimulationRequest) (models.Acl, []models.Acl, error) {
+	acl := req.Acl
+	switch req.Action {
+	case models.AclSimulateCreate:
+		if err := ValidateCreateAclReq(acl); err != nil {
+			return acl, nil, err
+		}
+		acl.ID = uuid.New().String()
+		acl.CreatedAt = time.Now().UTC()
+		acl.Default = false
+		if acl.ServiceType == models.Any {
+			acl.Port = []string{}
+			acl.Proto = models.ALL
+		}
+		if err := ApplyAclService(&acl); err != nil {
+			return acl, nil, err
+		}
+		if !IsAclPolicyValid(acl) {
+			return acl, nil, errors.New("invalid policy")
+		}
+		companions, err := aclServiceCompanions(acl)
+		if err != nil {
+			return acl, nil, err
+		}
+		acls, _ := ListAclsByNetwork(acl.NetworkID)
+		return acl, append(append(acls, acl), companions...), nil
+	case models.AclSimulateUpdate, models.AclSimulateDelete:
+		existing, err := GetAcl(acl.ID)
+		if err != nil {
+			return acl, nil, err
+		}
+		acls, _ := ListAclsByNetwork(existing.NetworkID)
+		proposed := []models.Acl{}
+		if req.Action == models.AclSimulateDelete {
+			if existing.Default {
+				return acl, nil, errors.New("cannot delete default policy")
+			}
+			for _, a := range acls {
+				if a.ID != existing.ID && a.ServiceParentID != existing.ID {
+					proposed = append(proposed, a)
+				}
+			}
+			return existing, proposed, nil
+		}
+		if acl.NetworkID != existing.NetworkID {
+			return acl, nil, errors.New("invalid policy, network id mismatch")
+		}
+		if err := ApplyAclService(&acl); err != nil {
+			return acl, nil, err
+		}
+		if !IsAclPolicyValid(acl) {
+			return acl, nil, errors.New("invalid policy")
+		}
+		acl = mergeAclUpdate(acl, existing)
+		companions, err := aclServiceCompanions(acl)
+		if err != nil {
+			return acl, nil, err
+		}
+		for _, a := range acls {
+			if a.ServiceParentID == acl.ID {
+				continue
+			}
+			if a.ID == acl.ID {
+				a = acl
+			}
+			proposed = append(proposed, a)
+		}
+		return acl, append(proposed, companions...), nil
+	}
+	return acl, nil, errors.New("unknown action " + string(req.Action))
+}
+
+func simulationDevice(node models.Node) models.AclSimulationDevice {
+	if node.IsStatic {
+		return models.AclSimulationDevice{
+			ID:       node.StaticNode.ClientID,
+			Name:     deviceDisplayName(node),
+			IsStatic: true,
+		}
+	}
+	return models.AclSimulationDevice{ID: node.ID.String(), Name: deviceDisplayName(node)}
+}
+
+// deviceDisplayName - the host name of a node or the client id of a static node
+func deviceDisplayName(node models.Node) string {
+	if node.IsStatic {
+		return node.StaticNode.ClientID
+	}
+	if host, err := GetHost(node.HostID.String()); err == nil {
+		return host.Name
+	}
+	return node.ID.String()
+}
+
+// policyGrants - the protocols and ports the policies allow or deny, in evaluation order
+func policyGrants(allowed bool, policies []models.Acl) []models.AclGrant {
+	grants := []models.AclGrant{}
+	if !allowed {
+		return grants
+	}
+	for _, policy := range uniquePolicies(policies) {
+		action := models.AclAllow
+		if policy.IsDeny() {
+			action = models.AclDeny
+		}
+		grants = append(grants, models.AclGrant{
+			PolicyID:   policy.ID,
+			PolicyName: policy.Name,
+			Action:     action,
+			Proto:      policy.Proto,
+			Ports:      policy.Port,
+		})
+	}
+	return grants
+}
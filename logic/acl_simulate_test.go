@@ -0,0 +1,122 @@
+package logic
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/gravitl/netmaker/database"
+	"github.com/gravitl/netmaker/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSimulateAclChange(t *testing.T) {
+	database.InitializeDatabase()
+	defer database.CloseDB()
+	t.Setenv("DNS_MODE", "off")
+	netID := models.NetworkID("simulate-net")
+	network := models.Network{NetID: netID.String(), AddressRange: "10.20.0.0/24"}
+	assert.Nil(t, SaveNetwork(&network))
+	CreateDefaultAclNetworkPolicies(netID)
+	defer DeleteNetworkPolicies(netID)
+	newNode := func(name string) models.Node {
+		host := models.Host{ID: uuid.New(), Name: name}
+		assert.Nil(t, UpsertHost(&host))
+		node := models.Node{}
+		node.ID = uuid.New()
+		node.HostID = host.ID
+		node.Network = netID.String()
+		assert.Nil(t, UpsertNode(&node))
+		return node
+	}
+	a, b := newNode("sim-a"), newNode("sim-b")
+	defer DeleteNodeByID(&a)
+	defer DeleteNodeByID(&b)
+	defaultAcl, err := GetAcl(fmt.Sprintf("%s.all-nodes", netID))
+	assert.Nil(t, err)
+
+	t.Run("disabling the default policy denies all pairs", func(t *testing.T) {
+		result, err := SimulateAclChange(models.AclSimulationRequest{
+			Action: models.AclSimulateUpdate,
+			Acl:    models.Acl{ID: defaultAcl.ID, NetworkID: netID, AllowedDirection: models.TrafficDirectionBi, Enabled: false},
+		})
+		assert.Nil(t, err)
+		assert.Len(t, result.Changes, 2)
+		for _, change := range result.Changes {
+			assert.Equal(t, "denied", change.Change)
+			assert.Equal(t, defaultAcl.ID, change.Before[0].PolicyID)
+		}
+		assert.ElementsMatch(t, []string{a.HostID.String(), b.HostID.String()}, result.AffectedHosts)
+		// nothing was persisted
+		allowed, _ := IsNodeAllowedToCommunicateV1(a, b, true)
+		assert.True(t, allowed)
+	})
+
+	assert.Nil(t, UpdateAcl(models.Acl{Enabled: false}, defaultAcl))
+	t.Run("creating a uni-directional policy allows one direction", func(t *testing.T) {
+		before, _ := ListAclsByNetwork(netID)
+		result, err := SimulateAclChange(models.AclSimulationRequest{
+			Action: models.AclSimulateCreate,
+			Acl: models.Acl{
+				Name:             "a to b",
+				NetworkID:        netID,
+				RuleType:         models.DevicePolicy,
+				Src:              []models.AclPolicyTag{{ID: models.NodeID, Value: a.ID.String()}},
+				Dst:              []models.AclPolicyTag{{ID: models.NodeID, Value: b.ID.String()}},
+				AllowedDirection: models.TrafficDirectionUni,
+				Proto:            models.TCP,
+				ServiceType:      models.Custom,
+				Port:             []string{"5432"},
+				Enabled:          true,
+			},
+		})
+		assert.Nil(t, err)
+		assert.Len(t, result.Changes, 1)
+		change := result.Changes[0]
+		assert.Equal(t, "allowed", change.Change)
+		assert.Equal(t, "sim-a", change.Src.Name)
+		assert.Equal(t, "sim-b", change.Dst.Name)
+		assert.Equal(t, models.TCP, change.After[0].Proto)
+		assert.Equal(t, []string{"5432"}, change.After[0].Ports)
+		after, _ := ListAclsByNetwork(netID)
+		assert.Len(t, after, len(before))
+	})
+	t.Run("the further protocols of a service are simulated", func(t *testing.T) {
+		svc, err := CreateAclService(models.AclService{
+			NetworkID: netID,
+			Name:      "dns",
+			Entries: []models.AclServiceEntry{
+				{Protocol: models.TCP, Ports: []string{"53"}},
+				{Protocol: models.UDP, Ports: []string{"53"}},
+			},
+		}, "admin")
+		assert.Nil(t, err)
+		defer DeleteAclService(svc.ID)
+		result, err := SimulateAclChange(models.AclSimulationRequest{
+			Action: models.AclSimulateCreate,
+			Acl: models.Acl{
+				Name:             "b to a",
+				NetworkID:        netID,
+				RuleType:         models.DevicePolicy,
+				Src:              []models.AclPolicyTag{{ID: models.NodeID, Value: b.ID.String()}},
+				Dst:              []models.AclPolicyTag{{ID: models.NodeID, Value: a.ID.String()}},
+				AllowedDirection: models.TrafficDirectionUni,
+				ServiceID:        svc.ID,
+				Enabled:          true,
+			},
+		})
+		assert.Nil(t, err)
+		assert.Len(t, result.Changes, 1)
+		protos := []models.Protocol{}
+		for _, rule := range result.Changes[0].After {
+			protos = append(protos, rule.Proto)
+		}
+		assert.ElementsMatch(t, []models.Protocol{models.TCP, models.UDP}, protos)
+	})
+	t.Run("invalid requests", func(t *testing.T) {
+		_, err := SimulateAclChange(models.AclSimulationRequest{Action: models.AclSimulateDelete, Acl: defaultAcl})
+		assert.NotNil(t, err)
+		_, err = SimulateAclChange(models.AclSimulationRequest{Action: "rename", Acl: defaultAcl})
+		assert.NotNil(t, err)
+	})
+}
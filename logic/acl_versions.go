@@ -0,0 +1,234 @@
+package logic
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gravitl/netmaker/database"
+	"github.com/gravitl/netmaker/models"
+	"golang.org/x/exp/slog"
+)
+
+// MaxAclVersions - versions kept per network, older ones are dropped
+const MaxAclVersions = 100
+
+var aclVersionMutex = &sync.Mutex{}
+
+// GetAclVersionHistory - fetches the policy versions of a network
+func GetAclVersionHistory(netID models.NetworkID) (models.AclVersionHistory, error) {
+	history := models.AclVersionHistory{NetworkID: netID, Versions: []models.AclVersion{}}
+	record, err := database.FetchRecord(database.ACL_VERSIONS_TABLE_NAME, netID.String())
+	if err != nil {
+		if database.IsEmptyRecord(err) {
+			return history, nil
+		}
+		return history, err
+	}
+	err = json.Unmarshal([]byte(record), &history)
+	return history, err
+}
+
+// DeleteAclVersions - removes the policy versions of a network
+func DeleteAclVersions(netID models.NetworkID) {
+	aclVersionMutex.Lock()
+	defer aclVersionMutex.Unlock()
+	_ = database.DeleteRecord(database.ACL_VERSIONS_TABLE_NAME, netID.String())
+}
+
+// GetAclVersion - fetches a version of the policies of a network
+func GetAclVersion(netID models.NetworkID, version int) (models.AclVersion, error) {
+	history, err := GetAclVersionHistory(netID)
+	if err != nil {
+		return models.AclVersion{}, err
+	}
+	for _, v := range history.Versions {
+		if v.Version == version {
+			return v, nil
+		}
+	}
+	return models.AclVersion{}, fmt.Errorf("version %d of the policies of network %s not found", version, netID)
+}
+
+// sortAclsByID - orders a policy set for storing and comparing
+func sortAclsByID(acls []models.Acl) {
+	sort.Slice(acls, func(i, j int) bool { return acls[i].ID < acls[j].ID })
+}
+
+// diffAclSets - the policies added, removed and modified from one policy set to another
+func diffAclSets(from, to []models.Acl) models.AclVersionDiff {
+	diff := models.AclVersionDiff{Added: []models.Acl{}, Removed: []models.Acl{}, Modified: []models.AclPolicyChange{}}
+	before := make(map[string]models.Acl)
+	for _, acl := range from {
+		before[acl.ID] = acl
+	}
+	after := make(map[string]struct{})
+	for _, acl := range to {
+		after[acl.ID] = struct{}{}
+		old, ok := before[acl.ID]
+		if !ok {
+			diff.Added = append(diff.Added, acl)
+		} else if !reflect.DeepEqual(old, acl) {
+			diff.Modified = append(diff.Modified, models.AclPolicyChange{Before: old, After: acl})
+		}
+	}
+	for _, acl := range from {
+		if _, ok := after[acl.ID]; !ok {
+			diff.Removed = append(diff.Removed, acl)
+		}
+	}
+	return diff
+}
+
+// RecordAclBaseline - snapshots the policies of a network as its first version, so the first
+// change is recorded against what was there before it. Nothing is done once a network has versions
+func RecordAclBaseline(netID models.NetworkID) {
+	aclVersionMutex.Lock()
+	defer aclVersionMutex.Unlock()
+	history, err := GetAclVersionHistory(netID)
+	if err != nil || len(history.Versions) > 0 {
+		return
+	}
+	policies, _ := ListAclsByNetwork(netID)
+	sortAclsByID(policies)
+	version := models.AclVersion{Version: 1, Time: time.Now().UTC(), Action: "baseline", Policies: policies}
+	version.Changes = diffAclSets(policies, policies)
+	version.Changes.From, version.Changes.To = 0, 1
+	storeAclVersion(netID, history, version)
+}
+
+// RecordAclVersion - snapshots the policies of a network after a change made by user, nothing is
+// recorded when the policies did not change. Changes made by other operations, like policies
+// dropping a deleted node, show up in the next version
+func RecordAclVersion(netID models.NetworkID, user, action string) {
+	aclVersionMutex.Lock()
+	defer aclVersionMutex.Unlock()
+	history, err := GetAclVersionHistory(netID)
+	if err != nil {
+		slog.Error("failed to fetch acl versions", "network", netID, "error", err)
+		history = models.AclVersionHistory{NetworkID: netID}
+	}
+	policies, _ := ListAclsByNetwork(netID)
+	sortAclsByID(policies)
+	version := models.AclVersion{Version: 1, Time: time.Now().UTC(), User: user, Action: action, Policies: policies}
+	previous := []models.Acl{}
+	if n := len(history.Versions); n > 0 {
+		last := history.Versions[n-1]
+		previous = last.Policies
+		version.Version = last.Version + 1
+	}
+	version.Changes = diffAclSets(previous, policies)
+	version.Changes.From, version.Changes.To = version.Version-1, version.Version
+	if len(version.Changes.Added)+len(version.Changes.Removed)+len(version.Changes.Modified) == 0 {
+		return
+	}
+	storeAclVersion(netID, history, version)
+}
+
+// storeAclVersion - appends a version to the history of a network, dropping the oldest ones
+// past MaxAclVersions
+func storeAclVersion(netID models.NetworkID, history models.AclVersionHistory, version models.AclVersion) {
+	history.Versions = append(history.Versions, version)
+	if len(history.Versions) > MaxAclVersions {
+		history.Versions = history.Versions[len(history.Versions)-MaxAclVersions:]
+	}
+	data, err := json.Marshal(history)
+	if err == nil {
+		err = database.Insert(netID.String(), string(data), database.ACL_VERSIONS_TABLE_NAME)
+	}
+	if err != nil {
+		slog.Error("failed to store acl version", "network", netID, "version", version.Version, "error", err)
+	}
+}
+
+// ListAclVersions - the versions of the policies of a network without their snapshots, newest first
+func ListAclVersions(netID models.NetworkID) ([]models.AclVersion, error) {
+	history, err := GetAclVersionHistory(netID)
+	if err != nil {
+		return nil, err
+	}
+	versions := make([]models.AclVersion, 0, len(history.Versions))
+	for i := len(history.Versions) - 1; i >= 0; i-- {
+		v := history.Versions[i]
+		v.Policies = nil
+		versions = append(versions, v)
+	}
+	return versions, nil
+}
+
+// DiffAclVersions - the changes between two versions of the policies of a network
+func DiffAclVersions(netID models.NetworkID, from, to int) (models.AclVersionDiff, error) {
+	fromVersion, err := GetAclVersion(netID, from)
+	if err != nil {
+		return models.AclVersionDiff{}, err
+	}
+	toVersion, err := GetAclVersion(netID, to)
+	if err != nil {
+		return models.AclVersionDiff{}, err
+	}
+	diff := diffAclSets(fromVersion.Policies, toVersion.Policies)
+	diff.From, diff.To = from, to
+	return diff, nil
+}
+
+// RollbackAclVersion - restores the policies of a network to a prior version and records the
+// rollback as a new version. Policies referencing tags, users, devices or services that no longer
+// exist fail the rollback. Policies using a service take its current protocols and ports
+func RollbackAclVersion(netID models.NetworkID, version int, user string) (models.AclVersionDiff, error) {
+	target, err := GetAclVersion(netID, version)
+	if err != nil {
+		return models.AclVersionDiff{}, err
+	}
+	invalid := []string{}
+	restored := []models.Acl{}
+	for _, acl := range target.Policies {
+		if !acl.Default && !IsAclPolicyValid(acl) {
+			invalid = append(invalid, acl.Name)
+			continue
+		}
+		// the copies carrying further service entries are made from the current service below
+		if acl.ServiceParentID != "" {
+			continue
+		}
+		if err := ApplyAclService(&acl); err != nil {
+			invalid = append(invalid, acl.Name)
+			continue
+		}
+		restored = append(restored, acl)
+	}
+	if len(invalid) > 0 {
+		return models.AclVersionDiff{}, fmt.Errorf("policies %s of version %d reference entities that no longer exist",
+			strings.Join(invalid, ", "), version)
+	}
+	latest := 0
+	if history, err := GetAclVersionHistory(netID); err == nil && len(history.Versions) > 0 {
+		latest = history.Versions[len(history.Versions)-1].Version
+	}
+	aclPolicySetMutex.Lock()
+	current, _ := ListAclsByNetwork(netID)
+	sortAclsByID(current)
+	restoreAclPolicies(netID, restored)
+	for _, acl := range restored {
+		if acl.ServiceID == "" {
+			continue
+		}
+		if err = SyncAclServicePolicies(acl); err != nil {
+			restoreAclPolicies(netID, current)
+			break
+		}
+	}
+	after, _ := ListAclsByNetwork(netID)
+	aclPolicySetMutex.Unlock()
+	if err != nil {
+		return models.AclVersionDiff{}, err
+	}
+	RecordAclVersion(netID, user, fmt.Sprintf("rolled back to version %d", version))
+	sortAclsByID(after)
+	diff := diffAclSets(current, after)
+	diff.From, diff.To = latest, version
+	return diff, nil
+}
@@ -0,0 +1,80 @@
+package logic
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/gravitl/netmaker/database"
+	"github.com/gravitl/netmaker/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAclVersions(t *testing.T) {
+	database.InitializeDatabase()
+	defer database.CloseDB()
+	t.Setenv("DNS_MODE", "off")
+	netID := models.NetworkID("version-net")
+	network := models.Network{NetID: netID.String(), AddressRange: "10.70.0.0/24"}
+	assert.Nil(t, SaveNetwork(&network))
+	CreateDefaultAclNetworkPolicies(netID)
+	defer DeleteNetworkPolicies(netID)
+	baseline, err := GetAclVersion(netID, 1)
+	assert.Nil(t, err)
+	assert.Equal(t, "baseline", baseline.Action)
+	assert.NotEmpty(t, baseline.Policies)
+	assert.Empty(t, baseline.Changes.Added)
+	// the defaults are only recorded once
+	CreateDefaultAclNetworkPolicies(netID)
+
+	acl := models.Acl{
+		ID:               uuid.NewString(),
+		Name:             "all tcp",
+		NetworkID:        netID,
+		RuleType:         models.DevicePolicy,
+		Src:              []models.AclPolicyTag{{ID: models.NodeTagID, Value: "*"}},
+		Dst:              []models.AclPolicyTag{{ID: models.NodeTagID, Value: "*"}},
+		AllowedDirection: models.TrafficDirectionUni,
+		Proto:            models.TCP,
+		Port:             []string{},
+		Enabled:          true,
+	}
+	assert.Nil(t, InsertAcl(acl))
+	RecordAclVersion(netID, "alice", "created policy all tcp")
+	acl.Port = []string{"443"}
+	assert.Nil(t, UpsertAcl(acl))
+	RecordAclVersion(netID, "bob", "updated policy all tcp")
+	// nothing changed, nothing recorded
+	RecordAclVersion(netID, "bob", "updated policy all tcp")
+
+	versions, err := ListAclVersions(netID)
+	assert.Nil(t, err)
+	assert.Len(t, versions, 3)
+	assert.Equal(t, 3, versions[0].Version)
+	assert.Equal(t, "bob", versions[0].User)
+	assert.Nil(t, versions[0].Policies)
+	assert.Len(t, versions[0].Changes.Modified, 1)
+	assert.Equal(t, []string{"443"}, versions[0].Changes.Modified[0].After.Port)
+	assert.Equal(t, []string{acl.ID}, aclIDs(versions[1].Changes.Added))
+
+	diff, err := DiffAclVersions(netID, 1, 3)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{acl.ID}, aclIDs(diff.Added))
+	assert.Empty(t, diff.Modified)
+	_, err = DiffAclVersions(netID, 1, 9)
+	assert.NotNil(t, err)
+
+	diff, err = RollbackAclVersion(netID, 2, "carol")
+	assert.Nil(t, err)
+	assert.Len(t, diff.Modified, 1)
+	acl, err = GetAcl(acl.ID)
+	assert.Nil(t, err)
+	assert.Empty(t, acl.Port)
+	diff, err = RollbackAclVersion(netID, 1, "carol")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{acl.ID}, aclIDs(diff.Removed))
+	_, err = GetAcl(acl.ID)
+	assert.NotNil(t, err)
+	versions, _ = ListAclVersions(netID)
+	assert.Len(t, versions, 5)
+	assert.Equal(t, "rolled back to version 1", versions[0].Action)
+}
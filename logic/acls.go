@@ -127,6 +127,8 @@ func CreateDefaultAclNetworkPolicies(netID models.NetworkID) {
 		InsertAcl(defaultUserAcl)
 	}
 	CreateDefaultUserPolicies(netID)
+	// the policies a network starts with, or had before versions were recorded
+	RecordAclBaseline(netID)
 }
 
 // DeleteNetworkPolicies - deletes all default network acl policies
@@ -137,6 +139,8 @@ func DeleteNetworkPolicies(netId models.NetworkID) {
 			DeleteAcl(acl)
 		}
 	}
+	DeleteAclVersions(netId)
+	DeleteNetworkAclServices(netId)
 }
 
 // ValidateCreateAclReq - validates create req for acl
@@ -192,6 +196,10 @@ func InsertAcl(a models.Acl) error {
 	if err == nil && servercfg.CacheEnabled() {
 		storeAclInCache(a)
 	}
+	if err == nil {
+		InvalidateNetworkPolicy(a.NetworkID)
+		InvalidateNetworkPeerModel(a.NetworkID)
+	}
 	return err
 }
 
@@ -330,6 +338,15 @@ func IsAclPolicyValid(acl models.Acl) bool {
 		acl.AllowedDirection != models.TrafficDirectionUni {
 		return false
 	}
+	if acl.Action != "" && acl.Action != models.AclAllow && acl.Action != models.AclDeny {
+		return false
+	}
+	if acl.Priority < 0 || acl.Priority > MaxAclPriority {
+		return false
+	}
+	if ValidateAclSchedule(acl.Schedule) != nil {
+		return false
+	}
 	switch acl.RuleType {
 	case models.UserPolicy:
 		// src list should only contain users
@@ -380,6 +397,24 @@ func IsAclPolicyValid(acl models.Acl) bool {
 
 // UpdateAcl - updates allowed fields on acls and commits to DB
 func UpdateAcl(newAcl, acl models.Acl) error {
+	acl = mergeAclUpdate(newAcl, acl)
+	d, err := json.Marshal(acl)
+	if err != nil {
+		return err
+	}
+	err = database.Insert(acl.ID, string(d), database.ACLS_TABLE_NAME)
+	if err == nil && servercfg.CacheEnabled() {
+		storeAclInCache(acl)
+	}
+	if err == nil {
+		InvalidateNetworkPolicy(acl.NetworkID)
+		InvalidateNetworkPeerModel(acl.NetworkID)
+	}
+	return err
+}
+
+// mergeAclUpdate - applies the fields an update is allowed to change onto acl
+func mergeAclUpdate(newAcl, acl models.Acl) models.Acl {
 	if !acl.Default {
 		acl.Name = newAcl.Name
 		acl.Src = newAcl.Src
@@ -388,21 +423,17 @@ func UpdateAcl(newAcl, acl models.Acl) error {
 		acl.Port = newAcl.Port
 		acl.Proto = newAcl.Proto
 		acl.ServiceType = newAcl.ServiceType
+		acl.Action = newAcl.Action
+		acl.Priority = newAcl.Priority
+		acl.Schedule = newAcl.Schedule
+		acl.ServiceID = newAcl.ServiceID
 	}
 	if newAcl.ServiceType == models.Any {
 		acl.Port = []string{}
 		acl.Proto = models.ALL
 	}
 	acl.Enabled = newAcl.Enabled
-	d, err := json.Marshal(acl)
-	if err != nil {
-		return err
-	}
-	err = database.Insert(acl.ID, string(d), database.ACLS_TABLE_NAME)
-	if err == nil && servercfg.CacheEnabled() {
-		storeAclInCache(acl)
-	}
-	return err
+	return acl
 }
 
 // UpsertAcl - upserts acl
@@ -415,6 +446,10 @@ func UpsertAcl(acl models.Acl) error {
 	if err == nil && servercfg.CacheEnabled() {
 		storeAclInCache(acl)
 	}
+	if err == nil {
+		InvalidateNetworkPolicy(acl.NetworkID)
+		InvalidateNetworkPeerModel(acl.NetworkID)
+	}
 	return err
 }
 
@@ -424,46 +459,55 @@ func DeleteAcl(a models.Acl) error {
 	if err == nil && servercfg.CacheEnabled() {
 		removeAclFromCache(a)
 	}
+	if err == nil {
+		InvalidateNetworkPolicy(a.NetworkID)
+		InvalidateNetworkPeerModel(a.NetworkID)
+	}
 	return err
 }
 
 // GetDefaultPolicy - fetches default policy in the network by ruleType
 func GetDefaultPolicy(netID models.NetworkID, ruleType models.AclPolicyType) (models.Acl, error) {
+	policies, _ := ListAclsByNetwork(netID)
+	return defaultPolicyOf(netID, ruleType, policies)
+}
+
+// defaultPolicyOf - picks the default policy by ruleType out of the policies of a network
+func defaultPolicyOf(netID models.NetworkID, ruleType models.AclPolicyType, policies []models.Acl) (models.Acl, error) {
 	aclID := "all-users"
 	if ruleType == models.DevicePolicy {
 		aclID = "all-nodes"
 	}
-	acl, err := GetAcl(fmt.Sprintf("%s.%s", netID, aclID))
-	if err != nil {
+	aclID = fmt.Sprintf("%s.%s", netID, aclID)
+	var acl models.Acl
+	var found bool
+	for _, policy := range policies {
+		if policy.ID == aclID {
+			acl, found = policy, true
+			break
+		}
+	}
+	if !found {
 		return models.Acl{}, errors.New("default rule not found")
 	}
 	if acl.Enabled {
 		return acl, nil
 	}
 	// check if there are any custom all policies
-	srcMap := make(map[string]struct{})
-	dstMap := make(map[string]struct{})
-	defer func() {
-		srcMap = nil
-		dstMap = nil
-	}()
-	policies, _ := ListAclsByNetwork(netID)
 	for _, policy := range policies {
-		if !policy.Enabled {
+		if !isAclActive(policy) {
 			continue
 		}
 		if policy.RuleType == ruleType {
-			dstMap = convAclTagToValueMap(policy.Dst)
-			srcMap = convAclTagToValueMap(policy.Src)
+			dstMap := convAclTagToValueMap(policy.Dst)
+			srcMap := convAclTagToValueMap(policy.Src)
 			if _, ok := srcMap["*"]; ok {
 				if _, ok := dstMap["*"]; ok {
 					return policy, nil
 				}
 			}
 		}
-
 	}
-
 	return acl, nil
 }
 
@@ -609,7 +653,7 @@ func IsUserAllowedToCommunicate(userName string, peer models.Node) (bool, []mode
 	}
 	peerTags[models.TagID(peerId)] = struct{}{}
 	acl, _ := GetDefaultPolicy(models.NetworkID(peer.Network), models.UserPolicy)
-	if acl.Enabled {
+	if acl.Enabled && !getNetworkPolicy(models.NetworkID(peer.Network)).userDeny {
 		return true, []models.Acl{acl}
 	}
 	user, err := GetUser(userName)
@@ -618,8 +662,9 @@ func IsUserAllowedToCommunicate(userName string, peer models.Node) (bool, []mode
 	}
 	allowedPolicies := []models.Acl{}
 	policies := listPoliciesOfUser(*user, models.NetworkID(peer.Network))
+	sortAclsByPrecedence(policies)
 	for _, policy := range policies {
-		if !policy.Enabled {
+		if !isAclActive(policy) {
 			continue
 		}
 		dstMap := convAclTagToValueMap(policy.Dst)
@@ -639,8 +684,8 @@ func IsUserAllowedToCommunicate(userName string, peer models.Node) (bool, []mode
 		}
 
 	}
-	if len(allowedPolicies) > 0 {
-		return true, allowedPolicies
+	if allowed, policies := evaluatePolicies(allowedPolicies); allowed {
+		return true, policies
 	}
 	return false, []models.Acl{}
 }
@@ -666,60 +711,31 @@ func IsPeerAllowed(node, peer models.Node, checkDefaultPolicy bool) bool {
 	} else {
 		peerId = peer.ID.String()
 	}
-
-	var nodeTags, peerTags map[models.TagID]struct{}
-	if node.Mutex != nil {
-		node.Mutex.Lock()
-		nodeTags = maps.Clone(node.Tags)
-		node.Mutex.Unlock()
-	} else {
-		nodeTags = node.Tags
-	}
-	if peer.Mutex != nil {
-		peer.Mutex.Lock()
-		peerTags = maps.Clone(peer.Tags)
-		peer.Mutex.Unlock()
-	} else {
-		peerTags = peer.Tags
-	}
-	if nodeTags == nil {
-		nodeTags = make(map[models.TagID]struct{})
-	}
-	if peerTags == nil {
-		peerTags = make(map[models.TagID]struct{})
-	}
-	nodeTags[models.TagID(nodeId)] = struct{}{}
-	peerTags[models.TagID(peerId)] = struct{}{}
 	if checkDefaultPolicy {
 		// check default policy if all allowed return true
-		defaultPolicy, err := GetDefaultPolicy(models.NetworkID(node.Network), models.DevicePolicy)
-		if err == nil {
-			if defaultPolicy.Enabled {
-				return true
-			}
-		}
-
-	}
-	// list device policies
-	policies := listDevicePolicies(models.NetworkID(peer.Network))
-	srcMap := make(map[string]struct{})
-	dstMap := make(map[string]struct{})
-	defer func() {
-		srcMap = nil
-		dstMap = nil
-	}()
-	for _, policy := range policies {
-		if !policy.Enabled {
-			continue
-		}
-		srcMap = convAclTagToValueMap(policy.Src)
-		dstMap = convAclTagToValueMap(policy.Dst)
-		if checkTagGroupPolicy(srcMap, dstMap, node, peer, nodeTags, peerTags) {
+		nodePolicy := getNetworkPolicy(models.NetworkID(node.Network))
+		if nodePolicy.defaultErr == nil && nodePolicy.defaultDevice.Enabled && !nodePolicy.deviceDeny {
 			return true
 		}
-
 	}
-	return false
+	policy := getNetworkPolicy(models.NetworkID(peer.Network))
+	nodeKey, nodeTags := policy.policyIdentity(nodeId, deviceTags(&node))
+	peerKey, peerTags := policy.policyIdentity(peerId, deviceTags(&peer))
+	decision := policy.decide("peer|"+nodeKey+"|"+peerKey, func() policyDecision {
+		for _, c := range policy.devicePolicies {
+			if !checkTagGroupPolicy(c.src, c.dst, node, peer, nodeTags, peerTags) {
+				continue
+			}
+			if !c.acl.IsDeny() {
+				return policyDecision{allowed: true}
+			}
+			if aclCoversAllTraffic(c.acl) {
+				break
+			}
+		}
+		return policyDecision{}
+	})
+	return decision.allowed
 }
 
 func RemoveUserFromAclPolicy(userName string) {
@@ -904,6 +920,12 @@ func uniquePolicies(items []models.Acl) []models.Acl {
 
 // IsNodeAllowedToCommunicate - check node is allowed to communicate with the peer // ADD ALLOWED DIRECTION - 0 => node -> peer, 1 => peer-> node,
 func IsNodeAllowedToCommunicateV1(node, peer models.Node, checkDefaultPolicy bool) (bool, []models.Acl) {
+	return isNodeAllowedToCommunicate(node, peer, checkDefaultPolicy, getNetworkPolicy)
+}
+
+// isNodeAllowedToCommunicate - checks node against peer with the network policies returned by policyOf
+func isNodeAllowedToCommunicate(node, peer models.Node, checkDefaultPolicy bool,
+	policyOf func(models.NetworkID) *networkPolicy) (bool, []models.Acl) {
 	var nodeId, peerId string
 	if peer.IsFailOver && node.FailedOverBy != uuid.Nil && node.FailedOverBy == peer.ID {
 		return true, []models.Acl{}
@@ -923,148 +945,22 @@ func IsNodeAllowedToCommunicateV1(node, peer models.Node, checkDefaultPolicy boo
 	} else {
 		peerId = peer.ID.String()
 	}
-
-	var nodeTags, peerTags map[models.TagID]struct{}
-	if node.Mutex != nil {
-		node.Mutex.Lock()
-		nodeTags = maps.Clone(node.Tags)
-		node.Mutex.Unlock()
-	} else {
-		nodeTags = node.Tags
-	}
-	if peer.Mutex != nil {
-		peer.Mutex.Lock()
-		peerTags = maps.Clone(peer.Tags)
-		peer.Mutex.Unlock()
-	} else {
-		peerTags = peer.Tags
-	}
-	if nodeTags == nil {
-		nodeTags = make(map[models.TagID]struct{})
-	}
-	if peerTags == nil {
-		peerTags = make(map[models.TagID]struct{})
-	}
-	nodeTags[models.TagID(nodeId)] = struct{}{}
-	peerTags[models.TagID(peerId)] = struct{}{}
 	if checkDefaultPolicy {
 		// check default policy if all allowed return true
-		defaultPolicy, err := GetDefaultPolicy(models.NetworkID(node.Network), models.DevicePolicy)
-		if err == nil {
-			if defaultPolicy.Enabled {
-				return true, []models.Acl{defaultPolicy}
-			}
+		nodePolicy := policyOf(models.NetworkID(node.Network))
+		if nodePolicy.defaultErr == nil && nodePolicy.defaultDevice.Enabled && !nodePolicy.deviceDeny {
+			return true, []models.Acl{nodePolicy.defaultDevice}
 		}
 	}
-	allowedPolicies := []models.Acl{}
-	defer func() {
-		allowedPolicies = uniquePolicies(allowedPolicies)
-	}()
-	// list device policies
-	policies := listDevicePolicies(models.NetworkID(peer.Network))
-	srcMap := make(map[string]struct{})
-	dstMap := make(map[string]struct{})
-	defer func() {
-		srcMap = nil
-		dstMap = nil
-	}()
-	for _, policy := range policies {
-		if !policy.Enabled {
-			continue
-		}
-		allowed := false
-		srcMap = convAclTagToValueMap(policy.Src)
-		dstMap = convAclTagToValueMap(policy.Dst)
-		_, srcAll := srcMap["*"]
-		_, dstAll := dstMap["*"]
-		if policy.AllowedDirection == models.TrafficDirectionBi {
-			if _, ok := srcMap[nodeId]; ok || srcAll {
-				if _, ok := dstMap[peerId]; ok || dstAll {
-					allowedPolicies = append(allowedPolicies, policy)
-					continue
-				}
-
-			}
-			if _, ok := dstMap[nodeId]; ok || dstAll {
-				if _, ok := srcMap[peerId]; ok || srcAll {
-					allowedPolicies = append(allowedPolicies, policy)
-					continue
-				}
-			}
-		}
-		if _, ok := dstMap[peerId]; ok || dstAll {
-			if _, ok := srcMap[nodeId]; ok || srcAll {
-				allowedPolicies = append(allowedPolicies, policy)
-				continue
-			}
-		}
-		if policy.AllowedDirection == models.TrafficDirectionBi {
-
-			for tagID := range nodeTags {
-
-				if _, ok := dstMap[tagID.String()]; ok || dstAll {
-					if srcAll {
-						allowed = true
-						break
-					}
-					for tagID := range peerTags {
-						if _, ok := srcMap[tagID.String()]; ok {
-							allowed = true
-							break
-						}
-					}
-				}
-				if allowed {
-					allowedPolicies = append(allowedPolicies, policy)
-					break
-				}
-				if _, ok := srcMap[tagID.String()]; ok || srcAll {
-					if dstAll {
-						allowed = true
-						break
-					}
-					for tagID := range peerTags {
-						if _, ok := dstMap[tagID.String()]; ok {
-							allowed = true
-							break
-						}
-					}
-				}
-				if allowed {
-					break
-				}
-			}
-			if allowed {
-				allowedPolicies = append(allowedPolicies, policy)
-				continue
-			}
-		}
-		for tagID := range peerTags {
-			if _, ok := dstMap[tagID.String()]; ok || dstAll {
-				if srcAll {
-					allowed = true
-					break
-				}
-				for tagID := range nodeTags {
-					if _, ok := srcMap[tagID.String()]; ok {
-						allowed = true
-						break
-					}
-				}
-			}
-			if allowed {
-				break
-			}
-		}
-		if allowed {
-			allowedPolicies = append(allowedPolicies, policy)
-		}
-	}
-
-	if len(allowedPolicies) > 0 {
-		return true, allowedPolicies
-	}
-	return false, allowedPolicies
+	policy := policyOf(models.NetworkID(peer.Network))
+	nodeKey, nodeTags := policy.policyIdentity(nodeId, deviceTags(&node))
+	peerKey, peerTags := policy.policyIdentity(peerId, deviceTags(&peer))
+	decision := policy.decide("comm|"+nodeKey+"|"+peerKey, func() policyDecision {
+		allowed, policies := policy.allowedPolicies(nodeId, peerId, nodeTags, peerTags)
+		return policyDecision{allowed: allowed, policies: policies}
+	})
+	// decisions are shared, so appending callers must not write into them
+	return decision.allowed, decision.policies[:len(decision.policies):len(decision.policies)]
 }
 
 // SortTagEntrys - Sorts slice of Tag entries by their id
@@ -1150,19 +1046,19 @@ func RemoveDeviceTagFromAclPolicies(tagID models.TagID, netID models.NetworkID)
 	return nil
 }
 
-func getEgressUserRulesForNode(targetnode *models.Node,
+func getEgressUserRulesForNode(targetnode *models.Node, policy *networkPolicy,
 	rules map[string]models.AclRule) map[string]models.AclRule {
 	userNodes := GetStaticUserNodesByNetwork(models.NetworkID(targetnode.Network))
 	userGrpMap := GetUserGrpMap()
 	allowedUsers := make(map[string][]models.Acl)
-	acls := listUserPolicies(models.NetworkID(targetnode.Network))
+	acls := policy.userPolicies
 	var targetNodeTags = make(map[models.TagID]struct{})
 	targetNodeTags["*"] = struct{}{}
 	for _, rangeI := range targetnode.EgressGatewayRanges {
 		targetNodeTags[models.TagID(rangeI)] = struct{}{}
 	}
 	for _, acl := range acls {
-		if !acl.Enabled {
+		if !isAclActive(acl) {
 			continue
 		}
 		dstTags := convAclTagToValueMap(acl.Dst)
@@ -1207,7 +1103,7 @@ func getEgressUserRulesForNode(targetnode *models.Node,
 		}
 		for _, acl := range acls {
 
-			if !acl.Enabled {
+			if !isAclActive(acl) {
 				continue
 			}
 			r := models.AclRule{
@@ -1215,7 +1111,8 @@ func getEgressUserRulesForNode(targetnode *models.Node,
 				AllowedProtocol: acl.Proto,
 				AllowedPorts:    acl.Port,
 				Direction:       acl.AllowedDirection,
-				Allowed:         true,
+				Allowed:         !acl.IsDeny(),
+				Priority:        aclRulePriority(acl),
 			}
 			// Get peers in the tags and add allowed rules
 			if userNode.StaticNode.Address != "" {
@@ -1250,12 +1147,12 @@ func getEgressUserRulesForNode(targetnode *models.Node,
 	return rules
 }
 
-func getUserAclRulesForNode(targetnode *models.Node,
+func getUserAclRulesForNode(targetnode *models.Node, policy *networkPolicy,
 	rules map[string]models.AclRule) map[string]models.AclRule {
 	userNodes := GetStaticUserNodesByNetwork(models.NetworkID(targetnode.Network))
 	userGrpMap := GetUserGrpMap()
 	allowedUsers := make(map[string][]models.Acl)
-	acls := listUserPolicies(models.NetworkID(targetnode.Network))
+	acls := policy.userPolicies
 	var targetNodeTags = make(map[models.TagID]struct{})
 	if targetnode.Mutex != nil {
 		targetnode.Mutex.Lock()
@@ -1269,7 +1166,7 @@ func getUserAclRulesForNode(targetnode *models.Node,
 	}
 	targetNodeTags[models.TagID(targetnode.ID.String())] = struct{}{}
 	for _, acl := range acls {
-		if !acl.Enabled {
+		if !isAclActive(acl) {
 			continue
 		}
 		dstTags := convAclTagToValueMap(acl.Dst)
@@ -1314,7 +1211,7 @@ func getUserAclRulesForNode(targetnode *models.Node,
 		}
 		for _, acl := range acls {
 
-			if !acl.Enabled {
+			if !isAclActive(acl) {
 				continue
 			}
 			r := models.AclRule{
@@ -1322,7 +1219,8 @@ func getUserAclRulesForNode(targetnode *models.Node,
 				AllowedProtocol: acl.Proto,
 				AllowedPorts:    acl.Port,
 				Direction:       acl.AllowedDirection,
-				Allowed:         true,
+				Allowed:         !acl.IsDeny(),
+				Priority:        aclRulePriority(acl),
 			}
 			// Get peers in the tags and add allowed rules
 			if userNode.StaticNode.Address != "" {
@@ -1366,7 +1264,7 @@ func checkIfAnyActiveEgressPolicy(targetNode models.Node) bool {
 	targetNodeTags["*"] = struct{}{}
 	acls, _ := ListAclsByNetwork(models.NetworkID(targetNode.Network))
 	for _, acl := range acls {
-		if !acl.Enabled {
+		if !isAclActive(acl) {
 			continue
 		}
 		srcTags := convAclTagToValueMap(acl.Src)
@@ -1408,7 +1306,7 @@ func checkIfAnyPolicyisUniDirectional(targetNode models.Node) bool {
 	targetNodeTags["*"] = struct{}{}
 	acls, _ := ListAclsByNetwork(models.NetworkID(targetNode.Network))
 	for _, acl := range acls {
-		if !acl.Enabled {
+		if !isAclActive(acl) {
 			continue
 		}
 		if acl.AllowedDirection == models.TrafficDirectionBi && acl.Proto == models.ALL && acl.ServiceType == models.Any {
@@ -1441,21 +1339,25 @@ func checkIfAnyPolicyisUniDirectional(targetNode models.Node) bool {
 }
 
 func GetAclRulesForNode(targetnodeI *models.Node) (rules map[string]models.AclRule) {
+	return getAclRulesForNode(targetnodeI, getNetworkPolicy(models.NetworkID(targetnodeI.Network)))
+}
+
+// getAclRulesForNode - builds the acl rules of a node from the given compiled network policy
+func getAclRulesForNode(targetnodeI *models.Node, policy *networkPolicy) (rules map[string]models.AclRule) {
 	targetnode := *targetnodeI
 	defer func() {
 		if !targetnode.IsIngressGateway {
-			rules = getUserAclRulesForNode(&targetnode, rules)
+			rules = getUserAclRulesForNode(&targetnode, policy, rules)
 		}
 	}()
 	rules = make(map[string]models.AclRule)
 	var taggedNodes map[models.TagID][]models.Node
 	if targetnode.IsIngressGateway {
-		taggedNodes = GetTagMapWithNodesByNetwork(models.NetworkID(targetnode.Network), false)
+		taggedNodes = getNetworkTagMap(models.NetworkID(targetnode.Network), false)
 	} else {
-		taggedNodes = GetTagMapWithNodesByNetwork(models.NetworkID(targetnode.Network), true)
+		taggedNodes = getNetworkTagMap(models.NetworkID(targetnode.Network), true)
 	}
 
-	acls := listDevicePolicies(models.NetworkID(targetnode.Network))
 	var targetNodeTags = make(map[models.TagID]struct{})
 	if targetnode.Mutex != nil {
 		targetnode.Mutex.Lock()
@@ -1469,20 +1371,17 @@ func GetAclRulesForNode(targetnodeI *models.Node) (rules map[string]models.AclRu
 	}
 	targetNodeTags[models.TagID(targetnode.ID.String())] = struct{}{}
 	targetNodeTags["*"] = struct{}{}
-	for _, acl := range acls {
-		if !acl.Enabled {
-			continue
-		}
-		srcTags := convAclTagToValueMap(acl.Src)
-		dstTags := convAclTagToValueMap(acl.Dst)
-		_, srcAll := srcTags["*"]
-		_, dstAll := dstTags["*"]
+	for _, compiled := range policy.devicePolicies {
+		acl := compiled.acl
+		srcTags, dstTags := compiled.src, compiled.dst
+		srcAll, dstAll := compiled.srcAll, compiled.dstAll
 		aclRule := models.AclRule{
 			ID:              acl.ID,
 			AllowedProtocol: acl.Proto,
 			AllowedPorts:    acl.Port,
 			Direction:       acl.AllowedDirection,
-			Allowed:         true,
+			Allowed:         !acl.IsDeny(),
+			Priority:        aclRulePriority(acl),
 		}
 		for nodeTag := range targetNodeTags {
 			if acl.AllowedDirection == models.TrafficDirectionBi {
@@ -1510,7 +1409,7 @@ func GetAclRulesForNode(targetnodeI *models.Node) (rules map[string]models.AclRu
 						}
 						// Get peers in the tags and add allowed rules
 						nodes := taggedNodes[models.TagID(dst)]
-						if dst != targetnode.ID.String() {
+						if _, ok := compiled.devices[dst]; ok && dst != targetnode.ID.String() {
 							node, err := GetNodeByID(dst)
 							if err == nil {
 								nodes = append(nodes, node)
@@ -1547,7 +1446,7 @@ func GetAclRulesForNode(targetnodeI *models.Node) (rules map[string]models.AclRu
 						}
 						// Get peers in the tags and add allowed rules
 						nodes := taggedNodes[models.TagID(src)]
-						if src != targetnode.ID.String() {
+						if _, ok := compiled.devices[src]; ok && src != targetnode.ID.String() {
 							node, err := GetNodeByID(src)
 							if err == nil {
 								nodes = append(nodes, node)
@@ -1578,7 +1477,7 @@ func GetAclRulesForNode(targetnodeI *models.Node) (rules map[string]models.AclRu
 				if existsInDstTag && existsInSrcTag {
 					nodes := taggedNodes[nodeTag]
 					for srcID := range srcTags {
-						if srcID == targetnode.ID.String() {
+						if _, ok := compiled.devices[srcID]; !ok || srcID == targetnode.ID.String() {
 							continue
 						}
 						node, err := GetNodeByID(srcID)
@@ -1587,7 +1486,7 @@ func GetAclRulesForNode(targetnodeI *models.Node) (rules map[string]models.AclRu
 						}
 					}
 					for dstID := range dstTags {
-						if dstID == targetnode.ID.String() {
+						if _, ok := compiled.devices[dstID]; !ok || dstID == targetnode.ID.String() {
 							continue
 						}
 						node, err := GetNodeByID(dstID)
@@ -1662,13 +1561,17 @@ func GetAclRulesForNode(targetnodeI *models.Node) (rules map[string]models.AclRu
 }
 
 func GetEgressRulesForNode(targetnode models.Node) (rules map[string]models.AclRule) {
+	return getEgressRulesForNode(targetnode, getNetworkPolicy(models.NetworkID(targetnode.Network)))
+}
+
+// getEgressRulesForNode - builds the egress rules of a node from the given compiled network policy
+func getEgressRulesForNode(targetnode models.Node, policy *networkPolicy) (rules map[string]models.AclRule) {
 	rules = make(map[string]models.AclRule)
 	defer func() {
-		rules = getEgressUserRulesForNode(&targetnode, rules)
+		rules = getEgressUserRulesForNode(&targetnode, policy, rules)
 	}()
-	taggedNodes := GetTagMapWithNodesByNetwork(models.NetworkID(targetnode.Network), true)
+	taggedNodes := getNetworkTagMap(models.NetworkID(targetnode.Network), true)
 
-	acls := listDevicePolicies(models.NetworkID(targetnode.Network))
 	var targetNodeTags = make(map[models.TagID]struct{})
 	targetNodeTags["*"] = struct{}{}
 
@@ -1681,21 +1584,18 @@ func GetEgressRulesForNode(targetnode models.Node) (rules map[string]models.AclR
 	for _, rangeI := range targetnode.EgressGatewayRanges {
 		targetNodeTags[models.TagID(rangeI)] = struct{}{}
 	}
-	for _, acl := range acls {
-		if !acl.Enabled {
-			continue
-		}
-		srcTags := convAclTagToValueMap(acl.Src)
-		dstTags := convAclTagToValueMap(acl.Dst)
-		_, srcAll := srcTags["*"]
-		_, dstAll := dstTags["*"]
+	for _, compiled := range policy.devicePolicies {
+		acl := compiled.acl
+		srcTags, dstTags := compiled.src, compiled.dst
+		srcAll, dstAll := compiled.srcAll, compiled.dstAll
 		for nodeTag := range targetNodeTags {
 			aclRule := models.AclRule{
 				ID:              acl.ID,
 				AllowedProtocol: acl.Proto,
 				AllowedPorts:    acl.Port,
 				Direction:       acl.AllowedDirection,
-				Allowed:         true,
+				Allowed:         !acl.IsDeny(),
+				Priority:        aclRulePriority(acl),
 			}
 			if nodeTag != "*" {
 				ip, cidr, err := net.ParseCIDR(nodeTag.String())
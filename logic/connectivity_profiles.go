@@ -0,0 +1,446 @@
+package logic
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"sync"
+
+	"github.com/gravitl/netmaker/database"
+	"github.com/gravitl/netmaker/models"
+)
+
+var (
+	connectivityProfileMutex = &sync.RWMutex{}
+	networkConnectivityMutex = &sync.RWMutex{}
+	networkConnectivity      = make(map[models.NetworkID]*networkConnectivitySnapshot)
+	networkConnectivityGen   uint64
+)
+
+// networkConnectivitySnapshot - the connectivity profiles of a network and the tags that attach them
+type networkConnectivitySnapshot struct {
+	tags     map[models.TagID]models.Tag
+	profiles map[string]models.ConnectivityProfile
+}
+
+// GetConnectivityProfile - fetches a connectivity profile
+func GetConnectivityProfile(id string) (models.ConnectivityProfile, error) {
+	data, err := database.FetchRecord(database.CONNECTIVITY_PROFILES_TABLE_NAME, id)
+	if err != nil {
+		return models.ConnectivityProfile{}, err
+	}
+	profile := models.ConnectivityProfile{}
+	err = json.Unmarshal([]byte(data), &profile)
+	return profile, err
+}
+
+// UpsertConnectivityProfile - creates or updates a connectivity profile
+func UpsertConnectivityProfile(profile models.ConnectivityProfile) error {
+	connectivityProfileMutex.Lock()
+	defer connectivityProfileMutex.Unlock()
+	d, err := json.Marshal(profile)
+	if err != nil {
+		return err
+	}
+	defer InvalidateNetworkConnectivity(profile.Network)
+	return database.Insert(profile.ID, string(d), database.CONNECTIVITY_PROFILES_TABLE_NAME)
+}
+
+// DeleteConnectivityProfile - deletes a connectivity profile, fails if a tag still uses it
+func DeleteConnectivityProfile(profile models.ConnectivityProfile) error {
+	tags, err := ListNetworkTags(profile.Network)
+	if err != nil {
+		return err
+	}
+	for _, tag := range tags {
+		if tag.ProfileID == profile.ID {
+			return fmt.Errorf("profile is in use by tag %s", tag.TagName)
+		}
+	}
+	connectivityProfileMutex.Lock()
+	defer connectivityProfileMutex.Unlock()
+	defer InvalidateNetworkConnectivity(profile.Network)
+	return database.DeleteRecord(database.CONNECTIVITY_PROFILES_TABLE_NAME, profile.ID)
+}
+
+// ListConnectivityProfiles - lists all connectivity profiles
+func ListConnectivityProfiles() ([]models.ConnectivityProfile, error) {
+	connectivityProfileMutex.RLock()
+	defer connectivityProfileMutex.RUnlock()
+	data, err := database.FetchRecords(database.CONNECTIVITY_PROFILES_TABLE_NAME)
+	if err != nil && !database.IsEmptyRecord(err) {
+		return []models.ConnectivityProfile{}, err
+	}
+	profiles := []models.ConnectivityProfile{}
+	for _, dataI := range data {
+		profile := models.ConnectivityProfile{}
+		if err := json.Unmarshal([]byte(dataI), &profile); err != nil {
+			continue
+		}
+		profiles = append(profiles, profile)
+	}
+	return profiles, nil
+}
+
+// ListNetworkConnectivityProfiles - lists connectivity profiles of a network
+func ListNetworkConnectivityProfiles(netID models.NetworkID) ([]models.ConnectivityProfile, error) {
+	profiles, err := ListConnectivityProfiles()
+	if err != nil {
+		return profiles, err
+	}
+	networkProfiles := []models.ConnectivityProfile{}
+	for _, profile := range profiles {
+		if profile.Network == netID {
+			networkProfiles = append(networkProfiles, profile)
+		}
+	}
+	sortConnectivityProfiles(networkProfiles)
+	return networkProfiles, nil
+}
+
+// DeleteNetworkConnectivityProfiles - deletes all connectivity profiles of a network
+func DeleteNetworkConnectivityProfiles(netID models.NetworkID) {
+	profiles, _ := ListNetworkConnectivityProfiles(netID)
+	connectivityProfileMutex.Lock()
+	defer connectivityProfileMutex.Unlock()
+	defer InvalidateNetworkConnectivity(netID)
+	for _, profile := range profiles {
+		database.DeleteRecord(database.CONNECTIVITY_PROFILES_TABLE_NAME, profile.ID)
+	}
+}
+
+// ValidateConnectivityProfile - validates the fields of a connectivity profile
+func ValidateConnectivityProfile(profile models.ConnectivityProfile) error {
+	if err := CheckIDSyntax(profile.Name); err != nil {
+		return err
+	}
+	if _, err := GetNetwork(profile.Network.String()); err != nil {
+		return fmt.Errorf("failed to get network details for %s", profile.Network)
+	}
+	if profile.MTU != 0 && (profile.MTU < 576 || profile.MTU > 9000) {
+		return errors.New("mtu should be between 576 and 9000")
+	}
+	if profile.PersistentKeepalive < 0 || profile.PersistentKeepalive > 1000 {
+		return errors.New("persistent keepalive should be between 0 and 1000 seconds")
+	}
+	if profile.ListenPort != 0 && (profile.ListenPort < 1024 || profile.ListenPort > 65535) {
+		return errors.New("listen port should be between 1024 and 65535")
+	}
+	profiles, err := ListNetworkConnectivityProfiles(profile.Network)
+	if err != nil {
+		return err
+	}
+	for _, profileI := range profiles {
+		if profileI.Name == profile.Name && profileI.ID != profile.ID {
+			return fmt.Errorf("profile with name %s exists already", profile.Name)
+		}
+	}
+	return nil
+}
+
+// ValidateTagProfile - checks that a profile can be attached to a tag of the network
+func ValidateTagProfile(profileID string, netID models.NetworkID) error {
+	if profileID == "" {
+		return nil
+	}
+	profile, err := GetConnectivityProfile(profileID)
+	if err != nil {
+		return fmt.Errorf("connectivity profile %s not found", profileID)
+	}
+	if profile.Network != netID {
+		return errors.New("connectivity profile belongs to a different network")
+	}
+	return nil
+}
+
+// sortConnectivityProfiles - sorts profiles from lowest to highest precedence,
+// a higher priority wins and ties go to the alphabetically first name
+func sortConnectivityProfiles(profiles []models.ConnectivityProfile) {
+	sort.Slice(profiles, func(i, j int) bool {
+		if profiles[i].Priority != profiles[j].Priority {
+			return profiles[i].Priority < profiles[j].Priority
+		}
+		return profiles[i].Name > profiles[j].Name
+	})
+}
+
+// overlayConnectivityProfiles - applies profiles in order on top of the given settings,
+// only fields set on a profile override the previous value
+func overlayConnectivityProfiles(settings models.ConnectivitySettings,
+	profiles []models.ConnectivityProfile) models.ConnectivitySettings {
+	for _, profile := range profiles {
+		if profile.MTU != 0 {
+			settings.MTU = profile.MTU
+		}
+		if profile.PersistentKeepalive != 0 {
+			settings.PersistentKeepalive = profile.PersistentKeepalive
+		}
+		if profile.ListenPort != 0 {
+			settings.ListenPort = profile.ListenPort
+		}
+		settings.ProfileIDs = append(settings.ProfileIDs, profile.ID)
+	}
+	return settings
+}
+
+// getNetworkConnectivity - fetches the connectivity profiles of a network and the tags attaching them,
+// loading them if needed
+func getNetworkConnectivity(netID models.NetworkID) *networkConnectivitySnapshot {
+	networkConnectivityMutex.RLock()
+	c, ok := networkConnectivity[netID]
+	gen := networkConnectivityGen
+	networkConnectivityMutex.RUnlock()
+	if ok {
+		return c
+	}
+	c = &networkConnectivitySnapshot{
+		tags:     make(map[models.TagID]models.Tag),
+		profiles: make(map[string]models.ConnectivityProfile),
+	}
+	profiles, _ := ListNetworkConnectivityProfiles(netID)
+	for _, profile := range profiles {
+		c.profiles[profile.ID] = profile
+	}
+	if len(profiles) > 0 {
+		tags, _ := ListNetworkTags(netID)
+		for _, tag := range tags {
+			if tag.ProfileID != "" {
+				c.tags[tag.ID] = tag
+			}
+		}
+	}
+	networkConnectivityMutex.Lock()
+	defer networkConnectivityMutex.Unlock()
+	// profiles or tags changed while loading, use the result once but do not keep it
+	if gen == networkConnectivityGen {
+		networkConnectivity[netID] = c
+	}
+	return c
+}
+
+// InvalidateNetworkConnectivity - drops the loaded connectivity profiles of a network after
+// a profile or tag changed
+func InvalidateNetworkConnectivity(netID models.NetworkID) {
+	networkConnectivityMutex.Lock()
+	defer networkConnectivityMutex.Unlock()
+	delete(networkConnectivity, netID)
+	networkConnectivityGen++
+}
+
+// connectivityResolver - resolves connectivity profiles of nodes from a single snapshot of tags and profiles
+type connectivityResolver struct {
+	tags     map[models.TagID]models.Tag
+	profiles map[string]models.ConnectivityProfile
+	cache    map[string][]models.ConnectivityProfile
+	// networks - the networks whose snapshot was merged in, nil when the resolver is not loaded lazily
+	networks map[string]struct{}
+}
+
+func newConnectivityResolver() *connectivityResolver {
+	return &connectivityResolver{
+		tags:     make(map[models.TagID]models.Tag),
+		profiles: make(map[string]models.ConnectivityProfile),
+		cache:    make(map[string][]models.ConnectivityProfile),
+		networks: make(map[string]struct{}),
+	}
+}
+
+// loadNetwork - merges the cached connectivity snapshot of a network into the resolver
+func (r *connectivityResolver) loadNetwork(network string) {
+	if r.networks == nil {
+		return
+	}
+	if _, ok := r.networks[network]; ok {
+		return
+	}
+	r.networks[network] = struct{}{}
+	c := getNetworkConnectivity(models.NetworkID(network))
+	for id, tag := range c.tags {
+		r.tags[id] = tag
+	}
+	for id, profile := range c.profiles {
+		r.profiles[id] = profile
+	}
+}
+
+// nodeProfiles - profiles attached to the node's tags, lowest precedence first
+func (r *connectivityResolver) nodeProfiles(node *models.Node) []models.ConnectivityProfile {
+	r.loadNetwork(node.Network)
+	if len(r.tags) == 0 {
+		return nil
+	}
+	if profiles, ok := r.cache[node.ID.String()]; ok {
+		return profiles
+	}
+	profiles := []models.ConnectivityProfile{}
+	seen := make(map[string]struct{})
+	for tagID := range node.Tags {
+		tag, ok := r.tags[tagID]
+		if !ok {
+			continue
+		}
+		profile, ok := r.profiles[tag.ProfileID]
+		if !ok || profile.Network.String() != node.Network {
+			continue
+		}
+		if _, ok := seen[profile.ID]; ok {
+			continue
+		}
+		seen[profile.ID] = struct{}{}
+		profiles = append(profiles, profile)
+	}
+	sortConnectivityProfiles(profiles)
+	r.cache[node.ID.String()] = profiles
+	return profiles
+}
+
+// peerKeepalive - keepalive to use towards a peer, when both ends have a profile keepalive
+// the shorter interval wins so that the more constrained side keeps its NAT mapping open
+func (r *connectivityResolver) peerKeepalive(node, peer *models.Node) int32 {
+	nodeKeepalive := overlayConnectivityProfiles(models.ConnectivitySettings{}, r.nodeProfiles(node)).PersistentKeepalive
+	peerKeepalive := overlayConnectivityProfiles(models.ConnectivitySettings{}, r.nodeProfiles(peer)).PersistentKeepalive
+	if nodeKeepalive == 0 || (peerKeepalive != 0 && peerKeepalive < nodeKeepalive) {
+		return peerKeepalive
+	}
+	return nodeKeepalive
+}
+
+// hostSettings - host level settings resolved from the profiles of all the host's nodes,
+// profiles are applied in order of precedence across networks, except for MTU and keepalive
+// where the smallest value set by any profile is used since the interface is shared
+func (r *connectivityResolver) hostSettings(nodes []models.Node) models.ConnectivitySettings {
+	profiles := []models.ConnectivityProfile{}
+	var mtu, keepalive int32
+	for i := range nodes {
+		for _, profile := range r.nodeProfiles(&nodes[i]) {
+			profiles = append(profiles, profile)
+			if profile.MTU != 0 && (mtu == 0 || profile.MTU < mtu) {
+				mtu = profile.MTU
+			}
+			if profile.PersistentKeepalive != 0 && (keepalive == 0 || profile.PersistentKeepalive < keepalive) {
+				keepalive = profile.PersistentKeepalive
+			}
+		}
+	}
+	sortConnectivityProfiles(profiles)
+	settings := overlayConnectivityProfiles(models.ConnectivitySettings{}, profiles)
+	settings.MTU = mtu
+	settings.PersistentKeepalive = keepalive
+	return settings
+}
+
+// ResolveNodeConnectivity - resolves the effective connectivity settings of a node.
+// Precedence, lowest to highest:
+//  1. network defaults (DefaultMTU, DefaultKeepalive, DefaultListenPort)
+//  2. profiles attached to the node's tags, in ascending priority, ties go to the alphabetically first name
+//
+// A profile only overrides the fields it sets. Ingress gateway settings (IngressMTU,
+// IngressPersistentKeepalive) keep applying to the configs of the gateway's ext clients.
+func ResolveNodeConnectivity(node *models.Node) (models.ConnectivitySettings, error) {
+	network, err := GetNetwork(node.Network)
+	if err != nil {
+		return models.ConnectivitySettings{}, err
+	}
+	settings := models.ConnectivitySettings{
+		MTU:                 network.DefaultMTU,
+		PersistentKeepalive: network.DefaultKeepalive,
+		ListenPort:          network.DefaultListenPort,
+	}
+	return overlayConnectivityProfiles(settings, newConnectivityResolver().nodeProfiles(node)), nil
+}
+
+// ApplyNetworkConnectivity - applies the connectivity profiles of a network to the MTU and listen
+// port of its hosts, to be called when a profile or the tags attaching it change. Returns the hosts
+// that changed and need a host update
+func ApplyNetworkConnectivity(netID models.NetworkID) []models.Host {
+	nodes, err := GetNetworkNodes(netID.String())
+	if err != nil {
+		return nil
+	}
+	resolver := newConnectivityResolver()
+	changed := []models.Host{}
+	seen := make(map[string]struct{})
+	for _, node := range nodes {
+		if _, ok := seen[node.HostID.String()]; ok {
+			continue
+		}
+		seen[node.HostID.String()] = struct{}{}
+		host, err := GetHost(node.HostID.String())
+		if err != nil {
+			continue
+		}
+		if applyHostConnectivityProfiles(host, resolver) {
+			changed = append(changed, *host)
+		}
+	}
+	return changed
+}
+
+// ApplyHostConnectivityProfiles - applies the connectivity profiles of all the host's nodes to
+// its MTU and listen port and saves the host. Returns true if the host changed and needs a host update
+func ApplyHostConnectivityProfiles(host *models.Host) bool {
+	return applyHostConnectivityProfiles(host, newConnectivityResolver())
+}
+
+func applyHostConnectivityProfiles(host *models.Host, resolver *connectivityResolver) bool {
+	nodes := []models.Node{}
+	for _, nodeID := range host.Nodes {
+		if node, err := GetNodeByID(nodeID); err == nil {
+			nodes = append(nodes, node)
+		}
+	}
+	settings := resolver.hostSettings(nodes)
+	if !ApplyHostConnectivity(host, &settings) {
+		return false
+	}
+	if err := UpsertHost(host); err != nil {
+		slog.Error("failed to apply connectivity profiles to host", "host", host.ID, "error", err)
+		return false
+	}
+	return true
+}
+
+// CheckHostConnectivityEdit - rejects changing the MTU or listen port of a host while a
+// connectivity profile sets it, the edit would be replaced on the next profile change
+func CheckHostConnectivityEdit(newHost, currHost *models.Host) error {
+	if currHost.ProfileBaseMTU != nil && newHost.MTU != currHost.MTU {
+		return errors.New("mtu is set by a connectivity profile of the host's tags, change the profile instead")
+	}
+	if currHost.ProfileBaseListenPort != nil && newHost.ListenPort != currHost.ListenPort {
+		return errors.New("listen port is set by a connectivity profile of the host's tags, change the profile instead")
+	}
+	return nil
+}
+
+// ApplyHostConnectivity - sets the MTU and listen port of a host to the ones resolved from its
+// connectivity profiles, keeping the values the host had so they can be restored once no profile
+// sets them. Returns true if the host changed and needs a host update
+func ApplyHostConnectivity(host *models.Host, settings *models.ConnectivitySettings) bool {
+	if settings == nil {
+		settings = &models.ConnectivitySettings{}
+	}
+	changed := false
+	apply := func(current *int, base **int, want int32) {
+		switch {
+		case want != 0:
+			if *base == nil {
+				original := *current
+				*base = &original
+				changed = true
+			}
+			if *current != int(want) {
+				*current = int(want)
+				changed = true
+			}
+		case *base != nil:
+			*current = **base
+			*base = nil
+			changed = true
+		}
+	}
+	apply(&host.MTU, &host.ProfileBaseMTU, settings.MTU)
+	apply(&host.ListenPort, &host.ProfileBaseListenPort, settings.ListenPort)
+	return changed
+}
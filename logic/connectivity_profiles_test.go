@@ -0,0 +1,138 @@
+package logic
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/gravitl/netmaker/database"
+	"github.com/gravitl/netmaker/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConnectivityProfilePrecedence(t *testing.T) {
+	mobile := models.ConnectivityProfile{ID: "mobile", Name: "mobile", Network: "skynet", MTU: 1280, PersistentKeepalive: 10, Priority: 1}
+	satellite := models.ConnectivityProfile{ID: "satellite", Name: "satellite", Network: "skynet", MTU: 1200, ListenPort: 51830, Priority: 2}
+	dc := models.ConnectivityProfile{ID: "dc", Name: "dc", Network: "skynet", PersistentKeepalive: 60, Priority: 1}
+	r := &connectivityResolver{
+		tags: map[models.TagID]models.Tag{
+			"skynet.mobile":    {ID: "skynet.mobile", ProfileID: mobile.ID},
+			"skynet.satellite": {ID: "skynet.satellite", ProfileID: satellite.ID},
+			"skynet.dc":        {ID: "skynet.dc", ProfileID: dc.ID},
+		},
+		profiles: map[string]models.ConnectivityProfile{
+			mobile.ID:    mobile,
+			satellite.ID: satellite,
+			dc.ID:        dc,
+		},
+		cache: make(map[string][]models.ConnectivityProfile),
+	}
+	newNode := func(tags ...models.TagID) models.Node {
+		node := models.Node{Tags: make(map[models.TagID]struct{})}
+		node.ID = uuid.New()
+		node.Network = "skynet"
+		for _, tag := range tags {
+			node.Tags[tag] = struct{}{}
+		}
+		return node
+	}
+	t.Run("HigherPriorityWins", func(t *testing.T) {
+		node := newNode("skynet.mobile", "skynet.satellite")
+		settings := overlayConnectivityProfiles(models.ConnectivitySettings{MTU: 1420}, r.nodeProfiles(&node))
+		assert.Equal(t, int32(1200), settings.MTU)
+		assert.Equal(t, int32(10), settings.PersistentKeepalive)
+		assert.Equal(t, int32(51830), settings.ListenPort)
+		assert.Equal(t, []string{"mobile", "satellite"}, settings.ProfileIDs)
+	})
+	t.Run("UnsetFieldsKeepDefaults", func(t *testing.T) {
+		node := newNode()
+		settings := overlayConnectivityProfiles(models.ConnectivitySettings{MTU: 1420, PersistentKeepalive: 20}, r.nodeProfiles(&node))
+		assert.Equal(t, int32(1420), settings.MTU)
+		assert.Equal(t, int32(20), settings.PersistentKeepalive)
+	})
+	t.Run("ShorterPeerKeepaliveWins", func(t *testing.T) {
+		node := newNode("skynet.dc")
+		peer := newNode("skynet.mobile")
+		assert.Equal(t, int32(10), r.peerKeepalive(&node, &peer))
+		assert.Equal(t, int32(10), r.peerKeepalive(&peer, &node))
+		untagged := newNode()
+		assert.Equal(t, int32(60), r.peerKeepalive(&untagged, &node))
+		assert.Equal(t, int32(0), r.peerKeepalive(&untagged, &untagged))
+	})
+	t.Run("HostUsesSmallestMTU", func(t *testing.T) {
+		nodes := []models.Node{newNode("skynet.mobile"), newNode("skynet.satellite", "skynet.dc")}
+		settings := r.hostSettings(nodes)
+		assert.Equal(t, int32(1200), settings.MTU)
+		assert.Equal(t, int32(10), settings.PersistentKeepalive)
+		assert.Equal(t, int32(51830), settings.ListenPort)
+	})
+}
+
+func TestApplyHostConnectivity(t *testing.T) {
+	host := models.Host{MTU: 1420, ListenPort: 51821}
+	settings := &models.ConnectivitySettings{MTU: 1280, ProfileIDs: []string{"mobile"}}
+	assert.True(t, ApplyHostConnectivity(&host, settings))
+	assert.Equal(t, 1280, host.MTU)
+	assert.Equal(t, 51821, host.ListenPort)
+	assert.False(t, ApplyHostConnectivity(&host, settings), "applying the same settings changes nothing")
+
+	settings.ListenPort = 51830
+	assert.True(t, ApplyHostConnectivity(&host, settings))
+	assert.Equal(t, 51830, host.ListenPort)
+
+	// once the profiles are gone the host gets its own settings back
+	assert.True(t, ApplyHostConnectivity(&host, nil))
+	assert.Equal(t, 1420, host.MTU)
+	assert.Equal(t, 51821, host.ListenPort)
+	assert.Nil(t, host.ProfileBaseMTU)
+	assert.False(t, ApplyHostConnectivity(&host, nil))
+}
+
+func TestApplyNetworkConnectivity(t *testing.T) {
+	database.InitializeDatabase()
+	defer database.CloseDB()
+	netID := models.NetworkID("connnet")
+	profile := models.ConnectivityProfile{ID: uuid.NewString(), Name: "satellite", Network: netID, MTU: 1280}
+	assert.Nil(t, UpsertConnectivityProfile(profile))
+	defer DeleteNetworkConnectivityProfiles(netID)
+	tag := models.Tag{ID: "connnet.satellite", TagName: "satellite", Network: netID, ProfileID: profile.ID}
+	assert.Nil(t, InsertTag(tag))
+	defer DeleteTag(tag.ID, false)
+	host := models.Host{ID: uuid.New(), Name: "uplink", MTU: 1420, ListenPort: 51821}
+	node := models.Node{CommonNode: models.CommonNode{ID: uuid.New(), HostID: host.ID, Network: netID.String()},
+		Tags: map[models.TagID]struct{}{tag.ID: {}}}
+	host.Nodes = []string{node.ID.String()}
+	assert.Nil(t, UpsertHost(&host))
+	defer RemoveHost(&host, true)
+	assert.Nil(t, UpsertNode(&node))
+	defer DeleteNodeByID(&node)
+
+	changed := ApplyNetworkConnectivity(netID)
+	assert.Len(t, changed, 1)
+	assert.Equal(t, 1280, changed[0].MTU)
+	assert.Empty(t, ApplyNetworkConnectivity(netID), "applying again changes nothing")
+
+	t.Run("ProfileChange", func(t *testing.T) {
+		profile.MTU = 1200
+		assert.Nil(t, UpsertConnectivityProfile(profile))
+		changed := ApplyNetworkConnectivity(netID)
+		assert.Len(t, changed, 1)
+		assert.Equal(t, 1200, changed[0].MTU)
+	})
+	t.Run("UserEdit", func(t *testing.T) {
+		current, err := GetHost(host.ID.String())
+		assert.Nil(t, err)
+		edited := *current
+		edited.MTU = 1400
+		assert.NotNil(t, CheckHostConnectivityEdit(&edited, current))
+		edited.MTU = current.MTU
+		assert.Nil(t, CheckHostConnectivityEdit(&edited, current))
+	})
+	t.Run("ProfileDetached", func(t *testing.T) {
+		tag.ProfileID = ""
+		assert.Nil(t, UpsertTag(tag))
+		changed := ApplyNetworkConnectivity(netID)
+		assert.Len(t, changed, 1)
+		assert.Equal(t, 1420, changed[0].MTU)
+		assert.Nil(t, changed[0].ProfileBaseMTU)
+	})
+}
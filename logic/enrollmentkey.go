@@ -38,7 +38,10 @@ var (
 )
 
 // CreateEnrollmentKey - creates a new enrollment key in db
-func CreateEnrollmentKey(uses int, expiration time.Time, networks, tags []string, groups []models.TagID, unlimited bool, relay uuid.UUID, defaultKey bool) (*models.EnrollmentKey, error) {
+func CreateEnrollmentKey(uses int, expiration time.Time, networks, tags []string, groups []models.TagID, labels map[string]string, unlimited bool, relay uuid.UUID, defaultKey bool) (*models.EnrollmentKey, error) {
+	if err := ValidateHostLabels(labels); err != nil {
+		return nil, err
+	}
 	newKeyID, err := getUniqueEnrollmentID()
 	if err != nil {
 		return nil, err
@@ -54,6 +57,7 @@ func CreateEnrollmentKey(uses int, expiration time.Time, networks, tags []string
 		Relay:         relay,
 		Groups:        groups,
 		Default:       defaultKey,
+		Labels:        labels,
 	}
 	if uses > 0 {
 		k.UsesRemaining = uses
@@ -92,11 +96,14 @@ func CreateEnrollmentKey(uses int, expiration time.Time, networks, tags []string
 }
 
 // UpdateEnrollmentKey - updates an existing enrollment key's associated relay
-func UpdateEnrollmentKey(keyId string, relayId uuid.UUID, groups []models.TagID) (*models.EnrollmentKey, error) {
+func UpdateEnrollmentKey(keyId string, relayId uuid.UUID, groups []models.TagID, labels map[string]string) (*models.EnrollmentKey, error) {
 	key, err := GetEnrollmentKey(keyId)
 	if err != nil {
 		return nil, err
 	}
+	if err = ValidateHostLabels(labels); err != nil {
+		return nil, err
+	}
 
 	if relayId != uuid.Nil {
 		relayNode, err := GetNodeByID(relayId.String())
@@ -113,6 +120,7 @@ func UpdateEnrollmentKey(keyId string, relayId uuid.UUID, groups []models.TagID)
 
 	key.Relay = relayId
 	key.Groups = groups
+	key.Labels = labels
 	if err = upsertEnrollmentKey(&key); err != nil {
 		return nil, err
 	}
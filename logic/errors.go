@@ -23,6 +23,8 @@ func FormatError(err error, errType string) models.ErrorResponse {
 		status = http.StatusUnauthorized
 	case "forbidden":
 		status = http.StatusForbidden
+	case "locked":
+		status = http.StatusLocked
 	default:
 		status = http.StatusInternalServerError
 	}
@@ -106,6 +106,8 @@ func DeleteExtClient(network string, clientid string) error {
 	if err != nil {
 		return err
 	}
+	InvalidateNetworkTagMap(models.NetworkID(network))
+	InvalidateNetworkPeerModel(models.NetworkID(network))
 	if servercfg.CacheEnabled() {
 		// recycle ip address
 		if extClient.Address != "" {
@@ -342,6 +344,8 @@ func SaveExtClient(extclient *models.ExtClient) error {
 	if err = database.Insert(key, string(data), database.EXT_CLIENT_TABLE_NAME); err != nil {
 		return err
 	}
+	InvalidateNetworkTagMap(models.NetworkID(extclient.Network))
+	InvalidateNetworkPeerModel(models.NetworkID(extclient.Network))
 	if servercfg.CacheEnabled() {
 		storeExtClientInCache(key, *extclient)
 		if _, ok := allocatedIpMap[extclient.Network]; ok {
@@ -480,12 +484,13 @@ func GetStaticNodeIps(node models.Node) (ips []net.IP) {
 	defaultUserPolicy, _ := GetDefaultPolicy(models.NetworkID(node.Network), models.UserPolicy)
 	defaultDevicePolicy, _ := GetDefaultPolicy(models.NetworkID(node.Network), models.DevicePolicy)
 
+	denies := hasDenyPolicy(models.NetworkID(node.Network))
 	extclients := GetStaticNodesByNetwork(models.NetworkID(node.Network), false)
 	for _, extclient := range extclients {
-		if extclient.IsUserNode && defaultUserPolicy.Enabled {
+		if extclient.IsUserNode && defaultUserPolicy.Enabled && !denies {
 			continue
 		}
-		if !extclient.IsUserNode && defaultDevicePolicy.Enabled {
+		if !extclient.IsUserNode && defaultDevicePolicy.Enabled && !denies {
 			continue
 		}
 		if extclient.StaticNode.Address != "" {
@@ -514,7 +519,7 @@ func getFwRulesForNodeAndPeerOnGw(node, peer models.Node, allowedPolicies []mode
 				},
 				AllowedProtocol: policy.Proto,
 				AllowedPorts:    policy.Port,
-				Allow:           true,
+				Allow:           !policy.IsDeny(),
 			})
 		}
 
@@ -530,7 +535,7 @@ func getFwRulesForNodeAndPeerOnGw(node, peer models.Node, allowedPolicies []mode
 				},
 				AllowedProtocol: policy.Proto,
 				AllowedPorts:    policy.Port,
-				Allow:           true,
+				Allow:           !policy.IsDeny(),
 			})
 		}
 		if policy.AllowedDirection == models.TrafficDirectionBi {
@@ -546,7 +551,7 @@ func getFwRulesForNodeAndPeerOnGw(node, peer models.Node, allowedPolicies []mode
 					},
 					AllowedProtocol: policy.Proto,
 					AllowedPorts:    policy.Port,
-					Allow:           true,
+					Allow:           !policy.IsDeny(),
 				})
 			}
 
@@ -562,11 +567,12 @@ func getFwRulesForNodeAndPeerOnGw(node, peer models.Node, allowedPolicies []mode
 					},
 					AllowedProtocol: policy.Proto,
 					AllowedPorts:    policy.Port,
-					Allow:           true,
+					Allow:           !policy.IsDeny(),
 				})
 			}
 		}
-		if len(node.StaticNode.ExtraAllowedIPs) > 0 {
+		// extra allowed ips are not limited to the policy's ports, so denies leave them alone
+		if len(node.StaticNode.ExtraAllowedIPs) > 0 && !policy.IsDeny() {
 			for _, additionalAllowedIPNet := range node.StaticNode.ExtraAllowedIPs {
 				_, ipNet, err := net.ParseCIDR(additionalAllowedIPNet)
 				if err != nil {
@@ -595,7 +601,7 @@ func getFwRulesForNodeAndPeerOnGw(node, peer models.Node, allowedPolicies []mode
 			}
 
 		}
-		if len(peer.StaticNode.ExtraAllowedIPs) > 0 {
+		if len(peer.StaticNode.ExtraAllowedIPs) > 0 && !policy.IsDeny() {
 			for _, additionalAllowedIPNet := range peer.StaticNode.ExtraAllowedIPs {
 				_, ipNet, err := net.ParseCIDR(additionalAllowedIPNet)
 				if err != nil {
@@ -640,7 +646,7 @@ func getFwRulesForNodeAndPeerOnGw(node, peer models.Node, allowedPolicies []mode
 								DstIP:           *cidr,
 								AllowedProtocol: policy.Proto,
 								AllowedPorts:    policy.Port,
-								Allow:           true,
+								Allow:           !policy.IsDeny(),
 							})
 						}
 					} else {
@@ -653,7 +659,7 @@ func getFwRulesForNodeAndPeerOnGw(node, peer models.Node, allowedPolicies []mode
 								DstIP:           *cidr,
 								AllowedProtocol: policy.Proto,
 								AllowedPorts:    policy.Port,
-								Allow:           true,
+								Allow:           !policy.IsDeny(),
 							})
 						}
 					}
@@ -668,6 +674,7 @@ func getFwRulesForNodeAndPeerOnGw(node, peer models.Node, allowedPolicies []mode
 
 func getFwRulesForUserNodesOnGw(node models.Node, nodes []models.Node) (rules []models.FwRule) {
 	defaultUserPolicy, _ := GetDefaultPolicy(models.NetworkID(node.Network), models.UserPolicy)
+	userDeny := getNetworkPolicy(models.NetworkID(node.Network)).userDeny
 	userNodes := GetStaticUserNodesByNetwork(models.NetworkID(node.Network))
 	for _, userNodeI := range userNodes {
 		for _, peer := range nodes {
@@ -679,7 +686,7 @@ func getFwRulesForUserNodesOnGw(node models.Node, nodes []models.Node) (rules []
 				if peer.IsStatic {
 					peer = peer.StaticNode.ConvertToStaticNode()
 				}
-				if !defaultUserPolicy.Enabled {
+				if !defaultUserPolicy.Enabled || userDeny {
 					for _, policy := range allowedPolicies {
 						if userNodeI.StaticNode.Address != "" {
 							rules = append(rules, models.FwRule{
@@ -690,7 +697,7 @@ func getFwRulesForUserNodesOnGw(node models.Node, nodes []models.Node) (rules []
 								},
 								AllowedProtocol: policy.Proto,
 								AllowedPorts:    policy.Port,
-								Allow:           true,
+								Allow:           !policy.IsDeny(),
 							})
 						}
 						if userNodeI.StaticNode.Address6 != "" {
@@ -702,7 +709,7 @@ func getFwRulesForUserNodesOnGw(node models.Node, nodes []models.Node) (rules []
 								},
 								AllowedProtocol: policy.Proto,
 								AllowedPorts:    policy.Port,
-								Allow:           true,
+								Allow:           !policy.IsDeny(),
 							})
 						}
 
@@ -717,7 +724,7 @@ func getFwRulesForUserNodesOnGw(node models.Node, nodes []models.Node) (rules []
 											DstIP:           *cidr,
 											AllowedProtocol: policy.Proto,
 											AllowedPorts:    policy.Port,
-											Allow:           true,
+											Allow:           !policy.IsDeny(),
 										})
 									} else if ip.To16() != nil && userNodeI.StaticNode.Address6 != "" {
 										rules = append(rules, models.FwRule{
@@ -725,7 +732,7 @@ func getFwRulesForUserNodesOnGw(node models.Node, nodes []models.Node) (rules []
 											DstIP:           *cidr,
 											AllowedProtocol: policy.Proto,
 											AllowedPorts:    policy.Port,
-											Allow:           true,
+											Allow:           !policy.IsDeny(),
 										})
 									}
 								}
@@ -744,7 +751,8 @@ func getFwRulesForUserNodesOnGw(node models.Node, nodes []models.Node) (rules []
 func GetFwRulesOnIngressGateway(node models.Node) (rules []models.FwRule) {
 	// fetch user access to static clients via policies
 	defer func() {
-		sort.Slice(rules, func(i, j int) bool {
+		// stable, so deny rules stay ahead of the allow rules they restrict
+		sort.SliceStable(rules, func(i, j int) bool {
 			if !rules[i].SrcIP.IP.Equal(rules[j].SrcIP.IP) {
 				return string(rules[i].SrcIP.IP.To16()) < string(rules[j].SrcIP.IP.To16())
 			}
@@ -755,7 +763,7 @@ func GetFwRulesOnIngressGateway(node models.Node) (rules []models.FwRule) {
 	nodes, _ := GetNetworkNodes(node.Network)
 	nodes = append(nodes, GetStaticNodesByNetwork(models.NetworkID(node.Network), true)...)
 	rules = getFwRulesForUserNodesOnGw(node, nodes)
-	if defaultDevicePolicy.Enabled {
+	if defaultDevicePolicy.Enabled && !getNetworkPolicy(models.NetworkID(node.Network)).deviceDeny {
 		return
 	}
 	for _, nodeI := range nodes {
@@ -797,12 +805,14 @@ func GetUniquePolicies(policies1, policies2 []models.Acl) []models.Acl {
 	for _, policy1I := range policies1 {
 		policies1Map[policy1I.ID] = struct{}{}
 	}
-	for i := len(policies2) - 1; i >= 0; i-- {
-		if _, ok := policies1Map[policies2[i].ID]; ok {
-			policies2 = append(policies2[:i], policies2[i+1:]...)
+	// policies2 may be shared with the policy decision cache, so it is not filtered in place
+	unique := []models.Acl{}
+	for _, policy2I := range policies2 {
+		if _, ok := policies1Map[policy2I.ID]; !ok {
+			unique = append(unique, policy2I)
 		}
 	}
-	return policies2
+	return unique
 }
 
 func GetExtPeers(node, peer *models.Node) ([]wgtypes.PeerConfig, []models.IDandAddr, []models.EgressNetworkRoutes, error) {
@@ -0,0 +1,217 @@
+package logic
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/gravitl/netmaker/database"
+	"github.com/gravitl/netmaker/models"
+	"github.com/gravitl/netmaker/servercfg"
+	"golang.org/x/exp/slog"
+)
+
+// hostGenerationBlock - generations reserved per write, so issuing them does not write each time
+const hostGenerationBlock = 100
+
+var (
+	hostGenerationMutex = &sync.Mutex{}
+	hostGenerationCache = make(map[string]models.HostGeneration)
+	// hostGenerationFloor - the reserved generations found on load, some may have been issued
+	// before the restart so the next one issued is above them
+	hostGenerationFloor  = make(map[string]uint64)
+	hostGenerationLoaded bool
+)
+
+// loadHostGenerations - loads the generations from the DB into the cache, caller must hold the lock
+func loadHostGenerations() {
+	if hostGenerationLoaded {
+		return
+	}
+	records, err := database.FetchRecords(database.HOST_GENERATIONS_TABLE_NAME)
+	if err != nil && !database.IsEmptyRecord(err) {
+		slog.Error("failed to load host generations", "error", err)
+		return
+	}
+	for _, record := range records {
+		gen := models.HostGeneration{}
+		if err := json.Unmarshal([]byte(record), &gen); err != nil {
+			continue
+		}
+		hostGenerationCache[gen.HostID] = gen
+		if gen.Reserved > gen.Desired {
+			hostGenerationFloor[gen.HostID] = gen.Reserved
+		}
+	}
+	hostGenerationLoaded = true
+}
+
+// storeHostGeneration - caller must hold the lock, the cache stays authoritative
+// for the running server even if persisting fails
+func storeHostGeneration(gen models.HostGeneration) error {
+	hostGenerationCache[gen.HostID] = gen
+	d, err := json.Marshal(gen)
+	if err != nil {
+		return err
+	}
+	return database.Insert(gen.HostID, string(d), database.HOST_GENERATIONS_TABLE_NAME)
+}
+
+// NextHostGeneration - bumps and returns the desired state generation of a host,
+// to be stamped on every peer and host update sent to it. Generations are reserved in blocks
+// and only persisted when a block runs out or the host falls behind, after a restart the
+// server continues above the reserved block so generations never go backwards
+func NextHostGeneration(hostID string) uint64 {
+	hostGenerationMutex.Lock()
+	defer hostGenerationMutex.Unlock()
+	loadHostGenerations()
+	gen := hostGenerationCache[hostID]
+	gen.HostID = hostID
+	if floor, ok := hostGenerationFloor[hostID]; ok {
+		if floor > gen.Desired {
+			gen.Desired = floor
+		}
+		delete(hostGenerationFloor, hostID)
+	}
+	gen.Desired++
+	persist := false
+	if gen.Desired > gen.Reserved {
+		gen.Reserved = gen.Desired + hostGenerationBlock - 1
+		persist = true
+	}
+	if gen.BehindSince.IsZero() {
+		gen.BehindSince = time.Now().UTC()
+		persist = true
+	}
+	if !persist {
+		hostGenerationCache[hostID] = gen
+		return gen.Desired
+	}
+	if err := storeHostGeneration(gen); err != nil {
+		slog.Error("failed to store host generation", "host", hostID, "error", err)
+	}
+	return gen.Desired
+}
+
+// RecordAppliedGeneration - records the generation a host reports as applied,
+// a zero generation is sent by clients that do not track generations and is ignored
+func RecordAppliedGeneration(hostID string, applied uint64) {
+	if applied == 0 {
+		return
+	}
+	hostGenerationMutex.Lock()
+	defer hostGenerationMutex.Unlock()
+	loadHostGenerations()
+	gen := hostGenerationCache[hostID]
+	gen.HostID = hostID
+	now := time.Now().UTC()
+	if applied > gen.Applied {
+		gen.Applied = applied
+		// a host applying updates is catching up, it only drifts if it stops making progress
+		if !gen.BehindSince.IsZero() {
+			gen.BehindSince = now
+		}
+	}
+	// the server lost track of the generation, continue from the host's value
+	if gen.Applied > gen.Desired {
+		gen.Desired = gen.Applied
+	}
+	if gen.Desired > gen.Reserved {
+		gen.Reserved = gen.Desired
+	}
+	if gen.Applied == gen.Desired {
+		gen.BehindSince = time.Time{}
+	}
+	gen.ReportedAt = now
+	if err := storeHostGeneration(gen); err != nil {
+		slog.Error("failed to store host generation", "host", hostID, "error", err)
+	}
+}
+
+// DeleteHostGeneration - removes the generation record of a host
+func DeleteHostGeneration(hostID string) {
+	hostGenerationMutex.Lock()
+	defer hostGenerationMutex.Unlock()
+	delete(hostGenerationCache, hostID)
+	delete(hostGenerationFloor, hostID)
+	database.DeleteRecord(database.HOST_GENERATIONS_TABLE_NAME, hostID)
+}
+
+// GetHostGeneration - fetches the generation record of a host
+func GetHostGeneration(hostID string) models.HostGeneration {
+	hostGenerationMutex.Lock()
+	defer hostGenerationMutex.Unlock()
+	loadHostGenerations()
+	gen := hostGenerationCache[hostID]
+	gen.HostID = hostID
+	return gen
+}
+
+// GetHostGenerations - fetches the generation records of all hosts
+func GetHostGenerations() map[string]models.HostGeneration {
+	hostGenerationMutex.Lock()
+	defer hostGenerationMutex.Unlock()
+	loadHostGenerations()
+	gens := make(map[string]models.HostGeneration, len(hostGenerationCache))
+	for hostID, gen := range hostGenerationCache {
+		gens[hostID] = gen
+	}
+	return gens
+}
+
+// hostDriftStatus - derives the drift status from a generation record
+func hostDriftStatus(gen models.HostGeneration, threshold time.Duration) models.HostDriftStatus {
+	if gen.ReportedAt.IsZero() {
+		return models.HostDriftUnknown
+	}
+	if gen.Applied >= gen.Desired {
+		return models.HostInSync
+	}
+	if !gen.BehindSince.IsZero() && time.Since(gen.BehindSince) > threshold {
+		return models.HostDrifted
+	}
+	return models.HostLagging
+}
+
+// GetHostDrift - returns the drift status of a host
+func GetHostDrift(host *models.Host) models.HostDrift {
+	return newHostDrift(host, GetHostGeneration(host.ID.String()), servercfg.GetHostDriftThreshold())
+}
+
+// GetHostsDrift - returns the drift status of the given hosts
+func GetHostsDrift(hosts []models.Host) []models.HostDrift {
+	gens := GetHostGenerations()
+	threshold := servercfg.GetHostDriftThreshold()
+	drifts := []models.HostDrift{}
+	for i := range hosts {
+		gen := gens[hosts[i].ID.String()]
+		gen.HostID = hosts[i].ID.String()
+		drifts = append(drifts, newHostDrift(&hosts[i], gen, threshold))
+	}
+	return drifts
+}
+
+func newHostDrift(host *models.Host, gen models.HostGeneration, threshold time.Duration) models.HostDrift {
+	drift := models.HostDrift{
+		HostID:     host.ID.String(),
+		HostName:   host.Name,
+		Desired:    gen.Desired,
+		Applied:    gen.Applied,
+		Status:     hostDriftStatus(gen, threshold),
+		ReportedAt: gen.ReportedAt,
+	}
+	if gen.Desired > gen.Applied {
+		drift.Lag = gen.Desired - gen.Applied
+		drift.BehindSince = gen.BehindSince
+	}
+	return drift
+}
+
+// isHostDrifted - checks if a host stayed behind its desired state for longer than the threshold
+func isHostDrifted(gens map[string]models.HostGeneration, hostID string, threshold time.Duration) bool {
+	gen, ok := gens[hostID]
+	if !ok {
+		return false
+	}
+	return hostDriftStatus(gen, threshold) == models.HostDrifted
+}
@@ -0,0 +1,84 @@
+package logic
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gravitl/netmaker/database"
+	"github.com/gravitl/netmaker/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHostGenerationDrift(t *testing.T) {
+	database.InitializeDatabase()
+	defer database.CloseDB()
+	host := models.Host{ID: uuid.New(), Name: "drifter"}
+	hostID := host.ID.String()
+	defer DeleteHostGeneration(hostID)
+	t.Run("UnknownUntilReported", func(t *testing.T) {
+		assert.Equal(t, uint64(1), NextHostGeneration(hostID))
+		assert.Equal(t, uint64(2), NextHostGeneration(hostID))
+		RecordAppliedGeneration(hostID, 0)
+		assert.Equal(t, models.HostDriftUnknown, GetHostDrift(&host).Status)
+	})
+	t.Run("Lagging", func(t *testing.T) {
+		RecordAppliedGeneration(hostID, 1)
+		drift := GetHostDrift(&host)
+		assert.Equal(t, models.HostLagging, drift.Status)
+		assert.Equal(t, uint64(1), drift.Lag)
+	})
+	t.Run("Drifted", func(t *testing.T) {
+		gen := GetHostGeneration(hostID)
+		gen.BehindSince = time.Now().Add(-time.Hour)
+		assert.Equal(t, models.HostDrifted, hostDriftStatus(gen, time.Minute*5))
+		assert.True(t, isHostDrifted(map[string]models.HostGeneration{hostID: gen}, hostID, time.Minute*5))
+	})
+	t.Run("SteadyChurn", func(t *testing.T) {
+		gen := GetHostGeneration(hostID)
+		gen.BehindSince = time.Now().Add(-time.Hour)
+		hostGenerationMutex.Lock()
+		hostGenerationCache[hostID] = gen
+		hostGenerationMutex.Unlock()
+		// a host always one generation behind keeps applying updates and is not drifted
+		NextHostGeneration(hostID)
+		RecordAppliedGeneration(hostID, 2)
+		gen = GetHostGeneration(hostID)
+		assert.Equal(t, uint64(3), gen.Desired)
+		assert.Equal(t, models.HostLagging, hostDriftStatus(gen, time.Minute*5))
+	})
+	t.Run("InSync", func(t *testing.T) {
+		RecordAppliedGeneration(hostID, 3)
+		drift := GetHostDrift(&host)
+		assert.Equal(t, models.HostInSync, drift.Status)
+		assert.Equal(t, uint64(0), drift.Lag)
+		assert.True(t, GetHostGeneration(hostID).BehindSince.IsZero())
+	})
+	t.Run("HostAheadOfServer", func(t *testing.T) {
+		RecordAppliedGeneration(hostID, 10)
+		assert.Equal(t, uint64(11), NextHostGeneration(hostID))
+	})
+}
+
+func TestHostGenerationRestart(t *testing.T) {
+	database.InitializeDatabase()
+	defer database.CloseDB()
+	hostID := uuid.New().String()
+	defer DeleteHostGeneration(hostID)
+	var issued uint64
+	for i := 0; i < 5; i++ {
+		issued = NextHostGeneration(hostID)
+	}
+	RecordAppliedGeneration(hostID, issued)
+	for i := 0; i < hostGenerationBlock+5; i++ {
+		issued = NextHostGeneration(hostID)
+	}
+	// the server restarts and reloads the persisted generations
+	hostGenerationMutex.Lock()
+	hostGenerationCache = make(map[string]models.HostGeneration)
+	hostGenerationFloor = make(map[string]uint64)
+	hostGenerationLoaded = false
+	hostGenerationMutex.Unlock()
+	assert.Less(t, GetHostGeneration(hostID).Desired, issued)
+	assert.Greater(t, NextHostGeneration(hostID), issued)
+}
@@ -0,0 +1,161 @@
+package logic
+
+import (
+	"encoding/json"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gravitl/netmaker/database"
+	"github.com/gravitl/netmaker/models"
+	"github.com/gravitl/netmaker/servercfg"
+	"golang.org/x/exp/slog"
+)
+
+// MaxHostHistoryEntries - changes kept per host, older entries are dropped
+const MaxHostHistoryEntries = 100
+
+var hostHistoryMutex = &sync.Mutex{}
+
+// HostEndpointEventHook - fired for unexpected public endpoint changes when HOST_ENDPOINT_EVENTS is enabled
+var HostEndpointEventHook = func(event models.HostEndpointEvent) {
+	slog.Warn("host public endpoint changed unexpectedly", "host", event.HostID, "name", event.HostName,
+		"old", event.Old, "new", event.New)
+}
+
+func ipString(ip net.IP) string {
+	if ip == nil {
+		return ""
+	}
+	return ip.String()
+}
+
+// ifacesString - stable representation of a host's interfaces
+func ifacesString(ifaces []models.Iface) string {
+	list := make([]string, 0, len(ifaces))
+	for _, iface := range ifaces {
+		list = append(list, iface.Name+"="+iface.Address.String())
+	}
+	sort.Strings(list)
+	return strings.Join(list, ",")
+}
+
+// sameEndpointPrefix - checks if two addresses share the /16 (IPv4) or /48 (IPv6) prefix
+func sameEndpointPrefix(a, b net.IP) bool {
+	if a4, b4 := a.To4(), b.To4(); a4 != nil || b4 != nil {
+		if a4 == nil || b4 == nil {
+			return false
+		}
+		return a4.Mask(net.CIDRMask(16, 32)).Equal(b4.Mask(net.CIDRMask(16, 32)))
+	}
+	return a.Mask(net.CIDRMask(48, 128)).Equal(b.Mask(net.CIDRMask(48, 128)))
+}
+
+// HostInterfacesChanged - checks if a host reports other interfaces or interface addresses than stored
+func HostInterfacesChanged(old, new []models.Iface) bool {
+	return ifacesString(old) != ifacesString(new)
+}
+
+// classifyEndpointChange - checks if an endpoint change is unexpected, which is when the new
+// endpoint leaves the /16 (/48 for IPv6) of the previous one. The first endpoint a host reports
+// is never unexpected
+func classifyEndpointChange(entry *models.HostHistoryEntry, old, new net.IP) *models.HostEndpointEvent {
+	if new == nil || old == nil {
+		return nil
+	}
+	entry.Unexpected = !sameEndpointPrefix(old, new)
+	if !entry.Unexpected {
+		return nil
+	}
+	return &models.HostEndpointEvent{Old: ipString(old), New: ipString(new), Time: entry.Time}
+}
+
+// hostChanges - lists the endpoint, nat and interface changes between the stored and the reported host
+func hostChanges(old, new *models.Host, withInterfaces bool) ([]models.HostHistoryEntry, []*models.HostEndpointEvent) {
+	now := time.Now().UTC()
+	entries := []models.HostHistoryEntry{}
+	events := []*models.HostEndpointEvent{}
+	if !old.EndpointIP.Equal(new.EndpointIP) {
+		entry := models.HostHistoryEntry{Time: now, Type: models.HostEndpointChange,
+			Old: ipString(old.EndpointIP), New: ipString(new.EndpointIP)}
+		if event := classifyEndpointChange(&entry, old.EndpointIP, new.EndpointIP); event != nil {
+			events = append(events, event)
+		}
+		entries = append(entries, entry)
+	}
+	if !old.EndpointIPv6.Equal(new.EndpointIPv6) {
+		entry := models.HostHistoryEntry{Time: now, Type: models.HostEndpoint6Change,
+			Old: ipString(old.EndpointIPv6), New: ipString(new.EndpointIPv6)}
+		if event := classifyEndpointChange(&entry, old.EndpointIPv6, new.EndpointIPv6); event != nil {
+			events = append(events, event)
+		}
+		entries = append(entries, entry)
+	}
+	if len(new.NatType) > 0 && new.NatType != old.NatType {
+		entries = append(entries, models.HostHistoryEntry{Time: now, Type: models.HostNatChange,
+			Old: old.NatType, New: new.NatType})
+	}
+	if withInterfaces {
+		if oldIfaces, newIfaces := ifacesString(old.Interfaces), ifacesString(new.Interfaces); oldIfaces != newIfaces {
+			entries = append(entries, models.HostHistoryEntry{Time: now, Type: models.HostInterfacesChange,
+				Old: oldIfaces, New: newIfaces})
+		}
+	}
+	return entries, events
+}
+
+// RecordHostHistory - records the changes a host reports before they are applied to the stored host,
+// interfaces are only compared when the caller applies them
+func RecordHostHistory(current, reported *models.Host, withInterfaces bool) {
+	entries, events := hostChanges(current, reported, withInterfaces)
+	if len(entries) == 0 {
+		return
+	}
+	hostID := current.ID.String()
+	hostHistoryMutex.Lock()
+	history, err := GetHostHistory(hostID)
+	if err != nil {
+		history = models.HostHistory{HostID: hostID}
+	}
+	history.Entries = append(history.Entries, entries...)
+	if len(history.Entries) > MaxHostHistoryEntries {
+		history.Entries = history.Entries[len(history.Entries)-MaxHostHistoryEntries:]
+	}
+	if data, err := json.Marshal(history); err == nil {
+		if err := database.Insert(hostID, string(data), database.HOST_HISTORY_TABLE_NAME); err != nil {
+			slog.Error("failed to store host history", "host", hostID, "error", err)
+		}
+	}
+	hostHistoryMutex.Unlock()
+	if !servercfg.IsHostEndpointEventsEnabled() {
+		return
+	}
+	for _, event := range events {
+		event.HostID = hostID
+		event.HostName = current.Name
+		go HostEndpointEventHook(*event)
+	}
+}
+
+// GetHostHistory - fetches the history of a host
+func GetHostHistory(hostID string) (models.HostHistory, error) {
+	history := models.HostHistory{HostID: hostID, Entries: []models.HostHistoryEntry{}}
+	record, err := database.FetchRecord(database.HOST_HISTORY_TABLE_NAME, hostID)
+	if err != nil {
+		if database.IsEmptyRecord(err) {
+			return history, nil
+		}
+		return history, err
+	}
+	err = json.Unmarshal([]byte(record), &history)
+	return history, err
+}
+
+// DeleteHostHistory - removes the history of a host
+func DeleteHostHistory(hostID string) {
+	hostHistoryMutex.Lock()
+	defer hostHistoryMutex.Unlock()
+	_ = database.DeleteRecord(database.HOST_HISTORY_TABLE_NAME, hostID)
+}
@@ -0,0 +1,63 @@
+package logic
+
+import (
+	"net"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/gravitl/netmaker/database"
+	"github.com/gravitl/netmaker/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHostHistory(t *testing.T) {
+	database.InitializeDatabase()
+	defer database.CloseDB()
+	host := models.Host{ID: uuid.New(), Name: "roaming", NatType: models.NAT_Types.Public}
+	host.EndpointIP = net.ParseIP("203.0.113.10")
+	defer DeleteHostHistory(host.ID.String())
+	t.Run("NoChange", func(t *testing.T) {
+		reported := host
+		RecordHostHistory(&host, &reported, true)
+		history, err := GetHostHistory(host.ID.String())
+		assert.Nil(t, err)
+		assert.Empty(t, history.Entries)
+	})
+	t.Run("SamePrefix", func(t *testing.T) {
+		reported := host
+		reported.EndpointIP = net.ParseIP("203.0.200.1")
+		reported.NatType = ""
+		RecordHostHistory(&host, &reported, false)
+		history, _ := GetHostHistory(host.ID.String())
+		assert.Len(t, history.Entries, 1)
+		assert.Equal(t, models.HostEndpointChange, history.Entries[0].Type)
+		assert.Equal(t, "203.0.113.10", history.Entries[0].Old)
+		assert.False(t, history.Entries[0].Unexpected)
+	})
+	t.Run("NewPrefix", func(t *testing.T) {
+		reported := host
+		reported.EndpointIP = net.ParseIP("198.51.100.7")
+		reported.NatType = models.NAT_Types.BehindNAT
+		RecordHostHistory(&host, &reported, false)
+		history, _ := GetHostHistory(host.ID.String())
+		assert.Len(t, history.Entries, 3)
+		assert.True(t, history.Entries[1].Unexpected)
+		assert.Equal(t, models.HostNatChange, history.Entries[2].Type)
+	})
+	t.Run("InterfaceAddresses", func(t *testing.T) {
+		eth0 := []models.Iface{{Name: "eth0", Address: net.IPNet{IP: net.IPv4(10, 0, 0, 1), Mask: net.CIDRMask(24, 32)}}}
+		moved := []models.Iface{{Name: "eth0", Address: net.IPNet{IP: net.IPv4(10, 0, 1, 1), Mask: net.CIDRMask(24, 32)}}}
+		assert.False(t, HostInterfacesChanged(eth0, eth0))
+		assert.True(t, HostInterfacesChanged(eth0, moved), "same count, other address")
+	})
+	t.Run("Bounded", func(t *testing.T) {
+		for i := 0; i < MaxHostHistoryEntries; i++ {
+			reported := host
+			reported.Interfaces = []models.Iface{{Name: "eth0", Address: net.IPNet{IP: net.IPv4(10, 0, byte(i/256), byte(i%256)), Mask: net.CIDRMask(24, 32)}}}
+			RecordHostHistory(&host, &reported, true)
+		}
+		history, _ := GetHostHistory(host.ID.String())
+		assert.Len(t, history.Entries, MaxHostHistoryEntries)
+		assert.Equal(t, models.HostInterfacesChange, history.Entries[0].Type)
+	})
+}
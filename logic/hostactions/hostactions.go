@@ -2,55 +2,328 @@ package hostactions
 
 import (
 	"encoding/json"
+	"errors"
+	"sync"
+	"time"
 
+	"github.com/google/uuid"
 	"github.com/gravitl/netmaker/database"
 	"github.com/gravitl/netmaker/models"
 )
 
-// AddAction - adds a host action to a host's list to be retrieved from broker update
+// MaxAttempts - deliveries of an action before it is marked as failed
+const MaxAttempts = 3
+
+// AckTimeout - time a host has to acknowledge a delivered action before it is delivered again
+const AckTimeout = 5 * time.Minute
+
+// maxFinished - acknowledged and failed actions kept per host for the admin view
+const maxFinished = 20
+
+var actionsMutex = &sync.Mutex{}
+
+// getActions - fetches the action queue of a host, caller must hold the lock
+func getActions(hostID string) ([]models.HostAction, error) {
+	record, err := database.FetchRecord(database.HOST_ACTIONS_TABLE_NAME, hostID)
+	if err != nil {
+		if database.IsEmptyRecord(err) {
+			return []models.HostAction{}, nil
+		}
+		return nil, err
+	}
+	var actions []models.HostAction
+	if err := json.Unmarshal([]byte(record), &actions); err != nil {
+		return nil, err
+	}
+	if len(actions) > 0 && actions[0].ID == "" {
+		// queue stored as a plain list of host updates by older servers
+		var updates []models.HostUpdate
+		if err := json.Unmarshal([]byte(record), &updates); err != nil {
+			return nil, err
+		}
+		actions = []models.HostAction{}
+		for _, hu := range updates {
+			actions = append(actions, newAction(hu))
+		}
+	}
+	return actions, nil
+}
+
+// storeActions - persists the action queue of a host, caller must hold the lock
+func storeActions(hostID string, actions []models.HostAction) error {
+	finished := 0
+	for i := len(actions) - 1; i >= 0; i-- {
+		if actions[i].State != models.HostActionAcknowledged && actions[i].State != models.HostActionFailed {
+			continue
+		}
+		finished++
+		if finished > maxFinished {
+			actions = append(actions[:i], actions[i+1:]...)
+		}
+	}
+	data, err := json.Marshal(actions)
+	if err != nil {
+		return err
+	}
+	return database.Insert(hostID, string(data), database.HOST_ACTIONS_TABLE_NAME)
+}
+
+func newAction(hu models.HostUpdate) models.HostAction {
+	now := time.Now().UTC()
+	return models.HostAction{
+		ID:        uuid.New().String(),
+		HostID:    hu.Host.ID.String(),
+		Action:    hu.Action,
+		Update:    hu,
+		State:     models.HostActionPending,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+}
+
+// AddAction - adds a host action to a host's queue to be delivered when the host acknowledges
 func AddAction(hu models.HostUpdate) {
+	actionsMutex.Lock()
+	defer actionsMutex.Unlock()
 	hostID := hu.Host.ID.String()
-	currentRecords, err := database.FetchRecord(database.HOST_ACTIONS_TABLE_NAME, hostID)
+	actions, err := getActions(hostID)
 	if err != nil {
-		if database.IsEmptyRecord(err) { // no list exists yet
-			newEntry, err := json.Marshal([]models.HostUpdate{hu})
-			if err != nil {
-				return
-			}
-			_ = database.Insert(hostID, string(newEntry), database.HOST_ACTIONS_TABLE_NAME)
-		}
 		return
 	}
-	var currentList []models.HostUpdate
-	if err := json.Unmarshal([]byte(currentRecords), &currentList); err != nil {
+	_ = storeActions(hostID, append(actions, newAction(hu)))
+}
+
+// Acknowledge - marks the delivered actions of a host that an acknowledgement confirms as acknowledged.
+// An acknowledgement names the action it confirms, or the generation the host applied, which confirms
+// the actions delivered up to it. Older clients send neither, their acknowledgement confirms the
+// action delivered last
+func Acknowledge(hostID, actionID string, generation uint64) {
+	actionsMutex.Lock()
+	defer actionsMutex.Unlock()
+	actions, err := getActions(hostID)
+	if err != nil {
 		return
 	}
-	currentList = append(currentList, hu)
-	newData, err := json.Marshal(currentList)
+	acked := func(action models.HostAction) bool {
+		switch {
+		case actionID != "":
+			return action.ID == actionID
+		case generation != 0:
+			return action.Generation != 0 && action.Generation <= generation
+		default:
+			return false
+		}
+	}
+	if actionID == "" && generation == 0 {
+		last := -1
+		for i := range actions {
+			if actions[i].State == models.HostActionDelivered &&
+				(last < 0 || !actions[i].DeliveredAt.Before(actions[last].DeliveredAt)) {
+				last = i
+			}
+		}
+		if last < 0 {
+			return
+		}
+		lastID := actions[last].ID
+		acked = func(action models.HostAction) bool {
+			return action.ID == lastID
+		}
+	}
+	changed := false
+	now := time.Now().UTC()
+	for i := range actions {
+		if actions[i].State == models.HostActionDelivered && acked(actions[i]) {
+			actions[i].State = models.HostActionAcknowledged
+			actions[i].AcknowledgedAt = now
+			actions[i].UpdatedAt = now
+			changed = true
+		}
+	}
+	if changed {
+		_ = storeActions(hostID, actions)
+	}
+}
+
+// SetDeliveredGeneration - records the host generation a delivered action was sent with
+func SetDeliveredGeneration(hostID, actionID string, generation uint64) {
+	actionsMutex.Lock()
+	defer actionsMutex.Unlock()
+	actions, err := getActions(hostID)
 	if err != nil {
 		return
 	}
-	_ = database.Insert(hostID, string(newData), database.HOST_ACTIONS_TABLE_NAME)
+	for i := range actions {
+		if actions[i].ID == actionID {
+			actions[i].Generation = generation
+			actions[i].Update.Generation = generation
+			_ = storeActions(hostID, actions)
+			return
+		}
+	}
 }
 
-// GetAction - gets an action if exists
-func GetAction(id string) *models.HostUpdate {
-	currentRecords, err := database.FetchRecord(database.HOST_ACTIONS_TABLE_NAME, id)
+// ExpireDeliveries - puts delivered actions that were not acknowledged within AckTimeout back in the
+// queue, or marks them as failed once they ran out of attempts. Returns the hosts with actions to deliver again
+func ExpireDeliveries(now time.Time) []string {
+	actionsMutex.Lock()
+	defer actionsMutex.Unlock()
+	records, err := database.FetchRecords(database.HOST_ACTIONS_TABLE_NAME)
 	if err != nil {
 		return nil
 	}
-	var currentList []models.HostUpdate
-	if err = json.Unmarshal([]byte(currentRecords), &currentList); err != nil {
+	hosts := []string{}
+	for hostID := range records {
+		actions, err := getActions(hostID)
+		if err != nil {
+			continue
+		}
+		changed, requeued := false, false
+		for i := range actions {
+			if actions[i].State != models.HostActionDelivered || now.Sub(actions[i].DeliveredAt) < AckTimeout {
+				continue
+			}
+			actions[i].State = models.HostActionPending
+			requeued = true
+			if actions[i].Attempts >= MaxAttempts {
+				actions[i].State = models.HostActionFailed
+			}
+			actions[i].LastError = "not acknowledged within " + AckTimeout.String()
+			actions[i].UpdatedAt = now.UTC()
+			changed = true
+		}
+		if !changed || storeActions(hostID, actions) != nil {
+			continue
+		}
+		for _, action := range actions {
+			if requeued && action.State == models.HostActionPending {
+				hosts = append(hosts, hostID)
+				break
+			}
+		}
+	}
+	return hosts
+}
+
+// GetAction - gets the next pending action of a host if exists and marks it as delivered
+func GetAction(hostID string) *models.HostAction {
+	actionsMutex.Lock()
+	defer actionsMutex.Unlock()
+	actions, err := getActions(hostID)
+	if err != nil {
 		return nil
 	}
-	if len(currentList) > 0 {
-		hu := currentList[0]
-		newData, err := json.Marshal(currentList[1:])
-		if err != nil {
-			newData, _ = json.Marshal([]models.HostUpdate{})
+	for i := range actions {
+		if actions[i].State != models.HostActionPending {
+			continue
+		}
+		now := time.Now().UTC()
+		actions[i].State = models.HostActionDelivered
+		actions[i].Attempts++
+		actions[i].DeliveredAt = now
+		actions[i].UpdatedAt = now
+		actions[i].Update.ActionID = actions[i].ID
+		if err := storeActions(hostID, actions); err != nil {
+			return nil
 		}
-		_ = database.Insert(id, string(newData), database.HOST_ACTIONS_TABLE_NAME)
-		return &hu
+		action := actions[i]
+		return &action
 	}
 	return nil
 }
+
+// DeliveryFailed - puts an action that could not be sent back in the queue,
+// or marks it as failed once it ran out of attempts
+func DeliveryFailed(hostID, actionID string, deliveryErr error) {
+	actionsMutex.Lock()
+	defer actionsMutex.Unlock()
+	actions, err := getActions(hostID)
+	if err != nil {
+		return
+	}
+	for i := range actions {
+		if actions[i].ID != actionID {
+			continue
+		}
+		actions[i].State = models.HostActionPending
+		if actions[i].Attempts >= MaxAttempts {
+			actions[i].State = models.HostActionFailed
+		}
+		actions[i].LastError = deliveryErr.Error()
+		actions[i].UpdatedAt = time.Now().UTC()
+		_ = storeActions(hostID, actions)
+		return
+	}
+}
+
+// ListActions - lists the queued and recently finished actions of a host
+func ListActions(hostID string) ([]models.HostAction, error) {
+	actionsMutex.Lock()
+	defer actionsMutex.Unlock()
+	return getActions(hostID)
+}
+
+// CancelAction - removes an action that was not acknowledged yet from a host's queue
+func CancelAction(hostID, actionID string) error {
+	actionsMutex.Lock()
+	defer actionsMutex.Unlock()
+	actions, err := getActions(hostID)
+	if err != nil {
+		return err
+	}
+	for i := range actions {
+		if actions[i].ID != actionID {
+			continue
+		}
+		if actions[i].State == models.HostActionAcknowledged {
+			return errors.New("action was already acknowledged")
+		}
+		return storeActions(hostID, append(actions[:i], actions[i+1:]...))
+	}
+	return errors.New("action not found")
+}
+
+// RetryAction - queues a delivered or failed action again
+func RetryAction(hostID, actionID string) (models.HostAction, error) {
+	actionsMutex.Lock()
+	defer actionsMutex.Unlock()
+	actions, err := getActions(hostID)
+	if err != nil {
+		return models.HostAction{}, err
+	}
+	for i := range actions {
+		if actions[i].ID != actionID {
+			continue
+		}
+		if actions[i].State == models.HostActionPending {
+			return actions[i], errors.New("action is already pending")
+		}
+		actions[i].State = models.HostActionPending
+		actions[i].Attempts = 0
+		actions[i].LastError = ""
+		actions[i].UpdatedAt = time.Now().UTC()
+		return actions[i], storeActions(hostID, actions)
+	}
+	return models.HostAction{}, errors.New("action not found")
+}
+
+// HasPending - checks if a host has actions waiting to be delivered
+func HasPending(hostID string) bool {
+	actions, err := ListActions(hostID)
+	if err != nil {
+		return false
+	}
+	for _, action := range actions {
+		if action.State == models.HostActionPending {
+			return true
+		}
+	}
+	return false
+}
+
+// DeleteActions - removes the action queue of a host
+func DeleteActions(hostID string) {
+	actionsMutex.Lock()
+	defer actionsMutex.Unlock()
+	_ = database.DeleteRecord(database.HOST_ACTIONS_TABLE_NAME, hostID)
+}
@@ -0,0 +1,121 @@
+package hostactions
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gravitl/netmaker/database"
+	"github.com/gravitl/netmaker/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHostActionQueue(t *testing.T) {
+	database.InitializeDatabase()
+	defer database.CloseDB()
+	host := models.Host{ID: uuid.New(), Name: "queued"}
+	hostID := host.ID.String()
+	defer DeleteActions(hostID)
+	AddAction(models.HostUpdate{Action: models.JoinHostToNetwork, Host: host})
+	AddAction(models.HostUpdate{Action: models.UpdateKeys, Host: host})
+
+	t.Run("DeliverInOrder", func(t *testing.T) {
+		action := GetAction(hostID)
+		assert.NotNil(t, action)
+		assert.Equal(t, models.JoinHostToNetwork, action.Action)
+		assert.Equal(t, models.HostActionDelivered, action.State)
+		assert.Equal(t, 1, action.Attempts)
+		assert.Equal(t, action.ID, action.Update.ActionID)
+		Acknowledge(hostID, uuid.NewString(), 0)
+		actions, _ := ListActions(hostID)
+		assert.Equal(t, models.HostActionDelivered, actions[0].State, "an ack of another action confirms nothing")
+		Acknowledge(hostID, action.ID, 0)
+		actions, err := ListActions(hostID)
+		assert.Nil(t, err)
+		assert.Equal(t, models.HostActionAcknowledged, actions[0].State)
+		assert.True(t, HasPending(hostID))
+	})
+	t.Run("FailAfterMaxAttempts", func(t *testing.T) {
+		var action *models.HostAction
+		for i := 0; i < MaxAttempts; i++ {
+			action = GetAction(hostID)
+			assert.NotNil(t, action)
+			DeliveryFailed(hostID, action.ID, errors.New("broker down"))
+		}
+		assert.Nil(t, GetAction(hostID))
+		actions, _ := ListActions(hostID)
+		assert.Equal(t, models.HostActionFailed, actions[1].State)
+		assert.Equal(t, "broker down", actions[1].LastError)
+	})
+	t.Run("RetryAndCancel", func(t *testing.T) {
+		actions, _ := ListActions(hostID)
+		retried, err := RetryAction(hostID, actions[1].ID)
+		assert.Nil(t, err)
+		assert.Equal(t, models.HostActionPending, retried.State)
+		assert.Equal(t, 0, retried.Attempts)
+		assert.Error(t, CancelAction(hostID, actions[0].ID))
+		assert.Nil(t, CancelAction(hostID, actions[1].ID))
+		assert.False(t, HasPending(hostID))
+	})
+	t.Run("LegacyQueue", func(t *testing.T) {
+		legacyID := uuid.New()
+		defer DeleteActions(legacyID.String())
+		data, _ := json.Marshal([]models.HostUpdate{{Action: models.JoinHostToNetwork, Host: models.Host{ID: legacyID}}})
+		assert.Nil(t, database.Insert(legacyID.String(), string(data), database.HOST_ACTIONS_TABLE_NAME))
+		action := GetAction(legacyID.String())
+		assert.NotNil(t, action)
+		assert.Equal(t, models.JoinHostToNetwork, action.Action)
+		assert.NotEmpty(t, action.ID)
+	})
+	t.Run("AckByGeneration", func(t *testing.T) {
+		AddAction(models.HostUpdate{Action: models.UpdateKeys, Host: host})
+		action := GetAction(hostID)
+		SetDeliveredGeneration(hostID, action.ID, 7)
+		Acknowledge(hostID, "", 6)
+		assert.Equal(t, models.HostActionDelivered, findAction(hostID, action.ID).State)
+		Acknowledge(hostID, "", 7)
+		assert.Equal(t, models.HostActionAcknowledged, findAction(hostID, action.ID).State)
+	})
+	t.Run("LegacyAckConfirmsLastDelivery", func(t *testing.T) {
+		AddAction(models.HostUpdate{Action: models.UpdateKeys, Host: host})
+		AddAction(models.HostUpdate{Action: models.UpdateKeys, Host: host})
+		first := GetAction(hostID)
+		time.Sleep(time.Millisecond)
+		second := GetAction(hostID)
+		Acknowledge(hostID, "", 0)
+		assert.Equal(t, models.HostActionDelivered, findAction(hostID, first.ID).State)
+		assert.Equal(t, models.HostActionAcknowledged, findAction(hostID, second.ID).State)
+	})
+	t.Run("ExpireDeliveries", func(t *testing.T) {
+		// the first action of the previous subtest was never acknowledged
+		actions, _ := ListActions(hostID)
+		var delivered models.HostAction
+		for _, action := range actions {
+			if action.State == models.HostActionDelivered {
+				delivered = action
+			}
+		}
+		assert.Empty(t, ExpireDeliveries(time.Now()))
+		assert.Contains(t, ExpireDeliveries(time.Now().Add(AckTimeout)), hostID)
+		assert.Equal(t, models.HostActionPending, findAction(hostID, delivered.ID).State)
+		for i := 1; i < MaxAttempts; i++ {
+			GetAction(hostID)
+			ExpireDeliveries(time.Now().Add(AckTimeout))
+		}
+		expired := findAction(hostID, delivered.ID)
+		assert.Equal(t, models.HostActionFailed, expired.State)
+		assert.NotEmpty(t, expired.LastError)
+	})
+}
+
+func findAction(hostID, actionID string) models.HostAction {
+	actions, _ := ListActions(hostID)
+	for _, action := range actions {
+		if action.ID == actionID {
+			return action
+		}
+	}
+	return models.HostAction{}
+}
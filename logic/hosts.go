@@ -14,6 +14,7 @@ import (
 
 	"github.com/gravitl/netmaker/database"
 	"github.com/gravitl/netmaker/logger"
+	"github.com/gravitl/netmaker/logic/hostactions"
 	"github.com/gravitl/netmaker/models"
 	"github.com/gravitl/netmaker/servercfg"
 )
@@ -269,8 +270,11 @@ func UpdateHost(newHost, currentHost *models.Host) {
 
 // UpdateHostFromClient - used for updating host on server with update recieved from client
 func UpdateHostFromClient(newHost, currHost *models.Host) (sendPeerUpdate bool) {
+	RecordHostHistory(currHost, newHost, false)
+	currHost.PeerDeltas = newHost.PeerDeltas
 	if newHost.PublicKey != currHost.PublicKey {
 		currHost.PublicKey = newHost.PublicKey
+		RecordHostKeyRotation(currHost.ID.String())
 		sendPeerUpdate = true
 	}
 	if newHost.ListenPort != 0 && currHost.ListenPort != newHost.ListenPort {
@@ -337,6 +341,7 @@ func UpsertHost(h *models.Host) error {
 	if servercfg.CacheEnabled() {
 		storeHostInCache(*h)
 	}
+	invalidateHostPeerModels(h)
 
 	return nil
 }
@@ -357,9 +362,16 @@ func RemoveHost(h *models.Host, forceDelete bool) error {
 	if err != nil {
 		return err
 	}
+	invalidateHostPeerModels(h)
 	if servercfg.CacheEnabled() {
 		deleteHostFromCache(h.ID.String())
 	}
+	DeleteHostGeneration(h.ID.String())
+	DeleteHostKeyRotation(h.ID.String())
+	DeleteHostQuarantine(h.ID.String())
+	DeleteHostHistory(h.ID.String())
+	ResetPeerState(h.ID.String())
+	hostactions.DeleteActions(h.ID.String())
 	go func() {
 		if servercfg.IsDNSMode() {
 			SetDNS()
@@ -371,7 +383,9 @@ func RemoveHost(h *models.Host, forceDelete bool) error {
 
 // RemoveHostByID - removes a given host by id from server
 func RemoveHostByID(hostID string) error {
-
+	if h, err := GetHost(hostID); err == nil {
+		defer invalidateHostPeerModels(h)
+	}
 	err := database.DeleteRecord(database.HOSTS_TABLE_NAME, hostID)
 	if err != nil {
 		return err
@@ -379,6 +393,12 @@ func RemoveHostByID(hostID string) error {
 	if servercfg.CacheEnabled() {
 		deleteHostFromCache(hostID)
 	}
+	DeleteHostGeneration(hostID)
+	DeleteHostKeyRotation(hostID)
+	DeleteHostQuarantine(hostID)
+	DeleteHostHistory(hostID)
+	ResetPeerState(hostID)
+	hostactions.DeleteActions(hostID)
 	return nil
 }
 
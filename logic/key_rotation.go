@@ -0,0 +1,371 @@
+package logic
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gravitl/netmaker/database"
+	"github.com/gravitl/netmaker/models"
+	"golang.org/x/exp/slog"
+)
+
+const (
+	// KeyRotationCheckInterval - how often the hook manager looks for hosts due for a key rotation
+	KeyRotationCheckInterval = 5 * time.Minute
+	// keyRotationTimeout - time a host has to report its new key before the request is retried
+	keyRotationTimeout = 30 * time.Minute
+	// globalKeyRotationPolicy - DB key of the policy applying to hosts without a network policy
+	globalKeyRotationPolicy = "*"
+	defaultKeyRotationBatch = 10
+)
+
+var keyRotationMutex = &sync.Mutex{}
+
+func keyRotationPolicyKey(network string) string {
+	if network == "" {
+		return globalKeyRotationPolicy
+	}
+	return network
+}
+
+// GetKeyRotationPolicy - fetches the key rotation policy of a network, an empty network fetches the global policy
+func GetKeyRotationPolicy(network string) (models.KeyRotationPolicy, error) {
+	data, err := database.FetchRecord(database.KEY_ROTATION_POLICIES_TABLE_NAME, keyRotationPolicyKey(network))
+	if err != nil {
+		return models.KeyRotationPolicy{}, err
+	}
+	policy := models.KeyRotationPolicy{}
+	err = json.Unmarshal([]byte(data), &policy)
+	return policy, err
+}
+
+// ListKeyRotationPolicies - lists the global and all network key rotation policies
+func ListKeyRotationPolicies() ([]models.KeyRotationPolicy, error) {
+	data, err := database.FetchRecords(database.KEY_ROTATION_POLICIES_TABLE_NAME)
+	if err != nil && !database.IsEmptyRecord(err) {
+		return []models.KeyRotationPolicy{}, err
+	}
+	policies := []models.KeyRotationPolicy{}
+	for _, dataI := range data {
+		policy := models.KeyRotationPolicy{}
+		if err := json.Unmarshal([]byte(dataI), &policy); err != nil {
+			continue
+		}
+		policies = append(policies, policy)
+	}
+	sort.Slice(policies, func(i, j int) bool {
+		return policies[i].Network < policies[j].Network
+	})
+	return policies, nil
+}
+
+// UpsertKeyRotationPolicy - validates and saves a key rotation policy
+func UpsertKeyRotationPolicy(policy models.KeyRotationPolicy) (models.KeyRotationPolicy, error) {
+	if policy.Network != "" {
+		if _, err := GetNetwork(policy.Network); err != nil {
+			return policy, fmt.Errorf("failed to get network details for %s", policy.Network)
+		}
+	}
+	if policy.IntervalDays <= 0 {
+		return policy, errors.New("rotation interval has to be at least one day")
+	}
+	if policy.BatchSize < 0 {
+		return policy, errors.New("batch size can not be negative")
+	}
+	if policy.BatchSize == 0 {
+		policy.BatchSize = defaultKeyRotationBatch
+	}
+	if (policy.WindowStart == "") != (policy.WindowEnd == "") {
+		return policy, errors.New("maintenance window needs both a start and an end")
+	}
+	if policy.WindowStart != "" {
+		if _, err := time.Parse("15:04", policy.WindowStart); err != nil {
+			return policy, fmt.Errorf("invalid window start %s, expected HH:MM", policy.WindowStart)
+		}
+		if _, err := time.Parse("15:04", policy.WindowEnd); err != nil {
+			return policy, fmt.Errorf("invalid window end %s, expected HH:MM", policy.WindowEnd)
+		}
+	}
+	policy.UpdatedAt = time.Now().UTC()
+	policy.EnabledAt = time.Time{}
+	if policy.Enabled {
+		policy.EnabledAt = policy.UpdatedAt
+		if current, err := GetKeyRotationPolicy(policy.Network); err == nil && current.Enabled && !current.EnabledAt.IsZero() {
+			policy.EnabledAt = current.EnabledAt
+		}
+	}
+	d, err := json.Marshal(policy)
+	if err != nil {
+		return policy, err
+	}
+	return policy, database.Insert(keyRotationPolicyKey(policy.Network), string(d), database.KEY_ROTATION_POLICIES_TABLE_NAME)
+}
+
+// DeleteKeyRotationPolicy - deletes the key rotation policy of a network, an empty network deletes the global policy
+func DeleteKeyRotationPolicy(network string) error {
+	return database.DeleteRecord(database.KEY_ROTATION_POLICIES_TABLE_NAME, keyRotationPolicyKey(network))
+}
+
+// inKeyRotationWindow - checks if the time of day is within the policy's maintenance window,
+// windows ending before they start wrap around midnight
+func inKeyRotationWindow(policy models.KeyRotationPolicy, now time.Time) bool {
+	if policy.WindowStart == "" {
+		return true
+	}
+	start, err := time.Parse("15:04", policy.WindowStart)
+	if err != nil {
+		return false
+	}
+	end, err := time.Parse("15:04", policy.WindowEnd)
+	if err != nil {
+		return false
+	}
+	now = now.UTC()
+	minute := now.Hour()*60 + now.Minute()
+	startMinute := start.Hour()*60 + start.Minute()
+	endMinute := end.Hour()*60 + end.Minute()
+	if startMinute <= endMinute {
+		return minute >= startMinute && minute < endMinute
+	}
+	return minute >= startMinute || minute < endMinute
+}
+
+// GetHostKeyRotation - fetches the key rotation state of a host
+func GetHostKeyRotation(hostID string) models.HostKeyRotation {
+	state := models.HostKeyRotation{HostID: hostID, Status: models.KeyRotationIdle}
+	data, err := database.FetchRecord(database.HOST_KEY_ROTATIONS_TABLE_NAME, hostID)
+	if err != nil {
+		return state
+	}
+	_ = json.Unmarshal([]byte(data), &state)
+	return state
+}
+
+func getHostKeyRotations() map[string]models.HostKeyRotation {
+	states := make(map[string]models.HostKeyRotation)
+	data, err := database.FetchRecords(database.HOST_KEY_ROTATIONS_TABLE_NAME)
+	if err != nil {
+		return states
+	}
+	for _, dataI := range data {
+		state := models.HostKeyRotation{}
+		if err := json.Unmarshal([]byte(dataI), &state); err != nil {
+			continue
+		}
+		states[state.HostID] = state
+	}
+	return states
+}
+
+func upsertHostKeyRotation(state models.HostKeyRotation) error {
+	d, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	return database.Insert(state.HostID, string(d), database.HOST_KEY_ROTATIONS_TABLE_NAME)
+}
+
+// RecordHostKeyRotation - records that a host switched to a new wireguard key
+func RecordHostKeyRotation(hostID string) {
+	keyRotationMutex.Lock()
+	defer keyRotationMutex.Unlock()
+	state := GetHostKeyRotation(hostID)
+	state.Status = models.KeyRotationIdle
+	state.LastRotatedAt = time.Now().UTC()
+	state.RequestedAt = time.Time{}
+	if err := upsertHostKeyRotation(state); err != nil {
+		slog.Error("failed to record host key rotation", "host", hostID, "error", err)
+	}
+}
+
+// DeleteHostKeyRotation - removes the key rotation state of a host
+func DeleteHostKeyRotation(hostID string) {
+	database.DeleteRecord(database.HOST_KEY_ROTATIONS_TABLE_NAME, hostID)
+}
+
+// keyRotationPolicies - enabled policies by network, the global policy under the empty network
+func keyRotationPolicies() map[string]models.KeyRotationPolicy {
+	policies := make(map[string]models.KeyRotationPolicy)
+	all, _ := ListKeyRotationPolicies()
+	for _, policy := range all {
+		if policy.Enabled {
+			policies[policy.Network] = policy
+		}
+	}
+	return policies
+}
+
+// hostKeyRotationPolicy - the policy applying to a host, the strictest (shortest interval)
+// policy of the host's networks wins, hosts without a network policy follow the global one
+func hostKeyRotationPolicy(policies map[string]models.KeyRotationPolicy, networks []string) (models.KeyRotationPolicy, bool) {
+	var selected models.KeyRotationPolicy
+	found := false
+	for _, network := range networks {
+		policy, ok := policies[network]
+		if !ok {
+			continue
+		}
+		if !found || policy.IntervalDays < selected.IntervalDays ||
+			(policy.IntervalDays == selected.IntervalDays && policy.Network < selected.Network) {
+			selected = policy
+			found = true
+		}
+	}
+	if !found {
+		selected, found = policies[""]
+	}
+	return selected, found
+}
+
+// nextKeyRotation - when a host is due to rotate its keys again. Hosts that never rotated are
+// spread over the first interval after the policy was enabled, by an offset derived from the host ID,
+// so enabling a policy does not make every host due at once
+func nextKeyRotation(policy models.KeyRotationPolicy, state models.HostKeyRotation) time.Time {
+	interval := time.Duration(policy.IntervalDays) * 24 * time.Hour
+	if !state.LastRotatedAt.IsZero() {
+		return state.LastRotatedAt.Add(interval)
+	}
+	enabledAt := policy.EnabledAt
+	if enabledAt.IsZero() {
+		// policies saved before the enable time was recorded
+		enabledAt = policy.UpdatedAt
+	}
+	if interval <= 0 {
+		return enabledAt
+	}
+	h := fnv.New64a()
+	h.Write([]byte(state.HostID))
+	return enabledAt.Add(time.Duration(h.Sum64() % uint64(interval)))
+}
+
+// SelectHostsForKeyRotation - picks the hosts to rotate keys on now and marks them as requested.
+// Per policy at most BatchSize hosts rotate at a time, a new batch only starts once the hosts of the
+// previous one reported their new key (or timed out), so peers only ever have to swap a bounded
+// number of keys at once. Only hosts that are online are picked, offline hosts are retried later.
+func SelectHostsForKeyRotation(now time.Time) ([]models.Host, error) {
+	keyRotationMutex.Lock()
+	defer keyRotationMutex.Unlock()
+	policies := keyRotationPolicies()
+	if len(policies) == 0 {
+		return nil, nil
+	}
+	hosts, err := GetAllHosts()
+	if err != nil {
+		return nil, err
+	}
+	nodes, err := GetAllNodes()
+	if err != nil {
+		return nil, err
+	}
+	hostNetworks := make(map[string][]string)
+	online := make(map[string]bool)
+	for _, node := range nodes {
+		hostNetworks[node.HostID.String()] = append(hostNetworks[node.HostID.String()], node.Network)
+		if node.Connected && time.Since(node.LastCheckIn) < models.LastCheckInThreshold {
+			online[node.HostID.String()] = true
+		}
+	}
+	states := getHostKeyRotations()
+	inFlight := make(map[string]int)
+	due := make(map[string][]models.Host)
+	for _, host := range hosts {
+		hostID := host.ID.String()
+		policy, ok := hostKeyRotationPolicy(policies, hostNetworks[hostID])
+		if !ok {
+			continue
+		}
+		state, ok := states[hostID]
+		if !ok {
+			state = models.HostKeyRotation{HostID: hostID, Status: models.KeyRotationIdle}
+		}
+		if state.Status == models.KeyRotationRequested {
+			if now.Sub(state.RequestedAt) < keyRotationTimeout {
+				inFlight[policy.Network]++
+				continue
+			}
+			state.Status = models.KeyRotationTimedOut
+			if err := upsertHostKeyRotation(state); err != nil {
+				slog.Error("failed to update host key rotation", "host", hostID, "error", err)
+			}
+		}
+		if !online[hostID] || !inKeyRotationWindow(policy, now) {
+			continue
+		}
+		if !now.Before(nextKeyRotation(policy, state)) {
+			due[policy.Network] = append(due[policy.Network], host)
+		}
+	}
+	selected := []models.Host{}
+	for network, dueHosts := range due {
+		policy := policies[network]
+		// the longest unrotated hosts go first
+		sort.Slice(dueHosts, func(i, j int) bool {
+			return states[dueHosts[i].ID.String()].LastRotatedAt.Before(states[dueHosts[j].ID.String()].LastRotatedAt)
+		})
+		slots := policy.BatchSize - inFlight[network]
+		for i := 0; i < len(dueHosts) && i < slots; i++ {
+			state := states[dueHosts[i].ID.String()]
+			state.HostID = dueHosts[i].ID.String()
+			state.Status = models.KeyRotationRequested
+			state.RequestedAt = now
+			if err := upsertHostKeyRotation(state); err != nil {
+				slog.Error("failed to update host key rotation", "host", state.HostID, "error", err)
+				continue
+			}
+			selected = append(selected, dueHosts[i])
+		}
+	}
+	return selected, nil
+}
+
+// GetKeyRotationStatus - key rotation status of all hosts, optionally limited to a network
+func GetKeyRotationStatus(network string) ([]models.HostKeyRotationStatus, error) {
+	hosts, err := GetAllHosts()
+	if err != nil {
+		return nil, err
+	}
+	nodes, err := GetAllNodes()
+	if err != nil {
+		return nil, err
+	}
+	hostNetworks := make(map[string][]string)
+	for _, node := range nodes {
+		hostNetworks[node.HostID.String()] = append(hostNetworks[node.HostID.String()], node.Network)
+	}
+	policies := keyRotationPolicies()
+	states := getHostKeyRotations()
+	now := time.Now()
+	statuses := []models.HostKeyRotationStatus{}
+	for _, host := range hosts {
+		hostID := host.ID.String()
+		if network != "" && !StringSliceContains(hostNetworks[hostID], network) {
+			continue
+		}
+		state, ok := states[hostID]
+		if !ok {
+			state = models.HostKeyRotation{HostID: hostID, Status: models.KeyRotationIdle}
+		}
+		status := models.HostKeyRotationStatus{
+			HostID:        hostID,
+			HostName:      host.Name,
+			Status:        state.Status,
+			LastRotatedAt: state.LastRotatedAt,
+			RequestedAt:   state.RequestedAt,
+		}
+		if policy, ok := hostKeyRotationPolicy(policies, hostNetworks[hostID]); ok {
+			status.Policy = keyRotationPolicyKey(policy.Network)
+			status.NextRotation = nextKeyRotation(policy, state)
+			status.Overdue = now.After(status.NextRotation)
+		}
+		statuses = append(statuses, status)
+	}
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].HostName < statuses[j].HostName
+	})
+	return statuses, nil
+}
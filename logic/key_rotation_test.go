@@ -0,0 +1,61 @@
+package logic
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gravitl/netmaker/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKeyRotationPolicy(t *testing.T) {
+	t.Run("Window", func(t *testing.T) {
+		at := func(hour, minute int) time.Time {
+			return time.Date(2026, 1, 1, hour, minute, 0, 0, time.UTC)
+		}
+		policy := models.KeyRotationPolicy{WindowStart: "02:00", WindowEnd: "04:30"}
+		assert.True(t, inKeyRotationWindow(policy, at(3, 0)))
+		assert.False(t, inKeyRotationWindow(policy, at(4, 30)))
+		overnight := models.KeyRotationPolicy{WindowStart: "22:00", WindowEnd: "02:00"}
+		assert.True(t, inKeyRotationWindow(overnight, at(23, 15)))
+		assert.True(t, inKeyRotationWindow(overnight, at(1, 59)))
+		assert.False(t, inKeyRotationWindow(overnight, at(12, 0)))
+		assert.True(t, inKeyRotationWindow(models.KeyRotationPolicy{}, at(12, 0)))
+	})
+	t.Run("StrictestPolicyWins", func(t *testing.T) {
+		policies := map[string]models.KeyRotationPolicy{
+			"":     {IntervalDays: 90},
+			"prod": {Network: "prod", IntervalDays: 30},
+			"dev":  {Network: "dev", IntervalDays: 180},
+		}
+		policy, ok := hostKeyRotationPolicy(policies, []string{"dev", "prod"})
+		assert.True(t, ok)
+		assert.Equal(t, "prod", policy.Network)
+		policy, ok = hostKeyRotationPolicy(policies, []string{"lab"})
+		assert.True(t, ok)
+		assert.Equal(t, 90, policy.IntervalDays)
+		_, ok = hostKeyRotationPolicy(map[string]models.KeyRotationPolicy{}, []string{"lab"})
+		assert.False(t, ok)
+	})
+	t.Run("NextRotation", func(t *testing.T) {
+		last := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+		next := nextKeyRotation(models.KeyRotationPolicy{IntervalDays: 90}, models.HostKeyRotation{LastRotatedAt: last})
+		assert.Equal(t, last.AddDate(0, 0, 90), next)
+	})
+	t.Run("FirstRotationSpread", func(t *testing.T) {
+		enabled := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+		policy := models.KeyRotationPolicy{IntervalDays: 90, EnabledAt: enabled}
+		seen := make(map[time.Time]struct{})
+		for i := 0; i < 20; i++ {
+			next := nextKeyRotation(policy, models.HostKeyRotation{HostID: uuid.NewString()})
+			assert.False(t, next.Before(enabled))
+			assert.True(t, next.Before(enabled.AddDate(0, 0, 90)))
+			seen[next] = struct{}{}
+		}
+		assert.Greater(t, len(seen), 1, "hosts are not all due at once")
+		hostID := uuid.NewString()
+		assert.Equal(t, nextKeyRotation(policy, models.HostKeyRotation{HostID: hostID}),
+			nextKeyRotation(policy, models.HostKeyRotation{HostID: hostID}))
+	})
+}
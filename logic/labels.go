@@ -0,0 +1,188 @@
+package logic
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/gravitl/netmaker/models"
+)
+
+const (
+	maxLabelKeyLength   = 63
+	maxLabelValueLength = 63
+	maxHostLabels       = 32
+)
+
+var (
+	labelKeyRegex   = regexp.MustCompile(`^[a-zA-Z0-9]([a-zA-Z0-9._/-]*[a-zA-Z0-9])?$`)
+	labelValueRegex = regexp.MustCompile(`^([a-zA-Z0-9]([a-zA-Z0-9._-]*[a-zA-Z0-9])?)?$`)
+)
+
+// selector operators
+const (
+	selectorEquals       = "="
+	selectorNotEquals    = "!="
+	selectorExists       = "exists"
+	selectorDoesNotExist = "!exists"
+)
+
+// labelRequirement - a single term of a label selector
+type labelRequirement struct {
+	key      string
+	operator string
+	value    string
+}
+
+// LabelSelector - a parsed label selector, all requirements have to match
+type LabelSelector []labelRequirement
+
+// ParseLabelSelector - parses a comma separated selector such as `env=prod,site!=fra1`.
+// Supported terms are `key=value`, `key==value`, `key!=value`, `key` (label is set)
+// and `!key` (label is not set). An empty selector matches everything.
+func ParseLabelSelector(selector string) (LabelSelector, error) {
+	s := LabelSelector{}
+	selector = strings.TrimSpace(selector)
+	if selector == "" {
+		return s, nil
+	}
+	for _, term := range strings.Split(selector, ",") {
+		term = strings.TrimSpace(term)
+		if term == "" {
+			return nil, fmt.Errorf("invalid selector %q: empty term", selector)
+		}
+		req := labelRequirement{}
+		switch {
+		case strings.Contains(term, "!="):
+			parts := strings.SplitN(term, "!=", 2)
+			req = labelRequirement{key: parts[0], operator: selectorNotEquals, value: parts[1]}
+		case strings.Contains(term, "=="):
+			parts := strings.SplitN(term, "==", 2)
+			req = labelRequirement{key: parts[0], operator: selectorEquals, value: parts[1]}
+		case strings.Contains(term, "="):
+			parts := strings.SplitN(term, "=", 2)
+			req = labelRequirement{key: parts[0], operator: selectorEquals, value: parts[1]}
+		case strings.HasPrefix(term, "!"):
+			req = labelRequirement{key: strings.TrimPrefix(term, "!"), operator: selectorDoesNotExist}
+		default:
+			req = labelRequirement{key: term, operator: selectorExists}
+		}
+		req.key = strings.TrimSpace(req.key)
+		req.value = strings.TrimSpace(req.value)
+		if err := validateLabel(req.key, req.value); err != nil {
+			return nil, fmt.Errorf("invalid selector term %q: %w", term, err)
+		}
+		s = append(s, req)
+	}
+	return s, nil
+}
+
+// Matches - checks if the given labels satisfy every requirement of the selector
+func (s LabelSelector) Matches(labels map[string]string) bool {
+	for _, req := range s {
+		value, ok := labels[req.key]
+		switch req.operator {
+		case selectorEquals:
+			if !ok || value != req.value {
+				return false
+			}
+		case selectorNotEquals:
+			if ok && value == req.value {
+				return false
+			}
+		case selectorExists:
+			if !ok {
+				return false
+			}
+		case selectorDoesNotExist:
+			if ok {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// Empty - checks if the selector has no requirements
+func (s LabelSelector) Empty() bool {
+	return len(s) == 0
+}
+
+func validateLabel(key, value string) error {
+	if key == "" {
+		return errors.New("label key is empty")
+	}
+	if len(key) > maxLabelKeyLength || !labelKeyRegex.MatchString(key) {
+		return fmt.Errorf("invalid label key %q", key)
+	}
+	if len(value) > maxLabelValueLength || !labelValueRegex.MatchString(value) {
+		return fmt.Errorf("invalid label value %q for key %s", value, key)
+	}
+	return nil
+}
+
+// ValidateHostLabels - validates the keys and values of host labels
+func ValidateHostLabels(labels map[string]string) error {
+	if len(labels) > maxHostLabels {
+		return fmt.Errorf("a host can have at most %d labels", maxHostLabels)
+	}
+	for key, value := range labels {
+		if err := validateLabel(key, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// MergeHostLabels - returns the current labels overlaid with the given labels
+func MergeHostLabels(current, labels map[string]string) map[string]string {
+	if len(current) == 0 && len(labels) == 0 {
+		return current
+	}
+	merged := make(map[string]string, len(current)+len(labels))
+	for key, value := range current {
+		merged[key] = value
+	}
+	for key, value := range labels {
+		merged[key] = value
+	}
+	return merged
+}
+
+// FilterHostsBySelector - returns the hosts whose labels match the selector
+func FilterHostsBySelector(hosts []models.Host, selector LabelSelector) []models.Host {
+	if selector.Empty() {
+		return hosts
+	}
+	filtered := []models.Host{}
+	for _, host := range hosts {
+		if selector.Matches(host.Labels) {
+			filtered = append(filtered, host)
+		}
+	}
+	return filtered
+}
+
+// FilterNodesBySelector - returns the nodes whose host labels match the selector,
+// static nodes have no host and are matched against an empty label set
+func FilterNodesBySelector(nodes []models.Node, selector LabelSelector) ([]models.Node, error) {
+	if selector.Empty() {
+		return nodes, nil
+	}
+	hostsMap, err := GetHostsMap()
+	if err != nil {
+		return nil, err
+	}
+	filtered := []models.Node{}
+	for _, node := range nodes {
+		var labels map[string]string
+		if !node.IsStatic {
+			labels = hostsMap[node.HostID.String()].Labels
+		}
+		if selector.Matches(labels) {
+			filtered = append(filtered, node)
+		}
+	}
+	return filtered, nil
+}
@@ -0,0 +1,36 @@
+package logic
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLabelSelector(t *testing.T) {
+	labels := map[string]string{"env": "prod", "site": "ams1", "owner": "team-x"}
+	t.Run("Matches", func(t *testing.T) {
+		for _, selector := range []string{"", "env=prod", "env==prod,site!=fra1", "owner", "!gpu", " env = prod , site=ams1 "} {
+			s, err := ParseLabelSelector(selector)
+			assert.Nil(t, err, selector)
+			assert.True(t, s.Matches(labels), selector)
+		}
+	})
+	t.Run("DoesNotMatch", func(t *testing.T) {
+		for _, selector := range []string{"env=dev", "env=prod,site!=ams1", "gpu", "!owner"} {
+			s, err := ParseLabelSelector(selector)
+			assert.Nil(t, err, selector)
+			assert.False(t, s.Matches(labels), selector)
+		}
+	})
+	t.Run("Invalid", func(t *testing.T) {
+		for _, selector := range []string{"env=prod,", "=prod", "env=pr od", "!"} {
+			_, err := ParseLabelSelector(selector)
+			assert.NotNil(t, err, selector)
+		}
+	})
+	t.Run("ValidateHostLabels", func(t *testing.T) {
+		assert.Nil(t, ValidateHostLabels(labels))
+		assert.NotNil(t, ValidateHostLabels(map[string]string{"env": "pr,od"}))
+		assert.NotNil(t, ValidateHostLabels(map[string]string{"": "prod"}))
+	})
+}
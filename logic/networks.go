@@ -22,6 +22,11 @@ import (
 	"golang.org/x/exp/slog"
 )
 
+var (
+	// ErrNetworkFrozen - error returned when a change is attempted on a frozen network
+	ErrNetworkFrozen = errors.New("network is frozen")
+)
+
 var (
 	networkCacheMutex = &sync.RWMutex{}
 	networkCacheMap   = make(map[string]models.Network)
@@ -299,6 +304,7 @@ func CreateNetwork(network models.Network) (models.Network, error) {
 		[]string{network.NetID},
 		[]string{network.NetID},
 		[]models.TagID{},
+		nil,
 		true,
 		uuid.Nil,
 		true,
@@ -731,6 +737,59 @@ func SaveNetwork(network *models.Network) error {
 	return nil
 }
 
+// FreezeNetwork - freezes a network so that no changes can be made to its resources
+func FreezeNetwork(netID string, req models.NetworkFreezeRequest, frozenBy string) (models.Network, error) {
+	network, err := GetNetwork(netID)
+	if err != nil {
+		return network, err
+	}
+	if req.Reason == "" {
+		return network, errors.New("freeze reason is required")
+	}
+	if !req.Until.IsZero() && req.Until.Before(time.Now()) {
+		return network, errors.New("freeze expiry is in the past")
+	}
+	network.Frozen = true
+	network.FreezeReason = req.Reason
+	network.FrozenBy = frozenBy
+	network.FrozenAt = time.Now().UTC()
+	network.FrozenUntil = req.Until.UTC()
+	return network, SaveNetwork(&network)
+}
+
+// UnfreezeNetwork - lifts the freeze on a network
+func UnfreezeNetwork(netID string) (models.Network, error) {
+	network, err := GetNetwork(netID)
+	if err != nil {
+		return network, err
+	}
+	network.Frozen = false
+	network.FreezeReason = ""
+	network.FrozenBy = ""
+	network.FrozenAt = time.Time{}
+	network.FrozenUntil = time.Time{}
+	return network, SaveNetwork(&network)
+}
+
+// CheckNetworkFreeze - returns ErrNetworkFrozen if changes are not allowed on the network
+func CheckNetworkFreeze(netID string) error {
+	if netID == "" {
+		return nil
+	}
+	network, err := GetNetwork(netID)
+	if err != nil {
+		return nil
+	}
+	if network.IsFrozen() {
+		reason := network.FreezeReason
+		if !network.FrozenUntil.IsZero() {
+			reason += ", until " + network.FrozenUntil.Format(time.RFC3339)
+		}
+		return fmt.Errorf("%w: %s (%s)", ErrNetworkFrozen, netID, reason)
+	}
+	return nil
+}
+
 // NetworkExists - check if network exists
 func NetworkExists(name string) (bool, error) {
 
@@ -0,0 +1,188 @@
+package logic
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/gravitl/netmaker/models"
+	"github.com/gravitl/netmaker/servercfg"
+	"golang.org/x/exp/slog"
+)
+
+var (
+	nodeExpiryWarnedMutex = &sync.Mutex{}
+	// nodeExpiryWarned - expiry a warning was already fired for, per node;
+	// a renewal changes the expiry and re-arms the warning
+	nodeExpiryWarned = make(map[string]time.Time)
+)
+
+// SetNetworkNodeExpiry - sets the default node lifetime and expiry action of a network
+func SetNetworkNodeExpiry(netID string, policy models.NodeExpiryPolicy) (models.Network, error) {
+	network, err := GetNetwork(netID)
+	if err != nil {
+		return network, err
+	}
+	if policy.DefaultNodeTTL < 0 {
+		return network, errors.New("node ttl cannot be negative")
+	}
+	switch policy.NodeExpiryAction {
+	case "":
+		policy.NodeExpiryAction = models.NodeExpiryDelete
+	case models.NodeExpiryDelete, models.NodeExpiryDisconnect:
+	default:
+		return network, errors.New("invalid node expiry action " + policy.NodeExpiryAction)
+	}
+	network.DefaultNodeTTL = policy.DefaultNodeTTL
+	network.NodeExpiryAction = policy.NodeExpiryAction
+	return network, SaveNetwork(&network)
+}
+
+// SetEnrollmentKeyNodeTTL - sets the lifetime of nodes created with an enrollment key,
+// 0 falls back to the default of the network
+func SetEnrollmentKeyNodeTTL(key *models.EnrollmentKey, ttl int64) error {
+	if ttl < 0 {
+		return errors.New("node ttl cannot be negative")
+	}
+	key.NodeTTL = ttl
+	return upsertEnrollmentKey(key)
+}
+
+// ApplyNodeLease - gives a node a lease of ttl seconds starting now
+func ApplyNodeLease(node *models.Node, ttl int64) {
+	if ttl <= 0 {
+		return
+	}
+	node.LeaseTTL = ttl
+	node.ExpirationDateTime = time.Now().Add(time.Duration(ttl) * time.Second)
+}
+
+// RenewNode - extends the lease of a node by ttl seconds from now, a zero ttl renews by the lease
+// of the node; a host renewing its own node can not exceed the lease.
+// A node disconnected on expiry is reconnected
+func RenewNode(node *models.Node, ttl int64, byHost bool) error {
+	if ttl < 0 {
+		return errors.New("ttl cannot be negative")
+	}
+	network, err := GetNetwork(node.Network)
+	if err != nil {
+		return err
+	}
+	lease := node.LeaseTTL
+	if lease == 0 {
+		lease = network.DefaultNodeTTL
+	}
+	if byHost {
+		if lease == 0 {
+			return errors.New("node has no lease to renew")
+		}
+		if ttl > lease {
+			return errors.New("ttl exceeds the lease of the node")
+		}
+	}
+	if ttl == 0 {
+		ttl = lease
+	}
+	if ttl == 0 {
+		return errors.New("node has no lease to renew, a ttl is required")
+	}
+	if time.Now().After(node.ExpirationDateTime) && network.NodeExpiryAction == models.NodeExpiryDisconnect {
+		node.Connected = true
+	}
+	node.ExpirationDateTime = time.Now().Add(time.Duration(ttl) * time.Second)
+	if node.LeaseTTL == 0 {
+		node.LeaseTTL = lease
+	}
+	return UpsertNode(node)
+}
+
+// nodeExpiryAction - action taken when a node of the network expires
+func nodeExpiryAction(networks map[string]models.Network, netID string) string {
+	if network, ok := networks[netID]; ok && network.NodeExpiryAction == models.NodeExpiryDisconnect {
+		return models.NodeExpiryDisconnect
+	}
+	return models.NodeExpiryDelete
+}
+
+// checkNodeExpiry - sorts nodes into the ones to delete, the ones to disconnect and
+// the ones to warn about, each expiry is only warned about once
+func checkNodeExpiry(nodes []models.Node, networks map[string]models.Network, now time.Time,
+	warning time.Duration) (expired, disconnect, warn []models.Node) {
+	nodeExpiryWarnedMutex.Lock()
+	defer nodeExpiryWarnedMutex.Unlock()
+	seen := make(map[string]struct{}, len(nodes))
+	for _, node := range nodes {
+		nodeID := node.ID.String()
+		seen[nodeID] = struct{}{}
+		if now.After(node.ExpirationDateTime) {
+			if nodeExpiryAction(networks, node.Network) == models.NodeExpiryDelete {
+				expired = append(expired, node)
+			} else if node.Connected {
+				disconnect = append(disconnect, node)
+			}
+			continue
+		}
+		if node.ExpirationDateTime.Sub(now) > warning {
+			continue
+		}
+		if warnedFor, ok := nodeExpiryWarned[nodeID]; ok && warnedFor.Equal(node.ExpirationDateTime) {
+			continue
+		}
+		nodeExpiryWarned[nodeID] = node.ExpirationDateTime
+		warn = append(warn, node)
+	}
+	for nodeID := range nodeExpiryWarned {
+		if _, ok := seen[nodeID]; !ok {
+			delete(nodeExpiryWarned, nodeID)
+		}
+	}
+	return
+}
+
+// DeleteExpiredNodes - goroutine which deletes or disconnects nodes which are expired
+// and fires warnings for nodes about to expire
+func DeleteExpiredNodes(ctx context.Context, peerUpdate chan *models.Node, events chan models.NodeExpiryEvent) {
+	// Check Expired Nodes Every Hour
+	ticker := time.NewTicker(time.Hour)
+	for {
+		select {
+		case <-ctx.Done():
+			ticker.Stop()
+			return
+		case <-ticker.C:
+			allnodes, err := GetAllNodes()
+			if err != nil {
+				slog.Error("failed to retrieve all nodes", "error", err.Error())
+				return
+			}
+			networks := make(map[string]models.Network)
+			if nets, err := GetNetworks(); err == nil {
+				for _, network := range nets {
+					networks[network.NetID] = network
+				}
+			}
+			expired, disconnect, warn := checkNodeExpiry(allnodes, networks, time.Now(), servercfg.GetNodeExpiryWarning())
+			for _, node := range warn {
+				slog.Warn("node is about to expire", "nodeid", node.ID.String(), "network", node.Network,
+					"expires", node.ExpirationDateTime)
+				events <- models.NodeExpiryEvent{Type: models.NodeExpiryWarning, Node: node, ExpiresAt: node.ExpirationDateTime}
+			}
+			for _, node := range disconnect {
+				node := node
+				node.Connected = false
+				if err := UpsertNode(&node); err != nil {
+					slog.Error("failed to disconnect expired node", "nodeid", node.ID.String(), "error", err)
+					continue
+				}
+				slog.Info("disconnected expired node", "nodeid", node.ID.String())
+				events <- models.NodeExpiryEvent{Type: models.NodeExpiryDisconnected, Node: node, ExpiresAt: node.ExpirationDateTime}
+			}
+			for _, node := range expired {
+				node := node
+				peerUpdate <- &node
+				slog.Info("deleting expired node", "nodeid", node.ID.String())
+			}
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package logic
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gravitl/netmaker/models"
+	"github.com/matryer/is"
+)
+
+func TestCheckNodeExpiry(t *testing.T) {
+	is := is.New(t)
+	now := time.Now()
+	newNode := func(network string, expires time.Time, connected bool) models.Node {
+		node := models.Node{}
+		node.ID = uuid.New()
+		node.Network = network
+		node.Connected = connected
+		node.ExpirationDateTime = expires
+		return node
+	}
+	networks := map[string]models.Network{
+		"del":  {NetID: "del"},
+		"disc": {NetID: "disc", NodeExpiryAction: models.NodeExpiryDisconnect},
+	}
+	expiredDel := newNode("del", now.Add(-time.Minute), true)
+	expiredDisc := newNode("disc", now.Add(-time.Minute), true)
+	alreadyDisc := newNode("disc", now.Add(-time.Minute), false)
+	expiring := newNode("del", now.Add(time.Hour), true)
+	healthy := newNode("del", now.AddDate(1, 0, 0), true)
+	nodes := []models.Node{expiredDel, expiredDisc, alreadyDisc, expiring, healthy}
+
+	t.Run("sorts nodes", func(t *testing.T) {
+		expired, disconnect, warn := checkNodeExpiry(nodes, networks, now, 24*time.Hour)
+		is.Equal(len(expired), 1)
+		is.Equal(expired[0].ID, expiredDel.ID)
+		is.Equal(len(disconnect), 1)
+		is.Equal(disconnect[0].ID, expiredDisc.ID)
+		is.Equal(len(warn), 1)
+		is.Equal(warn[0].ID, expiring.ID)
+	})
+	t.Run("warns once per expiry", func(t *testing.T) {
+		_, _, warn := checkNodeExpiry(nodes, networks, now, 24*time.Hour)
+		is.Equal(len(warn), 0)
+	})
+	t.Run("renewal re-arms warning", func(t *testing.T) {
+		nodes[3].ExpirationDateTime = now.Add(2 * time.Hour)
+		_, _, warn := checkNodeExpiry(nodes, networks, now, 24*time.Hour)
+		is.Equal(len(warn), 1)
+	})
+}
@@ -1,7 +1,6 @@
 package logic
 
 import (
-	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -191,6 +190,8 @@ func UpsertNode(newNode *models.Node) error {
 		storeNodeInCache(*newNode)
 		storeNodeInNetworkCache(*newNode, newNode.Network)
 	}
+	InvalidateNetworkTagMap(models.NetworkID(newNode.Network))
+	InvalidateNetworkPeerModel(models.NetworkID(newNode.Network))
 	return nil
 }
 
@@ -227,6 +228,8 @@ func UpdateNode(currentNode *models.Node, newNode *models.Node) error {
 			if err != nil {
 				return err
 			}
+			InvalidateNetworkTagMap(models.NetworkID(newNode.Network))
+			InvalidateNetworkPeerModel(models.NetworkID(newNode.Network))
 			if servercfg.CacheEnabled() {
 				storeNodeInCache(*newNode)
 				storeNodeInNetworkCache(*newNode, newNode.Network)
@@ -303,7 +306,7 @@ func DeleteNode(node *models.Node, purge bool) error {
 		if err := UpdateNode(node, &newnode); err != nil {
 			return err
 		}
-		newZombie <- node.ID
+		recordZombie(nodeZombie(node, models.ZombiePendingDelete, "delete requested, waiting for the node to leave"))
 		return nil
 	}
 	if alreadyDeleted {
@@ -352,6 +355,8 @@ func DeleteNodeByID(node *models.Node) error {
 		deleteNodeFromCache(node.ID.String())
 		deleteNodeFromNetworkCache(node.ID.String(), node.Network)
 	}
+	InvalidateNetworkTagMap(models.NetworkID(node.Network))
+	InvalidateNetworkPeerModel(models.NetworkID(node.Network))
 	if servercfg.IsDNSMode() {
 		SetDNS()
 	}
@@ -460,6 +465,8 @@ func AddStaticNodestoList(nodes []models.Node) []models.Node {
 
 func AddStatusToNodes(nodes []models.Node, statusCall bool) (nodesWithStatus []models.Node) {
 	aclDefaultPolicyStatusMap := make(map[string]bool)
+	hostGenerations := GetHostGenerations()
+	driftThreshold := servercfg.GetHostDriftThreshold()
 	for _, node := range nodes {
 		if _, ok := aclDefaultPolicyStatusMap[node.Network]; !ok {
 			// check default policy if all allowed return true
@@ -471,6 +478,14 @@ func AddStatusToNodes(nodes []models.Node, statusCall bool) (nodesWithStatus []m
 		} else {
 			GetNodeCheckInStatus(&node, true)
 		}
+		// an online host that did not apply its desired state in time is flagged
+		if node.Status == models.OnlineSt && !node.IsStatic &&
+			isHostDrifted(hostGenerations, node.HostID.String(), driftThreshold) {
+			node.Status = models.WarningSt
+		}
+		if IsNodeQuarantined(&node) {
+			node.Status = models.Quarantined
+		}
 
 		nodesWithStatus = append(nodesWithStatus, node)
 	}
@@ -517,6 +532,9 @@ func SetNodeDefaults(node *models.Node, resetConnected bool) {
 	if resetConnected {
 		node.SetDefaultConnected()
 	}
+	if node.ExpirationDateTime.IsZero() {
+		ApplyNodeLease(node, parentNetwork.DefaultNodeTTL)
+	}
 	node.SetExpirationDateTime()
 	if node.Tags == nil {
 		node.Tags = make(map[models.TagID]struct{})
@@ -602,32 +620,6 @@ func GetNodesStatusAPI(nodes []models.Node) map[string]models.ApiNodeStatus {
 	return apiStatusNodesMap
 }
 
-// DeleteExpiredNodes - goroutine which deletes nodes which are expired
-func DeleteExpiredNodes(ctx context.Context, peerUpdate chan *models.Node) {
-	// Delete Expired Nodes Every Hour
-	ticker := time.NewTicker(time.Hour)
-	for {
-		select {
-		case <-ctx.Done():
-			ticker.Stop()
-			return
-		case <-ticker.C:
-			allnodes, err := GetAllNodes()
-			if err != nil {
-				slog.Error("failed to retrieve all nodes", "error", err.Error())
-				return
-			}
-			for _, node := range allnodes {
-				node := node
-				if time.Now().After(node.ExpirationDateTime) {
-					peerUpdate <- &node
-					slog.Info("deleting expired node", "nodeid", node.ID.String())
-				}
-			}
-		}
-	}
-}
-
 // createNode - creates a node in database
 func createNode(node *models.Node) error {
 	// lock because we need unique IPs and having it concurrent makes parallel calls result in same "unique" IPs
@@ -710,6 +702,8 @@ func createNode(node *models.Node) error {
 	if err != nil {
 		return err
 	}
+	InvalidateNetworkTagMap(models.NetworkID(node.Network))
+	InvalidateNetworkPeerModel(models.NetworkID(node.Network))
 	if servercfg.CacheEnabled() {
 		storeNodeInCache(*node)
 		storeNodeInNetworkCache(*node, node.Network)
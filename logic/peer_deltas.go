@@ -0,0 +1,233 @@
+package logic
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+	"sync"
+
+	"github.com/gravitl/netmaker/models"
+	"github.com/gravitl/netmaker/servercfg"
+	"golang.zx2c4.com/wireguard/wgctrl/wgtypes"
+)
+
+// hostPeerState - the last peer update sent to a host, split into the parts a delta can carry
+type hostPeerState struct {
+	seq      uint64
+	header   []byte
+	peers    map[string]wgtypes.PeerConfig
+	peerIDs  models.PeerMap
+	hostInfo models.HostInfoMap
+	routes   map[string]models.EgressNetworkRoutes
+	fw       []byte
+	aclRules map[string]models.AclRule
+}
+
+var (
+	peerStateMutex = &sync.Mutex{}
+	peerStates     = make(map[string]*hostPeerState)
+)
+
+func sameJSON(a, b any) bool {
+	x, err := json.Marshal(a)
+	if err != nil {
+		return false
+	}
+	y, err := json.Marshal(b)
+	if err != nil {
+		return false
+	}
+	return bytes.Equal(x, y)
+}
+
+func egressRouteKey(route models.EgressNetworkRoutes) string {
+	return route.Network + "/" + route.PeerKey
+}
+
+// newHostPeerState - splits a peer update, returns false if the update can not be diffed
+// because peers or routes are listed more than once
+func newHostPeerState(update *models.HostPeerUpdate) (*hostPeerState, bool) {
+	state := &hostPeerState{
+		peers:    make(map[string]wgtypes.PeerConfig),
+		peerIDs:  update.PeerIDs,
+		hostInfo: update.HostNetworkInfo,
+		routes:   make(map[string]models.EgressNetworkRoutes),
+		aclRules: update.FwUpdate.AclRules,
+	}
+	// everything not covered by a delta has to match for a delta to be sent
+	header := *update
+	header.Peers = nil
+	header.PeerIDs = nil
+	header.HostNetworkInfo = nil
+	header.EgressRoutes = nil
+	header.FwUpdate = models.FwUpdate{}
+	header.Generation = 0
+	header.ReplacePeers = false
+	// legacy per node peers are not used by clients applying deltas
+	header.NodePeers = nil
+	header.OldPeerUpdateFields = models.OldPeerUpdateFields{}
+	var err error
+	if state.header, err = json.Marshal(header); err != nil {
+		return nil, false
+	}
+	fw := update.FwUpdate
+	fw.AclRules = nil
+	if state.fw, err = json.Marshal(fw); err != nil {
+		return nil, false
+	}
+	for _, peer := range update.Peers {
+		if peer.Remove {
+			continue
+		}
+		if _, ok := state.peers[peer.PublicKey.String()]; ok {
+			return nil, false
+		}
+		state.peers[peer.PublicKey.String()] = peer
+	}
+	for _, route := range update.EgressRoutes {
+		if _, ok := state.routes[egressRouteKey(route)]; ok {
+			return nil, false
+		}
+		state.routes[egressRouteKey(route)] = route
+	}
+	return state, true
+}
+
+// diffPeerStates - computes the changes from the previous to the current state
+func diffPeerStates(prev, cur *hostPeerState) *models.HostPeerDelta {
+	delta := &models.HostPeerDelta{}
+	for key, peer := range cur.peers {
+		old, ok := prev.peers[key]
+		if !ok {
+			delta.AddedPeers = append(delta.AddedPeers, peer)
+			continue
+		}
+		if !sameJSON(old, peer) {
+			peer.ReplaceAllowedIPs = true
+			delta.ChangedPeers = append(delta.ChangedPeers, peer)
+		}
+	}
+	for key, peer := range prev.peers {
+		if _, ok := cur.peers[key]; !ok {
+			delta.RemovedPeers = append(delta.RemovedPeers, wgtypes.PeerConfig{PublicKey: peer.PublicKey, Remove: true})
+		}
+	}
+	for key, peerID := range cur.peerIDs {
+		if old, ok := prev.peerIDs[key]; !ok || old != peerID {
+			if delta.PeerIDs == nil {
+				delta.PeerIDs = make(models.PeerMap)
+			}
+			delta.PeerIDs[key] = peerID
+		}
+	}
+	for key := range prev.peerIDs {
+		if _, ok := cur.peerIDs[key]; !ok {
+			delta.RemovedPeerIDs = append(delta.RemovedPeerIDs, key)
+		}
+	}
+	for key, info := range cur.hostInfo {
+		if old, ok := prev.hostInfo[key]; !ok || !sameJSON(old, info) {
+			if delta.HostNetworkInfo == nil {
+				delta.HostNetworkInfo = make(models.HostInfoMap)
+			}
+			delta.HostNetworkInfo[key] = info
+		}
+	}
+	for key := range prev.hostInfo {
+		if _, ok := cur.hostInfo[key]; !ok {
+			delta.RemovedHostNetworkInfo = append(delta.RemovedHostNetworkInfo, key)
+		}
+	}
+	for key, route := range cur.routes {
+		if old, ok := prev.routes[key]; !ok || !sameJSON(old, route) {
+			delta.EgressRoutes = append(delta.EgressRoutes, route)
+		}
+	}
+	for key, route := range prev.routes {
+		if _, ok := cur.routes[key]; !ok {
+			delta.RemovedEgressRoutes = append(delta.RemovedEgressRoutes,
+				models.EgressNetworkRoutes{Network: route.Network, PeerKey: route.PeerKey})
+		}
+	}
+	if !bytes.Equal(prev.fw, cur.fw) {
+		fw := models.FwUpdate{}
+		if err := json.Unmarshal(cur.fw, &fw); err == nil {
+			delta.FwUpdate = &fw
+		}
+	}
+	for key, rule := range cur.aclRules {
+		if old, ok := prev.aclRules[key]; !ok || !sameJSON(old, rule) {
+			if delta.AclRules == nil {
+				delta.AclRules = make(map[string]models.AclRule)
+			}
+			delta.AclRules[key] = rule
+		}
+	}
+	for key := range prev.aclRules {
+		if _, ok := cur.aclRules[key]; !ok {
+			delta.RemovedAclRules = append(delta.RemovedAclRules, key)
+		}
+	}
+	// stable output for identical changes
+	sortPeers := func(peers []wgtypes.PeerConfig) {
+		sort.Slice(peers, func(i, j int) bool { return peers[i].PublicKey.String() < peers[j].PublicKey.String() })
+	}
+	sortPeers(delta.AddedPeers)
+	sortPeers(delta.ChangedPeers)
+	sortPeers(delta.RemovedPeers)
+	sort.Strings(delta.RemovedPeerIDs)
+	sort.Strings(delta.RemovedHostNetworkInfo)
+	sort.Strings(delta.RemovedAclRules)
+	sort.Slice(delta.EgressRoutes, func(i, j int) bool {
+		return egressRouteKey(delta.EgressRoutes[i]) < egressRouteKey(delta.EgressRoutes[j])
+	})
+	sort.Slice(delta.RemovedEgressRoutes, func(i, j int) bool {
+		return egressRouteKey(delta.RemovedEgressRoutes[i]) < egressRouteKey(delta.RemovedEgressRoutes[j])
+	})
+	return delta
+}
+
+// NextPeerDelta - records a peer update as sent to the host and stamps it with the next generation;
+// returns full when the host needs the complete update, otherwise the delta against the last update
+// sent, which is nil if nothing changed and nothing has to be published
+func NextPeerDelta(host *models.Host, update *models.HostPeerUpdate) (delta *models.HostPeerDelta, full bool) {
+	hostID := host.ID.String()
+	peerStateMutex.Lock()
+	defer peerStateMutex.Unlock()
+	if !host.PeerDeltas {
+		delete(peerStates, hostID)
+		update.Generation = NextHostGeneration(hostID)
+		return nil, true
+	}
+	state, ok := newHostPeerState(update)
+	prev := peerStates[hostID]
+	if !ok || prev == nil || update.ReplacePeers || !bytes.Equal(prev.header, state.header) {
+		update.Generation = NextHostGeneration(hostID)
+		if ok {
+			state.seq = update.Generation
+			peerStates[hostID] = state
+		} else {
+			delete(peerStates, hostID)
+		}
+		return nil, true
+	}
+	delta = diffPeerStates(prev, state)
+	if delta.IsEmpty() {
+		return nil, false
+	}
+	update.Generation = NextHostGeneration(hostID)
+	delta.HostID = hostID
+	delta.Server = servercfg.GetServer()
+	delta.BaseSeq = prev.seq
+	delta.Seq = update.Generation
+	state.seq = update.Generation
+	peerStates[hostID] = state
+	return delta, false
+}
+
+// ResetPeerState - forgets the last peer update sent to a host, the next update is sent in full
+func ResetPeerState(hostID string) {
+	peerStateMutex.Lock()
+	defer peerStateMutex.Unlock()
+	delete(peerStates, hostID)
+}
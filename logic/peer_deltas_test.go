@@ -0,0 +1,77 @@
+package logic
+
+import (
+	"net"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/gravitl/netmaker/database"
+	"github.com/gravitl/netmaker/models"
+	"github.com/stretchr/testify/assert"
+	"golang.zx2c4.com/wireguard/wgctrl/wgtypes"
+)
+
+func TestNextPeerDelta(t *testing.T) {
+	database.InitializeDatabase()
+	defer database.CloseDB()
+	host := models.Host{ID: uuid.New(), PeerDeltas: true}
+	defer DeleteHostGeneration(host.ID.String())
+	defer ResetPeerState(host.ID.String())
+	newPeer := func(ip string) wgtypes.PeerConfig {
+		key, _ := wgtypes.GeneratePrivateKey()
+		return wgtypes.PeerConfig{PublicKey: key.PublicKey(), AllowedIPs: []net.IPNet{{IP: net.ParseIP(ip), Mask: net.CIDRMask(32, 32)}}}
+	}
+	a, b, c := newPeer("10.0.0.1"), newPeer("10.0.0.2"), newPeer("10.0.0.3")
+	newUpdate := func(peers ...wgtypes.PeerConfig) *models.HostPeerUpdate {
+		return &models.HostPeerUpdate{Host: host, Peers: peers, FwUpdate: models.FwUpdate{
+			AclRules: map[string]models.AclRule{"rule": {ID: "rule"}},
+		}}
+	}
+
+	delta, full := NextPeerDelta(&host, newUpdate(a, b))
+	assert.True(t, full)
+	assert.Nil(t, delta)
+	first := GetHostGeneration(host.ID.String()).Desired
+
+	t.Run("Unchanged", func(t *testing.T) {
+		delta, full := NextPeerDelta(&host, newUpdate(b, a))
+		assert.False(t, full)
+		assert.Nil(t, delta)
+		assert.Equal(t, first, GetHostGeneration(host.ID.String()).Desired)
+	})
+	t.Run("Delta", func(t *testing.T) {
+		changed := b
+		changed.AllowedIPs = append(changed.AllowedIPs, net.IPNet{IP: net.ParseIP("10.1.0.0"), Mask: net.CIDRMask(16, 32)})
+		update := newUpdate(changed, c)
+		update.FwUpdate.AclRules = map[string]models.AclRule{}
+		delta, full := NextPeerDelta(&host, update)
+		assert.False(t, full)
+		assert.NotNil(t, delta)
+		assert.Equal(t, first, delta.BaseSeq)
+		assert.Equal(t, update.Generation, delta.Seq)
+		assert.Equal(t, []wgtypes.PeerConfig{c}, delta.AddedPeers)
+		assert.Len(t, delta.ChangedPeers, 1)
+		assert.True(t, delta.ChangedPeers[0].ReplaceAllowedIPs)
+		assert.Equal(t, []wgtypes.PeerConfig{{PublicKey: a.PublicKey, Remove: true}}, delta.RemovedPeers)
+		assert.Equal(t, []string{"rule"}, delta.RemovedAclRules)
+		assert.Nil(t, delta.FwUpdate)
+	})
+	t.Run("ReplacePeers", func(t *testing.T) {
+		update := newUpdate(c)
+		update.ReplacePeers = true
+		_, full := NextPeerDelta(&host, update)
+		assert.True(t, full)
+	})
+	t.Run("HeaderChange", func(t *testing.T) {
+		update := newUpdate(c)
+		update.NameServers = []string{"1.1.1.1"}
+		_, full := NextPeerDelta(&host, update)
+		assert.True(t, full)
+	})
+	t.Run("Unsupported", func(t *testing.T) {
+		legacy := host
+		legacy.PeerDeltas = false
+		_, full := NextPeerDelta(&legacy, newUpdate(c))
+		assert.True(t, full)
+	})
+}
@@ -0,0 +1,138 @@
+package logic
+
+import (
+	"net"
+	"sort"
+	"sync"
+
+	"github.com/gravitl/netmaker/logger"
+	"github.com/gravitl/netmaker/models"
+)
+
+// networkPeerModel - the peers of a network compiled for the peer updates of its nodes: the nodes
+// with their hosts and addresses, and per node the peers it may reach, compiled on the node's
+// first update. Rebuilt when the network's nodes, hosts, acls or tags change; state kept
+// elsewhere (quarantines, connectivity profiles, old style node acls) is checked on every update
+type networkPeerModel struct {
+	// nodes and hosts - the network's nodes ordered by id and the host of each
+	nodes []models.Node
+	hosts []models.Host
+	// addrs - the allowed ips of each node when it is a peer without gateway or relay roles,
+	// clipped so appending to them copies
+	addrs     [][]net.IPNet
+	mutex     sync.RWMutex
+	reachable map[string]peerSet
+}
+
+// peerSet - a bit per index of the model's nodes
+type peerSet []uint64
+
+func (s peerSet) has(i int) bool {
+	return s[i/64]&(1<<(i%64)) != 0
+}
+
+var (
+	networkPeerModelsMutex = &sync.RWMutex{}
+	networkPeerModels      = make(map[models.NetworkID]*networkPeerModel)
+	networkPeerModelsGen   uint64
+)
+
+// getNetworkPeerModel - fetches the peer model of a network, compiling it if needed
+func getNetworkPeerModel(netID models.NetworkID) *networkPeerModel {
+	networkPeerModelsMutex.RLock()
+	m, ok := networkPeerModels[netID]
+	gen := networkPeerModelsGen
+	networkPeerModelsMutex.RUnlock()
+	if ok {
+		return m
+	}
+	m = compileNetworkPeerModel(netID)
+	networkPeerModelsMutex.Lock()
+	defer networkPeerModelsMutex.Unlock()
+	if existing, ok := networkPeerModels[netID]; ok {
+		return existing
+	}
+	// the network changed while compiling, use the result once but do not keep it
+	if gen == networkPeerModelsGen {
+		networkPeerModels[netID] = m
+	}
+	return m
+}
+
+// compileNetworkPeerModel - compiles the peer model from the stored nodes and hosts of a network
+func compileNetworkPeerModel(netID models.NetworkID) *networkPeerModel {
+	m := &networkPeerModel{
+		reachable: make(map[string]peerSet),
+	}
+	nodes, _ := GetNetworkNodes(netID.String())
+	sort.Slice(nodes, func(i, j int) bool {
+		return nodes[i].ID.String() < nodes[j].ID.String()
+	})
+	for _, node := range nodes {
+		host, err := GetHost(node.HostID.String())
+		if err != nil {
+			logger.Log(1, "no peer host", node.HostID.String(), err.Error())
+			continue
+		}
+		addrs := getNodeAddrIPs(&node)
+		m.nodes = append(m.nodes, node)
+		m.hosts = append(m.hosts, *host)
+		m.addrs = append(m.addrs, addrs[:len(addrs):len(addrs)])
+	}
+	return m
+}
+
+// reachablePeers - the peers a node may reach by their state and the network's device policies
+func (m *networkPeerModel) reachablePeers(node *models.Node) peerSet {
+	m.mutex.RLock()
+	set, ok := m.reachable[node.ID.String()]
+	m.mutex.RUnlock()
+	if ok {
+		return set
+	}
+	defaultDevicePolicy, _ := GetDefaultPolicy(models.NetworkID(node.Network), models.DevicePolicy)
+	set = make(peerSet, (len(m.nodes)+63)/64)
+	for i := range m.nodes {
+		peer := &m.nodes[i]
+		if peer.ID == node.ID || peer.Action == models.NODE_DELETE || peer.PendingDelete || !peer.Connected {
+			continue
+		}
+		if defaultDevicePolicy.Enabled || IsPeerAllowed(*node, *peer, false) {
+			set[i/64] |= 1 << (i % 64)
+		}
+	}
+	m.mutex.Lock()
+	m.reachable[node.ID.String()] = set
+	m.mutex.Unlock()
+	return set
+}
+
+// allowedIPs - the allowed ips of the i-th peer of the model for a node, shared unless the peer
+// or the node has a role that adds ranges to them
+func (m *networkPeerModel) allowedIPs(node *models.Node, i int) []net.IPNet {
+	peer := &m.nodes[i]
+	if peer.IsEgressGateway || peer.IsRelay || peer.IsFailOver || peer.IsIngressGateway ||
+		(peer.IsInternetGateway && node.InternetGwID == peer.ID.String()) ||
+		(node.IsRelayed && node.RelayedBy == peer.ID.String()) {
+		p := *peer
+		return GetAllowedIPs(node, &p, nil)
+	}
+	return m.addrs[i]
+}
+
+// InvalidateNetworkPeerModel - drops the peer model of a network after its nodes, hosts, acls or tags changed
+func InvalidateNetworkPeerModel(netID models.NetworkID) {
+	networkPeerModelsMutex.Lock()
+	defer networkPeerModelsMutex.Unlock()
+	delete(networkPeerModels, netID)
+	networkPeerModelsGen++
+}
+
+// invalidateHostPeerModels - drops the peer models of the networks a host is in after it changed
+func invalidateHostPeerModels(h *models.Host) {
+	for _, nodeID := range h.Nodes {
+		if node, err := GetNodeByID(nodeID); err == nil {
+			InvalidateNetworkPeerModel(models.NetworkID(node.Network))
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package logic
+
+import (
+	"fmt"
+	"net"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/gravitl/netmaker/database"
+	"github.com/gravitl/netmaker/models"
+	"github.com/stretchr/testify/assert"
+	"golang.zx2c4.com/wireguard/wgctrl/wgtypes"
+)
+
+func TestNetworkPeerModel(t *testing.T) {
+	t.Setenv("DNS_MODE", "off")
+	// peers are checked against the device policies only
+	t.Setenv("OLD_ACL_SUPPORT", "false")
+	database.InitializeDatabase()
+	defer database.CloseDB()
+	netID := models.NetworkID("peermodelnet")
+	_, cidr, _ := net.ParseCIDR("10.78.0.0/24")
+	network := models.Network{NetID: netID.String(), AddressRange: cidr.String()}
+	assert.Nil(t, SaveNetwork(&network))
+	CreateDefaultAclNetworkPolicies(netID)
+	defer DeleteNetworkPolicies(netID)
+	defaultAcl, err := GetAcl(fmt.Sprintf("%s.%s", netID, "all-nodes"))
+	assert.Nil(t, err)
+	assert.Nil(t, UpdateAcl(models.Acl{Enabled: false}, defaultAcl))
+	policy := func(src, dst string) models.Acl {
+		return models.Acl{
+			ID:               uuid.NewString(),
+			Name:             src + "-" + dst,
+			NetworkID:        netID,
+			RuleType:         models.DevicePolicy,
+			Src:              []models.AclPolicyTag{{ID: models.NodeTagID, Value: src}},
+			Dst:              []models.AclPolicyTag{{ID: models.NodeTagID, Value: dst}},
+			AllowedDirection: models.TrafficDirectionBi,
+			Proto:            models.ALL,
+			ServiceType:      models.Any,
+			Enabled:          true,
+		}
+	}
+	assert.Nil(t, InsertAcl(policy("peermodelnet.web", "peermodelnet.db")))
+
+	hosts := make([]models.Host, 3)
+	nodes := make([]models.Node, 3)
+	for i, tag := range []models.TagID{"peermodelnet.web", "peermodelnet.db", "peermodelnet.ops"} {
+		key, _ := wgtypes.GeneratePrivateKey()
+		hosts[i] = models.Host{ID: uuid.New(), Name: string(tag), PublicKey: key.PublicKey(), ListenPort: 51821,
+			EndpointIP: net.IPv4(100, 64, 0, byte(i+1))}
+		nodes[i] = models.Node{CommonNode: models.CommonNode{ID: uuid.New(), HostID: hosts[i].ID, Network: netID.String(),
+			NetworkRange: *cidr, Address: net.IPNet{IP: net.IPv4(10, 78, 0, byte(i+1)), Mask: cidr.Mask}, Connected: true},
+			Tags: map[models.TagID]struct{}{tag: {}}}
+		hosts[i].Nodes = []string{nodes[i].ID.String()}
+		assert.Nil(t, UpsertHost(&hosts[i]))
+		defer RemoveHostByID(hosts[i].ID.String())
+		assert.Nil(t, UpsertNode(&nodes[i]))
+		defer DeleteNodeByID(&nodes[i])
+	}
+	web, db, ops := &hosts[0], &hosts[1], &hosts[2]
+	peerOf := func(update models.HostPeerUpdate, peerHost *models.Host) *wgtypes.PeerConfig {
+		for i := range update.Peers {
+			if update.Peers[i].PublicKey == peerHost.PublicKey {
+				return &update.Peers[i]
+			}
+		}
+		return nil
+	}
+
+	update, err := GetPeerUpdateForHost(netID.String(), web, nil, nil, nil)
+	assert.Nil(t, err)
+	assert.Len(t, peerOf(update, db).AllowedIPs, 1)
+	assert.True(t, peerOf(update, ops).Remove, "no policy lets web reach ops")
+	model := getNetworkPeerModel(netID)
+	assert.Same(t, model, getNetworkPeerModel(netID), "the model is kept until the network changes")
+
+	t.Run("AclChange", func(t *testing.T) {
+		assert.Nil(t, InsertAcl(policy("peermodelnet.web", "peermodelnet.ops")))
+		assert.NotSame(t, model, getNetworkPeerModel(netID))
+		update, err := GetPeerUpdateForHost(netID.String(), web, nil, nil, nil)
+		assert.Nil(t, err)
+		assert.False(t, peerOf(update, ops).Remove)
+		assert.Equal(t, nodes[2].Address.IP.String(), peerOf(update, ops).AllowedIPs[0].IP.String())
+	})
+	t.Run("HostChange", func(t *testing.T) {
+		db.EndpointIP = net.IPv4(100, 64, 1, 2)
+		assert.Nil(t, UpsertHost(db))
+		update, err := GetPeerUpdateForHost(netID.String(), web, nil, nil, nil)
+		assert.Nil(t, err)
+		assert.Equal(t, db.EndpointIP.String(), peerOf(update, db).Endpoint.IP.String())
+	})
+	t.Run("TagChange", func(t *testing.T) {
+		nodes[2].Tags = map[models.TagID]struct{}{}
+		assert.Nil(t, UpsertNode(&nodes[2]))
+		update, err := GetPeerUpdateForHost(netID.String(), web, nil, nil, nil)
+		assert.Nil(t, err)
+		assert.True(t, peerOf(update, ops).Remove, "ops lost the tag the policy allows")
+	})
+	t.Run("NodeChange", func(t *testing.T) {
+		nodes[1].Connected = false
+		assert.Nil(t, UpsertNode(&nodes[1]))
+		update, err := GetPeerUpdateForHost(netID.String(), web, nil, nil, nil)
+		assert.Nil(t, err)
+		assert.True(t, peerOf(update, db).Remove, "disconnected peers are not reachable")
+	})
+}
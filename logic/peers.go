@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"net"
 	"net/netip"
+	"time"
 
 	"github.com/gravitl/netmaker/database"
 	"github.com/gravitl/netmaker/logger"
@@ -64,10 +65,6 @@ func GetHostPeerInfo(host *models.Host) (models.HostPeerInfo, error) {
 	peerInfo := models.HostPeerInfo{
 		NetworkPeerIDs: make(map[models.NetworkID]models.PeerMap),
 	}
-	allNodes, err := GetAllNodes()
-	if err != nil {
-		return peerInfo, err
-	}
 	for _, nodeID := range host.Nodes {
 		nodeID := nodeID
 		node, err := GetNodeByID(nodeID)
@@ -79,34 +76,13 @@ func GetHostPeerInfo(host *models.Host) (models.HostPeerInfo, error) {
 			continue
 		}
 		networkPeersInfo := make(models.PeerMap)
-		defaultDevicePolicy, _ := GetDefaultPolicy(models.NetworkID(node.Network), models.DevicePolicy)
-
-		currentPeers := GetNetworkNodesMemory(allNodes, node.Network)
-		for _, peer := range currentPeers {
-			peer := peer
-			if peer.ID.String() == node.ID.String() {
-				logger.Log(2, "peer update, skipping self")
-				// skip yourself
-				continue
-			}
-
-			peerHost, err := GetHost(peer.HostID.String())
-			if err != nil {
-				logger.Log(1, "no peer host", peer.HostID.String(), err.Error())
-				continue
-			}
-
-			var allowedToComm bool
-			if defaultDevicePolicy.Enabled {
-				allowedToComm = true
-			} else {
-				allowedToComm = IsPeerAllowed(node, peer, false)
-			}
-			if peer.Action != models.NODE_DELETE &&
-				!peer.PendingDelete &&
-				peer.Connected &&
-				nodeacls.AreNodesAllowed(nodeacls.NetworkID(node.Network), nodeacls.NodeID(node.ID.String()), nodeacls.NodeID(peer.ID.String())) &&
-				(defaultDevicePolicy.Enabled || allowedToComm) {
+		peerModel := getNetworkPeerModel(models.NetworkID(node.Network))
+		reachable := peerModel.reachablePeers(&node)
+		for i := range peerModel.nodes {
+			peer := &peerModel.nodes[i]
+			peerHost := &peerModel.hosts[i]
+			if reachable.has(i) &&
+				nodeacls.AreNodesAllowed(nodeacls.NetworkID(node.Network), nodeacls.NodeID(node.ID.String()), nodeacls.NodeID(peer.ID.String())) {
 
 				networkPeersInfo[peerHost.PublicKey.String()] = models.IDandAddr{
 					ID:         peer.ID.String(),
@@ -133,7 +109,10 @@ func GetHostPeerInfo(host *models.Host) (models.HostPeerInfo, error) {
 	return peerInfo, nil
 }
 
-// GetPeerUpdateForHost - gets the consolidated peer update for the host from all networks
+// GetPeerUpdateForHost - gets the consolidated peer update for the host from all networks.
+// Peers, their hosts and whether they can be reached are served from the peer models of the
+// host's networks, which are compiled once per change of their nodes, hosts, acls or tags
+// allNodes is no longer read, the models are compiled from the stored nodes
 func GetPeerUpdateForHost(network string, host *models.Host, allNodes []models.Node,
 	deletedNode *models.Node, deletedClients []models.ExtClient) (models.HostPeerUpdate, error) {
 	if host == nil {
@@ -184,8 +163,14 @@ func GetPeerUpdateForHost(network string, host *models.Host, allNodes []models.N
 		}
 	}()
 
+	// a quarantined host gets no peers, and is removed from every peer below
+	if IsHostQuarantined(host.ID.String()) {
+		return hostPeerUpdate, nil
+	}
 	slog.Debug("peer update for host", "hostId", host.ID.String())
 	peerIndexMap := make(map[string]int)
+	connectivity := newConnectivityResolver()
+	hostNodes := []models.Node{}
 	for _, nodeID := range host.Nodes {
 		networkAllowAll := true
 		nodeID := nodeID
@@ -194,9 +179,10 @@ func GetPeerUpdateForHost(network string, host *models.Host, allNodes []models.N
 			continue
 		}
 
-		if !node.Connected || node.PendingDelete || node.Action == models.NODE_DELETE {
+		if !node.Connected || node.PendingDelete || node.Action == models.NODE_DELETE || IsNodeQuarantined(&node) {
 			continue
 		}
+		hostNodes = append(hostNodes, node)
 		hostPeerUpdate = SetDefaultGw(node, hostPeerUpdate)
 		if !hostPeerUpdate.IsInternetGw {
 			hostPeerUpdate.IsInternetGw = IsInternetGw(node)
@@ -204,7 +190,9 @@ func GetPeerUpdateForHost(network string, host *models.Host, allNodes []models.N
 		defaultUserPolicy, _ := GetDefaultPolicy(models.NetworkID(node.Network), models.UserPolicy)
 		defaultDevicePolicy, _ := GetDefaultPolicy(models.NetworkID(node.Network), models.DevicePolicy)
 
-		if (defaultDevicePolicy.Enabled && defaultUserPolicy.Enabled) || (!checkIfAnyPolicyisUniDirectional(node) && !checkIfAnyActiveEgressPolicy(node)) {
+		if !hasDenyPolicy(models.NetworkID(node.Network)) &&
+			((defaultDevicePolicy.Enabled && defaultUserPolicy.Enabled) ||
+				(!checkIfAnyPolicyisUniDirectional(node) && !checkIfAnyActiveEgressPolicy(node))) {
 			if node.NetworkRange.IP != nil {
 				hostPeerUpdate.FwUpdate.AllowedNetworks = append(hostPeerUpdate.FwUpdate.AllowedNetworks, node.NetworkRange)
 			}
@@ -228,25 +216,35 @@ func GetPeerUpdateForHost(network string, host *models.Host, allNodes []models.N
 			continue
 		}
 		hostPeerUpdate.NameServers = append(hostPeerUpdate.NameServers, networkSettings.NameServers...)
-		currentPeers := GetNetworkNodesMemory(allNodes, node.Network)
-		for _, peer := range currentPeers {
-			peer := peer
+		peerModel := getNetworkPeerModel(models.NetworkID(node.Network))
+		reachable := peerModel.reachablePeers(&node)
+		for i := range peerModel.nodes {
+			peer := peerModel.nodes[i]
 			if peer.ID.String() == node.ID.String() {
 				logger.Log(2, "peer update, skipping self")
 				// skip yourself
 				continue
 			}
 
-			peerHost, err := GetHost(peer.HostID.String())
-			if err != nil {
-				logger.Log(1, "no peer host", peer.HostID.String(), err.Error())
-				continue
-			}
+			peerHost := peerModel.hosts[i]
 			peerConfig := wgtypes.PeerConfig{
 				PublicKey:                   peerHost.PublicKey,
 				PersistentKeepaliveInterval: &peerHost.PersistentKeepalive,
 				ReplaceAllowedIPs:           true,
 			}
+			if IsNodeQuarantined(&peer) {
+				// server enforced, the peer can not undo it by reconnecting
+				if _, ok := peerIndexMap[peerHost.PublicKey.String()]; !ok {
+					peerConfig.Remove = true
+					hostPeerUpdate.Peers = append(hostPeerUpdate.Peers, peerConfig)
+					peerIndexMap[peerHost.PublicKey.String()] = len(hostPeerUpdate.Peers) - 1
+				}
+				continue
+			}
+			if keepalive := connectivity.peerKeepalive(&node, &peer); keepalive != 0 {
+				keepaliveInterval := time.Duration(keepalive) * time.Second
+				peerConfig.PersistentKeepaliveInterval = &keepaliveInterval
+			}
 			_, isFailOverPeer := node.FailOverPeers[peer.ID.String()]
 			if peer.IsEgressGateway {
 				peerKey := peerHost.PublicKey.String()
@@ -345,25 +343,16 @@ func GetPeerUpdateForHost(network string, host *models.Host, allNodes []models.N
 
 			peerConfig.Endpoint = &net.UDPAddr{
 				IP:   peerEndpoint,
-				Port: GetPeerListenPort(peerHost),
+				Port: GetPeerListenPort(&peerHost),
 			}
 
 			if uselocal {
 				peerConfig.Endpoint.Port = peerHost.ListenPort
 			}
-			var allowedToComm bool
-			if defaultDevicePolicy.Enabled {
-				allowedToComm = true
-			} else {
-				allowedToComm = IsPeerAllowed(node, peer, false)
-			}
-			if peer.Action != models.NODE_DELETE &&
-				!peer.PendingDelete &&
-				peer.Connected &&
+			if reachable.has(i) &&
 				nodeacls.AreNodesAllowed(nodeacls.NetworkID(node.Network), nodeacls.NodeID(node.ID.String()), nodeacls.NodeID(peer.ID.String())) &&
-				(defaultDevicePolicy.Enabled || allowedToComm) &&
 				(deletedNode == nil || (deletedNode != nil && peer.ID.String() != deletedNode.ID.String())) {
-				peerConfig.AllowedIPs = GetAllowedIPs(&node, &peer, nil) // only append allowed IPs if valid connection
+				peerConfig.AllowedIPs = peerModel.allowedIPs(&node, i) // only append allowed IPs if valid connection
 			}
 
 			var nodePeer wgtypes.PeerConfig
@@ -495,6 +484,9 @@ func GetPeerUpdateForHost(network string, host *models.Host, allNodes []models.N
 
 	}
 	// == post peer calculations ==
+	if settings := connectivity.hostSettings(hostNodes); len(settings.ProfileIDs) > 0 {
+		hostPeerUpdate.Connectivity = &settings
+	}
 	// indicate removal if no allowed IPs were calculated
 	for i := range hostPeerUpdate.Peers {
 		peer := hostPeerUpdate.Peers[i]
@@ -653,21 +645,7 @@ func GetEgressIPs(peer *models.Node) []net.IPNet {
 }
 
 func getNodeAllowedIPs(peer, node *models.Node) []net.IPNet {
-	var allowedips = []net.IPNet{}
-	if peer.Address.IP != nil {
-		allowed := net.IPNet{
-			IP:   peer.Address.IP,
-			Mask: net.CIDRMask(32, 32),
-		}
-		allowedips = append(allowedips, allowed)
-	}
-	if peer.Address6.IP != nil {
-		allowed := net.IPNet{
-			IP:   peer.Address6.IP,
-			Mask: net.CIDRMask(128, 128),
-		}
-		allowedips = append(allowedips, allowed)
-	}
+	allowedips := getNodeAddrIPs(peer)
 	// handle egress gateway peers
 	if peer.IsEgressGateway {
 		// hasGateway = true
@@ -695,6 +673,26 @@ func getNodeAllowedIPs(peer, node *models.Node) []net.IPNet {
 	return allowedips
 }
 
+// getNodeAddrIPs - the allowed ips of a node's own addresses
+func getNodeAddrIPs(node *models.Node) []net.IPNet {
+	var allowedips = []net.IPNet{}
+	if node.Address.IP != nil {
+		allowed := net.IPNet{
+			IP:   node.Address.IP,
+			Mask: net.CIDRMask(32, 32),
+		}
+		allowedips = append(allowedips, allowed)
+	}
+	if node.Address6.IP != nil {
+		allowed := net.IPNet{
+			IP:   node.Address6.IP,
+			Mask: net.CIDRMask(128, 128),
+		}
+		allowedips = append(allowedips, allowed)
+	}
+	return allowedips
+}
+
 func getCIDRMaskFromAddr(addr string) net.IPMask {
 	cidr := net.CIDRMask(32, 32)
 	ipAddr, err := netip.ParseAddr(addr)
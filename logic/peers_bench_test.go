@@ -0,0 +1,179 @@
+package logic
+
+import (
+	"fmt"
+	"net"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/gravitl/netmaker/database"
+	"github.com/gravitl/netmaker/models"
+	"golang.zx2c4.com/wireguard/wgctrl/wgtypes"
+)
+
+// benchNetworkSizes - node counts of the synthetic networks peer updates are measured against
+var benchNetworkSizes = []int{1000, 2000, 5000}
+
+// benchTagGroups - number of tags the synthetic nodes are spread over
+const benchTagGroups = 10
+
+// setupBenchNetwork - creates a network with size nodes on their own hosts, spread over tag groups
+// with one policy per neighbouring group pair and the default device policy disabled
+func setupBenchNetwork(b *testing.B, size int) (netID string, hosts []models.Host, nodes []models.Node, cleanup func()) {
+	b.Helper()
+	netID = fmt.Sprintf("bench-%d", size)
+	_, cidr, _ := net.ParseCIDR("10.0.0.0/16")
+	network := models.Network{NetID: netID, AddressRange: cidr.String()}
+	if err := SaveNetwork(&network); err != nil {
+		b.Fatal(err)
+	}
+	CreateDefaultAclNetworkPolicies(models.NetworkID(netID))
+	defaultAcl, err := GetAcl(fmt.Sprintf("%s.%s", netID, "all-nodes"))
+	if err != nil {
+		b.Fatal(err)
+	}
+	if err := UpdateAcl(models.Acl{Enabled: false}, defaultAcl); err != nil {
+		b.Fatal(err)
+	}
+	tag := func(i int) string { return fmt.Sprintf("%s.group-%d", netID, i%benchTagGroups) }
+	for i := 0; i < benchTagGroups; i++ {
+		acl := models.Acl{
+			ID:               uuid.NewString(),
+			Name:             tag(i),
+			NetworkID:        models.NetworkID(netID),
+			RuleType:         models.DevicePolicy,
+			Src:              []models.AclPolicyTag{{ID: models.NodeTagID, Value: tag(i)}},
+			Dst:              []models.AclPolicyTag{{ID: models.NodeTagID, Value: tag(i + 1)}},
+			AllowedDirection: models.TrafficDirectionUni,
+			Proto:            models.TCP,
+			ServiceType:      models.Custom,
+			Port:             []string{"443"},
+			Enabled:          true,
+		}
+		if err := InsertAcl(acl); err != nil {
+			b.Fatal(err)
+		}
+	}
+	for i := 0; i < size; i++ {
+		key, _ := wgtypes.GeneratePrivateKey()
+		host := models.Host{
+			ID:                  uuid.New(),
+			Name:                fmt.Sprintf("bench-host-%d", i),
+			PublicKey:           key.PublicKey(),
+			ListenPort:          51821,
+			EndpointIP:          net.IPv4(100, 64, byte(i>>8), byte(i)),
+			PersistentKeepalive: 20,
+		}
+		node := models.Node{
+			CommonNode: models.CommonNode{
+				ID:           uuid.New(),
+				HostID:       host.ID,
+				Network:      netID,
+				NetworkRange: *cidr,
+				Address:      net.IPNet{IP: net.IPv4(10, 0, byte((i+1)>>8), byte(i+1)), Mask: cidr.Mask},
+				Connected:    true,
+			},
+			Tags: map[models.TagID]struct{}{models.TagID(tag(i)): {}},
+		}
+		host.Nodes = []string{node.ID.String()}
+		if err := UpsertHost(&host); err != nil {
+			b.Fatal(err)
+		}
+		if err := UpsertNode(&node); err != nil {
+			b.Fatal(err)
+		}
+		hosts = append(hosts, host)
+		nodes = append(nodes, node)
+	}
+	cleanup = func() {
+		for i := range nodes {
+			DeleteNodeByID(&nodes[i])
+			RemoveHostByID(hosts[i].ID.String())
+		}
+		DeleteNetworkPolicies(models.NetworkID(netID))
+		DeleteNetwork(netID, false, nil)
+	}
+	return
+}
+
+func runBenchNetworks(b *testing.B, bench func(b *testing.B, netID string, hosts []models.Host, nodes []models.Node)) {
+	// deleting thousands of nodes would rewrite the hosts file for each of them
+	b.Setenv("DNS_MODE", "off")
+	// peers are checked against the device policies only
+	b.Setenv("OLD_ACL_SUPPORT", "false")
+	database.InitializeDatabase()
+	defer database.CloseDB()
+	for _, size := range benchNetworkSizes {
+		// set up once per size, sub-benchmarks are run repeatedly while b.N grows
+		netID, hosts, nodes, cleanup := setupBenchNetwork(b, size)
+		b.Run(fmt.Sprintf("nodes-%d", size), func(b *testing.B) {
+			bench(b, netID, hosts, nodes)
+		})
+		cleanup()
+	}
+}
+
+// BenchmarkGetPeerUpdateForHost - the update of a single host, served from the network's peer model
+// once compiled; rebuild pays for a node change in the network before every update
+func BenchmarkGetPeerUpdateForHost(b *testing.B) {
+	runBenchNetworks(b, func(b *testing.B, netID string, hosts []models.Host, nodes []models.Node) {
+		b.Run("cached", func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if _, err := GetPeerUpdateForHost("", &hosts[i%len(hosts)], nil, nil, nil); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+		b.Run("rebuild", func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				InvalidateNetworkPeerModel(models.NetworkID(netID))
+				if _, err := GetPeerUpdateForHost("", &hosts[i%len(hosts)], nil, nil, nil); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	})
+}
+
+// BenchmarkGetPeerUpdateForNetwork - the updates of every host of the network after one of its nodes changed
+func BenchmarkGetPeerUpdateForNetwork(b *testing.B) {
+	runBenchNetworks(b, func(b *testing.B, netID string, hosts []models.Host, nodes []models.Node) {
+		for i := 0; i < b.N; i++ {
+			InvalidateNetworkPeerModel(models.NetworkID(netID))
+			for j := range hosts {
+				if _, err := GetPeerUpdateForHost("", &hosts[j], nil, nil, nil); err != nil {
+					b.Fatal(err)
+				}
+			}
+		}
+	})
+}
+
+func BenchmarkIsNodeAllowedToCommunicate(b *testing.B) {
+	runBenchNetworks(b, func(b *testing.B, netID string, hosts []models.Host, nodes []models.Node) {
+		for i := 0; i < b.N; i++ {
+			node := nodes[i%len(nodes)]
+			for _, peer := range nodes[:100] {
+				IsNodeAllowedToCommunicateV1(node, peer, true)
+				IsPeerAllowed(node, peer, true)
+			}
+		}
+	})
+}
+
+func BenchmarkGetAclRulesForNode(b *testing.B) {
+	runBenchNetworks(b, func(b *testing.B, netID string, hosts []models.Host, nodes []models.Node) {
+		b.Run("cached", func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				GetAclRulesForNode(&nodes[i%len(nodes)])
+			}
+		})
+		b.Run("rebuild", func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				// every iteration pays for a node change in the network
+				InvalidateNetworkTagMap(models.NetworkID(netID))
+				GetAclRulesForNode(&nodes[i%len(nodes)])
+			}
+		})
+	})
+}
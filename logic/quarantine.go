@@ -0,0 +1,174 @@
+package logic
+
+import (
+	"encoding/json"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gravitl/netmaker/database"
+	"github.com/gravitl/netmaker/models"
+	"golang.org/x/exp/slog"
+)
+
+var (
+	quarantineMutex  = &sync.RWMutex{}
+	quarantineCache  = make(map[string]models.Quarantine)
+	quarantineLoaded bool
+)
+
+// loadQuarantine - loads the quarantine records from the DB into the cache, caller must hold the write lock
+func loadQuarantine() {
+	if quarantineLoaded {
+		return
+	}
+	records, err := database.FetchRecords(database.QUARANTINE_TABLE_NAME)
+	if err != nil && !database.IsEmptyRecord(err) {
+		slog.Error("failed to load quarantine records", "error", err)
+		return
+	}
+	for _, record := range records {
+		q := models.Quarantine{}
+		if err := json.Unmarshal([]byte(record), &q); err != nil {
+			continue
+		}
+		quarantineCache[q.ID] = q
+	}
+	quarantineLoaded = true
+}
+
+// getQuarantine - looks up a quarantine record, loading the cache on first use
+func getQuarantine(id string) (models.Quarantine, bool) {
+	quarantineMutex.RLock()
+	if quarantineLoaded {
+		q, ok := quarantineCache[id]
+		quarantineMutex.RUnlock()
+		return q, ok
+	}
+	quarantineMutex.RUnlock()
+	quarantineMutex.Lock()
+	defer quarantineMutex.Unlock()
+	loadQuarantine()
+	q, ok := quarantineCache[id]
+	return q, ok
+}
+
+func storeQuarantine(q models.Quarantine) error {
+	d, err := json.Marshal(q)
+	if err != nil {
+		return err
+	}
+	quarantineMutex.Lock()
+	defer quarantineMutex.Unlock()
+	loadQuarantine()
+	if _, ok := quarantineCache[q.ID]; ok {
+		return errors.New(string(q.Kind) + " is already quarantined")
+	}
+	if err := database.Insert(q.ID, string(d), database.QUARANTINE_TABLE_NAME); err != nil {
+		return err
+	}
+	quarantineCache[q.ID] = q
+	return nil
+}
+
+// QuarantineHost - cuts a host off from all its peers, keeping its record
+func QuarantineHost(host *models.Host, reason, actor string) (models.Quarantine, error) {
+	if reason == "" {
+		return models.Quarantine{}, errors.New("quarantine reason is required")
+	}
+	q := models.Quarantine{
+		ID:            host.ID.String(),
+		Kind:          models.QuarantineHost,
+		HostID:        host.ID.String(),
+		HostName:      host.Name,
+		Reason:        reason,
+		QuarantinedBy: actor,
+		QuarantinedAt: time.Now().UTC(),
+	}
+	return q, storeQuarantine(q)
+}
+
+// QuarantineNode - cuts a single node off from its peers in the network
+func QuarantineNode(node *models.Node, reason, actor string) (models.Quarantine, error) {
+	if reason == "" {
+		return models.Quarantine{}, errors.New("quarantine reason is required")
+	}
+	if node.IsStatic {
+		return models.Quarantine{}, errors.New("static nodes can not be quarantined")
+	}
+	q := models.Quarantine{
+		ID:            node.ID.String(),
+		Kind:          models.QuarantineNode,
+		HostID:        node.HostID.String(),
+		Network:       node.Network,
+		Reason:        reason,
+		QuarantinedBy: actor,
+		QuarantinedAt: time.Now().UTC(),
+	}
+	if host, err := GetHost(node.HostID.String()); err == nil {
+		q.HostName = host.Name
+	}
+	return q, storeQuarantine(q)
+}
+
+// LiftQuarantine - lifts the quarantine of a host or node
+func LiftQuarantine(id string, kind models.QuarantineKind) (models.Quarantine, error) {
+	quarantineMutex.Lock()
+	defer quarantineMutex.Unlock()
+	loadQuarantine()
+	q, ok := quarantineCache[id]
+	if !ok || q.Kind != kind {
+		return q, errors.New(string(kind) + " is not quarantined")
+	}
+	if err := database.DeleteRecord(database.QUARANTINE_TABLE_NAME, id); err != nil {
+		return q, err
+	}
+	delete(quarantineCache, id)
+	return q, nil
+}
+
+// DeleteHostQuarantine - removes the quarantine records of a deleted host and its nodes
+func DeleteHostQuarantine(hostID string) {
+	quarantineMutex.Lock()
+	defer quarantineMutex.Unlock()
+	loadQuarantine()
+	for id, q := range quarantineCache {
+		if q.HostID == hostID {
+			database.DeleteRecord(database.QUARANTINE_TABLE_NAME, id)
+			delete(quarantineCache, id)
+		}
+	}
+}
+
+// ListQuarantine - lists the quarantined hosts and nodes, newest first
+func ListQuarantine() []models.Quarantine {
+	quarantineMutex.Lock()
+	defer quarantineMutex.Unlock()
+	loadQuarantine()
+	list := []models.Quarantine{}
+	for _, q := range quarantineCache {
+		list = append(list, q)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].QuarantinedAt.After(list[j].QuarantinedAt)
+	})
+	return list
+}
+
+// IsHostQuarantined - checks if a host is quarantined
+func IsHostQuarantined(hostID string) bool {
+	_, ok := getQuarantine(hostID)
+	return ok
+}
+
+// IsNodeQuarantined - checks if a node or its host is quarantined
+func IsNodeQuarantined(node *models.Node) bool {
+	if node.IsStatic {
+		return false
+	}
+	if _, ok := getQuarantine(node.ID.String()); ok {
+		return true
+	}
+	return IsHostQuarantined(node.HostID.String())
+}
@@ -0,0 +1,52 @@
+package logic
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/gravitl/netmaker/database"
+	"github.com/gravitl/netmaker/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQuarantine(t *testing.T) {
+	database.InitializeDatabase()
+	defer database.CloseDB()
+	host := models.Host{ID: uuid.New(), Name: "suspect"}
+	node := models.Node{}
+	node.ID = uuid.New()
+	node.HostID = host.ID
+	node.Network = "quarantine-net"
+	defer DeleteHostQuarantine(host.ID.String())
+	t.Run("ReasonRequired", func(t *testing.T) {
+		_, err := QuarantineHost(&host, "", "admin")
+		assert.Error(t, err)
+		assert.False(t, IsHostQuarantined(host.ID.String()))
+	})
+	t.Run("NodeQuarantine", func(t *testing.T) {
+		q, err := QuarantineNode(&node, "lateral movement", "admin")
+		assert.Nil(t, err)
+		assert.Equal(t, models.QuarantineNode, q.Kind)
+		assert.True(t, IsNodeQuarantined(&node))
+		assert.False(t, IsHostQuarantined(host.ID.String()))
+		_, err = LiftQuarantine(node.ID.String(), models.QuarantineHost)
+		assert.Error(t, err)
+		_, err = LiftQuarantine(node.ID.String(), models.QuarantineNode)
+		assert.Nil(t, err)
+		assert.False(t, IsNodeQuarantined(&node))
+	})
+	t.Run("HostQuarantineCoversNodes", func(t *testing.T) {
+		q, err := QuarantineHost(&host, "leaked key", "admin")
+		assert.Nil(t, err)
+		assert.Equal(t, "admin", q.QuarantinedBy)
+		assert.True(t, IsHostQuarantined(host.ID.String()))
+		assert.True(t, IsNodeQuarantined(&node))
+		_, err = QuarantineHost(&host, "again", "admin")
+		assert.Error(t, err)
+		assert.Len(t, ListQuarantine(), 1)
+	})
+	t.Run("DeletedWithHost", func(t *testing.T) {
+		DeleteHostQuarantine(host.ID.String())
+		assert.False(t, IsNodeQuarantined(&node))
+	})
+}
@@ -35,6 +35,8 @@ func UpsertTag(tag models.Tag) error {
 	if err != nil {
 		return err
 	}
+	defer InvalidateNetworkConnectivity(tag.Network)
+	defer InvalidateNetworkPeerModel(tag.Network)
 	return database.Insert(tag.ID.String(), string(d), database.TAG_TABLE_NAME)
 }
 
@@ -50,6 +52,8 @@ func InsertTag(tag models.Tag) error {
 	if err != nil {
 		return err
 	}
+	defer InvalidateNetworkConnectivity(tag.Network)
+	defer InvalidateNetworkPeerModel(tag.Network)
 	return database.Insert(tag.ID.String(), string(d), database.TAG_TABLE_NAME)
 }
 
@@ -84,6 +88,8 @@ func DeleteTag(tagID models.TagID, removeFromPolicy bool) error {
 			SaveExtClient(&extclient)
 		}
 	}
+	defer InvalidateNetworkConnectivity(tag.Network)
+	defer InvalidateNetworkPeerModel(tag.Network)
 	return database.DeleteRecord(database.TAG_TABLE_NAME, tagID.String())
 }
 
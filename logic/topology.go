@@ -0,0 +1,267 @@
+package logic
+
+import (
+	"encoding/xml"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gravitl/netmaker/models"
+)
+
+// topologyPolicies - ids of the policies allowing an edge
+func topologyPolicies(policies []models.Acl) []string {
+	ids := []string{}
+	for _, policy := range policies {
+		ids = append(ids, policy.ID)
+	}
+	return ids
+}
+
+func topologyNodeRoles(node *models.Node) []string {
+	roles := []string{}
+	if node.IsRelay {
+		roles = append(roles, "relay")
+	}
+	if node.IsIngressGateway {
+		roles = append(roles, "ingress")
+	}
+	if node.IsEgressGateway {
+		roles = append(roles, "egress")
+	}
+	if node.IsInternetGateway {
+		roles = append(roles, "internet_gw")
+	}
+	if node.IsFailOver {
+		roles = append(roles, "failover")
+	}
+	return roles
+}
+
+// GetNetworkTopology - builds the graph of a network's nodes, ext clients and egress ranges,
+// edges between devices carry the acl decision, egress edges are routes and always allowed
+func GetNetworkTopology(network string) (models.Topology, error) {
+	topology := models.Topology{Network: network, Vertices: []models.TopologyVertex{}, Edges: []models.TopologyEdge{}}
+	nodes, err := GetNetworkNodes(network)
+	if err != nil {
+		return topology, err
+	}
+	extclients, err := GetNetworkExtClients(network)
+	if err != nil {
+		return topology, err
+	}
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].ID.String() < nodes[j].ID.String() })
+	nodeMap := make(map[string]models.Node)
+	seen := make(map[string]struct{})
+	addEdge := func(source, target string, kind models.TopologyEdgeKind, allowed bool, policies []models.Acl) {
+		key := string(kind) + ":" + source + ":" + target
+		if source > target {
+			key = string(kind) + ":" + target + ":" + source
+		}
+		if _, ok := seen[key]; ok {
+			return
+		}
+		seen[key] = struct{}{}
+		topology.Edges = append(topology.Edges, models.TopologyEdge{Source: source, Target: target, Kind: kind,
+			Allowed: allowed, Policies: topologyPolicies(policies)})
+	}
+	nodeEdge := func(node, peer models.Node, kind models.TopologyEdgeKind) {
+		allowed, policies := IsNodeAllowedToCommunicateV1(node, peer, true)
+		addEdge(node.ID.String(), peer.ID.String(), kind, allowed, policies)
+	}
+	egressRanges := []string{}
+	for _, node := range nodes {
+		node := node
+		nodeMap[node.ID.String()] = node
+		vertex := models.TopologyVertex{ID: node.ID.String(), Kind: models.TopologyNode, Label: node.ID.String(),
+			Roles: topologyNodeRoles(&node)}
+		if host, err := GetHost(node.HostID.String()); err == nil {
+			vertex.Label = host.Name
+		}
+		if node.Address.IP != nil {
+			vertex.Address = node.Address.String()
+		}
+		if node.Address6.IP != nil {
+			vertex.Address6 = node.Address6.String()
+		}
+		topology.Vertices = append(topology.Vertices, vertex)
+	}
+	for i, node := range nodes {
+		if node.RelayedBy != "" {
+			if relay, ok := nodeMap[node.RelayedBy]; ok {
+				nodeEdge(node, relay, models.TopologyRelay)
+			}
+		}
+		if node.InternetGwID != "" {
+			if gw, ok := nodeMap[node.InternetGwID]; ok {
+				nodeEdge(node, gw, models.TopologyInternetGw)
+			}
+		}
+		for peerID := range node.FailOverPeers {
+			if peer, ok := nodeMap[peerID]; ok {
+				nodeEdge(node, peer, models.TopologyFailOver)
+			}
+		}
+		if node.IsEgressGateway {
+			for _, r := range node.EgressGatewayRanges {
+				egressRanges = append(egressRanges, r)
+				addEdge(node.ID.String(), "egress:"+r, models.TopologyEgress, true, nil)
+			}
+		}
+		// relayed nodes only peer through their relay
+		if node.RelayedBy != "" {
+			continue
+		}
+		for _, peer := range nodes[i+1:] {
+			if peer.RelayedBy != "" {
+				continue
+			}
+			if _, ok := node.FailOverPeers[peer.ID.String()]; ok {
+				continue
+			}
+			nodeEdge(node, peer, models.TopologyPeer)
+		}
+	}
+	sort.Strings(egressRanges)
+	for i, r := range egressRanges {
+		if i > 0 && egressRanges[i-1] == r {
+			continue
+		}
+		topology.Vertices = append(topology.Vertices, models.TopologyVertex{ID: "egress:" + r,
+			Kind: models.TopologyEgressRange, Label: r, Address: r})
+	}
+	sort.Slice(extclients, func(i, j int) bool { return extclients[i].ClientID < extclients[j].ClientID })
+	for _, extclient := range extclients {
+		extclient := extclient
+		topology.Vertices = append(topology.Vertices, models.TopologyVertex{ID: extclient.ClientID,
+			Kind: models.TopologyExtClient, Label: extclient.ClientID, Address: extclient.Address, Address6: extclient.Address6})
+		gw, ok := nodeMap[extclient.IngressGatewayID]
+		if !ok {
+			continue
+		}
+		allowed := false
+		var policies []models.Acl
+		if extclient.Enabled {
+			allowed, policies = IsNodeAllowedToCommunicateV1(extclient.ConvertToStaticNode(), gw, true)
+		}
+		addEdge(extclient.ClientID, gw.ID.String(), models.TopologyIngress, allowed, policies)
+	}
+	return topology, nil
+}
+
+func dotQuote(s string) string {
+	return strconv.Quote(s)
+}
+
+// TopologyToDOT - renders a topology as a graphviz digraph, denied edges are drawn dashed in red
+func TopologyToDOT(topology models.Topology) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "digraph %s {\n", dotQuote(topology.Network))
+	shapes := map[models.TopologyVertexKind]string{
+		models.TopologyNode:        "box",
+		models.TopologyExtClient:   "ellipse",
+		models.TopologyEgressRange: "cloud",
+	}
+	for _, v := range topology.Vertices {
+		label := v.Label
+		if v.Address != "" && v.Kind != models.TopologyEgressRange {
+			label += "\\n" + v.Address
+		}
+		if len(v.Roles) > 0 {
+			label += "\\n[" + strings.Join(v.Roles, ",") + "]"
+		}
+		fmt.Fprintf(&b, "  %s [label=\"%s\" shape=%s];\n", dotQuote(v.ID),
+			strings.ReplaceAll(label, "\"", "\\\""), shapes[v.Kind])
+	}
+	for _, e := range topology.Edges {
+		attrs := []string{"label=" + dotQuote(string(e.Kind))}
+		if e.Kind == models.TopologyPeer {
+			attrs = append(attrs, "dir=none")
+		}
+		if !e.Allowed {
+			attrs = append(attrs, "style=dashed", "color=red")
+		}
+		fmt.Fprintf(&b, "  %s -> %s [%s];\n", dotQuote(e.Source), dotQuote(e.Target), strings.Join(attrs, " "))
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+type graphMLData struct {
+	Key   string `xml:"key,attr"`
+	Value string `xml:",chardata"`
+}
+
+type graphMLKey struct {
+	ID   string `xml:"id,attr"`
+	For  string `xml:"for,attr"`
+	Name string `xml:"attr.name,attr"`
+	Type string `xml:"attr.type,attr"`
+}
+
+type graphMLNode struct {
+	ID   string        `xml:"id,attr"`
+	Data []graphMLData `xml:"data"`
+}
+
+type graphMLEdge struct {
+	ID     string        `xml:"id,attr"`
+	Source string        `xml:"source,attr"`
+	Target string        `xml:"target,attr"`
+	Data   []graphMLData `xml:"data"`
+}
+
+type graphMLGraph struct {
+	ID          string        `xml:"id,attr"`
+	EdgeDefault string        `xml:"edgedefault,attr"`
+	Nodes       []graphMLNode `xml:"node"`
+	Edges       []graphMLEdge `xml:"edge"`
+}
+
+type graphML struct {
+	XMLName xml.Name     `xml:"graphml"`
+	XMLNS   string       `xml:"xmlns,attr"`
+	Keys    []graphMLKey `xml:"key"`
+	Graph   graphMLGraph `xml:"graph"`
+}
+
+// TopologyToGraphML - renders a topology as a graphml document
+func TopologyToGraphML(topology models.Topology) ([]byte, error) {
+	doc := graphML{
+		XMLNS: "http://graphml.graphdrawing.org/xmlns",
+		Keys: []graphMLKey{
+			{ID: "label", For: "node", Name: "label", Type: "string"},
+			{ID: "kind", For: "node", Name: "kind", Type: "string"},
+			{ID: "address", For: "node", Name: "address", Type: "string"},
+			{ID: "address6", For: "node", Name: "address6", Type: "string"},
+			{ID: "roles", For: "node", Name: "roles", Type: "string"},
+			{ID: "edgekind", For: "edge", Name: "kind", Type: "string"},
+			{ID: "allowed", For: "edge", Name: "allowed", Type: "boolean"},
+			{ID: "policies", For: "edge", Name: "policies", Type: "string"},
+		},
+		Graph: graphMLGraph{ID: topology.Network, EdgeDefault: "directed"},
+	}
+	for _, v := range topology.Vertices {
+		doc.Graph.Nodes = append(doc.Graph.Nodes, graphMLNode{ID: v.ID, Data: []graphMLData{
+			{Key: "label", Value: v.Label},
+			{Key: "kind", Value: string(v.Kind)},
+			{Key: "address", Value: v.Address},
+			{Key: "address6", Value: v.Address6},
+			{Key: "roles", Value: strings.Join(v.Roles, ",")},
+		}})
+	}
+	for i, e := range topology.Edges {
+		doc.Graph.Edges = append(doc.Graph.Edges, graphMLEdge{ID: "e" + strconv.Itoa(i), Source: e.Source, Target: e.Target,
+			Data: []graphMLData{
+				{Key: "edgekind", Value: string(e.Kind)},
+				{Key: "allowed", Value: strconv.FormatBool(e.Allowed)},
+				{Key: "policies", Value: strings.Join(e.Policies, ",")},
+			}})
+	}
+	data, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), data...), nil
+}
@@ -0,0 +1,55 @@
+package logic
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/gravitl/netmaker/database"
+	"github.com/gravitl/netmaker/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNetworkTopology(t *testing.T) {
+	database.InitializeDatabase()
+	defer database.CloseDB()
+	newNode := func() models.Node {
+		node := models.Node{}
+		node.ID = uuid.New()
+		node.HostID = uuid.New()
+		node.Network = "topology-net"
+		return node
+	}
+	relay, relayed, egress := newNode(), newNode(), newNode()
+	relay.IsRelay = true
+	relayed.RelayedBy = relay.ID.String()
+	egress.IsEgressGateway = true
+	egress.EgressGatewayRanges = []string{"10.100.0.0/16"}
+	for _, node := range []*models.Node{&relay, &relayed, &egress} {
+		assert.Nil(t, UpsertNode(node))
+		defer DeleteNodeByID(node)
+	}
+	topology, err := GetNetworkTopology("topology-net")
+	assert.Nil(t, err)
+	assert.Len(t, topology.Vertices, 4)
+	kinds := map[models.TopologyEdgeKind]int{}
+	for _, e := range topology.Edges {
+		kinds[e.Kind]++
+		if e.Kind == models.TopologyEgress {
+			assert.True(t, e.Allowed)
+		} else {
+			// no device policy in the network
+			assert.False(t, e.Allowed)
+		}
+	}
+	// relayed node only links to its relay
+	assert.Equal(t, map[models.TopologyEdgeKind]int{models.TopologyRelay: 1, models.TopologyPeer: 1, models.TopologyEgress: 1}, kinds)
+
+	dot := TopologyToDOT(topology)
+	assert.True(t, strings.HasPrefix(dot, "digraph \"topology-net\" {"))
+	assert.Contains(t, dot, "\""+relayed.ID.String()+"\" -> \""+relay.ID.String()+"\" [label=\"relay\" style=dashed color=red];")
+	graphml, err := TopologyToGraphML(topology)
+	assert.Nil(t, err)
+	assert.Contains(t, string(graphml), "<node id=\"egress:10.100.0.0/16\">")
+	assert.Equal(t, len(topology.Edges), strings.Count(string(graphml), "<edge "))
+}
@@ -0,0 +1,470 @@
+package logic
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gravitl/netmaker/database"
+	"github.com/gravitl/netmaker/models"
+	"github.com/gravitl/netmaker/servercfg"
+	"golang.org/x/exp/slog"
+)
+
+const (
+	// defaultCampaignCheckInTimeout - time upgraded hosts have to check in with the target version
+	defaultCampaignCheckInTimeout = 15 * 60
+	// UpgradeCampaignInterval - how often running campaigns are advanced
+	UpgradeCampaignInterval = 30 * time.Second
+)
+
+var upgradeCampaignMutex = &sync.Mutex{}
+
+// GetUpgradeCampaign - fetches an upgrade campaign
+func GetUpgradeCampaign(id string) (models.UpgradeCampaign, error) {
+	data, err := database.FetchRecord(database.UPGRADE_CAMPAIGNS_TABLE_NAME, id)
+	if err != nil {
+		return models.UpgradeCampaign{}, err
+	}
+	campaign := models.UpgradeCampaign{}
+	err = json.Unmarshal([]byte(data), &campaign)
+	return campaign, err
+}
+
+// ListUpgradeCampaigns - lists all upgrade campaigns, newest first
+func ListUpgradeCampaigns() ([]models.UpgradeCampaign, error) {
+	data, err := database.FetchRecords(database.UPGRADE_CAMPAIGNS_TABLE_NAME)
+	if err != nil && !database.IsEmptyRecord(err) {
+		return []models.UpgradeCampaign{}, err
+	}
+	campaigns := []models.UpgradeCampaign{}
+	for _, dataI := range data {
+		campaign := models.UpgradeCampaign{}
+		if err := json.Unmarshal([]byte(dataI), &campaign); err != nil {
+			continue
+		}
+		campaigns = append(campaigns, campaign)
+	}
+	sort.Slice(campaigns, func(i, j int) bool {
+		return campaigns[i].CreatedAt.After(campaigns[j].CreatedAt)
+	})
+	return campaigns, nil
+}
+
+func upsertUpgradeCampaign(campaign *models.UpgradeCampaign) error {
+	campaign.UpdatedAt = time.Now().UTC()
+	d, err := json.Marshal(campaign)
+	if err != nil {
+		return err
+	}
+	return database.Insert(campaign.ID, string(d), database.UPGRADE_CAMPAIGNS_TABLE_NAME)
+}
+
+// CreateUpgradeCampaign - validates the request, selects the targeted hosts and plans the waves.
+// The campaign starts running right away.
+func CreateUpgradeCampaign(req models.UpgradeCampaignReq, createdBy string) (models.UpgradeCampaign, error) {
+	if err := validateUpgradeCampaignReq(&req); err != nil {
+		return models.UpgradeCampaign{}, err
+	}
+	hosts, err := GetAllHosts()
+	if err != nil {
+		return models.UpgradeCampaign{}, err
+	}
+	nodes, err := GetAllNodes()
+	if err != nil {
+		return models.UpgradeCampaign{}, err
+	}
+	targets, err := selectCampaignHosts(req.Selector, req.TargetVersion, hosts, nodes)
+	if err != nil {
+		return models.UpgradeCampaign{}, err
+	}
+	if len(targets) == 0 {
+		return models.UpgradeCampaign{}, errors.New("no hosts match the campaign selector")
+	}
+	campaign := models.UpgradeCampaign{
+		ID:             uuid.New().String(),
+		Name:           req.Name,
+		TargetVersion:  req.TargetVersion,
+		Force:          req.Force,
+		Selector:       req.Selector,
+		WaveSpecs:      req.WaveSpecs,
+		WavePause:      req.WavePause,
+		CheckInTimeout: req.CheckInTimeout,
+		MaxFailures:    req.MaxFailures,
+		Status:         models.CampaignRunning,
+		Hosts:          make(map[string]models.UpgradeCampaignHost),
+		CreatedBy:      createdBy,
+		CreatedAt:      time.Now().UTC(),
+	}
+	hostIDs := []string{}
+	for _, host := range targets {
+		hostIDs = append(hostIDs, host.ID.String())
+		campaign.Hosts[host.ID.String()] = models.UpgradeCampaignHost{
+			HostID:      host.ID.String(),
+			Name:        host.Name,
+			FromVersion: host.Version,
+			Status:      models.CampaignHostPending,
+		}
+	}
+	campaign.Waves = planUpgradeWaves(hostIDs, req.WaveSpecs)
+	for _, wave := range campaign.Waves {
+		for _, hostID := range wave.HostIDs {
+			h := campaign.Hosts[hostID]
+			h.Wave = wave.Number
+			campaign.Hosts[hostID] = h
+		}
+	}
+	upgradeCampaignMutex.Lock()
+	defer upgradeCampaignMutex.Unlock()
+	if err = upsertUpgradeCampaign(&campaign); err != nil {
+		return models.UpgradeCampaign{}, err
+	}
+	return campaign, nil
+}
+
+func validateUpgradeCampaignReq(req *models.UpgradeCampaignReq) error {
+	if strings.TrimSpace(req.Name) == "" {
+		return errors.New("campaign name is required")
+	}
+	if req.TargetVersion == "" {
+		req.TargetVersion = servercfg.GetVersion()
+	}
+	// an upgrade request carries no version, hosts always upgrade to the version of the server
+	if !sameVersion(req.TargetVersion, servercfg.GetVersion()) {
+		return fmt.Errorf("hosts upgrade to the server version %s, target version %s is not supported",
+			servercfg.GetVersion(), req.TargetVersion)
+	}
+	for i, spec := range req.WaveSpecs {
+		if (spec.Size == 0) == (spec.Percent == 0) {
+			return fmt.Errorf("wave %d: exactly one of size or percent has to be set", i+1)
+		}
+		if spec.Size < 0 || spec.Percent < 0 || spec.Percent > 100 {
+			return fmt.Errorf("wave %d: size must be positive and percent between 1 and 100", i+1)
+		}
+	}
+	if req.WavePause < 0 || req.MaxFailures < 0 || req.CheckInTimeout < 0 {
+		return errors.New("wave pause, check-in timeout and max failures can not be negative")
+	}
+	if req.CheckInTimeout == 0 {
+		req.CheckInTimeout = defaultCampaignCheckInTimeout
+	}
+	if _, err := ParseLabelSelector(req.Selector.Labels); err != nil {
+		return err
+	}
+	return nil
+}
+
+// selectCampaignHosts - hosts matching the selector that are not on the target version yet
+func selectCampaignHosts(selector models.UpgradeCampaignSelector, targetVersion string,
+	hosts []models.Host, nodes []models.Node) ([]models.Host, error) {
+	labelSelector, err := ParseLabelSelector(selector.Labels)
+	if err != nil {
+		return nil, err
+	}
+	hostNodes := make(map[string][]models.Node)
+	for _, node := range nodes {
+		hostNodes[node.HostID.String()] = append(hostNodes[node.HostID.String()], node)
+	}
+	selected := []models.Host{}
+	for _, host := range hosts {
+		if sameVersion(host.Version, targetVersion) {
+			continue
+		}
+		if len(selector.OS) > 0 && !slices.Contains(selector.OS, host.OS) {
+			continue
+		}
+		if len(selector.Versions) > 0 && !slices.ContainsFunc(selector.Versions, func(v string) bool {
+			return sameVersion(v, host.Version)
+		}) {
+			continue
+		}
+		if !labelSelector.Matches(host.Labels) {
+			continue
+		}
+		if len(selector.Networks) > 0 || len(selector.Tags) > 0 {
+			matched := false
+			for _, node := range hostNodes[host.ID.String()] {
+				if len(selector.Networks) > 0 && !slices.Contains(selector.Networks, node.Network) {
+					continue
+				}
+				if len(selector.Tags) > 0 && !slices.ContainsFunc(selector.Tags, func(tag models.TagID) bool {
+					_, ok := node.Tags[tag]
+					return ok
+				}) {
+					continue
+				}
+				matched = true
+				break
+			}
+			if !matched {
+				continue
+			}
+		}
+		selected = append(selected, host)
+	}
+	sort.Slice(selected, func(i, j int) bool {
+		return selected[i].Name < selected[j].Name
+	})
+	return selected, nil
+}
+
+// planUpgradeWaves - splits the hosts into waves, percentages are of all targeted hosts.
+// The last spec is repeated until every host is planned, without specs all hosts
+// are upgraded in a single wave.
+func planUpgradeWaves(hostIDs []string, specs []models.UpgradeWaveSpec) []models.UpgradeWave {
+	waves := []models.UpgradeWave{}
+	if len(specs) == 0 {
+		specs = []models.UpgradeWaveSpec{{Percent: 100}}
+	}
+	total := len(hostIDs)
+	for i := 0; len(hostIDs) > 0; i++ {
+		spec := specs[len(specs)-1]
+		if i < len(specs) {
+			spec = specs[i]
+		}
+		size := spec.Size
+		if spec.Percent > 0 {
+			// round up so that a percentage never yields an empty wave
+			size = (total*spec.Percent + 99) / 100
+		}
+		if size > len(hostIDs) {
+			size = len(hostIDs)
+		}
+		waves = append(waves, models.UpgradeWave{
+			Number:  len(waves) + 1,
+			HostIDs: hostIDs[:size],
+			Status:  models.WavePending,
+		})
+		hostIDs = hostIDs[size:]
+	}
+	return waves
+}
+
+func sameVersion(a, b string) bool {
+	return strings.TrimPrefix(a, "v") == strings.TrimPrefix(b, "v")
+}
+
+// advanceUpgradeCampaign - moves a running campaign forward and returns the hosts to request an upgrade from.
+// A wave starts once the pause after the previous wave has passed, it finishes when all its hosts
+// run the target version or when the check-in timeout expires. Hosts still behind at that point
+// are failed and the campaign halts if there are more failures than allowed.
+func advanceUpgradeCampaign(campaign *models.UpgradeCampaign, hosts map[string]models.Host, now time.Time) []models.Host {
+	if campaign.Status != models.CampaignRunning {
+		return nil
+	}
+	if campaign.CurrentWave >= len(campaign.Waves) {
+		campaign.Status = models.CampaignCompleted
+		return nil
+	}
+	wave := &campaign.Waves[campaign.CurrentWave]
+	toUpgrade := []models.Host{}
+	switch wave.Status {
+	case models.WavePending:
+		if campaign.CurrentWave > 0 {
+			prev := campaign.Waves[campaign.CurrentWave-1]
+			if now.Before(prev.FinishedAt.Add(time.Duration(campaign.WavePause) * time.Second)) {
+				return nil
+			}
+		}
+		wave.Status = models.WaveRunning
+		wave.StartedAt = now
+		for _, hostID := range wave.HostIDs {
+			h := campaign.Hosts[hostID]
+			if h.Status == models.CampaignHostUpgraded {
+				// upgraded before the wave was retried
+				continue
+			}
+			host, ok := hosts[hostID]
+			if !ok {
+				h.Status = models.CampaignHostSkipped
+			} else {
+				h.Status = models.CampaignHostRequested
+				h.RequestedAt = now
+				toUpgrade = append(toUpgrade, host)
+			}
+			campaign.Hosts[hostID] = h
+		}
+	case models.WaveRunning:
+		pending := 0
+		for _, hostID := range wave.HostIDs {
+			h := campaign.Hosts[hostID]
+			if h.Status != models.CampaignHostRequested {
+				continue
+			}
+			host, ok := hosts[hostID]
+			switch {
+			case !ok:
+				h.Status = models.CampaignHostSkipped
+			case sameVersion(host.Version, campaign.TargetVersion):
+				h.Status = models.CampaignHostUpgraded
+				h.UpgradedAt = now
+			default:
+				pending++
+			}
+			campaign.Hosts[hostID] = h
+		}
+		timedOut := now.After(wave.StartedAt.Add(time.Duration(campaign.CheckInTimeout) * time.Second))
+		if pending > 0 && !timedOut {
+			return nil
+		}
+		failed := 0
+		for _, hostID := range wave.HostIDs {
+			h := campaign.Hosts[hostID]
+			if h.Status == models.CampaignHostRequested {
+				h.Status = models.CampaignHostFailed
+				campaign.Hosts[hostID] = h
+			}
+			if h.Status == models.CampaignHostFailed {
+				failed++
+			}
+		}
+		wave.FinishedAt = now
+		if failed > campaign.MaxFailures {
+			wave.Status = models.WaveFailed
+			campaign.Status = models.CampaignHalted
+			campaign.HaltReason = fmt.Sprintf("wave %d: %d hosts did not check in with version %s within %ds",
+				wave.Number, failed, campaign.TargetVersion, campaign.CheckInTimeout)
+			return nil
+		}
+		wave.Status = models.WaveSucceeded
+		campaign.CurrentWave++
+		if campaign.CurrentWave >= len(campaign.Waves) {
+			campaign.Status = models.CampaignCompleted
+		}
+	}
+	return toUpgrade
+}
+
+// RunUpgradeCampaigns - advances all running campaigns and requests the upgrades through the given function
+func RunUpgradeCampaigns(upgrade func(host models.Host, force bool) error) error {
+	upgradeCampaignMutex.Lock()
+	defer upgradeCampaignMutex.Unlock()
+	campaigns, err := ListUpgradeCampaigns()
+	if err != nil {
+		return err
+	}
+	var hosts map[string]models.Host
+	for i := range campaigns {
+		campaign := &campaigns[i]
+		if campaign.Status != models.CampaignRunning {
+			continue
+		}
+		if hosts == nil {
+			if hosts, err = GetHostsMap(); err != nil {
+				return err
+			}
+		}
+		for _, host := range advanceUpgradeCampaign(campaign, hosts, time.Now().UTC()) {
+			if err := upgrade(host, campaign.Force); err != nil {
+				slog.Error("failed to request host upgrade", "campaign", campaign.Name, "host", host.ID, "error", err)
+			}
+		}
+		if campaign.Status == models.CampaignHalted {
+			slog.Warn("upgrade campaign halted", "campaign", campaign.Name, "reason", campaign.HaltReason)
+		}
+		if err := upsertUpgradeCampaign(campaign); err != nil {
+			slog.Error("failed to save upgrade campaign", "campaign", campaign.Name, "error", err)
+		}
+	}
+	return nil
+}
+
+// UpdateUpgradeCampaignStatus - pauses, resumes or cancels a campaign.
+// Resuming a halted campaign retries the hosts of the failed wave before moving on.
+func UpdateUpgradeCampaignStatus(id string, status models.UpgradeCampaignStatus) (models.UpgradeCampaign, error) {
+	upgradeCampaignMutex.Lock()
+	defer upgradeCampaignMutex.Unlock()
+	campaign, err := GetUpgradeCampaign(id)
+	if err != nil {
+		return campaign, err
+	}
+	if campaign.Status == models.CampaignCompleted || campaign.Status == models.CampaignCancelled {
+		return campaign, fmt.Errorf("campaign is %s", campaign.Status)
+	}
+	switch status {
+	case models.CampaignPaused:
+		if campaign.Status != models.CampaignRunning {
+			return campaign, errors.New("only a running campaign can be paused")
+		}
+	case models.CampaignRunning:
+		if campaign.Status == models.CampaignRunning {
+			return campaign, errors.New("campaign is already running")
+		}
+		if campaign.Status == models.CampaignHalted {
+			retryUpgradeWave(&campaign)
+			campaign.HaltReason = ""
+		}
+		restartUpgradeWave(&campaign, time.Now().UTC())
+	case models.CampaignCancelled:
+	default:
+		return campaign, fmt.Errorf("invalid campaign status %s", status)
+	}
+	campaign.Status = status
+	if status == models.CampaignRunning && campaign.CurrentWave >= len(campaign.Waves) {
+		campaign.Status = models.CampaignCompleted
+	}
+	if err = upsertUpgradeCampaign(&campaign); err != nil {
+		return campaign, err
+	}
+	return campaign, nil
+}
+
+// retryUpgradeWave - puts the failed wave of a halted campaign back to pending, its failed hosts
+// are requested to upgrade again when the wave restarts and the hosts that upgraded are kept
+func retryUpgradeWave(campaign *models.UpgradeCampaign) {
+	if campaign.CurrentWave >= len(campaign.Waves) {
+		return
+	}
+	wave := &campaign.Waves[campaign.CurrentWave]
+	if wave.Status != models.WaveFailed {
+		return
+	}
+	wave.Status = models.WavePending
+	wave.StartedAt = time.Time{}
+	wave.FinishedAt = time.Time{}
+	for _, hostID := range wave.HostIDs {
+		if h := campaign.Hosts[hostID]; h.Status == models.CampaignHostFailed {
+			h.Status = models.CampaignHostPending
+			campaign.Hosts[hostID] = h
+		}
+	}
+}
+
+// restartUpgradeWave - gives the running wave of a resumed campaign its full check-in timeout
+// again, the time the campaign was paused does not count against its hosts
+func restartUpgradeWave(campaign *models.UpgradeCampaign, now time.Time) {
+	if campaign.CurrentWave >= len(campaign.Waves) {
+		return
+	}
+	wave := &campaign.Waves[campaign.CurrentWave]
+	if wave.Status != models.WaveRunning {
+		return
+	}
+	wave.StartedAt = now
+	for _, hostID := range wave.HostIDs {
+		if h := campaign.Hosts[hostID]; h.Status == models.CampaignHostRequested {
+			h.RequestedAt = now
+			campaign.Hosts[hostID] = h
+		}
+	}
+}
+
+// DeleteUpgradeCampaign - deletes a campaign that is not running
+func DeleteUpgradeCampaign(id string) error {
+	upgradeCampaignMutex.Lock()
+	defer upgradeCampaignMutex.Unlock()
+	campaign, err := GetUpgradeCampaign(id)
+	if err != nil {
+		return err
+	}
+	if campaign.Status == models.CampaignRunning {
+		return errors.New("pause or cancel the campaign before deleting it")
+	}
+	return database.DeleteRecord(database.UPGRADE_CAMPAIGNS_TABLE_NAME, id)
+}
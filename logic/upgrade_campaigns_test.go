@@ -0,0 +1,123 @@
+package logic
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gravitl/netmaker/models"
+	"github.com/gravitl/netmaker/servercfg"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPlanUpgradeWaves(t *testing.T) {
+	hostIDs := []string{"a", "b", "c", "d", "e", "f", "g", "h", "i", "j"}
+	t.Run("SingleWave", func(t *testing.T) {
+		waves := planUpgradeWaves(hostIDs, nil)
+		assert.Equal(t, 1, len(waves))
+		assert.Equal(t, 10, len(waves[0].HostIDs))
+	})
+	t.Run("CanaryThenPercent", func(t *testing.T) {
+		waves := planUpgradeWaves(hostIDs, []models.UpgradeWaveSpec{{Size: 1}, {Percent: 25}})
+		sizes := []int{}
+		for _, wave := range waves {
+			sizes = append(sizes, len(wave.HostIDs))
+		}
+		assert.Equal(t, []int{1, 3, 3, 3}, sizes)
+		assert.Equal(t, 4, waves[3].Number)
+	})
+}
+
+func TestAdvanceUpgradeCampaign(t *testing.T) {
+	newHost := func(version string) models.Host {
+		return models.Host{ID: uuid.New(), Version: version}
+	}
+	h1, h2, h3 := newHost("v0.25.0"), newHost("v0.25.0"), newHost("v0.25.0")
+	hosts := map[string]models.Host{h1.ID.String(): h1, h2.ID.String(): h2, h3.ID.String(): h3}
+	campaign := models.UpgradeCampaign{
+		TargetVersion:  "v0.26.0",
+		WavePause:      60,
+		CheckInTimeout: 300,
+		Status:         models.CampaignRunning,
+		Waves: planUpgradeWaves([]string{h1.ID.String(), h2.ID.String(), h3.ID.String()},
+			[]models.UpgradeWaveSpec{{Size: 1}, {Size: 2}}),
+		Hosts: map[string]models.UpgradeCampaignHost{},
+	}
+	for _, id := range []string{h1.ID.String(), h2.ID.String(), h3.ID.String()} {
+		campaign.Hosts[id] = models.UpgradeCampaignHost{HostID: id, Status: models.CampaignHostPending}
+	}
+	now := time.Now()
+	t.Run("FirstWaveStarts", func(t *testing.T) {
+		toUpgrade := advanceUpgradeCampaign(&campaign, hosts, now)
+		assert.Equal(t, 1, len(toUpgrade))
+		assert.Equal(t, models.WaveRunning, campaign.Waves[0].Status)
+	})
+	t.Run("WaveSucceeds", func(t *testing.T) {
+		h1.Version = "0.26.0"
+		hosts[h1.ID.String()] = h1
+		assert.Empty(t, advanceUpgradeCampaign(&campaign, hosts, now.Add(time.Minute)))
+		assert.Equal(t, models.WaveSucceeded, campaign.Waves[0].Status)
+		assert.Equal(t, models.CampaignHostUpgraded, campaign.Hosts[h1.ID.String()].Status)
+		assert.Equal(t, 1, campaign.CurrentWave)
+	})
+	t.Run("PauseBetweenWaves", func(t *testing.T) {
+		assert.Empty(t, advanceUpgradeCampaign(&campaign, hosts, now.Add(time.Minute+time.Second*30)))
+		assert.Equal(t, 2, len(advanceUpgradeCampaign(&campaign, hosts, now.Add(time.Minute*3))))
+	})
+	t.Run("HaltsOnTimeout", func(t *testing.T) {
+		h2.Version = "v0.26.0"
+		hosts[h2.ID.String()] = h2
+		assert.Empty(t, advanceUpgradeCampaign(&campaign, hosts, now.Add(time.Minute*4)))
+		assert.Equal(t, models.CampaignRunning, campaign.Status)
+		advanceUpgradeCampaign(&campaign, hosts, now.Add(time.Minute*9))
+		assert.Equal(t, models.CampaignHalted, campaign.Status)
+		assert.Equal(t, models.WaveFailed, campaign.Waves[1].Status)
+		assert.Equal(t, models.CampaignHostFailed, campaign.Hosts[h3.ID.String()].Status)
+		assert.NotEmpty(t, campaign.HaltReason)
+	})
+	t.Run("ResumeRetriesFailedHosts", func(t *testing.T) {
+		retryUpgradeWave(&campaign)
+		campaign.Status = models.CampaignRunning
+		assert.Equal(t, 1, campaign.CurrentWave)
+		toUpgrade := advanceUpgradeCampaign(&campaign, hosts, now.Add(time.Minute*10))
+		assert.Equal(t, []models.Host{h3}, toUpgrade, "only the failed host is requested again")
+		assert.Equal(t, models.CampaignHostUpgraded, campaign.Hosts[h2.ID.String()].Status)
+		h3.Version = "v0.26.0"
+		hosts[h3.ID.String()] = h3
+		advanceUpgradeCampaign(&campaign, hosts, now.Add(time.Minute*11))
+		assert.Equal(t, models.WaveSucceeded, campaign.Waves[1].Status)
+		assert.Equal(t, models.CampaignCompleted, campaign.Status)
+	})
+}
+
+func TestValidateUpgradeCampaignReq(t *testing.T) {
+	req := models.UpgradeCampaignReq{Name: "rollout", WaveSpecs: []models.UpgradeWaveSpec{{Percent: 100}}}
+	assert.Nil(t, validateUpgradeCampaignReq(&req))
+	assert.Equal(t, servercfg.GetVersion(), req.TargetVersion)
+	req.TargetVersion = "v999.0.0"
+	assert.NotNil(t, validateUpgradeCampaignReq(&req), "hosts can only upgrade to the server version")
+}
+
+func TestRestartUpgradeWave(t *testing.T) {
+	host := models.Host{ID: uuid.New(), Version: "v0.25.0"}
+	hosts := map[string]models.Host{host.ID.String(): host}
+	campaign := models.UpgradeCampaign{
+		TargetVersion:  "v0.26.0",
+		CheckInTimeout: 300,
+		Status:         models.CampaignRunning,
+		Waves:          planUpgradeWaves([]string{host.ID.String()}, []models.UpgradeWaveSpec{{Size: 1}}),
+		Hosts: map[string]models.UpgradeCampaignHost{
+			host.ID.String(): {HostID: host.ID.String(), Status: models.CampaignHostPending},
+		},
+	}
+	now := time.Now()
+	assert.Equal(t, 1, len(advanceUpgradeCampaign(&campaign, hosts, now)))
+	// paused for an hour, then resumed
+	resumed := now.Add(time.Hour)
+	restartUpgradeWave(&campaign, resumed)
+	assert.Equal(t, resumed, campaign.Waves[0].StartedAt)
+	assert.Equal(t, resumed, campaign.Hosts[host.ID.String()].RequestedAt)
+	advanceUpgradeCampaign(&campaign, hosts, resumed.Add(time.Minute))
+	assert.Equal(t, models.CampaignRunning, campaign.Status, "the wave does not time out right after resuming")
+	assert.Equal(t, models.WaveRunning, campaign.Waves[0].Status)
+}
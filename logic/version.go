@@ -4,11 +4,15 @@ import (
 	"strings"
 	"unicode"
 
+	"github.com/gravitl/netmaker/models"
 	"github.com/hashicorp/go-version"
 )
 
 const MinVersion = "v0.17.0"
 
+// AckRequestMinVersion - first netclient version that answers ack requests with the action it acknowledges
+const AckRequestMinVersion = "v0.90.0"
+
 // IsVersionCompatible checks that the version passed is compabtible (>=) with MinVersion
 func IsVersionCompatible(ver string) bool {
 	// during dev, assume developers know what they are doing
@@ -29,3 +33,9 @@ func IsVersionCompatible(ver string) bool {
 	return constraint.Check(v)
 
 }
+
+// SupportsAckRequests - checks if the netclient of a host answers RequestAck host updates
+func SupportsAckRequests(host *models.Host) bool {
+	vlt, err := VersionLessThan(host.Version, AckRequestMinVersion)
+	return err == nil && !vlt
+}
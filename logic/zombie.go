@@ -2,11 +2,16 @@ package logic
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"sort"
+	"sync"
 	"time"
 
-	"github.com/google/uuid"
+	"github.com/gravitl/netmaker/database"
 	"github.com/gravitl/netmaker/logger"
 	"github.com/gravitl/netmaker/models"
+	"github.com/gravitl/netmaker/servercfg"
 )
 
 const (
@@ -16,12 +21,179 @@ const (
 	ZOMBIE_DELETE_TIME = 10
 )
 
-var (
-	zombies       []uuid.UUID
-	hostZombies   []uuid.UUID
-	newZombie     chan uuid.UUID = make(chan (uuid.UUID), 10)
-	newHostZombie chan uuid.UUID = make(chan (uuid.UUID), 10)
-)
+var zombieMutex = &sync.Mutex{}
+
+// zombieReasonRank - how specific a zombie reason is, a stale check-in is only a hint
+// while a duplicate is proof and a requested delete overrides both
+var zombieReasonRank = map[models.ZombieReason]int{
+	models.ZombieStaleCheckIn:    0,
+	models.ZombieDuplicateMAC:    1,
+	models.ZombieDuplicateHostID: 1,
+	models.ZombiePendingDelete:   2,
+}
+
+// recordZombie - persists a zombie candidate, a candidate already reported keeps its state
+// so ignored false positives are not flagged again, unless the new reason is more specific
+func recordZombie(z models.Zombie) {
+	zombieMutex.Lock()
+	defer zombieMutex.Unlock()
+	if existing, err := GetZombie(z.ID); err == nil && zombieReasonRank[z.Reason] <= zombieReasonRank[existing.Reason] {
+		return
+	}
+	z.State = models.ZombiePending
+	z.DetectedAt = time.Now().UTC()
+	data, err := json.Marshal(z)
+	if err != nil {
+		return
+	}
+	if err := database.Insert(z.ID, string(data), database.ZOMBIES_TABLE_NAME); err != nil {
+		logger.Log(1, "failed to record zombie", z.ID, err.Error())
+		return
+	}
+	logger.Log(0, "adding", string(z.Kind), z.ID, "to zombie list:", string(z.Reason), z.Detail)
+}
+
+func nodeZombie(node *models.Node, reason models.ZombieReason, detail string) models.Zombie {
+	z := models.Zombie{
+		ID:          node.ID.String(),
+		Kind:        models.ZombieNode,
+		HostID:      node.HostID.String(),
+		Network:     node.Network,
+		Reason:      reason,
+		Detail:      detail,
+		LastCheckIn: node.LastCheckIn,
+	}
+	if host, err := GetHost(node.HostID.String()); err == nil {
+		z.HostName = host.Name
+	}
+	return z
+}
+
+// GetZombie - fetches a zombie candidate
+func GetZombie(id string) (models.Zombie, error) {
+	z := models.Zombie{}
+	record, err := database.FetchRecord(database.ZOMBIES_TABLE_NAME, id)
+	if err != nil {
+		return z, err
+	}
+	err = json.Unmarshal([]byte(record), &z)
+	return z, err
+}
+
+// getZombies - fetches all zombie candidates
+func getZombies() []models.Zombie {
+	zombies := []models.Zombie{}
+	records, err := database.FetchRecords(database.ZOMBIES_TABLE_NAME)
+	if err != nil {
+		return zombies
+	}
+	for _, record := range records {
+		z := models.Zombie{}
+		if err := json.Unmarshal([]byte(record), &z); err != nil {
+			continue
+		}
+		zombies = append(zombies, z)
+	}
+	return zombies
+}
+
+// zombieExists - checks if the suspected host or node still exists
+func zombieExists(z models.Zombie) bool {
+	if z.Kind == models.ZombieHost {
+		_, err := GetHost(z.ID)
+		return err == nil
+	}
+	_, err := GetNodeByID(z.ID)
+	return err == nil
+}
+
+// ListZombies - lists the zombie candidates, optionally filtered by network and state;
+// candidates that were deleted in the meantime are dropped
+func ListZombies(network string, state models.ZombieState) []models.Zombie {
+	list := []models.Zombie{}
+	for _, z := range getZombies() {
+		if !zombieExists(z) {
+			DeleteZombie(z.ID)
+			continue
+		}
+		if network != "" && z.Network != network {
+			continue
+		}
+		if state != "" && z.State != state {
+			continue
+		}
+		list = append(list, z)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].DetectedAt.After(list[j].DetectedAt)
+	})
+	return list
+}
+
+// IgnoreZombie - marks a zombie candidate as a false positive, it is kept and not flagged again
+func IgnoreZombie(id, actor string) (models.Zombie, error) {
+	zombieMutex.Lock()
+	defer zombieMutex.Unlock()
+	z, err := GetZombie(id)
+	if err != nil {
+		return z, err
+	}
+	if z.State == models.ZombieIgnored {
+		return z, errors.New("zombie is already ignored")
+	}
+	z.State = models.ZombieIgnored
+	z.ResolvedBy = actor
+	z.ResolvedAt = time.Now().UTC()
+	data, err := json.Marshal(z)
+	if err != nil {
+		return z, err
+	}
+	return z, database.Insert(z.ID, string(data), database.ZOMBIES_TABLE_NAME)
+}
+
+// DeleteZombie - removes a zombie candidate
+func DeleteZombie(id string) {
+	zombieMutex.Lock()
+	defer zombieMutex.Unlock()
+	_ = database.DeleteRecord(database.ZOMBIES_TABLE_NAME, id)
+}
+
+// SetNetworkZombieHandling - sets whether zombie candidates of a network are deleted automatically
+func SetNetworkZombieHandling(netID, mode string) (models.Network, error) {
+	network, err := GetNetwork(netID)
+	if err != nil {
+		return network, err
+	}
+	if mode != models.ZombieHandlingAuto && mode != models.ZombieHandlingManual {
+		return network, errors.New("invalid zombie handling " + mode)
+	}
+	network.ZombieHandling = mode
+	return network, SaveNetwork(&network)
+}
+
+// zombieAutoDelete - checks if a zombie candidate may be deleted without an admin, stale
+// check-ins are only reported, and a host is only deleted if all of its networks allow it
+func zombieAutoDelete(z models.Zombie, manual map[string]bool) bool {
+	if z.State != models.ZombiePending || z.Reason == models.ZombieStaleCheckIn {
+		return false
+	}
+	if z.Reason == models.ZombiePendingDelete {
+		return true
+	}
+	if z.Kind == models.ZombieNode {
+		return !manual[z.Network]
+	}
+	host, err := GetHost(z.ID)
+	if err != nil {
+		return false
+	}
+	for _, nodeID := range host.Nodes {
+		if node, err := GetNodeByID(nodeID); err == nil && manual[node.Network] {
+			return false
+		}
+	}
+	return true
+}
 
 // CheckZombies - checks if new node has same hostid as existing node
 // if so, existing node is added to zombie node quarantine list
@@ -38,8 +210,7 @@ func CheckZombies(newnode *models.Node) {
 			continue
 		}
 		if node.HostID == newnode.HostID {
-			logger.Log(0, "adding ", node.ID.String(), " to zombie list")
-			newZombie <- node.ID
+			recordZombie(nodeZombie(&node, models.ZombieDuplicateHostID, "host joined the network again as node "+newnode.ID.String()))
 		}
 	}
 }
@@ -58,22 +229,65 @@ func checkForZombieHosts(h *models.Host) {
 			continue
 		}
 		if existing.MacAddress.String() == h.MacAddress.String() {
-			//add to hostZombies
-			newHostZombie <- existing.ID
-			//add all nodes belonging to host to zombile list
-			for _, node := range existing.Nodes {
-				id, err := uuid.Parse(node)
+			detail := "mac address " + h.MacAddress.String() + " registered again by host " + h.ID.String()
+			recordZombie(models.Zombie{
+				ID:       existing.ID.String(),
+				Kind:     models.ZombieHost,
+				HostID:   existing.ID.String(),
+				HostName: existing.Name,
+				Reason:   models.ZombieDuplicateMAC,
+				Detail:   detail,
+			})
+			//add all nodes belonging to host to zombie list
+			for _, nodeID := range existing.Nodes {
+				node, err := GetNodeByID(nodeID)
 				if err != nil {
-					logger.Log(3, "error parsing uuid from host.Nodes", err.Error())
+					logger.Log(3, "error retrieving node of zombie host", nodeID, err.Error())
 					continue
 				}
-				newHostZombie <- id
+				recordZombie(nodeZombie(&node, models.ZombieDuplicateMAC, detail))
 			}
 		}
 	}
 }
 
-// ManageZombies - goroutine which adds/removes/deletes nodes from the zombie node quarantine list
+// checkStaleNodes - reports nodes that did not check in for longer than the stale threshold
+// and drops the stale check-in reports of nodes that checked in again since
+func checkStaleNodes() {
+	nodes, err := GetAllNodes()
+	if err != nil {
+		return
+	}
+	stale := make(map[string]bool)
+	for _, z := range getZombies() {
+		if z.Kind == models.ZombieNode && z.Reason == models.ZombieStaleCheckIn {
+			stale[z.ID] = true
+		}
+	}
+	threshold := servercfg.GetZombieStaleThreshold()
+	for _, node := range nodes {
+		if node.IsStatic || node.LastCheckIn.IsZero() {
+			continue
+		}
+		if time.Since(node.LastCheckIn) > threshold {
+			recordZombie(nodeZombie(&node, models.ZombieStaleCheckIn, "no check in since "+node.LastCheckIn.UTC().Format(time.RFC3339)))
+		} else if stale[node.ID.String()] {
+			dropStaleZombie(node.ID.String())
+		}
+	}
+}
+
+// dropStaleZombie - removes a stale check-in report, unless it was replaced by a more specific one
+func dropStaleZombie(id string) {
+	zombieMutex.Lock()
+	defer zombieMutex.Unlock()
+	if z, err := GetZombie(id); err == nil && z.Reason == models.ZombieStaleCheckIn {
+		logger.Log(1, "node", id, "checked in again, deleting it from zombie list")
+		_ = database.DeleteRecord(database.ZOMBIES_TABLE_NAME, id)
+	}
+}
+
+// ManageZombies - goroutine which deletes the zombie candidates of networks with automatic zombie handling
 func ManageZombies(ctx context.Context, peerUpdate chan *models.Node) {
 	logger.Log(2, "Zombie management started")
 	go InitializeZombies()
@@ -87,52 +301,57 @@ func ManageZombies(ctx context.Context, peerUpdate chan *models.Node) {
 			ticker.Stop()
 			close(peerUpdate)
 			return
-		case id := <-newZombie:
-			zombies = append(zombies, id)
-		case id := <-newHostZombie:
-			hostZombies = append(hostZombies, id)
 		case <-ticker.C: // run this check 4 times a day
 			logger.Log(3, "checking for zombie nodes")
-			if len(zombies) > 0 {
-				for i := len(zombies) - 1; i >= 0; i-- {
-					node, err := GetNodeByID(zombies[i].String())
+			checkStaleNodes()
+			manual := make(map[string]bool)
+			if networks, err := GetNetworks(); err == nil {
+				for _, network := range networks {
+					manual[network.NetID] = network.ZombieHandling == models.ZombieHandlingManual
+				}
+			}
+			zombies := getZombies()
+			// nodes first, so hosts left without nodes are removed in the same run
+			sort.SliceStable(zombies, func(i, j int) bool {
+				return zombies[i].Kind == models.ZombieNode && zombies[j].Kind == models.ZombieHost
+			})
+			for _, z := range zombies {
+				if !zombieExists(z) {
+					logger.Log(1, "deleting", z.ID, "from zombie list")
+					DeleteZombie(z.ID)
+					continue
+				}
+				if !zombieAutoDelete(z, manual) {
+					continue
+				}
+				if z.Kind == models.ZombieNode {
+					node, err := GetNodeByID(z.ID)
 					if err != nil {
-						logger.Log(1, "error retrieving zombie node", zombies[i].String(), err.Error())
-						logger.Log(1, "deleting ", node.ID.String(), " from zombie list")
-						zombies = append(zombies[:i], zombies[i+1:]...)
 						continue
 					}
 					if time.Since(node.LastCheckIn) > time.Minute*ZOMBIE_DELETE_TIME {
 						if err := DeleteNode(&node, true); err != nil {
-							logger.Log(1, "error deleting zombie node", zombies[i].String(), err.Error())
+							logger.Log(1, "error deleting zombie node", z.ID, err.Error())
 							continue
 						}
 						node.PendingDelete = true
 						node.Action = models.NODE_DELETE
 						peerUpdate <- &node
 						logger.Log(1, "deleting zombie node", node.ID.String())
-						zombies = append(zombies[:i], zombies[i+1:]...)
+						DeleteZombie(z.ID)
 					}
+					continue
 				}
-			}
-			if len(hostZombies) > 0 {
-				logger.Log(3, "checking host zombies")
-				for i := len(hostZombies) - 1; i >= 0; i-- {
-					host, err := GetHost(hostZombies[i].String())
-					if err != nil {
-						logger.Log(1, "error retrieving zombie host", err.Error())
-						if host != nil {
-							logger.Log(1, "deleting ", host.ID.String(), " from zombie list")
-						}
-						hostZombies = append(hostZombies[:i], hostZombies[i+1:]...)
+				host, err := GetHost(z.ID)
+				if err != nil {
+					continue
+				}
+				if len(host.Nodes) == 0 {
+					if err := RemoveHost(host, true); err != nil {
+						logger.Log(0, "error deleting zombie host", host.ID.String(), err.Error())
 						continue
 					}
-					if len(host.Nodes) == 0 {
-						if err := RemoveHost(host, true); err != nil {
-							logger.Log(0, "error deleting zombie host", host.ID.String(), err.Error())
-						}
-						hostZombies = append(hostZombies[:i], hostZombies[i+1:]...)
-					}
+					DeleteZombie(z.ID)
 				}
 			}
 		}
@@ -167,13 +386,12 @@ func InitializeZombies() {
 			}
 			if node.HostID == othernode.HostID {
 				if node.LastCheckIn.After(othernode.LastCheckIn) {
-					newZombie <- othernode.ID
-					logger.Log(1, "adding", othernode.ID.String(), "to zombie list")
+					recordZombie(nodeZombie(&othernode, models.ZombieDuplicateHostID, "host has a newer node "+node.ID.String()+" in the network"))
 				} else {
-					newZombie <- node.ID
-					logger.Log(1, "adding", node.ID.String(), "to zombie list")
+					recordZombie(nodeZombie(&node, models.ZombieDuplicateHostID, "host has a newer node "+othernode.ID.String()+" in the network"))
 				}
 			}
 		}
 	}
+	checkStaleNodes()
 }
@@ -0,0 +1,90 @@
+package logic
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gravitl/netmaker/database"
+	"github.com/gravitl/netmaker/models"
+	"github.com/gravitl/netmaker/servercfg"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestZombieResolution(t *testing.T) {
+	database.InitializeDatabase()
+	defer database.CloseDB()
+	node := models.Node{}
+	node.ID = uuid.New()
+	node.HostID = uuid.New()
+	node.Network = "zombienet"
+	defer DeleteZombie(node.ID.String())
+	t.Run("RecordOnce", func(t *testing.T) {
+		recordZombie(nodeZombie(&node, models.ZombieDuplicateHostID, "first"))
+		recordZombie(nodeZombie(&node, models.ZombieStaleCheckIn, "second"))
+		z, err := GetZombie(node.ID.String())
+		assert.Nil(t, err)
+		assert.Equal(t, models.ZombieDuplicateHostID, z.Reason)
+		assert.Equal(t, models.ZombiePending, z.State)
+	})
+	t.Run("HandlingPerNetwork", func(t *testing.T) {
+		z, _ := GetZombie(node.ID.String())
+		assert.True(t, zombieAutoDelete(z, map[string]bool{}))
+		assert.False(t, zombieAutoDelete(z, map[string]bool{"zombienet": true}))
+		z.Reason = models.ZombieStaleCheckIn
+		assert.False(t, zombieAutoDelete(z, map[string]bool{}))
+	})
+	t.Run("IgnoredIsNotFlaggedAgain", func(t *testing.T) {
+		z, err := IgnoreZombie(node.ID.String(), "admin")
+		assert.Nil(t, err)
+		assert.Equal(t, models.ZombieIgnored, z.State)
+		assert.False(t, zombieAutoDelete(z, map[string]bool{}))
+		recordZombie(nodeZombie(&node, models.ZombieDuplicateHostID, "again"))
+		z, _ = GetZombie(node.ID.String())
+		assert.Equal(t, models.ZombieIgnored, z.State)
+		_, err = IgnoreZombie(node.ID.String(), "admin")
+		assert.Error(t, err)
+	})
+	t.Run("RequestedDeleteReplacesIgnore", func(t *testing.T) {
+		recordZombie(nodeZombie(&node, models.ZombiePendingDelete, "deleted"))
+		z, _ := GetZombie(node.ID.String())
+		assert.Equal(t, models.ZombiePending, z.State)
+		assert.True(t, zombieAutoDelete(z, map[string]bool{"zombienet": true}))
+	})
+}
+
+func TestStaleZombies(t *testing.T) {
+	database.InitializeDatabase()
+	defer database.CloseDB()
+	node := models.Node{}
+	node.ID = uuid.New()
+	node.HostID = uuid.New()
+	node.Network = "zombienet"
+	node.LastCheckIn = time.Now().Add(-servercfg.GetZombieStaleThreshold() - time.Hour)
+	assert.Nil(t, UpsertNode(&node))
+	defer DeleteNodeByID(&node)
+	defer DeleteZombie(node.ID.String())
+	t.Run("ReportedWhenStale", func(t *testing.T) {
+		checkStaleNodes()
+		z, err := GetZombie(node.ID.String())
+		assert.Nil(t, err)
+		assert.Equal(t, models.ZombieStaleCheckIn, z.Reason)
+	})
+	t.Run("DroppedAfterCheckIn", func(t *testing.T) {
+		node.LastCheckIn = time.Now()
+		assert.Nil(t, UpsertNode(&node))
+		checkStaleNodes()
+		_, err := GetZombie(node.ID.String())
+		assert.NotNil(t, err)
+	})
+	t.Run("DuplicateReplacesStale", func(t *testing.T) {
+		recordZombie(nodeZombie(&node, models.ZombieStaleCheckIn, "stale"))
+		recordZombie(nodeZombie(&node, models.ZombieDuplicateHostID, "duplicate"))
+		z, err := GetZombie(node.ID.String())
+		assert.Nil(t, err)
+		assert.Equal(t, models.ZombieDuplicateHostID, z.Reason)
+		checkStaleNodes()
+		_, err = GetZombie(node.ID.String())
+		assert.Nil(t, err, "only stale check-in reports are dropped")
+	})
+}
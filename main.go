@@ -18,6 +18,7 @@ import (
 	"github.com/gravitl/netmaker/functions"
 	"github.com/gravitl/netmaker/logger"
 	"github.com/gravitl/netmaker/logic"
+	"github.com/gravitl/netmaker/logic/hostactions"
 	"github.com/gravitl/netmaker/migrate"
 	"github.com/gravitl/netmaker/models"
 	"github.com/gravitl/netmaker/mq"
@@ -177,10 +178,32 @@ func runMessageQueue(wg *sync.WaitGroup, ctx context.Context) {
 	}
 	defer mq.CloseClient()
 	go mq.Keepalive(ctx)
+	logic.HookManagerCh <- models.HookDetails{
+		Hook:     mq.RotateHostKeys,
+		Interval: logic.KeyRotationCheckInterval,
+	}
+	logic.HookManagerCh <- models.HookDetails{
+		Hook:     mq.ApplyAclSchedules,
+		Interval: logic.AclScheduleCheckInterval,
+	}
+	logic.HookManagerCh <- models.HookDetails{
+		Hook:     mq.RunUpgradeCampaigns,
+		Interval: logic.UpgradeCampaignInterval,
+	}
+	logic.HookManagerCh <- models.HookDetails{
+		Hook:     mq.RedeliverHostActions,
+		Interval: hostactions.AckTimeout,
+	}
 	go func() {
 		peerUpdate := make(chan *models.Node)
 		go logic.ManageZombies(ctx, peerUpdate)
-		go logic.DeleteExpiredNodes(ctx, peerUpdate)
+		expiryEvents := make(chan models.NodeExpiryEvent)
+		go func() {
+			for event := range expiryEvents {
+				mq.PublishNodeExpiryEvent(event)
+			}
+		}()
+		go logic.DeleteExpiredNodes(ctx, peerUpdate, expiryEvents)
 		for nodeUpdate := range peerUpdate {
 			if nodeUpdate == nil {
 				continue
@@ -148,6 +148,7 @@ func updateEnrollmentKeys() {
 			[]string{network.NetID},
 			[]string{network.NetID},
 			[]models.TagID{},
+			nil,
 			true,
 			uuid.Nil,
 			true,
@@ -0,0 +1,60 @@
+package models
+
+import "time"
+
+// AccessRequestStatus - state of a just-in-time access request
+type AccessRequestStatus string
+
+const (
+	AccessRequestPending  AccessRequestStatus = "pending"
+	AccessRequestApproved AccessRequestStatus = "approved"
+	AccessRequestDenied   AccessRequestStatus = "denied"
+	AccessRequestExpired  AccessRequestStatus = "expired"
+)
+
+// AccessRequestEvent - a step in the history of an access request
+type AccessRequestEvent struct {
+	Time   time.Time           `json:"time"`
+	Status AccessRequestStatus `json:"status"`
+	// User - who made the change, empty when the request expired
+	User string `json:"user,omitempty"`
+	Note string `json:"note,omitempty"`
+}
+
+// AccessRequest - a user's request for temporary access to a tag or device of a network,
+// an approval grants it through a user policy that expires with the request
+type AccessRequest struct {
+	ID        string    `json:"id"`
+	NetworkID NetworkID `json:"network_id"`
+	Requester string    `json:"requester"`
+	// Target - a node tag or a device of the network
+	Target        AclPolicyTag `json:"target"`
+	Proto         Protocol     `json:"protocol"`
+	Ports         []string     `json:"ports"`
+	Duration      int          `json:"duration_minutes"`
+	Justification string       `json:"justification"`
+	// Status, Reviewer and ReviewNote - the current state and who decided on the request
+	Status     AccessRequestStatus `json:"status"`
+	Reviewer   string              `json:"reviewer,omitempty"`
+	ReviewNote string              `json:"review_note,omitempty"`
+	// AclID - the user policy granting the access while the request is approved
+	AclID     string               `json:"acl_id,omitempty"`
+	CreatedAt time.Time            `json:"created_at"`
+	ExpiresAt time.Time            `json:"expires_at,omitempty"`
+	History   []AccessRequestEvent `json:"history"`
+}
+
+// AccessRequestReq - request for temporary access
+type AccessRequestReq struct {
+	NetworkID     NetworkID    `json:"network_id"`
+	Target        AclPolicyTag `json:"target"`
+	Proto         Protocol     `json:"protocol"`
+	Ports         []string     `json:"ports"`
+	Duration      int          `json:"duration_minutes"`
+	Justification string       `json:"justification"`
+}
+
+// AccessRequestReview - approval or denial of an access request
+type AccessRequestReview struct {
+	Note string `json:"note"`
+}
@@ -40,6 +40,16 @@ func (p Protocol) String() string {
 	return string(p)
 }
 
+// AclAction - what a policy does with the traffic it matches
+type AclAction string
+
+const (
+	// AclAllow lets the matched traffic through, the default for policies without an action
+	AclAllow AclAction = "allow"
+	// AclDeny drops the matched traffic, overriding allow policies evaluated after it
+	AclDeny AclAction = "deny"
+)
+
 type AclPolicyType string
 
 const (
@@ -92,8 +102,43 @@ type Acl struct {
 	Port             []string                `json:"ports"`
 	AllowedDirection AllowedTrafficDirection `json:"allowed_traffic_direction"`
 	Enabled          bool                    `json:"enabled"`
-	CreatedBy        string                  `json:"created_by"`
-	CreatedAt        time.Time               `json:"created_at"`
+	// Action - allow or deny, empty means allow
+	Action AclAction `json:"action"`
+	// Priority - policies are evaluated in ascending priority, deny before allow on a tie,
+	// and default policies after all others
+	Priority int `json:"priority"`
+	// Schedule - when the policy is in effect, a policy without one always is
+	Schedule *AclSchedule `json:"schedule,omitempty"`
+	// ServiceID - the service object the policy takes its protocol and ports from
+	ServiceID string `json:"service_id,omitempty"`
+	// ServiceParentID - set on the policies carrying the further protocols of the service used
+	// by policy ServiceParentID, they follow that policy and can not be changed on their own
+	ServiceParentID string    `json:"service_parent_id,omitempty"`
+	CreatedBy       string    `json:"created_by"`
+	CreatedAt       time.Time `json:"created_at"`
+}
+
+// AclSchedule - a validity window of a policy, optionally recurring. All fields are optional
+type AclSchedule struct {
+	// StartTime and EndTime - the policy is in effect from StartTime until EndTime
+	StartTime time.Time `json:"start_time" yaml:"start_time,omitempty"`
+	EndTime   time.Time `json:"end_time" yaml:"end_time,omitempty"`
+	// Cron - minute, hour, day of month, month and day of week fields, the policy is in effect
+	// during the minutes matching it, e.g. "* 8-17 * * 1-5" for Monday to Friday 08:00 to 18:00
+	Cron string `json:"cron" yaml:"cron,omitempty"`
+	// Timezone - IANA name of the timezone the cron fields are read in, UTC if empty
+	Timezone string `json:"timezone" yaml:"timezone,omitempty"`
+}
+
+// AclStatus - a policy along with whether it is in effect right now
+type AclStatus struct {
+	Acl
+	Effective bool `json:"effective"`
+}
+
+// IsDeny - checks if the policy drops the traffic it matches
+func (a Acl) IsDeny() bool {
+	return a.Action == AclDeny
 }
 
 type AclPolicyTypes struct {
@@ -101,6 +146,8 @@ type AclPolicyTypes struct {
 	RuleTypes     []AclPolicyType `json:"policy_types"`
 	SrcGroupTypes []AclGroupType  `json:"src_grp_types"`
 	DstGroupTypes []AclGroupType  `json:"dst_grp_types"`
+	// Services - the service objects of the network asked for
+	Services []AclServiceStatus `json:"services,omitempty"`
 }
 
 type ProtocolType struct {
@@ -119,5 +166,79 @@ type AclRule struct {
 	Direction       AllowedTrafficDirection `json:"direction"` // single or two-way
 	Dst             []net.IPNet             `json:"dst"`
 	Dst6            []net.IPNet             `json:"dst6"`
-	Allowed         bool
+	// Allowed - false for deny rules
+	Allowed bool
+	// Priority - the evaluation order of the rule, rules are matched in ascending priority
+	// and the first match decides
+	Priority int `json:"priority"`
+}
+
+// AclSimulationAction - a proposed change to simulate
+type AclSimulationAction string
+
+const (
+	AclSimulateCreate AclSimulationAction = "create"
+	AclSimulateUpdate AclSimulationAction = "update"
+	AclSimulateDelete AclSimulationAction = "delete"
+)
+
+// AclSimulationRequest - a proposed acl change, the acl needs only its id when deleting
+type AclSimulationRequest struct {
+	Action AclSimulationAction `json:"action"`
+	Acl    Acl                 `json:"acl"`
+}
+
+// AclSimulationDevice - a node or static node taking part in a reachability change
+type AclSimulationDevice struct {
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+	IsStatic bool   `json:"is_static"`
+}
+
+// AclGrant - a policy deciding on traffic and the protocol and ports it applies to,
+// deny grants restrict the allow grants after them
+type AclGrant struct {
+	PolicyID   string    `json:"policy_id"`
+	PolicyName string    `json:"policy_name"`
+	Action     AclAction `json:"action"`
+	Proto      Protocol  `json:"protocol"`
+	Ports      []string  `json:"ports"`
+}
+
+// AclSimulationPairChange - how the reachability from src to dst would change
+type AclSimulationPairChange struct {
+	Src    AclSimulationDevice `json:"src"`
+	Dst    AclSimulationDevice `json:"dst"`
+	Change string              `json:"change"` // allowed, denied or modified
+	Before []AclGrant          `json:"before"`
+	After  []AclGrant          `json:"after"`
+}
+
+// AclSimulationResult - the effect a proposed acl change would have on a network
+type AclSimulationResult struct {
+	NetworkID     NetworkID                 `json:"network_id"`
+	Action        AclSimulationAction       `json:"action"`
+	Acl           Acl                       `json:"acl"`
+	Changes       []AclSimulationPairChange `json:"changes"`
+	AffectedHosts []string                  `json:"affected_hosts"`
+}
+
+// AclMatrixEntity - a row and column of the reachability matrix
+type AclMatrixEntity struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	Type string `json:"type"` // node, static or user-group
+}
+
+// AclMatrixCell - the traffic the row entity may send to the column entity
+type AclMatrixCell struct {
+	Allowed bool       `json:"allowed"`
+	Grants  []AclGrant `json:"grants"`
+}
+
+// AclMatrix - the reachability between every pair of entities in a network
+type AclMatrix struct {
+	NetworkID NetworkID         `json:"network_id"`
+	Entities  []AclMatrixEntity `json:"entities"`
+	Cells     [][]AclMatrixCell `json:"cells"`
 }
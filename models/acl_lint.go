@@ -0,0 +1,52 @@
+package models
+
+// AclLintSeverity - how much a lint finding matters
+type AclLintSeverity string
+
+const (
+	// AclLintError - the policy is broken, e.g. it references a deleted tag
+	AclLintError AclLintSeverity = "error"
+	// AclLintWarning - the policy does not do what it appears to
+	AclLintWarning AclLintSeverity = "warning"
+	// AclLintInfo - the policy works but could be stated more clearly
+	AclLintInfo AclLintSeverity = "info"
+)
+
+// AclLintKind - the problem a lint finding reports
+type AclLintKind string
+
+const (
+	// AclLintShadowed - an earlier policy with the opposite action matches all of its traffic
+	AclLintShadowed AclLintKind = "shadowed"
+	// AclLintRedundant - an earlier policy with the same action matches all of its traffic
+	AclLintRedundant AclLintKind = "redundant"
+	// AclLintUnreachable - the policy matches no traffic at all
+	AclLintUnreachable AclLintKind = "unreachable"
+	// AclLintMissingReference - the policy references a tag, device, user, group or service
+	// that does not exist
+	AclLintMissingReference AclLintKind = "missing_reference"
+	// AclLintNoEffect - a uni-directional policy whose direction changes nothing
+	AclLintNoEffect AclLintKind = "no_effect"
+)
+
+// AclLintFinding - a problem with a policy and how to fix it
+type AclLintFinding struct {
+	Kind       AclLintKind     `json:"kind"`
+	Severity   AclLintSeverity `json:"severity"`
+	PolicyID   string          `json:"policy_id"`
+	PolicyName string          `json:"policy_name"`
+	// RelatedPolicyID and RelatedPolicyName - the policy causing the problem, if any
+	RelatedPolicyID   string `json:"related_policy_id,omitempty"`
+	RelatedPolicyName string `json:"related_policy_name,omitempty"`
+	Message           string `json:"message"`
+	SuggestedFix      string `json:"suggested_fix"`
+}
+
+// AclLintReport - the lint findings for the policies of a network, errors first
+type AclLintReport struct {
+	NetworkID NetworkID        `json:"network_id"`
+	Errors    int              `json:"errors"`
+	Warnings  int              `json:"warnings"`
+	Info      int              `json:"info"`
+	Findings  []AclLintFinding `json:"findings"`
+}
@@ -0,0 +1,58 @@
+package models
+
+// AclPolicyFileFormat - encoding of a policy file
+type AclPolicyFileFormat string
+
+const (
+	AclPolicyFileYAML AclPolicyFileFormat = "yaml"
+	// AclPolicyFileHuJSON - JSON allowing comments and trailing commas
+	AclPolicyFileHuJSON AclPolicyFileFormat = "hujson"
+)
+
+// AclPolicyFile - the policies of a network as a reviewable file. Rules reference entities as
+// type:value, e.g. tag:web, user:alice, user-group:devs, device:<node id> or tag:* for all
+type AclPolicyFile struct {
+	Network string `json:"network" yaml:"network"`
+	// Groups and Tags - the user groups and tags the rules may reference, tags without the network prefix
+	Groups []string `json:"groups,omitempty" yaml:"groups,omitempty"`
+	Tags   []string `json:"tags,omitempty" yaml:"tags,omitempty"`
+	// Services - named protocols and ports the rules may use instead of their own
+	Services map[string]AclPolicyFileService `json:"services,omitempty" yaml:"services,omitempty"`
+	Rules    []AclPolicyFileRule             `json:"rules" yaml:"rules"`
+}
+
+// AclPolicyFileService - a protocol and ports
+type AclPolicyFileService struct {
+	Protocol Protocol `json:"protocol" yaml:"protocol"`
+	Ports    []string `json:"ports,omitempty" yaml:"ports,omitempty"`
+}
+
+// AclPolicyFileRule - a policy in a policy file, rules with the id of an existing policy update it
+// and rules without one create a policy. Only enabled is applied to default policies
+type AclPolicyFileRule struct {
+	ID          string        `json:"id,omitempty" yaml:"id,omitempty"`
+	Name        string        `json:"name" yaml:"name"`
+	Description string        `json:"description,omitempty" yaml:"description,omitempty"`
+	Default     bool          `json:"default,omitempty" yaml:"default,omitempty"`
+	Type        AclPolicyType `json:"type" yaml:"type"`
+	Action      AclAction     `json:"action,omitempty" yaml:"action,omitempty"`
+	Priority    int           `json:"priority,omitempty" yaml:"priority,omitempty"`
+	Src         []string      `json:"src" yaml:"src"`
+	Dst         []string      `json:"dst" yaml:"dst"`
+	// Service - a service declared in the file, or else a service object of the network
+	Service  string   `json:"service,omitempty" yaml:"service,omitempty"`
+	Protocol Protocol `json:"protocol,omitempty" yaml:"protocol,omitempty"`
+	Ports    []string `json:"ports,omitempty" yaml:"ports,omitempty"`
+	// Direction - uni or bi, uni if empty
+	Direction string       `json:"direction,omitempty" yaml:"direction,omitempty"`
+	Enabled   *bool        `json:"enabled,omitempty" yaml:"enabled,omitempty"`
+	Schedule  *AclSchedule `json:"schedule,omitempty" yaml:"schedule,omitempty"`
+}
+
+// AclPolicyFileResult - the changes applying a policy file made
+type AclPolicyFileResult struct {
+	NetworkID NetworkID `json:"network_id"`
+	Created   []string  `json:"created"`
+	Updated   []string  `json:"updated"`
+	Deleted   []string  `json:"deleted"`
+}
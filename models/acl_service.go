@@ -0,0 +1,29 @@
+package models
+
+import "time"
+
+// AclServiceEntry - a protocol and the ports of it a service uses
+type AclServiceEntry struct {
+	Protocol Protocol `json:"protocol"`
+	// Ports - single ports or ranges like 9000-9100, empty for all ports of the protocol
+	Ports []string `json:"ports"`
+}
+
+// AclService - a named set of protocols and ports policies of a network can reference,
+// e.g. postgres for tcp/5432
+type AclService struct {
+	ID          string            `json:"id"`
+	NetworkID   NetworkID         `json:"network_id"`
+	Name        string            `json:"name"`
+	Description string            `json:"description"`
+	Entries     []AclServiceEntry `json:"entries"`
+	CreatedBy   string            `json:"created_by"`
+	CreatedAt   time.Time         `json:"created_at"`
+}
+
+// AclServiceStatus - a service along with the policies referencing it
+type AclServiceStatus struct {
+	AclService
+	UsageCount int      `json:"usage_count"`
+	Policies   []string `json:"policies"`
+}
@@ -0,0 +1,36 @@
+package models
+
+import "time"
+
+// AclVersion - a snapshot of the policies of a network taken after a change
+type AclVersion struct {
+	Version int       `json:"version"`
+	Time    time.Time `json:"time"`
+	User    string    `json:"user,omitempty"`
+	// Action - what the user did, e.g. created policy web-to-db
+	Action string `json:"action"`
+	// Changes - the difference to the previous version
+	Changes  AclVersionDiff `json:"changes"`
+	Policies []Acl          `json:"policies,omitempty"`
+}
+
+// AclVersionHistory - bounded history of the policy set of a network, oldest first
+type AclVersionHistory struct {
+	NetworkID NetworkID    `json:"network_id"`
+	Versions  []AclVersion `json:"versions"`
+}
+
+// AclPolicyChange - a policy as it was before and after a change
+type AclPolicyChange struct {
+	Before Acl `json:"before"`
+	After  Acl `json:"after"`
+}
+
+// AclVersionDiff - policies added, removed and modified between two versions
+type AclVersionDiff struct {
+	From     int               `json:"from"`
+	To       int               `json:"to"`
+	Added    []Acl             `json:"added"`
+	Removed  []Acl             `json:"removed"`
+	Modified []AclPolicyChange `json:"modified"`
+}
@@ -25,6 +25,7 @@ type ApiNode struct {
 	AllowedIPs                    []string            `json:"allowedips"`
 	LastModified                  int64               `json:"lastmodified" swaggertype:"primitive,integer" format:"int64"`
 	ExpirationDateTime            int64               `json:"expdatetime" swaggertype:"primitive,integer" format:"int64"`
+	LeaseTTL                      int64               `json:"leasettl" swaggertype:"primitive,integer" format:"int64"`
 	LastCheckIn                   int64               `json:"lastcheckin" swaggertype:"primitive,integer" format:"int64"`
 	LastPeerUpdate                int64               `json:"lastpeerupdate" swaggertype:"primitive,integer" format:"int64"`
 	Network                       string              `json:"network"`
@@ -127,6 +128,7 @@ func (a *ApiNode) ConvertToServerNode(currentNode *Node) *Node {
 	convertedNode.LastCheckIn = time.Unix(a.LastCheckIn, 0)
 	convertedNode.LastPeerUpdate = time.Unix(a.LastPeerUpdate, 0)
 	convertedNode.ExpirationDateTime = time.Unix(a.ExpirationDateTime, 0)
+	convertedNode.LeaseTTL = currentNode.LeaseTTL
 	convertedNode.Metadata = a.Metadata
 	for _, ip := range a.AdditionalRagIps {
 		ragIp := net.ParseIP(ip)
@@ -174,6 +176,7 @@ func (nm *Node) ConvertToAPINode() *ApiNode {
 	apiNode.LastCheckIn = nm.LastCheckIn.Unix()
 	apiNode.LastPeerUpdate = nm.LastPeerUpdate.Unix()
 	apiNode.ExpirationDateTime = nm.ExpirationDateTime.Unix()
+	apiNode.LeaseTTL = nm.LeaseTTL
 	apiNode.Network = nm.Network
 	apiNode.NetworkRange = nm.NetworkRange.String()
 	if isEmptyAddr(apiNode.NetworkRange) {
@@ -41,37 +41,44 @@ const (
 
 // Host - represents a host on the network
 type Host struct {
-	ID                  uuid.UUID        `json:"id"                      yaml:"id"`
-	Verbosity           int              `json:"verbosity"               yaml:"verbosity"`
-	FirewallInUse       string           `json:"firewallinuse"           yaml:"firewallinuse"`
-	Version             string           `json:"version"                 yaml:"version"`
-	IPForwarding        bool             `json:"ipforwarding"            yaml:"ipforwarding"`
-	DaemonInstalled     bool             `json:"daemoninstalled"         yaml:"daemoninstalled"`
-	AutoUpdate          bool             `json:"autoupdate"              yaml:"autoupdate"`
-	HostPass            string           `json:"hostpass"                yaml:"hostpass"`
-	Name                string           `json:"name"                    yaml:"name"`
-	OS                  string           `json:"os"                      yaml:"os"`
-	Interface           string           `json:"interface"               yaml:"interface"`
-	Debug               bool             `json:"debug"                   yaml:"debug"`
-	ListenPort          int              `json:"listenport"              yaml:"listenport"`
-	WgPublicListenPort  int              `json:"wg_public_listen_port"   yaml:"wg_public_listen_port"`
-	MTU                 int              `json:"mtu"                     yaml:"mtu"`
-	PublicKey           wgtypes.Key      `json:"publickey"               yaml:"publickey"`
-	MacAddress          net.HardwareAddr `json:"macaddress"              yaml:"macaddress"`
-	TrafficKeyPublic    []byte           `json:"traffickeypublic"        yaml:"traffickeypublic"`
-	Nodes               []string         `json:"nodes"                   yaml:"nodes"`
-	Interfaces          []Iface          `json:"interfaces"              yaml:"interfaces"`
-	DefaultInterface    string           `json:"defaultinterface"        yaml:"defaultinterface"`
-	EndpointIP          net.IP           `json:"endpointip"              yaml:"endpointip"`
-	EndpointIPv6        net.IP           `json:"endpointipv6"            yaml:"endpointipv6"`
-	IsDocker            bool             `json:"isdocker"                yaml:"isdocker"`
-	IsK8S               bool             `json:"isk8s"                   yaml:"isk8s"`
-	IsStaticPort        bool             `json:"isstaticport"            yaml:"isstaticport"`
-	IsStatic            bool             `json:"isstatic"        yaml:"isstatic"`
-	IsDefault           bool             `json:"isdefault"               yaml:"isdefault"`
-	NatType             string           `json:"nat_type,omitempty"      yaml:"nat_type,omitempty"`
-	TurnEndpoint        *netip.AddrPort  `json:"turn_endpoint,omitempty" yaml:"turn_endpoint,omitempty"`
-	PersistentKeepalive time.Duration    `json:"persistentkeepalive" swaggertype:"primitive,integer" format:"int64" yaml:"persistentkeepalive"`
+	ID                  uuid.UUID         `json:"id"                      yaml:"id"`
+	Verbosity           int               `json:"verbosity"               yaml:"verbosity"`
+	FirewallInUse       string            `json:"firewallinuse"           yaml:"firewallinuse"`
+	Version             string            `json:"version"                 yaml:"version"`
+	IPForwarding        bool              `json:"ipforwarding"            yaml:"ipforwarding"`
+	DaemonInstalled     bool              `json:"daemoninstalled"         yaml:"daemoninstalled"`
+	AutoUpdate          bool              `json:"autoupdate"              yaml:"autoupdate"`
+	HostPass            string            `json:"hostpass"                yaml:"hostpass"`
+	Name                string            `json:"name"                    yaml:"name"`
+	OS                  string            `json:"os"                      yaml:"os"`
+	Interface           string            `json:"interface"               yaml:"interface"`
+	Debug               bool              `json:"debug"                   yaml:"debug"`
+	ListenPort          int               `json:"listenport"              yaml:"listenport"`
+	WgPublicListenPort  int               `json:"wg_public_listen_port"   yaml:"wg_public_listen_port"`
+	MTU                 int               `json:"mtu"                     yaml:"mtu"`
+	PublicKey           wgtypes.Key       `json:"publickey"               yaml:"publickey"`
+	MacAddress          net.HardwareAddr  `json:"macaddress"              yaml:"macaddress"`
+	TrafficKeyPublic    []byte            `json:"traffickeypublic"        yaml:"traffickeypublic"`
+	Nodes               []string          `json:"nodes"                   yaml:"nodes"`
+	Interfaces          []Iface           `json:"interfaces"              yaml:"interfaces"`
+	DefaultInterface    string            `json:"defaultinterface"        yaml:"defaultinterface"`
+	EndpointIP          net.IP            `json:"endpointip"              yaml:"endpointip"`
+	EndpointIPv6        net.IP            `json:"endpointipv6"            yaml:"endpointipv6"`
+	IsDocker            bool              `json:"isdocker"                yaml:"isdocker"`
+	IsK8S               bool              `json:"isk8s"                   yaml:"isk8s"`
+	IsStaticPort        bool              `json:"isstaticport"            yaml:"isstaticport"`
+	IsStatic            bool              `json:"isstatic"        yaml:"isstatic"`
+	IsDefault           bool              `json:"isdefault"               yaml:"isdefault"`
+	NatType             string            `json:"nat_type,omitempty"      yaml:"nat_type,omitempty"`
+	TurnEndpoint        *netip.AddrPort   `json:"turn_endpoint,omitempty" yaml:"turn_endpoint,omitempty"`
+	PersistentKeepalive time.Duration     `json:"persistentkeepalive" swaggertype:"primitive,integer" format:"int64" yaml:"persistentkeepalive"`
+	Labels              map[string]string `json:"labels,omitempty"        yaml:"labels,omitempty"`
+	// PeerDeltas - set by clients that apply incremental peer updates
+	PeerDeltas bool `json:"peer_deltas,omitempty" yaml:"peer_deltas,omitempty"`
+	// ProfileBaseMTU and ProfileBaseListenPort - the MTU and listen port the host had before a
+	// connectivity profile overrode them, restored once no profile sets them
+	ProfileBaseMTU        *int `json:"profile_base_mtu,omitempty" yaml:"profile_base_mtu,omitempty"`
+	ProfileBaseListenPort *int `json:"profile_base_listen_port,omitempty" yaml:"profile_base_listen_port,omitempty"`
 }
 
 // FormatBool converts a boolean to a [yes|no] string
@@ -122,6 +129,10 @@ const (
 	SignalPull HostMqAction = "SIGNAL_PULL"
 	// UpdateMetrics - updates metrics data
 	UpdateMetrics HostMqAction = "UPDATE_METRICS"
+	// NodeExpiring - warns a host that one of its nodes is about to expire
+	NodeExpiring HostMqAction = "NODE_EXPIRING"
+	// PeerDeltaGap - host missed a peer delta and requests a full peer update
+	PeerDeltaGap HostMqAction = "PEER_DELTA_GAP"
 )
 
 // SignalAction - turn peer signal action
@@ -141,6 +152,47 @@ type HostUpdate struct {
 	Node       Node
 	Signal     Signal
 	NewMetrics Metrics
+	// Generation - set by the server to the desired state generation of the host,
+	// set by the client to the last generation it applied
+	Generation uint64 `json:"generation,omitempty"`
+	// ActionID - set by the server on a queued host action, echoed by the client when acknowledging it
+	ActionID string `json:"action_id,omitempty"`
+}
+
+// HostDriftStatus - whether a host applied the latest desired state generation
+type HostDriftStatus string
+
+const (
+	// HostInSync - host applied the latest generation
+	HostInSync HostDriftStatus = "in_sync"
+	// HostLagging - host is behind but within the drift threshold
+	HostLagging HostDriftStatus = "lagging"
+	// HostDrifted - host stayed behind for longer than the drift threshold
+	HostDrifted HostDriftStatus = "drifted"
+	// HostDriftUnknown - host never reported an applied generation
+	HostDriftUnknown HostDriftStatus = "unknown"
+)
+
+// HostGeneration - desired and applied state generations of a host
+type HostGeneration struct {
+	HostID      string    `json:"host_id"`
+	Desired     uint64    `json:"desired"`
+	Applied     uint64    `json:"applied"`
+	Reserved    uint64    `json:"reserved"` // generations up to this one may have been issued
+	BehindSince time.Time `json:"behind_since"`
+	ReportedAt  time.Time `json:"reported_at"`
+}
+
+// HostDrift - drift status of a host
+type HostDrift struct {
+	HostID      string          `json:"host_id"`
+	HostName    string          `json:"host_name"`
+	Desired     uint64          `json:"desired"`
+	Applied     uint64          `json:"applied"`
+	Lag         uint64          `json:"lag"`
+	Status      HostDriftStatus `json:"status"`
+	BehindSince time.Time       `json:"behind_since,omitempty"`
+	ReportedAt  time.Time       `json:"reported_at,omitempty"`
 }
 
 // HostTurnRegister - struct for host turn registration
@@ -0,0 +1,29 @@
+package models
+
+import "time"
+
+// HostActionState - delivery state of a queued host action
+type HostActionState string
+
+const (
+	HostActionPending      HostActionState = "pending"
+	HostActionDelivered    HostActionState = "delivered"
+	HostActionAcknowledged HostActionState = "acknowledged"
+	HostActionFailed       HostActionState = "failed"
+)
+
+// HostAction - a host update queued for a host, delivered when the host acknowledges a request
+type HostAction struct {
+	ID             string          `json:"id"`
+	HostID         string          `json:"host_id"`
+	Action         HostMqAction    `json:"action"`
+	Update         HostUpdate      `json:"update"`
+	State          HostActionState `json:"state"`
+	Attempts       int             `json:"attempts"`
+	LastError      string          `json:"last_error,omitempty"`
+	CreatedAt      time.Time       `json:"created_at"`
+	UpdatedAt      time.Time       `json:"updated_at"`
+	DeliveredAt    time.Time       `json:"delivered_at"`
+	Generation     uint64          `json:"generation,omitempty"` // host generation the action was delivered with
+	AcknowledgedAt time.Time       `json:"acknowledged_at"`
+}
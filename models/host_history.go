@@ -0,0 +1,38 @@
+package models
+
+import "time"
+
+// HostChangeType - the host attribute a history entry records
+type HostChangeType string
+
+const (
+	HostEndpointChange   HostChangeType = "endpoint"
+	HostEndpoint6Change  HostChangeType = "endpoint6"
+	HostNatChange        HostChangeType = "nat"
+	HostInterfacesChange HostChangeType = "interfaces"
+)
+
+// HostHistoryEntry - a change of a host's endpoint, nat type or interfaces
+type HostHistoryEntry struct {
+	Time time.Time      `json:"time"`
+	Type HostChangeType `json:"type"`
+	Old  string         `json:"old"`
+	New  string         `json:"new"`
+	// Unexpected - a public endpoint that left the previous /16 (/48 for IPv6)
+	Unexpected bool `json:"unexpected"`
+}
+
+// HostHistory - bounded history of a host's network changes, oldest first
+type HostHistory struct {
+	HostID  string             `json:"host_id"`
+	Entries []HostHistoryEntry `json:"entries"`
+}
+
+// HostEndpointEvent - fired when a host's public endpoint changes unexpectedly
+type HostEndpointEvent struct {
+	HostID   string    `json:"host_id"`
+	HostName string    `json:"host_name"`
+	Old      string    `json:"old"`
+	New      string    `json:"new"`
+	Time     time.Time `json:"time"`
+}
@@ -0,0 +1,50 @@
+package models
+
+import "time"
+
+// KeyRotationStatus - wireguard key rotation state of a host
+type KeyRotationStatus string
+
+const (
+	// KeyRotationIdle - no rotation in progress
+	KeyRotationIdle KeyRotationStatus = "idle"
+	// KeyRotationRequested - host was asked to rotate its keys and has not reported the new key yet
+	KeyRotationRequested KeyRotationStatus = "requested"
+	// KeyRotationTimedOut - host did not report a new key in time, it is retried in the next window
+	KeyRotationTimedOut KeyRotationStatus = "timed_out"
+)
+
+// KeyRotationPolicy - scheduled wireguard key rotation of hosts, an empty network is the global policy
+type KeyRotationPolicy struct {
+	Network      string `json:"network"`
+	Enabled      bool   `json:"enabled"`
+	IntervalDays int    `json:"interval_days"`
+	// WindowStart, WindowEnd - maintenance window as HH:MM in UTC, empty means any time
+	WindowStart string    `json:"window_start"`
+	WindowEnd   string    `json:"window_end"`
+	BatchSize   int       `json:"batch_size"`
+	UpdatedBy   string    `json:"updated_by"`
+	UpdatedAt   time.Time `json:"updated_at"`
+	// EnabledAt - when the policy was last enabled, first rotations of hosts are spread over the interval from here
+	EnabledAt time.Time `json:"enabled_at"`
+}
+
+// HostKeyRotation - key rotation state of a host
+type HostKeyRotation struct {
+	HostID        string            `json:"host_id"`
+	Status        KeyRotationStatus `json:"status"`
+	LastRotatedAt time.Time         `json:"last_rotated_at"`
+	RequestedAt   time.Time         `json:"requested_at"`
+}
+
+// HostKeyRotationStatus - key rotation status of a host as reported by the API
+type HostKeyRotationStatus struct {
+	HostID        string            `json:"host_id"`
+	HostName      string            `json:"host_name"`
+	Status        KeyRotationStatus `json:"status"`
+	Policy        string            `json:"policy"`
+	LastRotatedAt time.Time         `json:"last_rotated_at"`
+	RequestedAt   time.Time         `json:"requested_at"`
+	NextRotation  time.Time         `json:"next_rotation"`
+	Overdue       bool              `json:"overdue"`
+}
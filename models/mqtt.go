@@ -28,6 +28,10 @@ type HostPeerUpdate struct {
 	FwUpdate        FwUpdate              `json:"fw_update"`
 	ReplacePeers    bool                  `json:"replace_peers"`
 	NameServers     []string              `json:"name_servers"`
+	// Connectivity - host settings resolved from tag connectivity profiles, nil without profiles
+	Connectivity *ConnectivitySettings `json:"connectivity,omitempty"`
+	// Generation - desired state generation of the host, reported back once applied
+	Generation uint64 `json:"generation,omitempty"`
 	ServerConfig
 	OldPeerUpdateFields
 }
@@ -43,7 +47,8 @@ type FwRule struct {
 	DstIP           net.IPNet `json:"dst_ip"`
 	AllowedProtocol Protocol  `json:"allowed_protocols"` // tcp, udp, etc.
 	AllowedPorts    []string  `json:"allowed_ports"`
-	Allow           bool      `json:"allow"`
+	// Allow - false for deny rules, rules are matched in order and the first match decides
+	Allow bool `json:"allow"`
 }
 
 // IngressInfo - struct for ingress info
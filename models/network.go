@@ -8,23 +8,45 @@ import (
 // Network Struct - contains info for a given unique network
 // At  some point, need to replace all instances of Name with something else like  Identifier
 type Network struct {
-	AddressRange        string   `json:"addressrange" bson:"addressrange" validate:"omitempty,cidrv4"`
-	AddressRange6       string   `json:"addressrange6" bson:"addressrange6" validate:"omitempty,cidrv6"`
-	NetID               string   `json:"netid" bson:"netid" validate:"required,min=1,max=32,netid_valid"`
-	NodesLastModified   int64    `json:"nodeslastmodified" bson:"nodeslastmodified" swaggertype:"primitive,integer" format:"int64"`
-	NetworkLastModified int64    `json:"networklastmodified" bson:"networklastmodified" swaggertype:"primitive,integer" format:"int64"`
-	DefaultInterface    string   `json:"defaultinterface" bson:"defaultinterface" validate:"min=1,max=35"`
-	DefaultListenPort   int32    `json:"defaultlistenport,omitempty" bson:"defaultlistenport,omitempty" validate:"omitempty,min=1024,max=65535"`
-	NodeLimit           int32    `json:"nodelimit" bson:"nodelimit"`
-	DefaultPostDown     string   `json:"defaultpostdown" bson:"defaultpostdown"`
-	DefaultKeepalive    int32    `json:"defaultkeepalive" bson:"defaultkeepalive" validate:"omitempty,max=1000"`
-	AllowManualSignUp   string   `json:"allowmanualsignup" bson:"allowmanualsignup" validate:"checkyesorno"`
-	IsIPv4              string   `json:"isipv4" bson:"isipv4" validate:"checkyesorno"`
-	IsIPv6              string   `json:"isipv6" bson:"isipv6" validate:"checkyesorno"`
-	DefaultUDPHolePunch string   `json:"defaultudpholepunch" bson:"defaultudpholepunch" validate:"checkyesorno"`
-	DefaultMTU          int32    `json:"defaultmtu" bson:"defaultmtu"`
-	DefaultACL          string   `json:"defaultacl" bson:"defaultacl" yaml:"defaultacl" validate:"checkyesorno"`
-	NameServers         []string `json:"dns_nameservers"`
+	AddressRange        string    `json:"addressrange" bson:"addressrange" validate:"omitempty,cidrv4"`
+	AddressRange6       string    `json:"addressrange6" bson:"addressrange6" validate:"omitempty,cidrv6"`
+	NetID               string    `json:"netid" bson:"netid" validate:"required,min=1,max=32,netid_valid"`
+	NodesLastModified   int64     `json:"nodeslastmodified" bson:"nodeslastmodified" swaggertype:"primitive,integer" format:"int64"`
+	NetworkLastModified int64     `json:"networklastmodified" bson:"networklastmodified" swaggertype:"primitive,integer" format:"int64"`
+	DefaultInterface    string    `json:"defaultinterface" bson:"defaultinterface" validate:"min=1,max=35"`
+	DefaultListenPort   int32     `json:"defaultlistenport,omitempty" bson:"defaultlistenport,omitempty" validate:"omitempty,min=1024,max=65535"`
+	NodeLimit           int32     `json:"nodelimit" bson:"nodelimit"`
+	DefaultPostDown     string    `json:"defaultpostdown" bson:"defaultpostdown"`
+	DefaultKeepalive    int32     `json:"defaultkeepalive" bson:"defaultkeepalive" validate:"omitempty,max=1000"`
+	AllowManualSignUp   string    `json:"allowmanualsignup" bson:"allowmanualsignup" validate:"checkyesorno"`
+	IsIPv4              string    `json:"isipv4" bson:"isipv4" validate:"checkyesorno"`
+	IsIPv6              string    `json:"isipv6" bson:"isipv6" validate:"checkyesorno"`
+	DefaultUDPHolePunch string    `json:"defaultudpholepunch" bson:"defaultudpholepunch" validate:"checkyesorno"`
+	DefaultMTU          int32     `json:"defaultmtu" bson:"defaultmtu"`
+	DefaultACL          string    `json:"defaultacl" bson:"defaultacl" yaml:"defaultacl" validate:"checkyesorno"`
+	NameServers         []string  `json:"dns_nameservers"`
+	Frozen              bool      `json:"frozen" bson:"frozen"`
+	FreezeReason        string    `json:"freeze_reason" bson:"freeze_reason"`
+	FrozenBy            string    `json:"frozen_by" bson:"frozen_by"`
+	FrozenAt            time.Time `json:"frozen_at" bson:"frozen_at"`
+	FrozenUntil         time.Time `json:"frozen_until" bson:"frozen_until"`
+	DefaultNodeTTL      int64     `json:"defaultnodettl" bson:"defaultnodettl" swaggertype:"primitive,integer" format:"int64"`
+	NodeExpiryAction    string    `json:"nodeexpiryaction" bson:"nodeexpiryaction"`
+	ZombieHandling      string    `json:"zombiehandling" bson:"zombiehandling"`
+}
+
+// NodeExpiryPolicy - node lifetime settings of a network
+type NodeExpiryPolicy struct {
+	// DefaultNodeTTL - lifetime in seconds of nodes joining the network, 0 never expires
+	DefaultNodeTTL int64 `json:"defaultnodettl"`
+	// NodeExpiryAction - what happens to a node when it expires, delete (default) or disconnect
+	NodeExpiryAction string `json:"nodeexpiryaction"`
+}
+
+// NetworkFreezeRequest - request to freeze a network
+type NetworkFreezeRequest struct {
+	Reason string    `json:"reason"`
+	Until  time.Time `json:"until"`
 }
 
 // SaveData - sensitive fields of a network that should be kept the same
@@ -95,6 +117,14 @@ func (network *Network) SetDefaults() (upsert bool) {
 	return
 }
 
+// Network.IsFrozen - checks if the network is frozen and the freeze has not expired
+func (network *Network) IsFrozen() bool {
+	if !network.Frozen {
+		return false
+	}
+	return network.FrozenUntil.IsZero() || time.Now().Before(network.FrozenUntil)
+}
+
 func (network *Network) GetNetworkNetworkCIDR4() *net.IPNet {
 	if network.AddressRange == "" {
 		return nil
@@ -21,6 +21,7 @@ const (
 	ErrorSt      NodeStatus = "error"
 	UnKnown      NodeStatus = "unknown"
 	Disconnected NodeStatus = "disconnected"
+	Quarantined  NodeStatus = "quarantined"
 )
 
 // LastCheckInThreshold - if node's checkin more than this threshold,then node is declared as offline
@@ -98,6 +99,7 @@ type Node struct {
 	LastCheckIn                time.Time            `json:"lastcheckin"             bson:"lastcheckin"             yaml:"lastcheckin"`
 	LastPeerUpdate             time.Time            `json:"lastpeerupdate"          bson:"lastpeerupdate"          yaml:"lastpeerupdate"`
 	ExpirationDateTime         time.Time            `json:"expdatetime"             bson:"expdatetime"             yaml:"expdatetime"`
+	LeaseTTL                   int64                `json:"leasettl"                bson:"leasettl"                yaml:"leasettl"`
 	EgressGatewayNatEnabled    bool                 `json:"egressgatewaynatenabled" bson:"egressgatewaynatenabled" yaml:"egressgatewaynatenabled"`
 	EgressGatewayRequest       EgressGatewayRequest `json:"egressgatewayrequest"    bson:"egressgatewayrequest"    yaml:"egressgatewayrequest"`
 	IngressGatewayRange        string               `json:"ingressgatewayrange"     bson:"ingressgatewayrange"     yaml:"ingressgatewayrange"`
@@ -433,6 +435,9 @@ func (newNode *Node) Fill(
 	if newNode.ExpirationDateTime.IsZero() {
 		newNode.ExpirationDateTime = currentNode.ExpirationDateTime
 	}
+	if newNode.LeaseTTL == 0 {
+		newNode.LeaseTTL = currentNode.LeaseTTL
+	}
 	if newNode.LastPeerUpdate.IsZero() {
 		newNode.LastPeerUpdate = currentNode.LastPeerUpdate
 	}
@@ -0,0 +1,33 @@
+package models
+
+import "time"
+
+const (
+	// NodeExpiryDelete - expired nodes are deleted
+	NodeExpiryDelete = "delete"
+	// NodeExpiryDisconnect - expired nodes are disconnected but kept
+	NodeExpiryDisconnect = "disconnect"
+)
+
+// NodeExpiryEventType - type of a node expiry event
+type NodeExpiryEventType string
+
+const (
+	// NodeExpiryWarning - the node expires within the warning window
+	NodeExpiryWarning NodeExpiryEventType = "warning"
+	// NodeExpiryDisconnected - the node expired and was disconnected
+	NodeExpiryDisconnected NodeExpiryEventType = "disconnected"
+)
+
+// NodeExpiryEvent - event fired for nodes that are about to expire or were disconnected on expiry
+type NodeExpiryEvent struct {
+	Type      NodeExpiryEventType `json:"type"`
+	Node      Node                `json:"node"`
+	ExpiresAt time.Time           `json:"expires_at"`
+}
+
+// NodeRenewalReq - request to renew the lease of a node
+type NodeRenewalReq struct {
+	// TTL - lifetime in seconds from now, defaults to the lease of the node
+	TTL int64 `json:"ttl"`
+}
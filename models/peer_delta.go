@@ -0,0 +1,37 @@
+package models
+
+import "golang.zx2c4.com/wireguard/wgctrl/wgtypes"
+
+// HostPeerDelta - changes to the last peer update sent to a host, applies only on top of BaseSeq,
+// sequence numbers are the host's desired state generations
+type HostPeerDelta struct {
+	HostID  string `json:"host_id"`
+	Server  string `json:"server"`
+	Seq     uint64 `json:"seq"`
+	BaseSeq uint64 `json:"base_seq"`
+	// AddedPeers, ChangedPeers and RemovedPeers - wireguard peer configs, changed peers replace their allowed ips
+	AddedPeers   []wgtypes.PeerConfig `json:"added_peers,omitempty"`
+	ChangedPeers []wgtypes.PeerConfig `json:"changed_peers,omitempty"`
+	RemovedPeers []wgtypes.PeerConfig `json:"removed_peers,omitempty"`
+	// PeerIDs and HostNetworkInfo - added or changed entries, keyed like in the full update
+	PeerIDs                PeerMap     `json:"peerids,omitempty"`
+	RemovedPeerIDs         []string    `json:"removed_peerids,omitempty"`
+	HostNetworkInfo        HostInfoMap `json:"host_network_info,omitempty"`
+	RemovedHostNetworkInfo []string    `json:"removed_host_network_info,omitempty"`
+	// EgressRoutes - added or changed routes, identified by network and peer key
+	EgressRoutes        []EgressNetworkRoutes `json:"egress_network_routes,omitempty"`
+	RemovedEgressRoutes []EgressNetworkRoutes `json:"removed_egress_network_routes,omitempty"`
+	// FwUpdate - firewall settings without acl rules, only set when they changed
+	FwUpdate        *FwUpdate          `json:"fw_update,omitempty"`
+	AclRules        map[string]AclRule `json:"acl_rules,omitempty"`
+	RemovedAclRules []string           `json:"removed_acl_rules,omitempty"`
+}
+
+// IsEmpty - checks if a delta carries no changes
+func (d *HostPeerDelta) IsEmpty() bool {
+	return len(d.AddedPeers) == 0 && len(d.ChangedPeers) == 0 && len(d.RemovedPeers) == 0 &&
+		len(d.PeerIDs) == 0 && len(d.RemovedPeerIDs) == 0 &&
+		len(d.HostNetworkInfo) == 0 && len(d.RemovedHostNetworkInfo) == 0 &&
+		len(d.EgressRoutes) == 0 && len(d.RemovedEgressRoutes) == 0 &&
+		d.FwUpdate == nil && len(d.AclRules) == 0 && len(d.RemovedAclRules) == 0
+}
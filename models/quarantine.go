@@ -0,0 +1,29 @@
+package models
+
+import "time"
+
+// QuarantineKind - kind of a quarantined resource
+type QuarantineKind string
+
+const (
+	QuarantineHost QuarantineKind = "host"
+	QuarantineNode QuarantineKind = "node"
+)
+
+// Quarantine - a host or node cut off by the server, the record of the host is kept for forensics
+type Quarantine struct {
+	// ID - id of the quarantined host or node
+	ID            string         `json:"id"`
+	Kind          QuarantineKind `json:"kind"`
+	HostID        string         `json:"host_id"`
+	HostName      string         `json:"host_name"`
+	Network       string         `json:"network,omitempty"`
+	Reason        string         `json:"reason"`
+	QuarantinedBy string         `json:"quarantined_by"`
+	QuarantinedAt time.Time      `json:"quarantined_at"`
+}
+
+// QuarantineReq - request to quarantine a host or node
+type QuarantineReq struct {
+	Reason string `json:"reason"`
+}
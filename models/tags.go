@@ -25,6 +25,7 @@ type Tag struct {
 	TagName   string    `json:"tag_name"`
 	Network   NetworkID `json:"network"`
 	ColorCode string    `json:"color_code"`
+	ProfileID string    `json:"profile_id"`
 	CreatedBy string    `json:"created_by"`
 	CreatedAt time.Time `json:"created_at"`
 }
@@ -33,6 +34,7 @@ type CreateTagReq struct {
 	TagName     string    `json:"tag_name"`
 	Network     NetworkID `json:"network"`
 	ColorCode   string    `json:"color_code"`
+	ProfileID   string    `json:"profile_id"`
 	TaggedNodes []ApiNode `json:"tagged_nodes"`
 }
 
@@ -50,7 +52,31 @@ type TagListRespNodes struct {
 
 type UpdateTagReq struct {
 	Tag
-	NewName     string    `json:"new_name"`
-	ColorCode   string    `json:"color_code"`
+	NewName   string `json:"new_name"`
+	ColorCode string `json:"color_code"`
+	// ProfileID - connectivity profile to attach, unchanged if nil, detached if empty
+	ProfileID   *string   `json:"profile_id"`
 	TaggedNodes []ApiNode `json:"tagged_nodes"`
 }
+
+// ConnectivityProfile - named set of connectivity settings attached to tags,
+// non zero fields override the network defaults for nodes carrying the tag
+type ConnectivityProfile struct {
+	ID                  string    `json:"id"`
+	Name                string    `json:"name"`
+	Network             NetworkID `json:"network"`
+	MTU                 int32     `json:"mtu"`
+	PersistentKeepalive int32     `json:"persistent_keepalive"`
+	ListenPort          int32     `json:"listen_port"`
+	Priority            int       `json:"priority"`
+	CreatedBy           string    `json:"created_by"`
+	CreatedAt           time.Time `json:"created_at"`
+}
+
+// ConnectivitySettings - resolved connectivity settings, zero values are not set
+type ConnectivitySettings struct {
+	MTU                 int32    `json:"mtu,omitempty"`
+	PersistentKeepalive int32    `json:"persistent_keepalive,omitempty"`
+	ListenPort          int32    `json:"listen_port,omitempty"`
+	ProfileIDs          []string `json:"profile_ids,omitempty"`
+}
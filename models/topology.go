@@ -0,0 +1,52 @@
+package models
+
+// TopologyVertexKind - the kind of a topology vertex
+type TopologyVertexKind string
+
+// TopologyEdgeKind - the kind of link a topology edge represents
+type TopologyEdgeKind string
+
+const (
+	TopologyNode        TopologyVertexKind = "node"
+	TopologyExtClient   TopologyVertexKind = "extclient"
+	TopologyEgressRange TopologyVertexKind = "egress_range"
+
+	// TopologyPeer - direct wireguard peering between two nodes
+	TopologyPeer TopologyEdgeKind = "peer"
+	// TopologyRelay - relayed node to its relay
+	TopologyRelay TopologyEdgeKind = "relay"
+	// TopologyFailOver - node to a peer it reaches through its failover node
+	TopologyFailOver TopologyEdgeKind = "failover"
+	// TopologyIngress - ext client to its ingress gateway
+	TopologyIngress TopologyEdgeKind = "ingress"
+	// TopologyInternetGw - node to the internet gateway it routes through
+	TopologyInternetGw TopologyEdgeKind = "internet_gw"
+	// TopologyEgress - egress gateway to a range it routes
+	TopologyEgress TopologyEdgeKind = "egress"
+)
+
+// TopologyVertex - a node, ext client or egress range of a network
+type TopologyVertex struct {
+	ID       string             `json:"id"`
+	Kind     TopologyVertexKind `json:"kind"`
+	Label    string             `json:"label"`
+	Address  string             `json:"address,omitempty"`
+	Address6 string             `json:"address6,omitempty"`
+	Roles    []string           `json:"roles,omitempty"`
+}
+
+// TopologyEdge - a typed link between two vertices and whether the network's acls allow it
+type TopologyEdge struct {
+	Source   string           `json:"source"`
+	Target   string           `json:"target"`
+	Kind     TopologyEdgeKind `json:"kind"`
+	Allowed  bool             `json:"allowed"`
+	Policies []string         `json:"policies,omitempty"`
+}
+
+// Topology - graph of a network's mesh
+type Topology struct {
+	Network  string           `json:"network"`
+	Vertices []TopologyVertex `json:"vertices"`
+	Edges    []TopologyEdge   `json:"edges"`
+}
@@ -0,0 +1,105 @@
+package models
+
+import "time"
+
+// UpgradeCampaignStatus - state of an upgrade campaign
+type UpgradeCampaignStatus string
+
+const (
+	CampaignRunning   UpgradeCampaignStatus = "running"
+	CampaignPaused    UpgradeCampaignStatus = "paused"
+	CampaignHalted    UpgradeCampaignStatus = "halted"
+	CampaignCompleted UpgradeCampaignStatus = "completed"
+	CampaignCancelled UpgradeCampaignStatus = "cancelled"
+)
+
+// UpgradeWaveStatus - state of a wave of an upgrade campaign
+type UpgradeWaveStatus string
+
+const (
+	WavePending   UpgradeWaveStatus = "pending"
+	WaveRunning   UpgradeWaveStatus = "running"
+	WaveSucceeded UpgradeWaveStatus = "succeeded"
+	WaveFailed    UpgradeWaveStatus = "failed"
+)
+
+// CampaignHostStatus - upgrade state of a host in a campaign
+type CampaignHostStatus string
+
+const (
+	CampaignHostPending   CampaignHostStatus = "pending"
+	CampaignHostRequested CampaignHostStatus = "requested"
+	CampaignHostUpgraded  CampaignHostStatus = "upgraded"
+	CampaignHostFailed    CampaignHostStatus = "failed"
+	CampaignHostSkipped   CampaignHostStatus = "skipped"
+)
+
+// UpgradeCampaignSelector - selects the hosts targeted by a campaign,
+// empty fields match every host
+type UpgradeCampaignSelector struct {
+	Networks []string `json:"networks"`
+	Tags     []TagID  `json:"tags"`
+	OS       []string `json:"os"`
+	Versions []string `json:"versions"`
+	// Labels - host label selector, e.g. env=prod,site!=fra1
+	Labels string `json:"labels"`
+}
+
+// UpgradeWaveSpec - size of a wave, either an absolute number of hosts or a percentage of the targeted hosts
+type UpgradeWaveSpec struct {
+	Size    int `json:"size,omitempty"`
+	Percent int `json:"percent,omitempty"`
+}
+
+// UpgradeWave - a planned wave of an upgrade campaign
+type UpgradeWave struct {
+	Number     int               `json:"number"`
+	HostIDs    []string          `json:"host_ids"`
+	Status     UpgradeWaveStatus `json:"status"`
+	StartedAt  time.Time         `json:"started_at"`
+	FinishedAt time.Time         `json:"finished_at"`
+}
+
+// UpgradeCampaignHost - upgrade state of a host in a campaign
+type UpgradeCampaignHost struct {
+	HostID      string             `json:"host_id"`
+	Name        string             `json:"name"`
+	FromVersion string             `json:"from_version"`
+	Wave        int                `json:"wave"`
+	Status      CampaignHostStatus `json:"status"`
+	RequestedAt time.Time          `json:"requested_at"`
+	UpgradedAt  time.Time          `json:"upgraded_at"`
+}
+
+// UpgradeCampaign - staged rollout of a netclient upgrade
+type UpgradeCampaign struct {
+	ID             string                         `json:"id"`
+	Name           string                         `json:"name"`
+	TargetVersion  string                         `json:"target_version"`
+	Force          bool                           `json:"force"`
+	Selector       UpgradeCampaignSelector        `json:"selector"`
+	WaveSpecs      []UpgradeWaveSpec              `json:"wave_specs"`
+	WavePause      int                            `json:"wave_pause_seconds"`
+	CheckInTimeout int                            `json:"checkin_timeout_seconds"`
+	MaxFailures    int                            `json:"max_failures"`
+	Status         UpgradeCampaignStatus          `json:"status"`
+	HaltReason     string                         `json:"halt_reason,omitempty"`
+	CurrentWave    int                            `json:"current_wave"`
+	Waves          []UpgradeWave                  `json:"waves"`
+	Hosts          map[string]UpgradeCampaignHost `json:"hosts"`
+	CreatedBy      string                         `json:"created_by"`
+	CreatedAt      time.Time                      `json:"created_at"`
+	UpdatedAt      time.Time                      `json:"updated_at"`
+}
+
+// UpgradeCampaignReq - request to create an upgrade campaign
+type UpgradeCampaignReq struct {
+	Name           string                  `json:"name"`
+	TargetVersion  string                  `json:"target_version"`
+	Force          bool                    `json:"force"`
+	Selector       UpgradeCampaignSelector `json:"selector"`
+	WaveSpecs      []UpgradeWaveSpec       `json:"wave_specs"`
+	WavePause      int                     `json:"wave_pause_seconds"`
+	CheckInTimeout int                     `json:"checkin_timeout_seconds"`
+	MaxFailures    int                     `json:"max_failures"`
+}
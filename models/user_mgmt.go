@@ -46,6 +46,7 @@ var RsrcTypeMap = map[RsrcType]struct{}{
 	EnrollmentKeysRsrc: {},
 	UserRsrc:           {},
 	AclRsrc:            {},
+	AccessRequestRsrc:  {},
 	DnsRsrc:            {},
 	FailOverRsrc:       {},
 }
@@ -62,6 +63,7 @@ const (
 	EnrollmentKeysRsrc RsrcType = "enrollment_key"
 	UserRsrc           RsrcType = "users"
 	AclRsrc            RsrcType = "acl"
+	AccessRequestRsrc  RsrcType = "access_request"
 	TagRsrc            RsrcType = "tag"
 	DnsRsrc            RsrcType = "dns"
 	FailOverRsrc       RsrcType = "fail_over"
@@ -81,6 +83,7 @@ const (
 	AllDnsRsrcID            RsrcID = "all_dns"
 	AllFailOverRsrcID       RsrcID = "all_fail_over"
 	AllAclsRsrcID           RsrcID = "all_acl"
+	AllAccessRequestRsrcID  RsrcID = "all_access_request"
 	AllTagsRsrcID           RsrcID = "all_tag"
 )
 
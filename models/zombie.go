@@ -0,0 +1,61 @@
+package models
+
+import "time"
+
+// ZombieReason - why a host or node is suspected to be a zombie
+type ZombieReason string
+
+const (
+	ZombieDuplicateMAC    ZombieReason = "duplicate_mac"
+	ZombieDuplicateHostID ZombieReason = "duplicate_host_id"
+	ZombieStaleCheckIn    ZombieReason = "stale_checkin"
+	// ZombiePendingDelete - node deleted by an admin that did not leave the network yet,
+	// always cleaned up automatically
+	ZombiePendingDelete ZombieReason = "pending_delete"
+)
+
+// ZombieState - resolution state of a zombie candidate
+type ZombieState string
+
+const (
+	ZombiePending ZombieState = "pending"
+	ZombieIgnored ZombieState = "ignored"
+)
+
+// ZombieKind - kind of a zombie candidate
+type ZombieKind string
+
+const (
+	ZombieHost ZombieKind = "host"
+	ZombieNode ZombieKind = "node"
+)
+
+const (
+	// ZombieHandlingAuto - zombie candidates of the network are deleted automatically
+	ZombieHandlingAuto = "auto"
+	// ZombieHandlingManual - zombie candidates of the network wait for an admin to approve the delete
+	ZombieHandlingManual = "manual"
+)
+
+// Zombie - a host or node suspected to be a leftover of a re-registered or cloned machine
+type Zombie struct {
+	// ID - id of the suspected host or node
+	ID          string       `json:"id"`
+	Kind        ZombieKind   `json:"kind"`
+	HostID      string       `json:"host_id"`
+	HostName    string       `json:"host_name"`
+	Network     string       `json:"network,omitempty"`
+	Reason      ZombieReason `json:"reason"`
+	Detail      string       `json:"detail"`
+	State       ZombieState  `json:"state"`
+	LastCheckIn time.Time    `json:"last_check_in"`
+	DetectedAt  time.Time    `json:"detected_at"`
+	ResolvedBy  string       `json:"resolved_by,omitempty"`
+	ResolvedAt  time.Time    `json:"resolved_at"`
+}
+
+// ZombieHandlingReq - request to set the zombie handling of a network
+type ZombieHandlingReq struct {
+	// Mode - auto or manual
+	Mode string `json:"mode"`
+}
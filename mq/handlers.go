@@ -38,6 +38,10 @@ func UpdateNode(client mqtt.Client, msg mqtt.Message) {
 		slog.Error("error getting node", "id", id, "error", err)
 		return
 	}
+	if logic.IsNodeQuarantined(&currentNode) {
+		slog.Warn("dropping message from quarantined node", "id", id)
+		return
+	}
 	decrypted, decryptErr := DecryptMsg(&currentNode, msg.Payload())
 	if decryptErr != nil {
 		slog.Error("failed to decrypt message for node", "id", id, "error", decryptErr)
@@ -68,7 +72,7 @@ func UpdateNode(client mqtt.Client, msg mqtt.Message) {
 				PublishSingleHostPeerUpdate(host, allNodes, nil, nil, false, nil)
 			}
 		} else {
-			err = PublishPeerUpdate(false)
+			QueuePeerUpdate(false, newNode.Network)
 		}
 		if err != nil {
 			slog.Warn("error updating peers when node informed the server of an interface change", "nodeid", currentNode.ID, "error", err)
@@ -90,6 +94,10 @@ func UpdateHost(client mqtt.Client, msg mqtt.Message) {
 		slog.Error("error getting host", "id", id, "error", err)
 		return
 	}
+	if logic.IsHostQuarantined(id) {
+		slog.Warn("dropping message from quarantined host", "id", id, "name", currentHost.Name)
+		return
+	}
 	decrypted, decryptErr := decryptMsgWithHost(currentHost, msg.Payload())
 	if decryptErr != nil {
 		slog.Error("failed to decrypt message for host", "id", id, "name", currentHost.Name, "error", decryptErr)
@@ -105,14 +113,18 @@ func UpdateHost(client mqtt.Client, msg mqtt.Message) {
 	var replacePeers bool
 	switch hostUpdate.Action {
 	case models.CheckIn:
+		logic.RecordAppliedGeneration(currentHost.ID.String(), hostUpdate.Generation)
 		sendPeerUpdate = HandleHostCheckin(&hostUpdate.Host, currentHost)
 	case models.Acknowledgement:
-		hu := hostactions.GetAction(currentHost.ID.String())
-		if hu != nil {
-			if err = HostUpdate(hu); err != nil {
-				slog.Error("failed to send new node to host", "name", hostUpdate.Host.Name, "id", currentHost.ID, "error", err)
+		hostactions.Acknowledge(currentHost.ID.String(), hostUpdate.ActionID, hostUpdate.Generation)
+		action := hostactions.GetAction(currentHost.ID.String())
+		if action != nil {
+			if err = HostUpdate(&action.Update); err != nil {
+				hostactions.DeliveryFailed(currentHost.ID.String(), action.ID, err)
+				slog.Error("failed to send queued action to host", "name", hostUpdate.Host.Name, "id", currentHost.ID, "action", action.Action, "error", err)
 				return
 			} else {
+				hostactions.SetDeliveredGeneration(currentHost.ID.String(), action.ID, action.Update.Generation)
 				nodes, err := logic.GetAllNodes()
 				if err != nil {
 					return
@@ -121,9 +133,14 @@ func UpdateHost(client mqtt.Client, msg mqtt.Message) {
 					slog.Error("failed peers publish after join acknowledged", "name", hostUpdate.Host.Name, "id", currentHost.ID, "error", err)
 					return
 				}
+				// the next ack confirms the delivery and pulls the next queued action
+				if err = RequestAck(currentHost); err != nil {
+					slog.Error("failed to request ack from host", "name", hostUpdate.Host.Name, "id", currentHost.ID, "error", err)
+				}
 			}
 		}
 	case models.UpdateHost:
+		logic.RecordAppliedGeneration(currentHost.ID.String(), hostUpdate.Generation)
 		if hostUpdate.Host.PublicKey != currentHost.PublicKey {
 			//remove old peer entry
 			replacePeers = true
@@ -171,14 +188,22 @@ func UpdateHost(client mqtt.Client, msg mqtt.Message) {
 		sendPeerUpdate = true
 	case models.SignalHost:
 		signalPeer(hostUpdate.Signal)
+	case models.PeerDeltaGap:
+		slog.Info("host missed a peer delta, sending full peer update", "name", currentHost.Name, "id", currentHost.ID, "seq", hostUpdate.Generation)
+		logic.ResetPeerState(currentHost.ID.String())
+		nodes, err := logic.GetAllNodes()
+		if err != nil {
+			return
+		}
+		if err = PublishSingleHostPeerUpdate(currentHost, nodes, nil, nil, true, nil); err != nil {
+			slog.Error("failed to publish full peer update", "name", currentHost.Name, "id", currentHost.ID, "error", err)
+		}
 
 	}
 
 	if sendPeerUpdate {
-		err := PublishPeerUpdate(replacePeers)
-		if err != nil {
-			slog.Error("failed to publish peer update", "error", err)
-		}
+		// a deleted host has no networks left, so all hosts are updated
+		QueuePeerUpdate(replacePeers, logic.GetHostNetworks(currentHost.ID.String())...)
 	}
 }
 
@@ -218,6 +243,10 @@ func ClientPeerUpdate(client mqtt.Client, msg mqtt.Message) {
 		slog.Error("error getting node", "id", id, "error", err)
 		return
 	}
+	if logic.IsNodeQuarantined(&currentNode) {
+		slog.Warn("dropping message from quarantined node", "id", id)
+		return
+	}
 	decrypted, decryptErr := DecryptMsg(&currentNode, msg.Payload())
 	if decryptErr != nil {
 		slog.Error("failed to decrypt message for node", "id", id, "error", decryptErr)
@@ -227,10 +256,7 @@ func ClientPeerUpdate(client mqtt.Client, msg mqtt.Message) {
 	case ncutils.ACK:
 		// do we still need this
 	case ncutils.DONE:
-		if err = PublishPeerUpdate(false); err != nil {
-			slog.Error("error publishing peer update for node", "id", currentNode.ID, "error", err)
-			return
-		}
+		QueuePeerUpdate(false, currentNode.Network)
 	}
 
 	slog.Info("sent peer updates after signal received from", "id", id)
@@ -273,14 +299,14 @@ func HandleHostCheckin(h, currentHost *models.Host) bool {
 			return false
 		}
 	}
-	ifaceDelta := len(h.Interfaces) != len(currentHost.Interfaces) ||
+	ifaceDelta := logic.HostInterfacesChanged(currentHost.Interfaces, h.Interfaces) ||
 		!h.EndpointIP.Equal(currentHost.EndpointIP) ||
 		(len(h.NatType) > 0 && h.NatType != currentHost.NatType) ||
 		h.DefaultInterface != currentHost.DefaultInterface ||
 		(h.ListenPort != 0 && h.ListenPort != currentHost.ListenPort) ||
 		(h.WgPublicListenPort != 0 && h.WgPublicListenPort != currentHost.WgPublicListenPort) || (!h.EndpointIPv6.Equal(currentHost.EndpointIPv6))
 	if ifaceDelta { // only save if something changes
-
+		logic.RecordHostHistory(currentHost, h, true)
 		currentHost.EndpointIP = h.EndpointIP
 		currentHost.EndpointIPv6 = h.EndpointIPv6
 		currentHost.Interfaces = h.Interfaces
@@ -9,6 +9,7 @@ import (
 	mqtt "github.com/eclipse/paho.mqtt.golang"
 	"github.com/gravitl/netmaker/logger"
 	"github.com/gravitl/netmaker/logic"
+	"github.com/gravitl/netmaker/models"
 	"github.com/gravitl/netmaker/servercfg"
 	"golang.org/x/exp/slog"
 )
@@ -136,6 +137,17 @@ func Keepalive(ctx context.Context) {
 	}
 }
 
+// RunUpgradeCampaigns - hook advancing the running upgrade campaigns and requesting the upgrades of their hosts
+func RunUpgradeCampaigns() error {
+	return logic.RunUpgradeCampaigns(func(host models.Host, force bool) error {
+		action := models.Upgrade
+		if force {
+			action = models.ForceUpgrade
+		}
+		return HostUpdate(&models.HostUpdate{Action: action, Host: host})
+	})
+}
+
 // IsConnected - function for determining if the mqclient is connected or not
 func IsConnected() bool {
 	return mqclient != nil && mqclient.IsConnected()
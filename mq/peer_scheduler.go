@@ -0,0 +1,149 @@
+package mq
+
+import (
+	"sync"
+	"time"
+
+	"github.com/gravitl/netmaker/logger"
+	"github.com/gravitl/netmaker/logic"
+	"github.com/gravitl/netmaker/models"
+	"github.com/gravitl/netmaker/servercfg"
+	"golang.org/x/exp/slog"
+)
+
+// maxDebounceWindows - a burst of changes delays peer updates by at most this many debounce windows
+const maxDebounceWindows = 10
+
+// peerUpdateScheduler - coalesces queued peer updates per network until changes settle
+type peerUpdateScheduler struct {
+	mutex    sync.Mutex
+	flushing sync.Mutex
+	networks map[string]struct{}
+	all      bool
+	replace  bool
+	pending  bool
+	since    time.Time
+	timer    *time.Timer
+}
+
+var peerScheduler = &peerUpdateScheduler{networks: make(map[string]struct{})}
+
+// QueuePeerUpdate - marks networks as changed, the hosts in them get a peer update once
+// no further change was queued for the debounce window; without networks all hosts are updated
+func QueuePeerUpdate(replacePeers bool, networks ...string) {
+	if !servercfg.IsMessageQueueBackend() {
+		return
+	}
+	peerScheduler.queue(replacePeers, networks, servercfg.GetPeerUpdateDebounce())
+}
+
+func (s *peerUpdateScheduler) queue(replacePeers bool, networks []string, debounce time.Duration) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if len(networks) == 0 {
+		s.all = true
+	}
+	for _, network := range networks {
+		if network == "" {
+			s.all = true
+			continue
+		}
+		s.networks[network] = struct{}{}
+	}
+	s.replace = s.replace || replacePeers
+	if !s.pending {
+		s.pending = true
+		s.since = time.Now()
+		s.timer = time.AfterFunc(debounce, s.flush)
+		return
+	}
+	// extend the window while the burst goes on, but do not starve the hosts
+	if time.Since(s.since)+debounce <= debounce*maxDebounceWindows {
+		s.timer.Reset(debounce)
+	}
+}
+
+// take - hands out the queued changes and resets the queue
+func (s *peerUpdateScheduler) take() (networks map[string]struct{}, replace bool, ok bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if !s.pending {
+		return nil, false, false
+	}
+	networks, replace = s.networks, s.replace
+	if s.all {
+		networks = nil
+	}
+	s.networks = make(map[string]struct{})
+	s.all = false
+	s.replace = false
+	s.pending = false
+	return networks, replace, true
+}
+
+func (s *peerUpdateScheduler) flush() {
+	// flushes run one at a time so hosts receive their updates in order
+	s.flushing.Lock()
+	defer s.flushing.Unlock()
+	networks, replace, ok := s.take()
+	if !ok {
+		return
+	}
+	if err := publishPeerUpdates(networks, replace); err != nil {
+		slog.Error("failed to publish queued peer updates", "error", err)
+	}
+}
+
+// hostInNetworks - checks if a host has a node in one of the networks
+func hostInNetworks(host *models.Host, nodeNetworks map[string]string, networks map[string]struct{}) bool {
+	for _, nodeID := range host.Nodes {
+		if _, ok := networks[nodeNetworks[nodeID]]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// publishPeerUpdates - publishes peer updates to the hosts of the given networks, or to all hosts
+// when networks is nil, computing at most the configured number of hosts concurrently
+func publishPeerUpdates(networks map[string]struct{}, replacePeers bool) error {
+	if servercfg.GetManageDNS() {
+		sendDNSSync()
+	}
+	hosts, err := logic.GetAllHosts()
+	if err != nil {
+		logger.Log(1, "err getting all hosts", err.Error())
+		return err
+	}
+	allNodes, err := logic.GetAllNodes()
+	if err != nil {
+		return err
+	}
+	nodeNetworks := make(map[string]string, len(allNodes))
+	for _, node := range allNodes {
+		nodeNetworks[node.ID.String()] = node.Network
+	}
+	jobs := make(chan models.Host)
+	wg := &sync.WaitGroup{}
+	for i := 0; i < servercfg.GetPeerUpdateWorkers(); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for host := range jobs {
+				host := host
+				if err := PublishSingleHostPeerUpdate(&host, allNodes, nil, nil, replacePeers, nil); err != nil {
+					slog.Error("failed to publish peer update to host", "id", host.ID.String(), "name", host.Name, "error", err)
+				}
+			}
+		}()
+	}
+	for i := range hosts {
+		if networks != nil && !hostInNetworks(&hosts[i], nodeNetworks, networks) {
+			continue
+		}
+		jobs <- hosts[i]
+	}
+	close(jobs)
+	wg.Wait()
+	return nil
+}
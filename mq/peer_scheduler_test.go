@@ -0,0 +1,31 @@
+package mq
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPeerUpdateSchedulerCoalesces(t *testing.T) {
+	s := &peerUpdateScheduler{networks: make(map[string]struct{})}
+	s.queue(false, []string{"net1"}, time.Hour)
+	s.queue(true, []string{"net2", "net1"}, time.Hour)
+	defer s.timer.Stop()
+	networks, replace, ok := s.take()
+	assert.True(t, ok)
+	assert.True(t, replace)
+	assert.Equal(t, map[string]struct{}{"net1": {}, "net2": {}}, networks)
+
+	_, _, ok = s.take()
+	assert.False(t, ok)
+
+	s.queue(false, []string{"net1"}, time.Hour)
+	s.queue(false, nil, time.Hour)
+	defer s.timer.Stop()
+	networks, replace, ok = s.take()
+	assert.True(t, ok)
+	assert.False(t, replace)
+	// a change without network updates all hosts
+	assert.Nil(t, networks)
+}
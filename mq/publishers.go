@@ -7,49 +7,34 @@ import (
 	"sync"
 	"time"
 
-	"github.com/google/uuid"
 	"github.com/gravitl/netmaker/logger"
 	"github.com/gravitl/netmaker/logic"
+	"github.com/gravitl/netmaker/logic/hostactions"
 	"github.com/gravitl/netmaker/models"
 	"github.com/gravitl/netmaker/servercfg"
 	"golang.org/x/exp/slog"
 )
 
-// PublishPeerUpdate --- determines and publishes a peer update to all the hosts
+// PublishPeerUpdate --- determines and publishes a peer update to all the hosts right away,
+// use QueuePeerUpdate for changes that may come in bursts
 func PublishPeerUpdate(replacePeers bool) error {
 	if !servercfg.IsMessageQueueBackend() {
 		return nil
 	}
+	return publishPeerUpdates(nil, replacePeers)
+}
 
-	if servercfg.GetManageDNS() {
-		sendDNSSync()
-	}
-
-	hosts, err := logic.GetAllHosts()
-	if err != nil {
-		logger.Log(1, "err getting all hosts", err.Error())
-		return err
+// PublishNetworkPeerUpdates - publishes peer updates to the hosts of the given networks and
+// returns once they are sent
+func PublishNetworkPeerUpdates(replacePeers bool, networks ...string) error {
+	if !servercfg.IsMessageQueueBackend() || len(networks) == 0 {
+		return nil
 	}
-	allNodes, err := logic.GetAllNodes()
-	if err != nil {
-		return err
+	selected := make(map[string]struct{}, len(networks))
+	for _, network := range networks {
+		selected[network] = struct{}{}
 	}
-
-	for _, host := range hosts {
-		host := host
-		time.Sleep(5 * time.Millisecond)
-		go func(host models.Host) {
-			if err = PublishSingleHostPeerUpdate(&host, allNodes, nil, nil, replacePeers, nil); err != nil {
-				id := host.Name
-				if host.ID != uuid.Nil {
-					id = host.ID.String()
-				}
-				slog.Error("failed to publish peer update to host", id, ": ", err)
-			}
-		}(host)
-	}
-
-	return nil
+	return publishPeerUpdates(selected, replacePeers)
 }
 
 // PublishDeletedNodePeerUpdate --- determines and publishes a peer update
@@ -119,6 +104,17 @@ func PublishSingleHostPeerUpdate(host *models.Host, allNodes []models.Node, dele
 		EndpointDetection: peerUpdate.ServerConfig.EndpointDetection,
 	}
 	peerUpdate.ReplacePeers = replacePeers
+	delta, full := logic.NextPeerDelta(host, &peerUpdate)
+	if !full {
+		if delta == nil {
+			return nil
+		}
+		data, err := json.Marshal(delta)
+		if err != nil {
+			return err
+		}
+		return publish(host, fmt.Sprintf("peerdelta/host/%s/%s", host.ID.String(), servercfg.GetServer()), data)
+	}
 	data, err := json.Marshal(&peerUpdate)
 	if err != nil {
 		return err
@@ -126,6 +122,17 @@ func PublishSingleHostPeerUpdate(host *models.Host, allNodes []models.Node, dele
 	return publish(host, fmt.Sprintf("peers/host/%s/%s", host.ID.String(), servercfg.GetServer()), data)
 }
 
+// PublishNetworkConnectivity - applies the connectivity profiles of a network to its hosts and
+// sends a host update to the ones whose MTU or listen port changed
+func PublishNetworkConnectivity(netID models.NetworkID) {
+	for _, host := range logic.ApplyNetworkConnectivity(netID) {
+		host := host
+		if err := HostUpdate(&models.HostUpdate{Action: models.UpdateHost, Host: host}); err != nil {
+			slog.Error("failed to send connectivity profile host update", "host", host.ID, "error", err)
+		}
+	}
+}
+
 // NodeUpdate -- publishes a node update
 func NodeUpdate(node *models.Node) error {
 	host, err := logic.GetHost(node.HostID.String())
@@ -160,7 +167,13 @@ func HostUpdate(hostUpdate *models.HostUpdate) error {
 		return nil
 	}
 	logger.Log(3, "publishing host update to "+hostUpdate.Host.ID.String())
-
+	switch hostUpdate.Action {
+	// signals are relayed between peers, ack requests and expiry notices only ask the host to
+	// respond, none of them change the desired state
+	case models.SignalHost, models.RequestAck, models.NodeExpiring:
+	default:
+		hostUpdate.Generation = logic.NextHostGeneration(hostUpdate.Host.ID.String())
+	}
 	data, err := json.Marshal(hostUpdate)
 	if err != nil {
 		logger.Log(2, "error marshalling node update ", err.Error())
@@ -174,6 +187,84 @@ func HostUpdate(hostUpdate *models.HostUpdate) error {
 	return nil
 }
 
+// RequestAck - asks a host to acknowledge, which pulls the next queued action of the host.
+// Skipped for netclients that do not know the request, they pull queued actions with their own acknowledgements
+func RequestAck(host *models.Host) error {
+	if !logic.SupportsAckRequests(host) {
+		slog.Debug("host does not support ack requests", "host", host.ID, "version", host.Version)
+		return nil
+	}
+	return HostUpdate(&models.HostUpdate{Action: models.RequestAck, Host: *host})
+}
+
+// RedeliverHostActions - hook putting host actions that were not acknowledged in time back in the
+// queue and asking their hosts to pull them again
+func RedeliverHostActions() error {
+	for _, hostID := range hostactions.ExpireDeliveries(time.Now()) {
+		host, err := logic.GetHost(hostID)
+		if err != nil {
+			continue
+		}
+		if err := RequestAck(host); err != nil {
+			slog.Error("failed to request ack from host", "host", hostID, "error", err)
+		}
+	}
+	return nil
+}
+
+// RotateHostKeys - hook requesting the hosts due under a key rotation policy to rotate their wireguard keys,
+// the new public key reported by a host is then pushed to its peers with a peer update replacing the old one
+func RotateHostKeys() error {
+	hosts, err := logic.SelectHostsForKeyRotation(time.Now())
+	if err != nil {
+		return err
+	}
+	for i := range hosts {
+		slog.Info("requesting scheduled key rotation", "host", hosts[i].Name, "id", hosts[i].ID)
+		if err := HostUpdate(&models.HostUpdate{Action: models.UpdateKeys, Host: hosts[i]}); err != nil {
+			slog.Error("failed to request key rotation", "host", hosts[i].ID, "error", err)
+		}
+	}
+	return nil
+}
+
+// ApplyAclSchedules - pushes peer and firewall updates to networks whose scheduled policies
+// came into or went out of effect, or that lost the policy of an expired access request
+func ApplyAclSchedules() error {
+	now := time.Now()
+	if expired := logic.ExpireAccessRequests(now); len(expired) > 0 {
+		slog.Info("access requests expired, updating peers", "networks", expired)
+		QueuePeerUpdate(true, expired...)
+	}
+	networks := logic.CheckAclSchedules(now)
+	if len(networks) == 0 {
+		return nil
+	}
+	slog.Info("acl schedules changed, updating peers", "networks", networks)
+	QueuePeerUpdate(true, networks...)
+	return nil
+}
+
+// PublishNodeExpiryEvent - warns the host of a node about to expire, or pushes the
+// disconnect of a node that expired to the host and its peers
+func PublishNodeExpiryEvent(event models.NodeExpiryEvent) {
+	switch event.Type {
+	case models.NodeExpiryWarning:
+		host, err := logic.GetHost(event.Node.HostID.String())
+		if err != nil {
+			return
+		}
+		if err := HostUpdate(&models.HostUpdate{Action: models.NodeExpiring, Host: *host, Node: event.Node}); err != nil {
+			slog.Error("failed to send node expiry warning", "nodeid", event.Node.ID.String(), "error", err)
+		}
+	case models.NodeExpiryDisconnected:
+		if err := NodeUpdate(&event.Node); err != nil {
+			slog.Error("failed to send node update for expired node", "nodeid", event.Node.ID.String(), "error", err)
+		}
+		QueuePeerUpdate(false, event.Node.Network)
+	}
+}
+
 // ServerStartNotify - notifies all non server nodes to pull changes after a restart
 func ServerStartNotify() error {
 	nodes, err := logic.GetAllNodes()
@@ -21,11 +21,11 @@ import (
 func FailOverHandlers(r *mux.Router) {
 	r.HandleFunc("/api/v1/node/{nodeid}/failover", controller.Authorize(true, false, "host", http.HandlerFunc(getfailOver))).
 		Methods(http.MethodGet)
-	r.HandleFunc("/api/v1/node/{nodeid}/failover", logic.SecurityCheck(true, http.HandlerFunc(createfailOver))).
+	r.HandleFunc("/api/v1/node/{nodeid}/failover", logic.SecurityCheck(true, controller.NetworkFreezeCheck(http.HandlerFunc(createfailOver)))).
 		Methods(http.MethodPost)
-	r.HandleFunc("/api/v1/node/{nodeid}/failover", logic.SecurityCheck(true, http.HandlerFunc(deletefailOver))).
+	r.HandleFunc("/api/v1/node/{nodeid}/failover", logic.SecurityCheck(true, controller.NetworkFreezeCheck(http.HandlerFunc(deletefailOver)))).
 		Methods(http.MethodDelete)
-	r.HandleFunc("/api/v1/node/{network}/failover/reset", logic.SecurityCheck(true, http.HandlerFunc(resetFailOver))).
+	r.HandleFunc("/api/v1/node/{network}/failover/reset", logic.SecurityCheck(true, controller.NetworkFreezeCheck(http.HandlerFunc(resetFailOver)))).
 		Methods(http.MethodPost)
 	r.HandleFunc("/api/v1/node/{nodeid}/failover_me", controller.Authorize(true, false, "host", http.HandlerFunc(failOverME))).
 		Methods(http.MethodPost)
@@ -85,7 +85,7 @@ func createfailOver(w http.ResponseWriter, r *http.Request) {
 		logic.ReturnErrorResponse(w, r, logic.FormatError(err, "internal"))
 		return
 	}
-	go mq.PublishPeerUpdate(false)
+	mq.QueuePeerUpdate(false, node.Network)
 	w.Header().Set("Content-Type", "application/json")
 	logic.ReturnSuccessResponseWithJson(w, r, node, "created failover successfully")
 }
@@ -111,7 +111,7 @@ func resetFailOver(w http.ResponseWriter, r *http.Request) {
 			logic.UpsertNode(&node)
 		}
 	}
-	go mq.PublishPeerUpdate(false)
+	mq.QueuePeerUpdate(false, net)
 	w.Header().Set("Content-Type", "application/json")
 	logic.ReturnSuccessResponse(w, r, "failover has been reset successfully")
 }
@@ -144,7 +144,7 @@ func deletefailOver(w http.ResponseWriter, r *http.Request) {
 	proLogic.RemoveFailOverFromCache(node.Network)
 	go func() {
 		proLogic.ResetFailOver(&node)
-		mq.PublishPeerUpdate(false)
+		mq.QueuePeerUpdate(false, node.Network)
 	}()
 	w.Header().Set("Content-Type", "application/json")
 	logic.ReturnSuccessResponseWithJson(w, r, node, "deleted failover successfully")
@@ -288,7 +288,7 @@ func failOverME(w http.ResponseWriter, r *http.Request) {
 	sendPeerUpdate = true
 
 	if sendPeerUpdate {
-		go mq.PublishPeerUpdate(false)
+		mq.QueuePeerUpdate(false, node.Network)
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -6,6 +6,7 @@ import (
 	"net/http"
 
 	"github.com/gorilla/mux"
+	controller "github.com/gravitl/netmaker/controllers"
 	"github.com/gravitl/netmaker/logger"
 	"github.com/gravitl/netmaker/logic"
 	"github.com/gravitl/netmaker/models"
@@ -16,11 +17,11 @@ import (
 
 // InetHandlers - handlers for internet gw
 func InetHandlers(r *mux.Router) {
-	r.HandleFunc("/api/nodes/{network}/{nodeid}/inet_gw", logic.SecurityCheck(true, http.HandlerFunc(createInternetGw))).
+	r.HandleFunc("/api/nodes/{network}/{nodeid}/inet_gw", logic.SecurityCheck(true, controller.NetworkFreezeCheck(http.HandlerFunc(createInternetGw)))).
 		Methods(http.MethodPost)
-	r.HandleFunc("/api/nodes/{network}/{nodeid}/inet_gw", logic.SecurityCheck(true, http.HandlerFunc(updateInternetGw))).
+	r.HandleFunc("/api/nodes/{network}/{nodeid}/inet_gw", logic.SecurityCheck(true, controller.NetworkFreezeCheck(http.HandlerFunc(updateInternetGw)))).
 		Methods(http.MethodPut)
-	r.HandleFunc("/api/nodes/{network}/{nodeid}/inet_gw", logic.SecurityCheck(true, http.HandlerFunc(deleteInternetGw))).
+	r.HandleFunc("/api/nodes/{network}/{nodeid}/inet_gw", logic.SecurityCheck(true, controller.NetworkFreezeCheck(http.HandlerFunc(deleteInternetGw)))).
 		Methods(http.MethodDelete)
 }
 
@@ -80,7 +81,7 @@ func createInternetGw(w http.ResponseWriter, r *http.Request) {
 		if _, exists := proLogic.FailOverExists(node.Network); exists {
 			go func() {
 				proLogic.ResetFailedOverPeer(&node)
-				mq.PublishPeerUpdate(false)
+				mq.QueuePeerUpdate(false, node.Network)
 			}()
 		}
 	}
@@ -103,7 +104,7 @@ func createInternetGw(w http.ResponseWriter, r *http.Request) {
 	)
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(apiNode)
-	go mq.PublishPeerUpdate(false)
+	mq.QueuePeerUpdate(false, node.Network)
 }
 
 // @Summary     Update an internet gateway
@@ -163,7 +164,7 @@ func updateInternetGw(w http.ResponseWriter, r *http.Request) {
 	)
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(apiNode)
-	go mq.PublishPeerUpdate(false)
+	mq.QueuePeerUpdate(false, node.Network)
 }
 
 // @Summary     Delete an internet gateway
@@ -202,5 +203,5 @@ func deleteInternetGw(w http.ResponseWriter, r *http.Request) {
 	)
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(apiNode)
-	go mq.PublishPeerUpdate(false)
+	mq.QueuePeerUpdate(false, node.Network)
 }
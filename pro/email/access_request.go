@@ -0,0 +1,109 @@
+package email
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"strings"
+
+	"github.com/gravitl/netmaker/logic"
+	"github.com/gravitl/netmaker/models"
+	proLogic "github.com/gravitl/netmaker/pro/logic"
+	"github.com/gravitl/netmaker/servercfg"
+	"golang.org/x/exp/slog"
+)
+
+// AccessRequestMail - mail to network admins about a new access request, or to the requester
+// about the decision on it
+type AccessRequestMail struct {
+	BodyBuilder EmailBodyBuilder
+	Request     models.AccessRequest
+}
+
+// GetSubject - gets the subject of the email
+func (m AccessRequestMail) GetSubject(info Notification) string {
+	switch m.Request.Status {
+	case models.AccessRequestPending:
+		return fmt.Sprintf("Access request from %s on network %s", m.Request.Requester, m.Request.NetworkID)
+	case models.AccessRequestExpired:
+		return fmt.Sprintf("Your access on network %s has expired", m.Request.NetworkID)
+	}
+	return fmt.Sprintf("Your access request on network %s was %s", m.Request.NetworkID, m.Request.Status)
+}
+
+// GetBody - gets the body of the email
+func (m AccessRequestMail) GetBody(info Notification) string {
+	req := m.Request
+	ports := "all ports"
+	if len(req.Ports) > 0 {
+		ports = strings.Join(req.Ports, ", ")
+	}
+	content := m.BodyBuilder.WithParagraph("Hi,")
+	switch req.Status {
+	case models.AccessRequestPending:
+		content = content.
+			WithParagraph(fmt.Sprintf("%s requested access to %s on network %s for %d minutes.",
+				html.EscapeString(req.Requester), html.EscapeString(req.Target.Value), req.NetworkID, req.Duration)).
+			WithParagraph("Justification: " + html.EscapeString(req.Justification)).
+			WithParagraph("Review the request in the Netmaker dashboard.")
+	case models.AccessRequestApproved:
+		content = content.
+			WithParagraph(fmt.Sprintf("%s approved your access to %s on network %s until %s.",
+				html.EscapeString(req.Reviewer), html.EscapeString(req.Target.Value), req.NetworkID,
+				req.ExpiresAt.Format("2006-01-02 15:04 MST")))
+	case models.AccessRequestDenied:
+		content = content.
+			WithParagraph(fmt.Sprintf("%s denied your access to %s on network %s.",
+				html.EscapeString(req.Reviewer), html.EscapeString(req.Target.Value), req.NetworkID))
+	case models.AccessRequestExpired:
+		content = content.
+			WithParagraph(fmt.Sprintf("Your access to %s on network %s has expired.",
+				html.EscapeString(req.Target.Value), req.NetworkID))
+	}
+	if req.ReviewNote != "" && req.Status != models.AccessRequestPending {
+		content = content.WithParagraph("Note: " + html.EscapeString(req.ReviewNote))
+	}
+	return content.
+		WithParagraph(fmt.Sprintf("Requested protocol %s, %s.", req.Proto, html.EscapeString(ports))).
+		WithParagraph("Best Regards,").
+		WithParagraph("The Netmaker Team").
+		Build()
+}
+
+// NotifyAccessRequest - mails new access requests to the admins of the network, and decisions
+// and expiry to the requester
+func NotifyAccessRequest(req models.AccessRequest) {
+	if servercfg.GetSmtpHost() == "" {
+		return
+	}
+	recipients := []string{}
+	if req.Status == models.AccessRequestPending {
+		users, err := logic.GetUsersDB()
+		if err != nil {
+			slog.Error("failed to list network admins for access request", "request", req.ID, "error", err)
+			return
+		}
+		for _, user := range users {
+			if user.UserName != req.Requester && proLogic.IsNetworkAdmin(user, req.NetworkID) {
+				recipients = append(recipients, user.UserName)
+			}
+		}
+	} else {
+		recipients = append(recipients, req.Requester)
+	}
+	for _, recipient := range recipients {
+		if !IsValid(recipient) {
+			continue
+		}
+		e := AccessRequestMail{
+			BodyBuilder: &EmailBodyBuilderWithH1HeadlineAndImage{},
+			Request:     req,
+		}
+		n := Notification{
+			RecipientMail: recipient,
+		}
+		if err := GetClient().SendEmail(context.Background(), n, e); err != nil {
+			slog.Error("failed to send access request email", "request", req.ID, "user", recipient, "error", err)
+		}
+	}
+}
@@ -70,6 +70,12 @@ var NetworkUserAllPermissionTemplate = models.UserRolePermissionTemplate{
 				Read: true,
 			},
 		},
+		models.AccessRequestRsrc: {
+			models.AllAccessRequestRsrcID: models.RsrcPermissionScope{
+				Create:   true,
+				SelfOnly: true,
+			},
+		},
 		models.EgressGwRsrc: {
 			models.AllEgressGwRsrcID: models.RsrcPermissionScope{
 				Read: true,
@@ -187,6 +193,12 @@ func CreateDefaultNetworkRolesAndGroups(netID models.NetworkID) {
 					Read: true,
 				},
 			},
+			models.AccessRequestRsrc: {
+				models.AllAccessRequestRsrcID: models.RsrcPermissionScope{
+					Create:   true,
+					SelfOnly: true,
+				},
+			},
 			models.EgressGwRsrc: {
 				models.AllEgressGwRsrcID: models.RsrcPermissionScope{
 					Read: true,
@@ -632,7 +644,7 @@ func GetUserRAGNodesV1(user models.User) (gws map[string]models.Node) {
 	tagNodesMap := logic.GetTagMapWithNodes()
 	accessPolices := logic.ListUserPolicies(user)
 	for _, policyI := range accessPolices {
-		if !policyI.Enabled {
+		if !logic.IsAclEffective(policyI, time.Now()) {
 			continue
 		}
 		for _, dstI := range policyI.Dst {
@@ -1219,3 +1231,30 @@ func AddGlobalNetRolesToAdmins(u models.User) {
 	u.UserGroups[models.UserGroupID(fmt.Sprintf("global-%s-grp", models.NetworkAdmin))] = struct{}{}
 	logic.UpsertUser(u)
 }
+
+// IsNetworkAdmin - checks if a user has full access to a network through the platform role,
+// a network role or one of the user's groups
+func IsNetworkAdmin(user models.User, netID models.NetworkID) bool {
+	if platformRole, err := logic.GetRole(user.PlatformRoleID); err == nil && platformRole.FullAccess {
+		return true
+	}
+	hasFullAccess := func(networkRoles map[models.NetworkID]map[models.UserRoleID]struct{}) bool {
+		for _, roles := range []map[models.UserRoleID]struct{}{networkRoles[models.AllNetworks], networkRoles[netID]} {
+			for roleID := range roles {
+				if role, err := logic.GetRole(roleID); err == nil && role.FullAccess {
+					return true
+				}
+			}
+		}
+		return false
+	}
+	if hasFullAccess(user.NetworkRoles) {
+		return true
+	}
+	for gID := range user.UserGroups {
+		if userG, err := GetUserGroup(gID); err == nil && hasFullAccess(userG.NetworkRoles) {
+			return true
+		}
+	}
+	return false
+}
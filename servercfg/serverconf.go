@@ -121,6 +121,11 @@ func GetRacAutoDisable() bool {
 	return os.Getenv("RAC_AUTO_DISABLE") == "true"
 }
 
+// IsHostEndpointEventsEnabled - returns whether events are fired when a host's public endpoint changes unexpectedly
+func IsHostEndpointEventsEnabled() bool {
+	return os.Getenv("HOST_ENDPOINT_EVENTS") == "true"
+}
+
 // GetRacRestrictToSingleNetwork - returns whether the feature to allow simultaneous network connections via RAC is enabled
 func GetRacRestrictToSingleNetwork() bool {
 	return os.Getenv("RAC_RESTRICT_TO_SINGLE_NETWORK") == "true"
@@ -677,6 +682,54 @@ func GetMetricsPort() int {
 	return p
 }
 
+// GetNodeExpiryWarning - how long before a node expires a warning is fired
+func GetNodeExpiryWarning() time.Duration {
+	//default 24 hours
+	warning := 24
+	if os.Getenv("NODE_EXPIRY_WARNING") != "" {
+		if w, err := strconv.Atoi(os.Getenv("NODE_EXPIRY_WARNING")); err == nil && w > 0 {
+			warning = w
+		}
+	}
+	return time.Duration(warning) * time.Hour
+}
+
+// GetZombieStaleThreshold - time without a check in after which a node is reported as a stale zombie candidate
+func GetZombieStaleThreshold() time.Duration {
+	//default 7 days
+	threshold := 168
+	if os.Getenv("ZOMBIE_STALE_THRESHOLD") != "" {
+		if t, err := strconv.Atoi(os.Getenv("ZOMBIE_STALE_THRESHOLD")); err == nil && t > 0 {
+			threshold = t
+		}
+	}
+	return time.Duration(threshold) * time.Hour
+}
+
+// GetPeerUpdateDebounce - quiet period after a change before queued peer updates are published
+func GetPeerUpdateDebounce() time.Duration {
+	//default 500 milliseconds
+	debounce := 500
+	if os.Getenv("PEER_UPDATE_DEBOUNCE") != "" {
+		if d, err := strconv.Atoi(os.Getenv("PEER_UPDATE_DEBOUNCE")); err == nil && d >= 0 {
+			debounce = d
+		}
+	}
+	return time.Duration(debounce) * time.Millisecond
+}
+
+// GetPeerUpdateWorkers - number of hosts whose peer updates are computed concurrently
+func GetPeerUpdateWorkers() int {
+	//default 10 workers
+	workers := 10
+	if os.Getenv("PEER_UPDATE_WORKERS") != "" {
+		if w, err := strconv.Atoi(os.Getenv("PEER_UPDATE_WORKERS")); err == nil && w > 0 {
+			workers = w
+		}
+	}
+	return workers
+}
+
 // GetMetricInterval - get the publish metric interval
 func GetMetricIntervalInMinutes() time.Duration {
 	//default 15 minutes
@@ -692,6 +745,18 @@ func GetMetricIntervalInMinutes() time.Duration {
 	return time.Duration(interval) * time.Minute
 }
 
+// GetHostDriftThreshold - time a host may stay behind the desired state generation before it is reported as drifted
+func GetHostDriftThreshold() time.Duration {
+	//default 5 minutes
+	threshold := 5
+	if os.Getenv("HOST_DRIFT_THRESHOLD") != "" {
+		if t, err := strconv.Atoi(os.Getenv("HOST_DRIFT_THRESHOLD")); err == nil && t > 0 {
+			threshold = t
+		}
+	}
+	return time.Duration(threshold) * time.Minute
+}
+
 // GetMetricInterval - get the publish metric interval
 func GetMetricInterval() string {
 	//default 15 minutes